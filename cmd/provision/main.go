@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -12,22 +13,51 @@ import (
 )
 
 func main() {
-	backend := flag.String("backend", "", "postgres, mysql, sqlserver")
-	dsn := flag.String("dsn", "", "Admin Connection String")
+	backend := flag.String("backend", "", "postgres, mysql, sqlserver, sqlite")
+	dsn := flag.String("dsn", "", "Admin Connection String (database file path for sqlite)")
 	scopeFlag := flag.String("scope", "", "Comma-separated Schemas/DBs (Grants ALL access)")
 	resourceFlag := flag.String("resources", "", "Comma-separated FQNs (schema.table) (Grants Specific access)")
-	user := flag.String("user", "", "Username to create/manage")
-	revoke := flag.Bool("revoke", false, "Revoke and drop the user")
+	columnsFlag := flag.String("columns", "", "Column-level SELECT as 'schema.table:col1,col2', several tables separated by ';'")
+	functionsFlag := flag.String("functions", "", "Comma-separated functions (schema.name or schema.name(argtypes)) to grant EXECUTE on, in addition to -scope or -resources")
+	user := flag.String("user", "", "Username to create/manage (comma-separated list with -revoke)")
+	revoke := flag.Bool("revoke", false, "Revoke and drop the user(s)")
+	allManaged := flag.String("all-managed", "", "With -revoke, drop every user whose name starts with this prefix; with -list, list only those users")
+	list := flag.Bool("list", false, "List the login users and the privileges granted to each")
+	rotate := flag.Bool("rotate", false, "Set a new generated password for an existing user, keeping its grants")
+	dryRun := flag.Bool("dry-run", false, "Print the grant statements for the user without changing anything")
+	expires := flag.Duration("expires", 0, "End the user's access after this long, e.g. 72h (enforcement differs per backend)")
+	prune := flag.Bool("prune", false, "For an existing user, revoke grants outside the given scope")
+	requireTLS := flag.Bool("require-tls", false, "Fail instead of warning when the admin connection is not encrypted")
 
 	flag.Parse()
 
+	if *backend == "sqlite" {
+		provisionSqlite(*dsn)
+		return
+	}
+
 	if *scopeFlag != "" && *resourceFlag != "" {
 		log.Fatal("Error: You cannot use -scope and -resources together. Choose one.")
 	}
-	if *scopeFlag == "" && *resourceFlag == "" && !*revoke {
-		log.Fatal("Error: You must provide either -scope or -resources (unless revoking).")
+	if *scopeFlag == "" && *resourceFlag == "" && *columnsFlag == "" && !*revoke && !*list && !*rotate {
+		log.Fatal("Error: You must provide -scope, -resources or -columns (unless revoking, listing or rotating).")
+	}
+	if (*revoke && *list) || (*rotate && (*revoke || *list)) {
+		log.Fatal("Error: -revoke, -list and -rotate cannot be combined.")
+	}
+	if *rotate && (*user == "" || strings.Contains(*user, ",")) {
+		log.Fatal("Error: -rotate requires a single -user.")
+	}
+	if *expires < 0 || (*expires > 0 && (*revoke || *list || *rotate)) {
+		log.Fatal("Error: -expires takes a positive duration and only applies when provisioning a user.")
 	}
-	if *backend == "" || *dsn == "" || *user == "" {
+	if *dryRun && (*revoke || *list || *rotate) {
+		log.Fatal("Error: -dry-run only applies when provisioning a user.")
+	}
+	if *allManaged != "" && !*revoke && !*list {
+		log.Fatal("Error: -all-managed can only be used with -revoke or -list.")
+	}
+	if *backend == "" || *dsn == "" || (*user == "" && *allManaged == "" && !*list) {
 		log.Fatal("Error: -backend, -dsn, and -user are required.")
 	}
 
@@ -44,6 +74,12 @@ func main() {
 			scope.Resources = append(scope.Resources, strings.TrimSpace(v))
 		}
 	}
+	scope.Functions = splitFunctions(*functionsFlag)
+	columns, err := parseColumns(*columnsFlag)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	scope.Columns = columns
 
 	var p provision.Provisioner
 	switch *backend {
@@ -65,38 +101,267 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	checkTLS(ctx, p, *requireTLS)
+
+	if *list {
+		listUsers(ctx, p, *allManaged)
+		return
+	}
 	if *revoke {
-		if err := p.DropUser(ctx, *user); err != nil {
-			log.Fatalf("Revoke failed: %v", err)
-		}
-		fmt.Printf("User %s revoked.\n", *user)
+		revokeUsers(ctx, p, *user, *allManaged)
+		return
+	}
+	if *rotate {
+		rotatePassword(ctx, p, *user)
 		return
 	}
 
-	exists, err := p.UserExists(ctx, *user)
+	fmt.Println("Ensuring user and permissions...")
+	opts := provision.Options{Prune: *prune, DryRun: *dryRun}
+	if *expires > 0 {
+		opts.Expires = time.Now().Add(*expires)
+	}
+	result, err := provision.EnsureUser(ctx, p, *user, scope, opts)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("Provisioning failed: %v", err)
 	}
-
-	if !*exists {
-		fmt.Println("Generating password...")
-		password, err := provision.GeneratePassword()
+	if *dryRun {
+		printDryRun(*user, result, opts)
+		return
+	}
+	if result.Created {
+		fmt.Printf("Created user. User: %s Password: %s\n", *user, result.Password)
+		userDSN, err := p.UserDSN(*user, result.Password)
 		if err != nil {
-			log.Fatal(err)
+			log.Fatalf("Building read-only DSN failed: %v", err)
 		}
+		fmt.Printf("Read-only DSN: %s\n", userDSN)
+	} else {
+		fmt.Printf("User %s exists, permissions updated.\n", *user)
+	}
+	if !result.Expires.IsZero() {
+		fmt.Printf("Access expires at %s.\n", result.Expires.Format(time.RFC3339))
+		switch *backend {
+		case "mysql":
+			fmt.Println("Warning: MySQL does not expire accounts; revoke the user once it has expired.")
+		case "sqlserver":
+			fmt.Println("Warning: SQL Server does not expire logins; revoke the user once it has expired.")
+		}
+	}
 
-		fmt.Println("Creating user...")
-		err = p.CreateUser(ctx, *user, password)
+	// The password of an existing user is unknown, so only new users can be checked.
+	if result.Created {
+		fmt.Println("Verifying grants...")
+		err := p.VerifyReadOnly(ctx, *user, result.Password, scope)
+		switch {
+		case errors.Is(err, provision.ErrNoTableToVerify):
+			fmt.Println("Warning: no table in scope yet, grants were not verified.")
+		case err != nil:
+			log.Fatalf("Verification failed: %v", err)
+		}
+	}
+	fmt.Println("Success!")
+}
+
+// printDryRun reports what EnsureUser would do for the user and the grant
+// statements it would run.
+func printDryRun(user string, result *provision.EnsureResult, opts provision.Options) {
+	switch {
+	case result.Created:
+		fmt.Printf("Dry run: user %s would be created with a generated password.\n", user)
+	case opts.Prune:
+		fmt.Printf("Dry run: user %s exists; its grants would be revoked and rebuilt.\n", user)
+	default:
+		fmt.Printf("Dry run: user %s exists; missing grants would be added.\n", user)
+	}
+	if !opts.Expires.IsZero() {
+		fmt.Printf("Access would expire at %s.\n", opts.Expires.Format(time.RFC3339))
+	}
+	if len(result.Grants) == 0 {
+		fmt.Println("No grant statements.")
+	}
+	for _, stmt := range result.Grants {
+		fmt.Println(stmt)
+	}
+}
+
+// checkTLS warns, or exits with requireTLS, when the admin connection is plaintext,
+// since the generated password is sent over it.
+func checkTLS(ctx context.Context, p provision.Provisioner, requireTLS bool) {
+	encrypted, err := p.ConnectionEncrypted(ctx)
+	switch {
+	case err != nil && requireTLS:
+		log.Fatalf("Could not determine whether the connection is encrypted: %v", err)
+	case err != nil:
+		fmt.Printf("Warning: could not determine whether the connection is encrypted: %v\n", err)
+	case !encrypted && requireTLS:
+		log.Fatal("Error: the admin connection is not encrypted (-require-tls).")
+	case !encrypted:
+		fmt.Println("Warning: the admin connection is not encrypted; credentials are sent in plaintext. Use -require-tls to refuse this.")
+	}
+}
+
+// splitFunctions splits a comma-separated function list, keeping commas inside
+// argument lists such as "reporting.totals(integer, date)".
+func splitFunctions(list string) []string {
+	var functions []string
+	depth, start := 0, 0
+	for i, c := range list + "," {
+		switch {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			if fn := strings.TrimSpace(list[start:i]); fn != "" {
+				functions = append(functions, fn)
+			}
+			start = i + 1
+		}
+	}
+	return functions
+}
+
+// parseColumns parses a -columns value such as "public.users:id,name;public.orders:id"
+// into the columns granted per table.
+func parseColumns(list string) (map[string][]string, error) {
+	if list == "" {
+		return nil, nil
+	}
+	columns := map[string][]string{}
+	for entry := range strings.SplitSeq(list, ";") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		table, cols, ok := strings.Cut(entry, ":")
+		table = strings.TrimSpace(table)
+		if !ok || !strings.Contains(table, ".") {
+			return nil, fmt.Errorf("column grant '%s' must have the format 'schema.table:col1,col2'", entry)
+		}
+		for col := range strings.SplitSeq(cols, ",") {
+			if col = strings.TrimSpace(col); col != "" {
+				columns[table] = append(columns[table], col)
+			}
+		}
+		if len(columns[table]) == 0 {
+			return nil, fmt.Errorf("column grant '%s' lists no columns", entry)
+		}
+	}
+	return columns, nil
+}
+
+// revokeUsers drops the comma-separated users plus every user matching the
+// prefix, reporting each outcome. It exits non-zero if any drop failed.
+func revokeUsers(ctx context.Context, p provision.Provisioner, userList, prefix string) {
+	var users []string
+	for v := range strings.SplitSeq(userList, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			users = append(users, v)
+		}
+	}
+	if prefix != "" {
+		managed, err := provision.UsersWithPrefix(ctx, p, prefix)
 		if err != nil {
-			log.Fatal(err)
+			log.Fatalf("Listing users failed: %v", err)
+		}
+		if len(managed) == 0 {
+			fmt.Printf("No users found with prefix %q.\n", prefix)
 		}
+		users = append(users, managed...)
+	}
 
-		fmt.Printf("Success! User: %s Password: %s\n", *user, password)
+	failed := 0
+	for _, r := range provision.DropUsers(ctx, p, users) {
+		if r.Err != nil {
+			failed++
+			fmt.Printf("User %s: revoke failed: %v\n", r.User, r.Err)
+			continue
+		}
+		fmt.Printf("User %s revoked.\n", r.User)
+	}
+	if failed > 0 {
+		log.Fatalf("Revoke failed for %d of %d users", failed, len(users))
 	}
+}
 
-	fmt.Println("Granting permissions...")
-	if err := p.GrantReadOnly(ctx, *user, scope); err != nil {
-		log.Fatalf("Grant failed: %v", err)
+// rotatePassword sets a new generated password for an existing user and prints it
+// with the user's DSN.
+func rotatePassword(ctx context.Context, p provision.Provisioner, user string) {
+	exists, err := p.UserExists(ctx, user)
+	if err != nil {
+		log.Fatalf("Looking up user failed: %v", err)
 	}
-	fmt.Println("Success!")
+	if !*exists {
+		log.Fatalf("Error: user %s does not exist.", user)
+	}
+	expiry, err := p.UserExpiry(ctx, user)
+	if err != nil {
+		log.Fatalf("Looking up expiry failed: %v", err)
+	}
+	if !expiry.IsZero() && expiry.Before(time.Now()) {
+		log.Fatalf("Error: user %s expired at %s; provision it with a new -expires to renew it.", user, expiry.Format(time.RFC3339))
+	}
+	password, err := provision.GeneratePassword()
+	if err != nil {
+		log.Fatalf("Generating password failed: %v", err)
+	}
+	if err := p.RotatePassword(ctx, user, password); err != nil {
+		log.Fatalf("Rotating password failed: %v", err)
+	}
+	fmt.Printf("Rotated password. User: %s Password: %s\n", user, password)
+	userDSN, err := p.UserDSN(user, password)
+	if err != nil {
+		log.Fatalf("Building read-only DSN failed: %v", err)
+	}
+	fmt.Printf("Read-only DSN: %s\n", userDSN)
+}
+
+// listUsers prints each login user whose name starts with prefix, followed by the
+// privileges granted to it.
+func listUsers(ctx context.Context, p provision.Provisioner, prefix string) {
+	users, err := provision.ListUserGrants(ctx, p, prefix)
+	if err != nil {
+		log.Fatalf("Listing users failed: %v", err)
+	}
+	if len(users) == 0 {
+		fmt.Println("No users found.")
+	}
+	for _, u := range users {
+		switch {
+		case u.Expires.IsZero():
+			fmt.Println(u.User)
+		case u.Expires.Before(time.Now()):
+			fmt.Printf("%s (expired %s)\n", u.User, u.Expires.Format(time.RFC3339))
+		default:
+			fmt.Printf("%s (expires %s)\n", u.User, u.Expires.Format(time.RFC3339))
+		}
+		if len(u.Grants) == 0 {
+			fmt.Println("  (no grants)")
+		}
+		for _, grant := range u.Grants {
+			fmt.Printf("  %s\n", grant)
+		}
+	}
+}
+
+// provisionSqlite checks that the database file can be opened read-only and prints
+// the read config for it. SQLite has no users, so there is nothing to create or revoke.
+func provisionSqlite(dsn string) {
+	if dsn == "" {
+		log.Fatal("Error: -dsn is required.")
+	}
+
+	p := &provision.SqliteProvisioner{}
+	if err := p.Connect(dsn); err != nil {
+		log.Fatalf("Connection failed: %v", err)
+	}
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := p.GrantReadOnly(ctx, "", provision.AccessScope{}); err != nil {
+		log.Fatalf("Read-only check failed: %v", err)
+	}
+	fmt.Printf("SQLite has no users; read access is opened with mode=ro.\nRead-only DSN: %s\n", p.ReadOnlyDSN())
 }
@@ -0,0 +1,7 @@
+//go:build cgo
+
+package main
+
+// The DuckDB backend links the DuckDB library through cgo, so builds with
+// CGO_ENABLED=0 leave it out.
+import _ "github.com/tinternet/databaise/internal/duckdb"
@@ -1,15 +1,21 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"maps"
 	"os"
+	"os/signal"
 	"slices"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/tinternet/databaise/internal/backend"
 	"github.com/tinternet/databaise/internal/config"
 	"github.com/tinternet/databaise/internal/logging"
 	"github.com/tinternet/databaise/internal/server"
+	"github.com/tinternet/databaise/internal/tracing"
 
 	_ "github.com/tinternet/databaise/internal/mysql"
 	_ "github.com/tinternet/databaise/internal/postgres"
@@ -17,23 +23,99 @@ import (
 	_ "github.com/tinternet/databaise/internal/sqlserver"
 )
 
+// version is set at release time via -ldflags "-X main.version=...".
+var version string
+
+// traceFlushTimeout bounds how long exiting waits for pending spans to be exported.
+const traceFlushTimeout = 5 * time.Second
+
 func main() {
 	transportMode := flag.String("transport", "http", "Transport mode: http or stdio")
 	configPath := flag.String("config", "config.json", "Path to configuration file")
 	httpAddress := flag.String("address", "0.0.0.0:8888", "HTTP server address (only used in http mode)")
+	authToken := flag.String("auth-token", os.Getenv("DATABAISE_AUTH_TOKEN"), "Bearer token HTTP clients must send in the Authorization header (default $DATABAISE_AUTH_TOKEN; only used in http mode)")
 	gormLogLevel := flag.String("gorm-log-level", "silent", "GORM log level: silent, error, warn, info")
+	serverName := flag.String("server-name", "", "MCP server name advertised to clients (default \"databaise\")")
+	serverVersion := flag.String("server-version", version, "MCP server version advertised to clients (default: build version)")
+	readOnly := flag.Bool("read-only", false, "Disable all tools that modify databases, regardless of config")
+	maxDatabases := flag.Int("max-databases", 0, "Refuse to start if the config defines more databases than this (0 means no limit)")
+	warmSchema := flag.Bool("warm-schema", false, "At startup, cache list_tables and describe_table results for databases with a cache configured")
+	queryLog := flag.String("query-log", "", "File to append slow and failed SQL statements to as JSON lines, with literals replaced by ? (\"-\" for stderr)")
+	slowQueryMs := flag.Int("slow-query-ms", 1000, "Log statements taking at least this many milliseconds to -query-log (0 logs every statement, -1 none)")
+	logFailedQueries := flag.Bool("log-failed-queries", true, "Log statements that fail to -query-log")
+	auditLog := flag.String("audit-log", "", "File to append every database tool call to as JSON lines, with tool, database, caller, input, duration and error (\"-\" for stderr)")
+	auditHashQueries := flag.Bool("audit-hash-queries", false, "Replace SQL text in -audit-log with its SHA-256 hash")
 	flag.Parse()
 
+	server.SetImplementation(*serverName, *serverVersion)
+	if *readOnly {
+		server.SetReadOnly()
+	}
+
 	logging.SetGormLogLevel(logging.ParseGormLogLevel(*gormLogLevel))
 
 	if *transportMode == "stdio" {
 		logging.SetOutput(os.Stderr)
 	}
 
+	switch *queryLog {
+	case "":
+	case "-":
+		backend.SetQueryLog(os.Stderr, time.Duration(*slowQueryMs)*time.Millisecond, *logFailedQueries)
+	default:
+		f, err := os.OpenFile(*queryLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			logging.Fatal("Failed to open query log: %v", err)
+		}
+		defer f.Close()
+		backend.SetQueryLog(f, time.Duration(*slowQueryMs)*time.Millisecond, *logFailedQueries)
+	}
+
+	switch *auditLog {
+	case "":
+	case "-":
+		backend.SetAuditLog(os.Stderr, *auditHashQueries)
+	default:
+		f, err := os.OpenFile(*auditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			logging.Fatal("Failed to open audit log: %v", err)
+		}
+		defer f.Close()
+		backend.SetAuditLog(f, *auditHashQueries)
+	}
+
+	// The servers stop when ctx is cancelled by a signal, so main returns and the
+	// deferred calls run. A second signal kills the process as usual.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// Spans are exported only when the OTEL_* environment configures an exporter.
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		logging.Fatal("Failed to set up tracing: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), traceFlushTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logging.Warn("failed to flush traces: %v", err)
+		}
+	}()
+
 	cfg, err := config.LoadFromFile(*configPath)
 	if err != nil {
 		logging.Fatal("Failed to load config: %v", err)
 	}
+	if *maxDatabases > 0 && len(cfg) > *maxDatabases {
+		logging.Fatal("Config defines %d databases, more than -max-databases %d", len(cfg), *maxDatabases)
+	}
+	for _, names := range cfg.SharedConnections() {
+		logging.Warn("databases %s use the same read connection; check the config for a copy-paste mistake", strings.Join(names, ", "))
+	}
 
 	// Sorted for consistent log order
 	dbNames := slices.Sorted(maps.Keys(cfg))
@@ -50,12 +132,24 @@ func main() {
 		logging.Info("Registered database: %s (%s)", dbName, dbCfg.Backend)
 	}
 
+	if !backend.HasAnyAdmin() {
+		server.RemoveAdminTools()
+	}
+	if !backend.HasAnyWrite() {
+		server.RemoveWriteTools()
+	}
+
+	if *warmSchema {
+		backend.WarmSchemaCaches(ctx)
+	}
+
 	// Start server based on transport mode
 	switch *transportMode {
 	case "http":
-		server.StartHTTP(*httpAddress)
+		server.SetHealthCheck(backend.CheckHealth)
+		server.StartHTTP(ctx, *httpAddress, *authToken)
 	case "stdio":
-		server.StartSTDIO()
+		server.StartSTDIO(ctx)
 	default:
 		logging.Fatal("Unknown transport mode: %s (valid options: stdio, http)", *transportMode)
 	}
@@ -0,0 +1,107 @@
+package backend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/tinternet/databaise/internal/logging"
+	"github.com/tinternet/databaise/internal/server"
+)
+
+// auditEntry is a line of the audit log.
+type auditEntry struct {
+	Time       time.Time       `json:"time"`
+	Database   string          `json:"database"`
+	Tool       string          `json:"tool"`
+	Client     string          `json:"client,omitempty"`
+	Session    string          `json:"session,omitempty"`
+	Input      json.RawMessage `json:"input"`
+	DurationMs float64         `json:"duration_ms"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// auditLog writes every database tool call as a JSON line, including calls that
+// were refused before reaching the database.
+type auditLog struct {
+	mu          sync.Mutex
+	w           io.Writer
+	hashQueries bool
+}
+
+var auditLogger *auditLog
+
+// SetAuditLog starts writing every database tool call to w. With hashQueries,
+// the SQL text in the logged input is replaced by its SHA-256 hash, so the log
+// shows which calls ran the same statement without showing the statement.
+func SetAuditLog(w io.Writer, hashQueries bool) {
+	auditLogger = &auditLog{w: w, hashQueries: hashQueries}
+}
+
+// record logs a tool call that started at start. A nil log records nothing.
+func (l *auditLog) record(ctx context.Context, database string, in any, start time.Time, err error) {
+	if l == nil {
+		return
+	}
+	input, mErr := l.input(in)
+	if mErr != nil {
+		logging.Warn("audit log: %v", mErr)
+		return
+	}
+	caller := server.CallerOf(ctx)
+	entry := auditEntry{
+		Time:       start.UTC(),
+		Database:   database,
+		Tool:       server.ToolName(ctx),
+		Client:     caller.Client,
+		Session:    caller.Session,
+		Input:      input,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	line, mErr := json.Marshal(entry)
+	if mErr != nil {
+		logging.Warn("audit log: %v", mErr)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, wErr := l.w.Write(append(line, '\n')); wErr != nil {
+		logging.Warn("audit log: %v", wErr)
+	}
+}
+
+// input returns the tool input as JSON, with the statement hashed if the log hashes queries.
+func (l *auditLog) input(in any) (json.RawMessage, error) {
+	data, err := json.Marshal(in)
+	if err != nil || !l.hashQueries {
+		return data, err
+	}
+	s, ok := in.(statementInput)
+	if !ok || s.statement() == "" {
+		return data, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range fields {
+		if value == any(s.statement()) {
+			fields[name] = hashQuery(s.statement())
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// hashQuery returns the hex SHA-256 hash of query, prefixed with "sha256:".
+func hashQuery(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	instancesMu.Lock()
+	instances["audited"] = &Instance{Name: "audited"}
+	instancesMu.Unlock()
+	t.Cleanup(func() {
+		instancesMu.Lock()
+		delete(instances, "audited")
+		instancesMu.Unlock()
+		auditLogger = nil
+	})
+
+	var buf bytes.Buffer
+	getBackend := func(string) (SQLBackend, error) { return nil, nil }
+	query := func(err error) func(SQLBackend, context.Context, ReadQueryIn) (*QueryResult, error) {
+		return func(SQLBackend, context.Context, ReadQueryIn) (*QueryResult, error) {
+			return &QueryResult{}, err
+		}
+	}
+	entries := func() []auditEntry {
+		var out []auditEntry
+		for line := range strings.Lines(buf.String()) {
+			var e auditEntry
+			require.NoError(t, json.Unmarshal([]byte(line), &e))
+			out = append(out, e)
+		}
+		buf.Reset()
+		return out
+	}
+	in := ReadQueryIn{Query: "SELECT * FROM users WHERE id = 42"}
+
+	t.Run("Success", func(t *testing.T) {
+		SetAuditLog(&buf, false)
+		_, err := Handle(t.Context(), "audited", in, getBackend, query(nil))
+		require.NoError(t, err)
+		logged := entries()
+		require.Len(t, logged, 1)
+		require.Equal(t, "audited", logged[0].Database)
+		require.Contains(t, string(logged[0].Input), `"query":"SELECT * FROM users WHERE id = 42"`)
+		require.Empty(t, logged[0].Error)
+		require.False(t, logged[0].Time.IsZero())
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		SetAuditLog(&buf, false)
+		_, err := Handle(t.Context(), "audited", in, getBackend, query(errors.New("permission denied")))
+		require.Error(t, err)
+		logged := entries()
+		require.Len(t, logged, 1)
+		require.Contains(t, logged[0].Error, "permission denied")
+	})
+
+	t.Run("UnknownDatabase", func(t *testing.T) {
+		SetAuditLog(&buf, false)
+		_, err := Handle(t.Context(), "audited_missing", in, getBackend, query(nil))
+		require.Error(t, err)
+		logged := entries()
+		require.Len(t, logged, 1)
+		require.Equal(t, "audited_missing", logged[0].Database)
+		require.NotEmpty(t, logged[0].Error)
+	})
+
+	t.Run("HashQueries", func(t *testing.T) {
+		SetAuditLog(&buf, true)
+		_, err := Handle(t.Context(), "audited", in, getBackend, query(nil))
+		require.NoError(t, err)
+		logged := entries()
+		require.Len(t, logged, 1)
+		require.NotContains(t, string(logged[0].Input), "users")
+		require.Contains(t, string(logged[0].Input), `"query":"`+hashQuery(in.Query)+`"`)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		auditLogger = nil
+		_, err := Handle(t.Context(), "audited", in, getBackend, query(nil))
+		require.NoError(t, err)
+		require.Empty(t, entries())
+	})
+}
@@ -0,0 +1,44 @@
+package backend
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/tinternet/databaise/internal/server"
+)
+
+// globalTools are tools that do not operate on a database and so are not part of
+// any backend's capabilities.
+var globalTools = []string{"list_databases", "list_backends", "pool_stats"}
+
+// BackendInfo describes a registered backend type for list_backends.
+type BackendInfo struct {
+	Type    string   `json:"type" jsonschema:"The backend type used in the config (e.g. postgres)"`
+	Dialect string   `json:"dialect" jsonschema:"The SQL dialect of the backend"`
+	Tools   []string `json:"tools" jsonschema:"The database tools this backend supports"`
+}
+
+// ListBackendsOut is the output for the list_backends tool.
+type ListBackendsOut struct {
+	Backends []BackendInfo `json:"backends" jsonschema:"List of backend types compiled into the server"`
+}
+
+// ListBackends returns the registered backend types and their capabilities,
+// regardless of which databases are configured.
+func ListBackends() ListBackendsOut {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	result := make([]BackendInfo, 0, len(factories))
+	for backendType, entry := range factories {
+		var tools []string
+		for _, t := range server.Tools() {
+			if !slices.Contains(globalTools, t.Name) && !slices.Contains(entry.unsupported, t.Name) {
+				tools = append(tools, t.Name)
+			}
+		}
+		result = append(result, BackendInfo{Type: backendType, Dialect: entry.dialect, Tools: tools})
+	}
+	slices.SortFunc(result, func(a, b BackendInfo) int { return strings.Compare(a.Type, b.Type) })
+	return ListBackendsOut{Backends: result}
+}
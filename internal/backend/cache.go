@@ -0,0 +1,172 @@
+package backend
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tinternet/databaise/internal/config"
+	"github.com/tinternet/databaise/internal/sqlcommon"
+)
+
+const (
+	defaultCacheTTL        = 30 * time.Second
+	defaultCacheMaxEntries = 100
+)
+
+type cacheEntry struct {
+	key     string
+	result  *QueryResult
+	expires time.Time
+}
+
+// queryCache holds recent execute_query results of one database, keyed by query
+// text with comments removed and whitespace collapsed outside string literals and
+// quoted identifiers. The oldest entry is evicted when it is full.
+type queryCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+func newQueryCache(cfg *config.Cache) *queryCache {
+	if cfg == nil {
+		return nil
+	}
+	c := &queryCache{
+		ttl:        time.Duration(cfg.TTLSeconds) * time.Second,
+		maxEntries: cfg.MaxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+	if c.ttl <= 0 {
+		c.ttl = defaultCacheTTL
+	}
+	if c.maxEntries <= 0 {
+		c.maxEntries = defaultCacheMaxEntries
+	}
+	return c
+}
+
+func cacheKey(query string) string {
+	return sqlcommon.StripComments(query)
+}
+
+// get returns a copy of the cached result for query, if present and not expired.
+func (c *queryCache) get(query string) (*QueryResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[cacheKey(query)]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, entry.key)
+		return nil, false
+	}
+	res := *entry.result
+	return &res, true
+}
+
+// put stores a copy of result, so callers may modify theirs.
+func (c *queryCache) put(query string, result *QueryResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cacheKey(query)
+	if el, ok := c.entries[key]; ok {
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+	for c.order.Len() >= c.maxEntries {
+		oldest := c.order.Front()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+
+	res := *result
+	c.entries[key] = c.order.PushBack(&cacheEntry{key: key, result: &res, expires: time.Now().Add(c.ttl)})
+}
+
+// invalidate drops every cached result. A nil cache is a no-op.
+func (c *queryCache) invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	c.order.Init()
+}
+
+// cachingBackend serves execute_query, list_tables and describe_table from the
+// database's result caches.
+type cachingBackend struct {
+	SQLBackend
+	cache  *queryCache
+	schema *schemaCache
+}
+
+func (b *cachingBackend) unwrap() SQLBackend { return b.SQLBackend }
+
+func (b *cachingBackend) ExecuteQuery(ctx context.Context, in ReadQueryIn) (*QueryResult, error) {
+	// An export's result is the file it writes, so it always runs.
+	if in.OutputPath != "" {
+		return b.SQLBackend.ExecuteQuery(ctx, in)
+	}
+	query := cachedQuery(in)
+	if res, ok := b.cache.get(query); ok {
+		return res, nil
+	}
+	res, err := b.SQLBackend.ExecuteQuery(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	b.cache.put(query, res)
+	return res, nil
+}
+
+// cachedQuery is the text a query's result is cached under. A query routed to
+// another schema, or a page of its result, is cached apart from the same query in
+// the default one.
+func cachedQuery(in ReadQueryIn) string {
+	key := in.Query
+	if in.Offset != 0 || in.Limit != 0 {
+		key = fmt.Sprintf("%d,%d\x01%s", in.Offset, in.Limit, key)
+	}
+	if in.Schema != "" {
+		key = in.Schema + "\x00" + key
+	}
+	return key
+}
+
+func (b *cachingBackend) ListTables(ctx context.Context, in ListTablesIn) ([]Table, error) {
+	if tables, ok := b.schema.getTables(in); ok {
+		return tables, nil
+	}
+	tables, err := b.SQLBackend.ListTables(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	b.schema.putTables(in, tables)
+	return tables, nil
+}
+
+func (b *cachingBackend) DescribeTable(ctx context.Context, in DescribeTableIn) (*TableDescription, error) {
+	if desc, ok := b.schema.getDescription(in); ok {
+		return desc, nil
+	}
+	desc, err := b.SQLBackend.DescribeTable(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	b.schema.putDescription(in, desc)
+	return desc, nil
+}
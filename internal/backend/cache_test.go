@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinternet/databaise/internal/config"
+)
+
+func TestQueryCache(t *testing.T) {
+	c := newQueryCache(&config.Cache{MaxEntries: 2})
+	c.put("SELECT 1", &QueryResult{RowCount: 1})
+
+	t.Run("HitIgnoresWhitespace", func(t *testing.T) {
+		res, ok := c.get("SELECT\n  1 -- again")
+		require.True(t, ok)
+		require.Equal(t, 1, res.RowCount)
+
+		// Callers get a copy they can modify.
+		res.RowCount = 5
+		res, _ = c.get("SELECT 1")
+		require.Equal(t, 1, res.RowCount)
+	})
+
+	t.Run("MissOnLiteralWhitespace", func(t *testing.T) {
+		lit := newQueryCache(&config.Cache{})
+		lit.put("SELECT * FROM users WHERE name = 'a  b'", &QueryResult{RowCount: 1})
+		_, ok := lit.get("SELECT * FROM users WHERE name = 'a b'")
+		require.False(t, ok)
+		_, ok = lit.get("SELECT *\nFROM users WHERE name = 'a  b'")
+		require.True(t, ok)
+	})
+
+	t.Run("EvictsOldest", func(t *testing.T) {
+		c.put("SELECT 2", &QueryResult{})
+		c.put("SELECT 3", &QueryResult{})
+		_, ok := c.get("SELECT 1")
+		require.False(t, ok)
+		_, ok = c.get("SELECT 3")
+		require.True(t, ok)
+	})
+
+	t.Run("Invalidate", func(t *testing.T) {
+		c.invalidate()
+		_, ok := c.get("SELECT 3")
+		require.False(t, ok)
+	})
+}
+
+func TestCachingBackendSchema(t *testing.T) {
+	stub := &queryStub{}
+	b := &cachingBackend{SQLBackend: stub, cache: newQueryCache(&config.Cache{})}
+
+	_, err := b.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users"})
+	require.NoError(t, err)
+
+	// The same query in another schema is not served from the cache...
+	stub.query = ""
+	_, err = b.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users", Schema: "tenant_a"})
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * FROM users", stub.query)
+
+	// ...but is cached in its own right.
+	stub.query = ""
+	_, err = b.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users", Schema: "tenant_a"})
+	require.NoError(t, err)
+	require.Empty(t, stub.query)
+}
+
+func TestCachingBackendPages(t *testing.T) {
+	stub := &queryStub{}
+	b := &cachingBackend{SQLBackend: stub, cache: newQueryCache(&config.Cache{})}
+
+	_, err := b.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users", Limit: 10})
+	require.NoError(t, err)
+
+	// The next page is not served from the cache of the first.
+	stub.query = ""
+	_, err = b.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users", Offset: 10, Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * FROM users", stub.query)
+
+	stub.query = ""
+	_, err = b.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users", Offset: 10, Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, stub.query)
+}
@@ -0,0 +1,162 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tinternet/databaise/internal/sqlcommon"
+)
+
+type exportDirKey struct{}
+
+// withExportDir returns a context that lets execute_query write results to files
+// under dir. An empty dir leaves exports disabled.
+func withExportDir(ctx context.Context, dir string) context.Context {
+	if dir == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, exportDirKey{}, dir)
+}
+
+// exportQuery runs a query with its rows streamed to in.OutputPath as CSV or JSON
+// Lines instead of returned, so exports are not bound by the response size. The
+// file is removed if the query fails part way.
+func exportQuery(ctx context.Context, b SQLBackend, in ReadQueryIn) (*QueryResult, error) {
+	dir, _ := ctx.Value(exportDirKey{}).(string)
+	if dir == "" {
+		return nil, fmt.Errorf("exporting to a file is not enabled for this database: set export_dir in its config")
+	}
+	format := in.OutputFormat
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(in.OutputPath), ".")
+	}
+	if format != "csv" && format != "jsonl" {
+		return nil, fmt.Errorf("unsupported output_format %q: use csv or jsonl", format)
+	}
+	name, err := exportPath(dir, in.OutputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// The root confines the file to dir, also against symlinks that point out of it.
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	f, err := root.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	w := newExportWriter(f, format)
+	res, err := b.ExecuteQuery(sqlcommon.WithRowSink(ctx, w), in)
+	if err == nil {
+		err = w.flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = root.Remove(name)
+		return nil, err
+	}
+
+	res.Rows = nil
+	res.RowCount = w.rows
+	res.OutputPath = filepath.Join(dir, name)
+	return res, nil
+}
+
+// exportPath returns path relative to dir, or an error if it leads outside dir.
+// path may be relative to dir or absolute.
+func exportPath(dir, path string) (string, error) {
+	name := path
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return "", fmt.Errorf("output_path %q is outside the export directory %s", path, dir)
+		}
+		name = rel
+	}
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("output_path %q is outside the export directory %s", path, dir)
+	}
+	return filepath.Clean(name), nil
+}
+
+// exportWriter is a sqlcommon.RowSink writing rows as CSV with a header line, or
+// as JSON Lines with one object per row and keys in column order.
+type exportWriter struct {
+	buf     *bufio.Writer
+	csv     *csv.Writer
+	columns []string
+	rows    int
+}
+
+func newExportWriter(w io.Writer, format string) *exportWriter {
+	e := &exportWriter{buf: bufio.NewWriter(w)}
+	if format == "csv" {
+		e.csv = csv.NewWriter(e.buf)
+	}
+	return e
+}
+
+func (e *exportWriter) WriteHeader(columns []string) error {
+	e.columns = columns
+	if e.csv != nil {
+		return e.csv.Write(columns)
+	}
+	return nil
+}
+
+func (e *exportWriter) WriteRow(row map[string]any) error {
+	e.rows++
+	if e.csv != nil {
+		// NULL is written as an empty field.
+		record := make([]string, len(e.columns))
+		for i, col := range e.columns {
+			if v := derefValue(row[col]); v != nil {
+				record[i] = formatValue(v)
+			}
+		}
+		return e.csv.Write(record)
+	}
+
+	e.buf.WriteByte('{')
+	for i, col := range e.columns {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		key, err := json.Marshal(col)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(row[col])
+		if err != nil {
+			return err
+		}
+		e.buf.Write(key)
+		e.buf.WriteByte(':')
+		e.buf.Write(value)
+	}
+	_, err := e.buf.WriteString("}\n")
+	return err
+}
+
+func (e *exportWriter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return e.buf.Flush()
+}
@@ -0,0 +1,90 @@
+package backend
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinternet/databaise/internal/sqlcommon"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// rowsStub runs queries against a real database, so exports stream through QueryRows.
+type rowsStub struct {
+	SQLBackend
+	db *gorm.DB
+}
+
+func (s *rowsStub) ExecuteQuery(ctx context.Context, in ReadQueryIn) (*QueryResult, error) {
+	rows, err := sqlcommon.QueryRows(ctx, s.db, in.Query)
+	if err != nil {
+		return nil, err
+	}
+	return NewQueryResult(in.Query, rows), nil
+}
+
+func TestExportQuery(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`
+		CREATE TABLE notes (id INTEGER, body TEXT);
+		INSERT INTO notes VALUES (1, 'plain'), (2, 'has, comma'), (3, NULL);
+	`).Error)
+	b := &rowsStub{db: db}
+
+	dir := t.TempDir()
+	// Row caps are for inline results; an export writes every row.
+	ctx := sqlcommon.WithMaxRows(withExportDir(t.Context(), dir), 1)
+
+	t.Run("CSV", func(t *testing.T) {
+		res, err := exportQuery(ctx, b, ReadQueryIn{Query: "SELECT id, body FROM notes ORDER BY id", OutputPath: "notes.csv"})
+		require.NoError(t, err)
+		require.Equal(t, 3, res.RowCount)
+		require.Empty(t, res.Rows)
+		require.Equal(t, filepath.Join(dir, "notes.csv"), res.OutputPath)
+
+		data, err := os.ReadFile(res.OutputPath)
+		require.NoError(t, err)
+		require.Equal(t, "id,body\n1,plain\n2,\"has, comma\"\n3,\n", string(data))
+	})
+
+	t.Run("JSONL", func(t *testing.T) {
+		res, err := exportQuery(ctx, b, ReadQueryIn{Query: "SELECT body, id FROM notes ORDER BY id", OutputPath: filepath.Join(dir, "notes.out"), OutputFormat: "jsonl"})
+		require.NoError(t, err)
+		require.Equal(t, 3, res.RowCount)
+
+		data, err := os.ReadFile(res.OutputPath)
+		require.NoError(t, err)
+		require.Equal(t, `{"body":"plain","id":1}`+"\n"+`{"body":"has, comma","id":2}`+"\n"+`{"body":null,"id":3}`+"\n", string(data))
+	})
+
+	t.Run("FailedQueryRemovesFile", func(t *testing.T) {
+		_, err := exportQuery(ctx, b, ReadQueryIn{Query: "SELECT * FROM missing", OutputPath: "failed.csv"})
+		require.Error(t, err)
+		require.NoFileExists(t, filepath.Join(dir, "failed.csv"))
+	})
+
+	t.Run("OutsideExportDir", func(t *testing.T) {
+		outside := t.TempDir()
+		require.NoError(t, os.Symlink(outside, filepath.Join(dir, "link")))
+
+		for _, path := range []string{"../notes.csv", filepath.Join(outside, "notes.csv"), "link/notes.csv"} {
+			_, err := exportQuery(ctx, b, ReadQueryIn{Query: "SELECT 1", OutputPath: path})
+			require.Error(t, err, path)
+		}
+		require.NoFileExists(t, filepath.Join(outside, "notes.csv"))
+	})
+
+	t.Run("UnsupportedFormat", func(t *testing.T) {
+		_, err := exportQuery(ctx, b, ReadQueryIn{Query: "SELECT 1", OutputPath: "notes.xlsx"})
+		require.ErrorContains(t, err, "unsupported output_format")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		_, err := exportQuery(t.Context(), b, ReadQueryIn{Query: "SELECT 1", OutputPath: "notes.csv"})
+		require.ErrorContains(t, err, "export_dir")
+	})
+}
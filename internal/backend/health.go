@@ -0,0 +1,86 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PingResult is the outcome of pinging a database's read connection.
+type PingResult struct {
+	Database  string  `json:"database" jsonschema:"The database name"`
+	Healthy   bool    `json:"healthy" jsonschema:"Whether the read connection answered the ping"`
+	LatencyMs float64 `json:"latency_ms" jsonschema:"How long the ping took, in milliseconds"`
+	Error     string  `json:"error,omitempty" jsonschema:"Why the ping failed"`
+}
+
+// ping checks that the read connection can reach the database.
+func (i *Instance) ping(ctx context.Context) PingResult {
+	result := PingResult{Database: i.Name}
+	if i.readPool == nil {
+		result.Error = "the read connection has no connection pool to ping"
+		return result
+	}
+	start := time.Now()
+	err := i.readPool.PingContext(ctx)
+	result.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Healthy = true
+	return result
+}
+
+// PingDatabase pings the read connection of the named database. A failed ping is
+// reported in the result; only an unknown database is an error.
+func PingDatabase(ctx context.Context, name string) (*PingResult, error) {
+	inst, err := GetInstance(name)
+	if err != nil {
+		return nil, err
+	}
+	result := inst.ping(ctx)
+	return &result, nil
+}
+
+// PingAll pings the read connection of every initialized database concurrently,
+// sorted by database name.
+func PingAll(ctx context.Context) []PingResult {
+	instancesMu.RLock()
+	all := make([]*Instance, 0, len(instances))
+	for _, inst := range instances {
+		all = append(all, inst)
+	}
+	instancesMu.RUnlock()
+
+	results := make([]PingResult, len(all))
+	var wg sync.WaitGroup
+	for i, inst := range all {
+		wg.Go(func() {
+			results[i] = inst.ping(ctx)
+		})
+	}
+	wg.Wait()
+	slices.SortFunc(results, func(a, b PingResult) int { return strings.Compare(a.Database, b.Database) })
+	return results
+}
+
+// CheckHealth pings every database and returns an error naming the unreachable
+// ones. The ping errors are logged rather than returned, since the health
+// endpoint is served without authentication.
+func CheckHealth(ctx context.Context) error {
+	var unhealthy []string
+	for _, r := range PingAll(ctx) {
+		if !r.Healthy {
+			log.Printf("Health check: database %s is unreachable: %s", r.Database, r.Error)
+			unhealthy = append(unhealthy, r.Database)
+		}
+	}
+	if len(unhealthy) > 0 {
+		return fmt.Errorf("unreachable databases: %s", strings.Join(unhealthy, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPing(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	up, err := db.DB()
+	require.NoError(t, err)
+
+	closed, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	down, err := closed.DB()
+	require.NoError(t, err)
+	require.NoError(t, down.Close())
+
+	instancesMu.Lock()
+	instances["ping_up"] = &Instance{Name: "ping_up", readPool: up}
+	instances["ping_down"] = &Instance{Name: "ping_down", readPool: down}
+	instancesMu.Unlock()
+	t.Cleanup(func() {
+		instancesMu.Lock()
+		delete(instances, "ping_up")
+		delete(instances, "ping_down")
+		instancesMu.Unlock()
+	})
+
+	res, err := PingDatabase(t.Context(), "ping_up")
+	require.NoError(t, err)
+	require.True(t, res.Healthy)
+	require.Empty(t, res.Error)
+
+	res, err = PingDatabase(t.Context(), "ping_down")
+	require.NoError(t, err)
+	require.False(t, res.Healthy)
+	require.NotEmpty(t, res.Error)
+
+	_, err = PingDatabase(t.Context(), "ping_missing")
+	require.Error(t, err)
+
+	err = CheckHealth(t.Context())
+	require.ErrorContains(t, err, "ping_down")
+	require.NotContains(t, err.Error(), "ping_up")
+}
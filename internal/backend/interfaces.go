@@ -1,36 +1,137 @@
 package backend
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/tinternet/databaise/internal/sqlcommon"
+)
 
 // Table represents a database table.
 type Table struct {
-	Schema string `json:"schema,omitempty" jsonschema:"The schema name (if applicable)"`
-	Name   string `json:"name" jsonschema:"The table name"`
+	Schema        string `json:"schema,omitempty" jsonschema:"The schema name (if applicable)"`
+	Name          string `json:"name" jsonschema:"The table name"`
+	EstimatedRows int64  `json:"estimated_rows,omitempty" jsonschema:"Approximate number of rows from the database's statistics, exact on SQLite (only with with_stats)"`
+	SizeBytes     int64  `json:"size_bytes,omitempty" jsonschema:"Size of the table and its indexes in bytes (only with with_stats, not available for SQLite)"`
 }
 
 // TableDescription represents a table's DDL.
 type TableDescription struct {
-	CreateTable       string   `json:"create_table" jsonschema:"The CREATE TABLE statement"`
-	CreateIndexes     []string `json:"create_indexes,omitempty" jsonschema:"CREATE INDEX statements"`
-	CreateConstraints []string `json:"create_constraints,omitempty" jsonschema:"CREATE CONSTRAINT statements"`
+	CreateTable       string            `json:"create_table" jsonschema:"The CREATE TABLE statement"`
+	TableComment      string            `json:"table_comment,omitempty" jsonschema:"The comment describing the table, if any"`
+	ColumnComments    map[string]string `json:"column_comments,omitempty" jsonschema:"The comments describing the columns, by column name (only columns that have one)"`
+	CreateIndexes     []string          `json:"create_indexes,omitempty" jsonschema:"CREATE INDEX statements"`
+	CreateConstraints []string          `json:"create_constraints,omitempty" jsonschema:"CREATE CONSTRAINT statements"`
+	ForeignKeys       []ForeignKey      `json:"foreign_keys,omitempty" jsonschema:"The foreign keys of this table and the tables they reference"`
+	ReferencedBy      []ForeignKeyRef   `json:"referenced_by,omitempty" jsonschema:"Foreign keys in other tables that reference this table (only with include_referenced_by)"`
+	Hint              string            `json:"hint,omitempty" jsonschema:"Set when the table was found under a name that differs in case from the one requested, with how to refer to it in SQL"`
+}
+
+// ForeignKeyRef is a foreign key in another table that references the described table.
+type ForeignKeyRef struct {
+	Constraint        string `json:"constraint,omitempty" gorm:"column:constraint_name" jsonschema:"The foreign key constraint name"`
+	Schema            string `json:"schema,omitempty" gorm:"column:schema_name" jsonschema:"The schema of the referencing table"`
+	Table             string `json:"table" gorm:"column:table_name" jsonschema:"The referencing table"`
+	Columns           string `json:"columns" gorm:"column:columns" jsonschema:"The referencing columns, comma-separated"`
+	ReferencedColumns string `json:"referenced_columns" gorm:"column:referenced_columns" jsonschema:"The referenced columns in this table, comma-separated"`
+	OnDelete          string `json:"on_delete,omitempty" gorm:"column:on_delete" jsonschema:"The action on delete of a referenced row (e.g. CASCADE)"`
+	OnUpdate          string `json:"on_update,omitempty" gorm:"column:on_update" jsonschema:"The action on update of a referenced key"`
+}
+
+// ForeignKey is a foreign key of a table, for describe_table and list_foreign_keys.
+type ForeignKey struct {
+	Constraint        string `json:"constraint,omitempty" gorm:"column:constraint_name" jsonschema:"The foreign key constraint name"`
+	Schema            string `json:"schema,omitempty" gorm:"column:schema_name" jsonschema:"The schema of the referencing table"`
+	Table             string `json:"table" gorm:"column:table_name" jsonschema:"The referencing table"`
+	Columns           string `json:"columns" gorm:"column:columns" jsonschema:"The referencing columns, comma-separated"`
+	ReferencedSchema  string `json:"referenced_schema,omitempty" gorm:"column:referenced_schema" jsonschema:"The schema of the referenced table"`
+	ReferencedTable   string `json:"referenced_table" gorm:"column:referenced_table" jsonschema:"The referenced table"`
+	ReferencedColumns string `json:"referenced_columns" gorm:"column:referenced_columns" jsonschema:"The referenced columns, comma-separated (empty on SQLite when the key references the primary key implicitly)"`
+	OnDelete          string `json:"on_delete,omitempty" gorm:"column:on_delete" jsonschema:"The action on delete of a referenced row (e.g. CASCADE)"`
+	OnUpdate          string `json:"on_update,omitempty" gorm:"column:on_update" jsonschema:"The action on update of a referenced key"`
 }
 
 // QueryResult represents query results.
 type QueryResult struct {
-	Rows []map[string]any `json:"rows" jsonschema:"The result rows as key-value pairs"`
+	Columns     []string         `json:"columns,omitempty" jsonschema:"The result column names in query order"`
+	ColumnTypes []ColumnType     `json:"column_types,omitempty" jsonschema:"The database type of each result column, in query order, when include_column_types is set"`
+	Rows        []map[string]any `json:"rows" jsonschema:"The result rows as key-value pairs; null when format is markdown or output_path is set"`
+	Markdown    string           `json:"markdown,omitempty" jsonschema:"The result rows as a markdown table, when format is markdown"`
+	RowCount    int              `json:"row_count" jsonschema:"Number of rows returned"`
+	Truncated   bool             `json:"truncated,omitempty" jsonschema:"Whether rows were dropped because the result exceeded the row cap (max_rows) or the response size cap"`
+	OutputPath  string           `json:"output_path,omitempty" jsonschema:"The file the rows were written to, when output_path is set"`
+	Offset      int              `json:"offset,omitempty" jsonschema:"The number of rows skipped, when offset or limit is set"`
+	Limit       int              `json:"limit,omitempty" jsonschema:"The page size applied, when offset or limit is set"`
+	NextOffset  int              `json:"next_offset,omitempty" jsonschema:"The offset of the next page; omitted on the last page"`
+	// ExecutedSQL is only returned when the database has include_executed_sql set.
+	ExecutedSQL string `json:"executed_sql,omitempty" jsonschema:"The exact SQL the database ran"`
+}
+
+// ColumnType is the database type of a result column.
+type ColumnType struct {
+	Name         string `json:"name" jsonschema:"The column name"`
+	DatabaseType string `json:"database_type" jsonschema:"The type name reported by the database, e.g. VARCHAR or INT4"`
+	Nullable     *bool  `json:"nullable,omitempty" jsonschema:"Whether the column may contain NULL (omitted if the driver does not report it)"`
+}
+
+// NewQueryResult builds a QueryResult from the rows scanned for query.
+func NewQueryResult(query string, rows *sqlcommon.Rows) *QueryResult {
+	types := make([]ColumnType, len(rows.ColumnTypes))
+	for i, ct := range rows.ColumnTypes {
+		types[i] = ColumnType{Name: ct.Name(), DatabaseType: ct.DatabaseTypeName()}
+		if nullable, ok := ct.Nullable(); ok {
+			types[i].Nullable = &nullable
+		}
+	}
+	return &QueryResult{Columns: rows.Columns, ColumnTypes: types, Rows: rows.Rows, RowCount: len(rows.Rows), Truncated: rows.Truncated, ExecutedSQL: query}
 }
 
 // ExplainResult represents an execution plan.
 type ExplainResult struct {
 	Format     string `jsonschema:"Plan format: text | json | xml | table"`
 	Result     string `jsonschema:"Raw execution plan as returned by the database"`
-	ResultInfo string `jsonschema:"How to interpret this plan and key fields to look at"`
+	ResultInfo string `jsonschema:"How to interpret this plan, followed by a short reading of it (full scans, top operation)"`
+	// FullScanDetected is set if FullScans is not empty, as an explicit signal that an index may help.
+	FullScanDetected bool        `json:"full_scan_detected" jsonschema:"Whether the plan reads any table in full (Seq Scan, Table Scan, access type ALL, SCAN), which an index on the filtered or joined columns may avoid"`
+	FullScans        []TableScan `json:"full_scans,omitempty" jsonschema:"The tables the plan reads in full"`
+	Plan             []PlanNode  `json:"plan,omitempty" jsonschema:"The plan as a tree of operations, in the same form for every database; the top-level operations of each statement"`
+	// ExecutedSQL is only returned when the database has include_executed_sql set.
+	ExecutedSQL string `json:"executed_sql,omitempty" jsonschema:"The exact SQL the database ran, with parameters bound"`
+}
+
+// PlanNode is an operation of an execution plan, in a form shared by every backend.
+// Fields a database does not report are omitted.
+type PlanNode struct {
+	Operation     string   `json:"operation" jsonschema:"The operation as the database names it (e.g. Seq Scan, Hash Match (Inner Join), Full Table Scan, SCAN orders)"`
+	Table         string   `json:"table,omitempty" jsonschema:"The table the operation reads or writes"`
+	Index         string   `json:"index,omitempty" jsonschema:"The index the operation uses"`
+	EstimatedRows *float64 `json:"estimated_rows,omitempty" jsonschema:"Rows the planner expects the operation to return, per execution"`
+	ActualRows    *float64 `json:"actual_rows,omitempty" jsonschema:"Rows the operation returned, per execution (only when the query was analyzed)"`
+	Cost          *float64 `json:"cost,omitempty" jsonschema:"The planner's estimated cost of the operation and its children, in the database's own units"`
+	// Children holds PlanNode values. It is not a []PlanNode because the tool
+	// output schema is inferred from the types, and that cannot describe a recursive type.
+	Children []any `json:"children,omitempty" jsonschema:"The operations that feed this one, in the same form"`
+}
+
+// TableScan represents a full scan of a table found in an execution plan.
+type TableScan struct {
+	Table         string  `json:"table" jsonschema:"The scanned table"`
+	EstimatedRows float64 `json:"estimated_rows,omitempty" jsonschema:"Estimated number of rows in the table (omitted if unknown)"`
 }
 
 // DDLResult represents the result of a DDL operation.
 type DDLResult struct {
 	Success bool   `json:"success" jsonschema:"Whether the operation succeeded"`
 	Message string `json:"message,omitempty" jsonschema:"A message describing the result"`
+	// ExecutedSQL is only returned when the database has include_executed_sql set.
+	ExecutedSQL string `json:"executed_sql,omitempty" jsonschema:"The exact SQL the database ran"`
+}
+
+// WriteResult represents the result of a write statement.
+type WriteResult struct {
+	RowsAffected int64 `json:"rows_affected" jsonschema:"Number of rows the statement inserted, updated or deleted"`
+	// ExecutedSQL is only returned when the database has include_executed_sql set.
+	ExecutedSQL string `json:"executed_sql,omitempty" jsonschema:"The exact SQL the database ran"`
 }
 
 // MissingIndex represents a missing index recommendation.
@@ -50,11 +151,129 @@ type WaitingQuery struct {
 	State            string  `json:"state,omitempty" jsonschema:"Current state"`
 	WaitType         string  `json:"wait_type,omitempty" jsonschema:"Type of wait"`
 	WaitTime         float64 `json:"wait_time_sec,omitempty" jsonschema:"Wait time in seconds"`
-	BlockedBy        string  `json:"blocked_by,omitempty" jsonschema:"ID of blocking query"`
+	BlockedBy        string  `json:"blocked_by,omitempty" jsonschema:"IDs of the blocking queries, comma separated"`
 	Query            string  `json:"query" jsonschema:"The SQL query text"`
 	QueryDurationSec float64 `json:"query_duration_sec,omitempty" jsonschema:"Query duration in seconds"`
 }
 
+// Connection is a client session connected to the database server.
+type Connection struct {
+	ID               string  `json:"id" jsonschema:"Session or process identifier"`
+	Username         string  `json:"username,omitempty" jsonschema:"Database user"`
+	Database         string  `json:"database,omitempty" jsonschema:"Database name"`
+	ClientAddress    string  `json:"client_address,omitempty" jsonschema:"Address of the client (MySQL includes the port)"`
+	ApplicationName  string  `json:"application_name,omitempty" jsonschema:"Client application name (PostgreSQL and SQL Server)"`
+	State            string  `json:"state,omitempty" jsonschema:"Current state, e.g. active or idle in transaction (PostgreSQL), Sleep or Query (MySQL), running or sleeping (SQL Server)"`
+	ConnectedSec     float64 `json:"connected_sec,omitempty" jsonschema:"How long the session has been connected, in seconds (PostgreSQL and SQL Server)"`
+	Query            string  `json:"query,omitempty" jsonschema:"The running statement, or for PostgreSQL the last one"`
+	QueryDurationSec float64 `json:"query_duration_sec,omitempty" jsonschema:"How long the statement has been running, in seconds; for MySQL, how long the session has been in its state"`
+}
+
+type KillIdleTransactionsIn struct {
+	OlderThanSec int `json:"older_than_sec,omitempty" jsonschema:"Only terminate sessions idle in transaction for longer than this many seconds (optional, defaults to 300)"`
+}
+
+// TerminatedSession is a session ended by kill_idle_transactions.
+type TerminatedSession struct {
+	PID             int     `json:"pid" jsonschema:"Backend process ID"`
+	Username        string  `json:"username,omitempty" jsonschema:"Database user"`
+	Database        string  `json:"database,omitempty" jsonschema:"Database name"`
+	ApplicationName string  `json:"application_name,omitempty" jsonschema:"Client application name"`
+	IdleSec         float64 `json:"idle_sec" jsonschema:"How long the session had been idle in transaction, in seconds"`
+	Query           string  `json:"query,omitempty" jsonschema:"The last statement the session ran"`
+}
+
+// KillIdleTransactionsOut is the output for the kill_idle_transactions tool.
+type KillIdleTransactionsOut struct {
+	Terminated int                 `json:"terminated" jsonschema:"Number of sessions terminated"`
+	Sessions   []TerminatedSession `json:"sessions,omitempty" jsonschema:"The terminated sessions"`
+}
+
+// AnalyzeTableOut is the output for the analyze_table tool.
+type AnalyzeTableOut struct {
+	Success        bool       `json:"success" jsonschema:"Whether the statistics refresh completed"`
+	Message        string     `json:"message,omitempty" jsonschema:"A message describing the result"`
+	StatsUpdatedAt *time.Time `json:"stats_updated_at,omitempty" jsonschema:"When the table's statistics were last updated, as reported by the database (omitted if the database does not record it)"`
+}
+
+// SetCommentOut is the output for the set_comment tool.
+type SetCommentOut struct {
+	Success bool   `json:"success" jsonschema:"Whether the comment was written"`
+	Message string `json:"message,omitempty" jsonschema:"A message describing the result"`
+}
+
+// RefreshMaterializedViewOut is the output for the refresh_materialized_view tool.
+type RefreshMaterializedViewOut struct {
+	Success bool   `json:"success" jsonschema:"Whether the refresh completed"`
+	Message string `json:"message,omitempty" jsonschema:"A message describing the result"`
+}
+
+// TableProfile is the storage and access profile of a table, for the table_profile tool.
+type TableProfile struct {
+	Schema       string         `json:"schema,omitempty" jsonschema:"The schema name"`
+	Table        string         `json:"table" jsonschema:"The table name"`
+	RowEstimate  int64          `json:"row_estimate" jsonschema:"Approximate number of rows, from the database's statistics"`
+	TableBytes   int64          `json:"table_bytes" jsonschema:"Size of the table data in bytes"`
+	IndexBytes   int64          `json:"index_bytes" jsonschema:"Size of all of the table's indexes in bytes"`
+	TotalBytes   int64          `json:"total_bytes" jsonschema:"Total size of the table and its indexes in bytes"`
+	Indexes      []IndexProfile `json:"indexes,omitempty" jsonschema:"The table's indexes, largest first"`
+	SeqScans     *int64         `json:"seq_scans,omitempty" jsonschema:"Full scans of the table since statistics were last reset (omitted if the database does not track them)"`
+	IndexScans   *int64         `json:"index_scans,omitempty" jsonschema:"Index scans on the table since statistics were last reset (omitted if the database does not track them)"`
+	SeqScanRatio *float64       `json:"seq_scan_ratio,omitempty" jsonschema:"Fraction of scans that were full scans; close to 1 on a large table suggests a missing index"`
+	LastVacuum   *time.Time     `json:"last_vacuum,omitempty" jsonschema:"When the table was last vacuumed, manually or by autovacuum (PostgreSQL only)"`
+	LastAnalyze  *time.Time     `json:"last_analyze,omitempty" jsonschema:"When the table's statistics were last updated (omitted if unknown)"`
+	Hint         string         `json:"hint,omitempty" jsonschema:"Set when the table was found under a name that differs in case from the one requested, with how to refer to it in SQL"`
+}
+
+// IndexProfile is the size and usage of an index in a TableProfile.
+type IndexProfile struct {
+	Name  string `json:"name" jsonschema:"The index name"`
+	Bytes int64  `json:"bytes,omitempty" jsonschema:"Size of the index in bytes (omitted if unknown)"`
+	Scans *int64 `json:"scans,omitempty" jsonschema:"Times the index was used since statistics were last reset (omitted if the database does not track it)"`
+}
+
+// ProfileCategoricalColumnsOut is the output for the profile_categorical_columns tool.
+type ProfileCategoricalColumnsOut struct {
+	Columns []sqlcommon.CategoricalColumn `json:"columns" jsonschema:"The low-cardinality columns with their distinct values"`
+	Skipped []string                      `json:"skipped,omitempty" jsonschema:"Columns left out for having more than max_distinct distinct values, or a type that cannot be grouped (JSON, binary, spatial)"`
+	Hint    string                        `json:"hint,omitempty" jsonschema:"Set when the table was found under a name that differs in case from the one requested, with how to refer to it in SQL"`
+}
+
+// TableJSONSchemaOut is the output for the table_json_schema tool.
+type TableJSONSchemaOut struct {
+	JSONSchema map[string]any `json:"json_schema" jsonschema:"A JSON Schema (draft 2020-12) document describing a row of the table"`
+	Hint       string         `json:"hint,omitempty" jsonschema:"Set when the table was found under a name that differs in case from the one requested, with how to refer to it in SQL"`
+}
+
+// Sequence is a sequence or auto-increment counter, for the list_sequences tool.
+type Sequence struct {
+	Schema     string `json:"schema,omitempty" jsonschema:"The schema name"`
+	Name       string `json:"name" jsonschema:"The sequence name; for identity and auto-increment columns, the table name"`
+	Table      string `json:"table,omitempty" jsonschema:"The table of the column the sequence fills (omitted if no column uses it)"`
+	Column     string `json:"column,omitempty" jsonschema:"The column the sequence fills (omitted if no column uses it)"`
+	StartValue *int64 `json:"start_value,omitempty" jsonschema:"The first value the sequence hands out (PostgreSQL and SQL Server)"`
+	LastValue  *int64 `json:"last_value,omitempty" jsonschema:"The last value handed out (omitted if none has been yet, or if the database only reports next_value)"`
+	NextValue  *int64 `json:"next_value,omitempty" jsonschema:"The next value to be handed out (MySQL and SQLite)"`
+	Increment  int64  `json:"increment" jsonschema:"The step between values"`
+	MaxValue   *int64 `json:"max_value,omitempty" jsonschema:"The largest value the sequence or column can hold (omitted if unknown)"`
+}
+
+// View is a view with the query that defines it, for the list_views tool.
+type View struct {
+	Schema     string `json:"schema,omitempty" jsonschema:"The schema name"`
+	Name       string `json:"name" jsonschema:"The view name"`
+	Definition string `json:"definition,omitempty" jsonschema:"The SQL that defines the view (omitted if the user may not see it)"`
+}
+
+// MaterializedView is a materialized view with the query that defines it, for the
+// list_materialized_views tool.
+type MaterializedView struct {
+	Schema     string `json:"schema" jsonschema:"The schema name"`
+	Name       string `json:"name" jsonschema:"The materialized view name"`
+	Definition string `json:"definition,omitempty" jsonschema:"The SQL that defines the materialized view"`
+	Populated  bool   `json:"populated" jsonschema:"Whether the view holds data; one created WITH NO DATA cannot be queried until it is refreshed"`
+}
+
 // SlowQueryResult represents slow query statistics with database-specific metrics.
 type SlowQueryResult struct {
 	Columns map[string]string `json:"columns" jsonschema:"Column name to description mapping"`
@@ -72,35 +291,156 @@ type Deadlock struct {
 // Backend input types
 
 type ListTablesIn struct {
-	Schema string `json:"schema,omitempty" jsonschema:"Schema to filter by (optional)"`
+	Schema     string `json:"schema,omitempty" jsonschema:"Schema to filter by (optional)"`
+	AllSchemas bool   `json:"all_schemas,omitempty" jsonschema:"List tables in every non-system schema instead of one; overrides schema (PostgreSQL/SQL Server, use true or false)"`
+	Pattern    string `json:"pattern,omitempty" jsonschema:"Case-insensitive table name pattern using LIKE wildcards: % for any sequence, _ for a single character (optional)"`
+	Limit      int    `json:"limit,omitempty" jsonschema:"Maximum number of tables to return (optional, defaults to 1000)"`
+	Offset     int    `json:"offset,omitempty" jsonschema:"Number of tables to skip, for paging through large schemas (optional)"`
+	WithStats  bool   `json:"with_stats,omitempty" jsonschema:"Also return each table's approximate row count and size (use true or false; on SQLite rows are counted, which reads every table)"`
+}
+
+type ListSchemasIn struct {
+	IncludeSystem bool `json:"include_system,omitempty" jsonschema:"Also list system schemas such as pg_catalog, information_schema or sys (use true or false)"`
+}
+
+type ListSequencesIn struct {
+	Schema string `json:"schema,omitempty" jsonschema:"Schema to filter by (optional, defaults to every non-system schema; for MySQL, the current database)"`
+}
+
+type ListViewsIn struct {
+	Schema string `json:"schema,omitempty" jsonschema:"Schema to filter by (optional, defaults to every non-system schema; for MySQL, the current database)"`
+}
+
+type ListMaterializedViewsIn struct {
+	Schema string `json:"schema,omitempty" jsonschema:"Schema to filter by (optional, defaults to every non-system schema)"`
+}
+
+type ListForeignKeysIn struct {
+	Schema string `json:"schema,omitempty" jsonschema:"Schema to filter by (optional, defaults to every non-system schema; for MySQL, the current database)"`
+	Table  string `json:"table,omitempty" jsonschema:"Only list the foreign keys of this table (optional)"`
+}
+
+type ListTablesWithoutPKIn struct {
+	Schema string `json:"schema,omitempty" jsonschema:"Schema to audit (optional, defaults to every non-system schema; for MySQL, the current database)"`
 }
 
 type DescribeTableIn struct {
-	Schema string `json:"schema,omitempty" jsonschema:"The schema (required for PostgreSQL/SQL Server)"`
-	Table  string `json:"table" jsonschema:"required,The table name"`
+	Schema              string `json:"schema,omitempty" jsonschema:"The schema (required for PostgreSQL/SQL Server)"`
+	Table               string `json:"table" jsonschema:"required,The table name"`
+	IncludeReferencedBy bool   `json:"include_referenced_by,omitempty" jsonschema:"Also list foreign keys in other tables that reference this table (use true or false)"`
 }
 
 type ReadQueryIn struct {
-	Query string `json:"query" jsonschema:"required,The SQL query to execute"`
+	Query              string `json:"query" jsonschema:"required,The SQL query to execute"`
+	AllowFullScan      bool   `json:"allow_full_scan,omitempty" jsonschema:"Run the query even if the scan guard detects a full scan of a large table (use true or false)"`
+	Format             string `json:"format,omitempty" jsonschema:"Result format: json (default) returns rows, markdown returns a markdown table"`
+	IncludeColumnTypes bool   `json:"include_column_types,omitempty" jsonschema:"Also return the database type and nullability of each result column (use true or false)"`
+	Schema             string `json:"schema,omitempty" jsonschema:"MySQL only: run the query in this database on the same server instead of the default one, for servers hosting one database per tenant. It must be listed in the read config's tenant_databases (optional)"`
+	OutputPath         string `json:"output_path,omitempty" jsonschema:"Write the rows to this file on the server instead of returning them, relative to the database's export_dir; an existing file is overwritten (optional)"`
+	OutputFormat       string `json:"output_format,omitempty" jsonschema:"File format for output_path: csv or jsonl (optional, defaults to the file extension)"`
+	Offset             int    `json:"offset,omitempty" jsonschema:"Skip this many rows of the result, to read the next page; pass the next_offset of the previous page (optional). The query must not have its own LIMIT, TOP, OFFSET or FETCH"`
+	Limit              int    `json:"limit,omitempty" jsonschema:"Return at most this many rows per page (optional, defaults to and is capped at max_rows)"`
+}
+
+type ProfileCategoricalColumnsIn struct {
+	Schema      string `json:"schema,omitempty" jsonschema:"The schema (optional, defaults to the connection's current schema or database)"`
+	Table       string `json:"table" jsonschema:"required,The table to profile"`
+	MaxDistinct int    `json:"max_distinct,omitempty" jsonschema:"Only return columns with at most this many distinct values (optional, defaults to 20, at most 100)"`
+}
+
+type TableJSONSchemaIn struct {
+	Schema string `json:"schema,omitempty" jsonschema:"The schema (optional, defaults to the connection's current schema or database)"`
+	Table  string `json:"table" jsonschema:"required,The table to describe"`
+}
+
+type SampleTableIn struct {
+	Schema string `json:"schema,omitempty" jsonschema:"The schema (optional, defaults to the connection's current schema or database)"`
+	Table  string `json:"table" jsonschema:"required,The table to sample"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"Number of rows to return (optional, defaults to 10)"`
 }
 
 type ExplainQueryIn struct {
 	Query   string `json:"query" jsonschema:"required,The SQL query to explain"`
+	Params  []any  `json:"params,omitempty" jsonschema:"Values bound to ? placeholders in the query, in order (optional)"`
 	Analyze bool   `json:"analyze,omitempty" jsonschema:"Execute the query for actual runtime statistics (use true or false)"`
+	// Schema is the MySQL tenant database to explain in, set by the scan guard for
+	// queries routed with ReadQueryIn.Schema. It is not a tool parameter.
+	Schema string `json:"-"`
+}
+
+type AnalyzeTableIn struct {
+	Schema string `json:"schema,omitempty" jsonschema:"The schema (optional, defaults to the connection's current schema or database). Without a table, refreshes every table in it"`
+	Table  string `json:"table,omitempty" jsonschema:"The table to refresh statistics for (optional, refreshes every table in the database if omitted)"`
+}
+
+type SetCommentIn struct {
+	Schema  string `json:"schema,omitempty" jsonschema:"The schema (optional, defaults to the connection's current schema or database)"`
+	Table   string `json:"table" jsonschema:"required,The table to document"`
+	Column  string `json:"column,omitempty" jsonschema:"The column to document (optional, comments on the table itself if omitted)"`
+	Comment string `json:"comment" jsonschema:"The comment text; an empty comment removes the existing one"`
+}
+
+type RefreshMaterializedViewIn struct {
+	Schema       string `json:"schema,omitempty" jsonschema:"The schema (optional, defaults to public)"`
+	Name         string `json:"name" jsonschema:"required,The materialized view to refresh"`
+	Concurrently bool   `json:"concurrently,omitempty" jsonschema:"Refresh without locking out readers (optional). Needs a unique index on the view and a view that has been populated before"`
+}
+
+type TableProfileIn struct {
+	Schema string `json:"schema,omitempty" jsonschema:"The schema (optional, defaults to the connection's current schema or database)"`
+	Table  string `json:"table" jsonschema:"required,The table to profile"`
 }
 
 type ExecuteDDLIn struct {
 	DDL string `json:"ddl" jsonschema:"required,The DDL statement to execute (CREATE INDEX, DROP INDEX, etc)"`
 }
 
+type ExecuteWriteIn struct {
+	Query  string `json:"query" jsonschema:"required,The INSERT, UPDATE or DELETE statement to execute"`
+	Params []any  `json:"params,omitempty" jsonschema:"Values bound to ? placeholders in the statement, in order (optional)"`
+}
+
+type CheckDDLIn struct {
+	DDL string `json:"ddl" jsonschema:"required,The DDL statement to check (CREATE UNIQUE INDEX or ALTER TABLE); it is not executed"`
+}
+
+type RecommendIndexesIn struct {
+	Query  string `json:"query" jsonschema:"required,The SELECT query to recommend indexes for; it is explained, not executed"`
+	Params []any  `json:"params,omitempty" jsonschema:"Values bound to ? placeholders in the query, in order (optional)"`
+}
+
 // SQLBackend defines the interface that all SQL database backends must implement.
 type SQLBackend interface {
 	// ListTables returns all tables, optionally filtered by schema.
 	ListTables(ctx context.Context, in ListTablesIn) ([]Table, error)
 
+	// ListSchemas returns the names of the schemas (the databases, for MySQL).
+	ListSchemas(ctx context.Context, in ListSchemasIn) ([]string, error)
+
+	// ListSequences returns sequences and identity or auto-increment counters with their current values.
+	ListSequences(ctx context.Context, in ListSequencesIn) ([]Sequence, error)
+
+	// ListViews returns the views with their defining SQL.
+	ListViews(ctx context.Context, in ListViewsIn) ([]View, error)
+
+	// ListForeignKeys returns the foreign keys of the tables, with the tables and columns they reference.
+	ListForeignKeys(ctx context.Context, in ListForeignKeysIn) ([]ForeignKey, error)
+
+	// ListTablesWithoutPK returns the base tables that have no primary key.
+	ListTablesWithoutPK(ctx context.Context, in ListTablesWithoutPKIn) ([]Table, error)
+
 	// DescribeTable returns the DDL for a table.
 	DescribeTable(ctx context.Context, in DescribeTableIn) (*TableDescription, error)
 
+	// ProfileCategoricalColumns returns the distinct values of a table's low-cardinality columns.
+	ProfileCategoricalColumns(ctx context.Context, in ProfileCategoricalColumnsIn) (*ProfileCategoricalColumnsOut, error)
+
+	// TableJSONSchema returns a JSON Schema document describing a table's rows.
+	TableJSONSchema(ctx context.Context, in TableJSONSchemaIn) (*TableJSONSchemaOut, error)
+
+	// SampleTable returns the first rows of a table, in no particular order.
+	SampleTable(ctx context.Context, in SampleTableIn) (*QueryResult, error)
+
 	// ExecuteQuery executes a read-only SQL query.
 	ExecuteQuery(ctx context.Context, in ReadQueryIn) (*QueryResult, error)
 
@@ -110,17 +450,41 @@ type SQLBackend interface {
 	// ExecuteDDL executes a DDL statement (CREATE INDEX, DROP INDEX, etc).
 	ExecuteDDL(ctx context.Context, in ExecuteDDLIn) (*DDLResult, error)
 
+	// ExecuteWrite executes an INSERT, UPDATE or DELETE statement.
+	ExecuteWrite(ctx context.Context, in ExecuteWriteIn) (*WriteResult, error)
+
+	// CheckDDL reports whether existing data would make a DDL statement fail, without running it.
+	CheckDDL(ctx context.Context, in CheckDDLIn) (*sqlcommon.DDLCheckReport, error)
+
+	// RecommendIndexes proposes indexes for the tables a query filters, joins or sorts on.
+	RecommendIndexes(ctx context.Context, in RecommendIndexesIn) (*sqlcommon.IndexAdvice, error)
+
 	// ListMissingIndexes returns index recommendations.
 	ListMissingIndexes(ctx context.Context) ([]MissingIndex, error)
 
 	// ListWaitingQueries returns currently waiting/blocked queries.
 	ListWaitingQueries(ctx context.Context) ([]WaitingQuery, error)
 
+	// ListConnections returns the client sessions connected to the server, other than the caller's own.
+	ListConnections(ctx context.Context) ([]Connection, error)
+
 	// ListSlowestQueries returns the slowest queries by total time.
 	ListSlowestQueries(ctx context.Context) (*SlowQueryResult, error)
 
 	// ListDeadlocks returns deadlock information.
 	ListDeadlocks(ctx context.Context) ([]Deadlock, error)
+
+	// KillIdleTransactions terminates sessions left idle inside an open transaction.
+	KillIdleTransactions(ctx context.Context, in KillIdleTransactionsIn) (*KillIdleTransactionsOut, error)
+
+	// SetComment sets or removes the comment on a table or column.
+	SetComment(ctx context.Context, in SetCommentIn) (*SetCommentOut, error)
+
+	// AnalyzeTable refreshes the planner statistics of a single table.
+	AnalyzeTable(ctx context.Context, in AnalyzeTableIn) (*AnalyzeTableOut, error)
+
+	// TableProfile returns a table's size, indexes, scan counts and maintenance times.
+	TableProfile(ctx context.Context, in TableProfileIn) (*TableProfile, error)
 }
 
 // BackendFactory creates SQLBackend instances for a specific database type.
@@ -132,3 +496,90 @@ type BackendFactory[DB any] interface {
 	// New creates a new SQLBackend instance with the given database connection.
 	New(db DB) SQLBackend
 }
+
+// Read-only enforcement modes reported in ReadonlyStatus.
+const (
+	// ReadonlyGrantCheck means the read user was checked for write privileges.
+	ReadonlyGrantCheck = "grant_check"
+	// ReadonlyTx means every query runs in a read-only transaction.
+	ReadonlyTx = "readonly_tx"
+	// ReadonlyFile means the database file is opened read-only.
+	ReadonlyFile = "readonly_file"
+	// ReadonlyNone means read-only checks are bypassed.
+	ReadonlyNone = "none"
+)
+
+// ReadonlyStatus reports how a read connection is kept from writing, and the
+// result of the last verification.
+type ReadonlyStatus struct {
+	Mode      string    `json:"mode" jsonschema:"How writes are prevented: grant_check, readonly_tx, readonly_file or none"`
+	Verified  bool      `json:"verified" jsonschema:"Whether the last verification passed"`
+	CheckedAt time.Time `json:"checked_at" jsonschema:"When the connection was last verified"`
+	Error     string    `json:"error,omitempty" jsonschema:"Why verification failed"`
+	// Downgraded is set when the grant check failed and on_readonly_violation switched reads to read-only transactions.
+	Downgraded bool `json:"downgraded,omitempty" jsonschema:"Whether the read user has write privileges and reads were switched to read-only transactions instead"`
+}
+
+// ReadonlyChecker is optionally implemented by a Connector to verify that its
+// read connections cannot write.
+type ReadonlyChecker[R, DB any] interface {
+	// CheckReadonly verifies db, opened from cfg by ConnectRead.
+	CheckReadonly(cfg R, db DB) ReadonlyStatus
+}
+
+// ReadonlyDowngrader is optionally implemented by a Connector that can run every
+// read in a read-only transaction, for on_readonly_violation: downgrade.
+type ReadonlyDowngrader[DB any] interface {
+	// DowngradeReadonly returns db set up to run every query in a read-only transaction.
+	DowngradeReadonly(db DB) DB
+}
+
+// TableSize is the approximate size of a table, from the database's statistics.
+type TableSize struct {
+	Schema string `gorm:"column:schema"`
+	Name   string `gorm:"column:name"`
+	Rows   int64  `gorm:"column:row_estimate"`
+	Bytes  int64  `gorm:"column:bytes"`
+}
+
+// AddTableSizes sets the row estimate and size of each table found in sizes.
+func AddTableSizes(tables []Table, sizes []TableSize) {
+	type key struct{ schema, name string }
+	byTable := make(map[key]TableSize, len(sizes))
+	for _, s := range sizes {
+		byTable[key{s.Schema, s.Name}] = s
+	}
+	for i, t := range tables {
+		if s, ok := byTable[key{t.Schema, t.Name}]; ok {
+			tables[i].EstimatedRows, tables[i].SizeBytes = s.Rows, s.Bytes
+		}
+	}
+}
+
+// TableSizer is optionally implemented by an SQLBackend that can read table sizes
+// from the catalog cheaply, for schema summaries.
+type TableSizer interface {
+	// TableSizes returns the approximate size of every table.
+	TableSizes(ctx context.Context) ([]TableSize, error)
+}
+
+// MaterializedViewLister is optionally implemented by an SQLBackend whose database
+// has materialized views, for list_materialized_views.
+type MaterializedViewLister interface {
+	// ListMaterializedViews returns the materialized views with their defining SQL.
+	ListMaterializedViews(ctx context.Context, in ListMaterializedViewsIn) ([]MaterializedView, error)
+}
+
+// MaterializedViewRefresher is optionally implemented by an SQLBackend whose
+// database has materialized views, for refresh_materialized_view.
+type MaterializedViewRefresher interface {
+	// RefreshMaterializedView recomputes the contents of a materialized view.
+	RefreshMaterializedView(ctx context.Context, in RefreshMaterializedViewIn) (*RefreshMaterializedViewOut, error)
+}
+
+// ToolSupport is optionally implemented by a BackendFactory whose backends
+// cannot serve some tools (they return an error explaining why instead).
+type ToolSupport interface {
+	// UnsupportedTools returns the names of the tools the backend does not support.
+	UnsupportedTools() []string
+}
@@ -0,0 +1,30 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/tinternet/databaise/internal/sqlcommon"
+)
+
+// limitInjector wraps a read backend and adds a row limit to SELECT statements
+// that have none, so the database stops after maxRows instead of returning every
+// row for QueryRows to drop. One extra row is requested so truncation is still reported.
+// Exports to a file are not capped, so they are left alone.
+type limitInjector struct {
+	SQLBackend
+	maxRows int
+	// top injects SELECT TOP n (SQL Server) instead of a trailing LIMIT n.
+	top bool
+}
+
+func (l *limitInjector) unwrap() SQLBackend { return l.SQLBackend }
+
+func (l *limitInjector) ExecuteQuery(ctx context.Context, in ReadQueryIn) (*QueryResult, error) {
+	if in.OutputPath != "" {
+		return l.SQLBackend.ExecuteQuery(ctx, in)
+	}
+	if query, ok := sqlcommon.InjectLimit(in.Query, l.maxRows+1, l.top); ok {
+		in.Query = query
+	}
+	return l.SQLBackend.ExecuteQuery(ctx, in)
+}
@@ -0,0 +1,41 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// queryStub records the query that reaches the database.
+type queryStub struct {
+	SQLBackend
+	query string
+}
+
+func (s *queryStub) ExecuteQuery(ctx context.Context, in ReadQueryIn) (*QueryResult, error) {
+	s.query = in.Query
+	return &QueryResult{ExecutedSQL: in.Query}, nil
+}
+
+func TestLimitInjector(t *testing.T) {
+	stub := &queryStub{}
+
+	b := &limitInjector{SQLBackend: stub, maxRows: 100}
+	_, err := b.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users"})
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * FROM users\nLIMIT 101", stub.query)
+
+	_, err = b.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users LIMIT 5"})
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * FROM users LIMIT 5", stub.query)
+
+	_, err = b.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users", OutputPath: "users.csv"})
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * FROM users", stub.query)
+
+	b = &limitInjector{SQLBackend: stub, maxRows: 100, top: true}
+	_, err = b.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users"})
+	require.NoError(t, err)
+	require.Equal(t, "SELECT TOP 101 * FROM users", stub.query)
+}
@@ -0,0 +1,81 @@
+package backend
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// maxMarkdownCell is the number of characters kept per markdown table cell.
+const maxMarkdownCell = 80
+
+// renderMarkdown formats query rows as a GitHub-flavored markdown table with the
+// columns in query order.
+func renderMarkdown(columns []string, rows []map[string]any) string {
+	if len(columns) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("|")
+	for _, col := range columns {
+		b.WriteString(" " + markdownCell(col) + " |")
+	}
+	b.WriteString("\n|")
+	for range columns {
+		b.WriteString(" --- |")
+	}
+	for _, row := range rows {
+		b.WriteString("\n|")
+		for _, col := range columns {
+			b.WriteString(" " + markdownCell(formatValue(row[col])) + " |")
+		}
+	}
+	return b.String()
+}
+
+// markdownCell escapes a value for a table cell and shortens it to maxMarkdownCell characters.
+func markdownCell(s string) string {
+	if r := []rune(s); len(r) > maxMarkdownCell {
+		s = string(r[:maxMarkdownCell-1]) + "…"
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// formatValue renders a scanned column value as text. Drivers may return values
+// behind pointers, which are dereferenced first.
+func formatValue(v any) string {
+	v = derefValue(v)
+	if v == nil {
+		return "NULL"
+	}
+
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// derefValue returns the value behind the pointers a driver may scan a column
+// value into, or nil for NULL.
+func derefValue(v any) any {
+	rv := reflect.ValueOf(v)
+	for rv.IsValid() && (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	return rv.Interface()
+}
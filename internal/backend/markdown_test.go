@@ -0,0 +1,46 @@
+package backend
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	name := "Ada"
+	rows := []map[string]any{
+		{"name": &name, "id": int64(1), "note": nil},
+		{"name": []byte("a|b"), "id": int64(2), "note": "line 1\r\nline 2\nline 3"},
+	}
+
+	// Columns keep query order, not map order.
+	require.Equal(t, strings.Join([]string{
+		"| name | id | note |",
+		"| --- | --- | --- |",
+		"| Ada | 1 | NULL |",
+		`| a\|b | 2 | line 1<br>line 2<br>line 3 |`,
+	}, "\n"), renderMarkdown([]string{"name", "id", "note"}, rows))
+
+	require.Equal(t, "| id |\n| --- |", renderMarkdown([]string{"id"}, nil))
+	require.Empty(t, renderMarkdown(nil, rows))
+}
+
+func TestMarkdownCell(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"Plain", "users", "users"},
+		{"Pipe", "a|b", `a\|b`},
+		{"Backslash", `C:\tmp`, `C:\\tmp`},
+		{"EscapedPipe", `a\|b`, `a\\\|b`},
+		{"Newlines", "a\nb\r\nc", "a<br>b<br>c"},
+		{"AtLimit", strings.Repeat("é", maxMarkdownCell), strings.Repeat("é", maxMarkdownCell)},
+		{"Truncated", strings.Repeat("é", maxMarkdownCell+1), strings.Repeat("é", maxMarkdownCell-1) + "…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, markdownCell(tt.in))
+		})
+	}
+}
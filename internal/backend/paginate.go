@@ -0,0 +1,55 @@
+package backend
+
+import (
+	"regexp"
+	"strings"
+)
+
+// defaultListLimit bounds list responses when the caller doesn't set a limit.
+const defaultListLimit = 1000
+
+// paginateTables applies the name pattern, offset and limit from in to tables.
+func paginateTables(tables []Table, in ListTablesIn) *ListTablesOut {
+	if in.Pattern != "" {
+		re := likeToRegexp(in.Pattern)
+		filtered := tables[:0]
+		for _, t := range tables {
+			if re.MatchString(t.Name) {
+				filtered = append(filtered, t)
+			}
+		}
+		tables = filtered
+	}
+
+	total := len(tables)
+	limit := in.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	offset := min(max(in.Offset, 0), total)
+	end := min(offset+limit, total)
+
+	return &ListTablesOut{
+		Tables:  tables[offset:end],
+		Total:   total,
+		HasMore: end < total,
+	}
+}
+
+// likeToRegexp converts a SQL LIKE pattern to a case-insensitive regexp.
+func likeToRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?i)^")
+	for _, c := range pattern {
+		switch c {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
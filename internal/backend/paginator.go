@@ -0,0 +1,64 @@
+package backend
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tinternet/databaise/internal/sqlcommon"
+)
+
+// paginator wraps a read backend and pages execute_query results when the caller
+// sets offset or limit, wrapping the query so the database skips and limits the rows.
+// One extra row is requested to tell whether another page follows.
+type paginator struct {
+	SQLBackend
+	// maxRows caps the page size; zero or less means uncapped.
+	maxRows int
+	// top pages with OFFSET ... FETCH (SQL Server) instead of LIMIT ... OFFSET.
+	top bool
+}
+
+func (p *paginator) unwrap() SQLBackend { return p.SQLBackend }
+
+func (p *paginator) ExecuteQuery(ctx context.Context, in ReadQueryIn) (*QueryResult, error) {
+	if in.Offset == 0 && in.Limit == 0 {
+		return p.SQLBackend.ExecuteQuery(ctx, in)
+	}
+	if in.Offset < 0 || in.Limit < 0 {
+		return nil, errors.New("offset and limit must not be negative")
+	}
+	if in.OutputPath != "" {
+		return nil, errors.New("offset and limit do not apply to exports, which write every row: narrow the query instead")
+	}
+
+	limit := in.Limit
+	switch {
+	case p.maxRows > 0 && (limit == 0 || limit > p.maxRows):
+		limit = p.maxRows
+	case limit == 0:
+		limit = defaultListLimit
+	}
+	query, err := sqlcommon.Paginate(in.Query, limit+1, in.Offset, p.top)
+	if err != nil {
+		return nil, err
+	}
+	in.Query = query
+
+	res, err := p.SQLBackend.ExecuteQuery(ctx, in)
+	if err != nil || res == nil {
+		return res, err
+	}
+	res.Offset, res.Limit = in.Offset, limit
+
+	more := len(res.Rows) > limit || res.Truncated
+	if len(res.Rows) > limit {
+		res.Rows = res.Rows[:limit]
+		res.RowCount = limit
+	}
+	if more && res.RowCount > 0 {
+		res.NextOffset = in.Offset + res.RowCount
+		// A full page cut at the row cap is not truncated, only followed by another.
+		res.Truncated = res.RowCount < limit
+	}
+	return res, nil
+}
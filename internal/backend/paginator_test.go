@@ -0,0 +1,90 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinternet/databaise/internal/sqlcommon"
+)
+
+// pageStub returns the first n rows of a table of total rows, like a database
+// applying a LIMIT n, and records the query it was given.
+type pageStub struct {
+	SQLBackend
+	total int
+	n     int
+	query string
+}
+
+func (s *pageStub) ExecuteQuery(ctx context.Context, in ReadQueryIn) (*QueryResult, error) {
+	s.query = in.Query
+	res := &QueryResult{}
+	for i := range min(s.n, s.total) {
+		res.Rows = append(res.Rows, map[string]any{"id": i})
+	}
+	res.RowCount = len(res.Rows)
+	return res, nil
+}
+
+func TestPaginator(t *testing.T) {
+	t.Run("PassThrough", func(t *testing.T) {
+		stub := &pageStub{total: 5, n: 5}
+		p := &paginator{SQLBackend: stub, maxRows: 100}
+		res, err := p.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users"})
+		require.NoError(t, err)
+		require.Equal(t, "SELECT * FROM users", stub.query)
+		require.Zero(t, res.Limit)
+		require.Zero(t, res.NextOffset)
+	})
+
+	t.Run("MorePages", func(t *testing.T) {
+		stub := &pageStub{total: 50, n: 11}
+		p := &paginator{SQLBackend: stub, maxRows: 100}
+		res, err := p.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users", Offset: 20, Limit: 10})
+		require.NoError(t, err)
+		require.Equal(t, "SELECT * FROM (\nSELECT * FROM users\n) AS page\nLIMIT 11 OFFSET 20", stub.query)
+		require.Equal(t, 10, res.RowCount)
+		require.Len(t, res.Rows, 10)
+		require.Equal(t, 20, res.Offset)
+		require.Equal(t, 10, res.Limit)
+		require.Equal(t, 30, res.NextOffset)
+	})
+
+	t.Run("LastPage", func(t *testing.T) {
+		stub := &pageStub{total: 4, n: 11}
+		p := &paginator{SQLBackend: stub, maxRows: 100, top: true}
+		res, err := p.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users ORDER BY id", Limit: 10})
+		require.NoError(t, err)
+		require.Equal(t, "SELECT * FROM users ORDER BY id\nOFFSET 0 ROWS FETCH NEXT 11 ROWS ONLY", stub.query)
+		require.Equal(t, 4, res.RowCount)
+		require.Zero(t, res.NextOffset)
+	})
+
+	t.Run("CappedAtMaxRows", func(t *testing.T) {
+		stub := &pageStub{total: 50, n: 6}
+		p := &paginator{SQLBackend: stub, maxRows: 5}
+		res, err := p.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users", Offset: 5})
+		require.NoError(t, err)
+		require.Equal(t, 5, res.Limit)
+		require.Equal(t, 10, res.NextOffset)
+	})
+
+	t.Run("OwnLimit", func(t *testing.T) {
+		p := &paginator{SQLBackend: &pageStub{}, maxRows: 100}
+		_, err := p.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users LIMIT 5", Limit: 10})
+		require.ErrorIs(t, err, sqlcommon.ErrQueryHasLimit)
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		p := &paginator{SQLBackend: &pageStub{}, maxRows: 100}
+		_, err := p.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users", Limit: 10, OutputPath: "users.csv"})
+		require.Error(t, err)
+	})
+
+	t.Run("Negative", func(t *testing.T) {
+		p := &paginator{SQLBackend: &pageStub{}, maxRows: 100}
+		_, err := p.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users", Offset: -1})
+		require.Error(t, err)
+	})
+}
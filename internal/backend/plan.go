@@ -0,0 +1,46 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+)
+
+// interpretPlan appends to the ResultInfo of a plan a short reading of it, such as
+// "Contains a full scan of orders (~1.2M rows). The top operation is Hash Join
+// (estimated 120 rows, cost 5321)." so that an agent need not parse the raw plan
+// of each database to spot the obvious problems.
+func interpretPlan(r *ExplainResult) {
+	if r == nil || len(r.Plan) == 0 {
+		return
+	}
+
+	var sentences []string
+	if len(r.FullScans) > 0 {
+		tables := make([]string, len(r.FullScans))
+		for i, s := range r.FullScans {
+			tables[i] = s.Table
+			if s.EstimatedRows > 0 {
+				tables[i] += fmt.Sprintf(" (~%s rows)", approx(int64(s.EstimatedRows), 1000, "", "K", "M", "B"))
+			}
+		}
+		sentences = append(sentences, "Contains a full scan of "+strings.Join(tables, ", ")+"; an index on the filtered or joined columns may avoid it.")
+	} else {
+		sentences = append(sentences, "Contains no full table scan.")
+	}
+
+	top := r.Plan[0]
+	var estimates []string
+	if top.EstimatedRows != nil {
+		estimates = append(estimates, fmt.Sprintf("estimated %s rows", approx(int64(*top.EstimatedRows), 1000, "", "K", "M", "B")))
+	}
+	if top.Cost != nil {
+		estimates = append(estimates, fmt.Sprintf("cost %.0f", *top.Cost))
+	}
+	sentence := "The top operation is " + top.Operation
+	if len(estimates) > 0 {
+		sentence += " (" + strings.Join(estimates, ", ") + ")"
+	}
+	sentences = append(sentences, sentence+".")
+
+	r.ResultInfo = strings.TrimSuffix(r.ResultInfo, ".") + ". " + strings.Join(sentences, " ")
+}
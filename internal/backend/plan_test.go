@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpretPlan(t *testing.T) {
+	rows, cost := 120.0, 5321.4
+	tests := []struct {
+		name   string
+		result ExplainResult
+		want   string
+	}{
+		{
+			name: "FullScans",
+			result: ExplainResult{
+				ResultInfo: "The plan",
+				FullScans:  []TableScan{{Table: "public.orders", EstimatedRows: 1200000}, {Table: "users"}},
+				Plan:       []PlanNode{{Operation: "Hash Join", EstimatedRows: &rows, Cost: &cost}},
+			},
+			want: "The plan. Contains a full scan of public.orders (~1.2M rows), users; an index on the filtered or joined columns may avoid it. The top operation is Hash Join (estimated 120 rows, cost 5321).",
+		},
+		{
+			name: "NoFullScan",
+			result: ExplainResult{
+				ResultInfo: "The plan.",
+				Plan:       []PlanNode{{Operation: "SEARCH users USING INDEX idx_email (email=?)"}},
+			},
+			want: "The plan. Contains no full table scan. The top operation is SEARCH users USING INDEX idx_email (email=?).",
+		},
+		{
+			name:   "NoPlan",
+			result: ExplainResult{ResultInfo: "The plan"},
+			want:   "The plan",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interpretPlan(&tt.result)
+			require.Equal(t, tt.want, tt.result.ResultInfo)
+		})
+	}
+}
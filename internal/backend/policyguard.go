@@ -0,0 +1,56 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/tinternet/databaise/internal/sqlcommon"
+)
+
+// policyGuard wraps a backend and refuses the queries its policy blocks before
+// they reach the database.
+type policyGuard struct {
+	SQLBackend
+	policy *sqlcommon.QueryPolicy
+}
+
+func (g *policyGuard) unwrap() SQLBackend { return g.SQLBackend }
+
+func (g *policyGuard) ExecuteQuery(ctx context.Context, in ReadQueryIn) (*QueryResult, error) {
+	if err := g.policy.Check(in.Query); err != nil {
+		return nil, err
+	}
+	return g.SQLBackend.ExecuteQuery(ctx, in)
+}
+
+func (g *policyGuard) ExplainQuery(ctx context.Context, in ExplainQueryIn) (*ExplainResult, error) {
+	if err := g.policy.Check(in.Query); err != nil {
+		return nil, err
+	}
+	return g.SQLBackend.ExplainQuery(ctx, in)
+}
+
+// SampleTable runs no query of the caller's, so it is checked as the SELECT of
+// the whole table it reads.
+func (g *policyGuard) SampleTable(ctx context.Context, in SampleTableIn) (*QueryResult, error) {
+	if err := g.policy.Check(tableQuery(in.Schema, in.Table)); err != nil {
+		return nil, err
+	}
+	return g.SQLBackend.SampleTable(ctx, in)
+}
+
+// ProfileCategoricalColumns returns table data too, and is checked like SampleTable.
+func (g *policyGuard) ProfileCategoricalColumns(ctx context.Context, in ProfileCategoricalColumnsIn) (*ProfileCategoricalColumnsOut, error) {
+	if err := g.policy.Check(tableQuery(in.Schema, in.Table)); err != nil {
+		return nil, err
+	}
+	return g.SQLBackend.ProfileCategoricalColumns(ctx, in)
+}
+
+// tableQuery returns the query policies see for a tool that reads a whole table.
+func tableQuery(schema, table string) string {
+	name := table
+	if schema != "" {
+		name = schema + "." + table
+	}
+	return "SELECT * FROM " + name
+}
@@ -0,0 +1,64 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinternet/databaise/internal/config"
+	"github.com/tinternet/databaise/internal/sqlcommon"
+)
+
+type tableReadStub struct {
+	queryStub
+	table string
+}
+
+func (s *tableReadStub) SampleTable(ctx context.Context, in SampleTableIn) (*QueryResult, error) {
+	s.table = in.Table
+	return &QueryResult{}, nil
+}
+
+func (s *tableReadStub) ProfileCategoricalColumns(ctx context.Context, in ProfileCategoricalColumnsIn) (*ProfileCategoricalColumnsOut, error) {
+	s.table = in.Table
+	return &ProfileCategoricalColumnsOut{}, nil
+}
+
+func TestPolicyGuard(t *testing.T) {
+	policy, err := sqlcommon.NewQueryPolicy(config.QueryPolicy{Deny: []string{`\bsecrets\b`}})
+	require.NoError(t, err)
+	stub := &queryStub{}
+	b := &policyGuard{SQLBackend: stub, policy: policy}
+
+	_, err = b.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM users"})
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * FROM users", stub.query)
+
+	stub.query = ""
+	_, err = b.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM secrets"})
+	require.ErrorIs(t, err, sqlcommon.ErrQueryBlocked)
+	require.Empty(t, stub.query, "a blocked query must not reach the database")
+
+	_, err = b.ExplainQuery(t.Context(), ExplainQueryIn{Query: "SELECT * FROM secrets"})
+	require.ErrorIs(t, err, sqlcommon.ErrQueryBlocked)
+}
+
+func TestPolicyGuardTableReads(t *testing.T) {
+	policy, err := sqlcommon.NewQueryPolicy(config.QueryPolicy{Deny: []string{`\bsecrets\b`}})
+	require.NoError(t, err)
+	stub := &tableReadStub{}
+	b := &policyGuard{SQLBackend: stub, policy: policy}
+
+	_, err = b.SampleTable(t.Context(), SampleTableIn{Table: "users"})
+	require.NoError(t, err)
+	require.Equal(t, "users", stub.table)
+
+	stub.table = ""
+	_, err = b.SampleTable(t.Context(), SampleTableIn{Schema: "app", Table: "secrets"})
+	require.ErrorIs(t, err, sqlcommon.ErrQueryBlocked)
+	require.Empty(t, stub.table, "a denied table must not be sampled")
+
+	_, err = b.ProfileCategoricalColumns(t.Context(), ProfileCategoricalColumnsIn{Table: "secrets"})
+	require.ErrorIs(t, err, sqlcommon.ErrQueryBlocked)
+	require.Empty(t, stub.table)
+}
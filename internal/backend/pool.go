@@ -0,0 +1,96 @@
+package backend
+
+import (
+	"database/sql"
+	"slices"
+	"strings"
+)
+
+// sqlDBer is implemented by *gorm.DB.
+type sqlDBer interface {
+	DB() (*sql.DB, error)
+}
+
+// pooler is implemented by backend connection types that embed *gorm.DB, whose
+// DB method is hidden by the embedded field of the same name.
+type pooler interface {
+	Pool() (*sql.DB, error)
+}
+
+// sqlDB returns the connection pool behind a backend connection, or nil if it has none.
+func sqlDB(db any) *sql.DB {
+	var pool *sql.DB
+	var err error
+	switch d := db.(type) {
+	case pooler:
+		pool, err = d.Pool()
+	case sqlDBer:
+		pool, err = d.DB()
+	default:
+		return nil
+	}
+	if err != nil {
+		return nil
+	}
+	return pool
+}
+
+// PoolStats describes one connection pool of a database.
+type PoolStats struct {
+	Database           string  `json:"database" jsonschema:"The database name"`
+	Connection         string  `json:"connection" jsonschema:"Which connection the pool belongs to: read, admin or write"`
+	MaxOpenConnections int     `json:"max_open_connections" jsonschema:"Maximum number of open connections (0 means unlimited)"`
+	OpenConnections    int     `json:"open_connections" jsonschema:"Established connections, both in use and idle"`
+	InUse              int     `json:"in_use" jsonschema:"Connections currently in use"`
+	Idle               int     `json:"idle" jsonschema:"Idle connections"`
+	WaitCount          int64   `json:"wait_count" jsonschema:"Total number of times a caller waited for a free connection"`
+	WaitDurationMs     float64 `json:"wait_duration_ms" jsonschema:"Total time spent waiting for a free connection, in milliseconds"`
+	MaxIdleClosed      int64   `json:"max_idle_closed" jsonschema:"Connections closed because of the idle pool limit"`
+	MaxLifetimeClosed  int64   `json:"max_lifetime_closed" jsonschema:"Connections closed because they reached their maximum lifetime"`
+}
+
+// PoolStatsOut is the output for the pool_stats tool.
+type PoolStatsOut struct {
+	Pools []PoolStats `json:"pools" jsonschema:"Connection pool statistics per database and connection"`
+}
+
+// ListPoolStats returns the connection pool statistics of all initialized databases.
+func ListPoolStats() PoolStatsOut {
+	instancesMu.RLock()
+	defer instancesMu.RUnlock()
+
+	result := make([]PoolStats, 0, len(instances)*3)
+	for _, inst := range instances {
+		if inst.readPool != nil {
+			result = append(result, newPoolStats(inst.Name, "read", inst.readPool.Stats()))
+		}
+		if inst.adminPool != nil {
+			result = append(result, newPoolStats(inst.Name, "admin", inst.adminPool.Stats()))
+		}
+		if inst.writePool != nil {
+			result = append(result, newPoolStats(inst.Name, "write", inst.writePool.Stats()))
+		}
+	}
+	slices.SortFunc(result, func(a, b PoolStats) int {
+		if c := strings.Compare(a.Database, b.Database); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Connection, b.Connection)
+	})
+	return PoolStatsOut{Pools: result}
+}
+
+func newPoolStats(database, connection string, s sql.DBStats) PoolStats {
+	return PoolStats{
+		Database:           database,
+		Connection:         connection,
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDurationMs:     float64(s.WaitDuration.Microseconds()) / 1000,
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
@@ -0,0 +1,57 @@
+package backend
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// wrappedDB is a connection type like the backends' DB structs, whose embedded
+// *gorm.DB field hides its DB method.
+type wrappedDB struct {
+	*gorm.DB
+}
+
+func (db wrappedDB) Pool() (*sql.DB, error) {
+	return db.DB.DB()
+}
+
+func TestListPoolStats(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	pool, err := db.DB()
+	require.NoError(t, err)
+	pool.SetMaxOpenConns(3)
+
+	require.Same(t, pool, sqlDB(db))
+	require.Same(t, pool, sqlDB(wrappedDB{DB: db}))
+	require.Nil(t, sqlDB(struct{ *gorm.DB }{db}), "a struct without Pool hides the pool")
+
+	instancesMu.Lock()
+	instances["pool_b"] = &Instance{Name: "pool_b", readPool: sqlDB(wrappedDB{DB: db}), writePool: sqlDB(db)}
+	instances["pool_a"] = &Instance{Name: "pool_a", readPool: sqlDB(wrappedDB{DB: db})}
+	instancesMu.Unlock()
+	t.Cleanup(func() {
+		instancesMu.Lock()
+		delete(instances, "pool_a")
+		delete(instances, "pool_b")
+		instancesMu.Unlock()
+	})
+
+	var pools []PoolStats
+	for _, p := range ListPoolStats().Pools {
+		if p.Database == "pool_a" || p.Database == "pool_b" {
+			pools = append(pools, p)
+		}
+	}
+	require.Len(t, pools, 3)
+	require.Equal(t, []string{"pool_a/read", "pool_b/read", "pool_b/write"}, []string{
+		pools[0].Database + "/" + pools[0].Connection,
+		pools[1].Database + "/" + pools[1].Connection,
+		pools[2].Database + "/" + pools[2].Connection,
+	})
+	require.Equal(t, 3, pools[0].MaxOpenConnections)
+}
@@ -0,0 +1,84 @@
+package backend
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/tinternet/databaise/internal/logging"
+	"github.com/tinternet/databaise/internal/sqlcommon"
+)
+
+// queryLogEntry is a line of the query log.
+type queryLogEntry struct {
+	Time       time.Time `json:"time"`
+	Database   string    `json:"database"`
+	Tool       string    `json:"tool"`
+	Query      string    `json:"query"`
+	DurationMs float64   `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// queryLog writes slow and failed statements as JSON lines, with the literals
+// in the SQL replaced by ? so the log keeps the query shape but not the data.
+type queryLog struct {
+	mu        sync.Mutex
+	w         io.Writer
+	slow      time.Duration
+	logFailed bool
+}
+
+var queryLogger *queryLog
+
+// SetQueryLog starts writing to w every statement that takes at least slow,
+// and every statement that fails if logFailed is set. A negative slow logs
+// failed statements only.
+func SetQueryLog(w io.Writer, slow time.Duration, logFailed bool) {
+	queryLogger = &queryLog{w: w, slow: slow, logFailed: logFailed}
+}
+
+// statementInput is a tool input that carries the SQL statement it runs.
+type statementInput interface {
+	statement() string
+}
+
+func (in ReadQueryIn) statement() string        { return in.Query }
+func (in ExplainQueryIn) statement() string     { return in.Query }
+func (in ExecuteDDLIn) statement() string       { return in.DDL }
+func (in ExecuteWriteIn) statement() string     { return in.Query }
+func (in CheckDDLIn) statement() string         { return in.DDL }
+func (in RecommendIndexesIn) statement() string { return in.Query }
+
+// record logs a statement if it was slow or failed. A nil log records nothing.
+func (l *queryLog) record(database, tool, query string, duration time.Duration, err error) {
+	if l == nil {
+		return
+	}
+	slow := l.slow >= 0 && duration >= l.slow
+	if !slow && (err == nil || !l.logFailed) {
+		return
+	}
+
+	entry := queryLogEntry{
+		Time:       time.Now().UTC(),
+		Database:   database,
+		Tool:       tool,
+		Query:      sqlcommon.NormalizeQuery(query),
+		DurationMs: float64(duration.Microseconds()) / 1000,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	line, mErr := json.Marshal(entry)
+	if mErr != nil {
+		logging.Warn("query log: %v", mErr)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, wErr := l.w.Write(append(line, '\n')); wErr != nil {
+		logging.Warn("query log: %v", wErr)
+	}
+}
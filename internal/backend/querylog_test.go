@@ -0,0 +1,84 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLog(t *testing.T) {
+	instancesMu.Lock()
+	instances["logged"] = &Instance{Name: "logged"}
+	instancesMu.Unlock()
+	t.Cleanup(func() {
+		instancesMu.Lock()
+		delete(instances, "logged")
+		instancesMu.Unlock()
+		queryLogger = nil
+	})
+
+	var buf bytes.Buffer
+	getBackend := func(string) (SQLBackend, error) { return nil, nil }
+	query := func(delay time.Duration, err error) func(SQLBackend, context.Context, ReadQueryIn) (*QueryResult, error) {
+		return func(SQLBackend, context.Context, ReadQueryIn) (*QueryResult, error) {
+			time.Sleep(delay)
+			return &QueryResult{}, err
+		}
+	}
+	entries := func() []queryLogEntry {
+		var out []queryLogEntry
+		for line := range strings.Lines(buf.String()) {
+			var e queryLogEntry
+			require.NoError(t, json.Unmarshal([]byte(line), &e))
+			out = append(out, e)
+		}
+		buf.Reset()
+		return out
+	}
+	in := ReadQueryIn{Query: "SELECT * FROM users WHERE email = 'alice@example.com' AND id = 42"}
+
+	t.Run("Slow", func(t *testing.T) {
+		SetQueryLog(&buf, 20*time.Millisecond, true)
+		_, err := Handle(t.Context(), "logged", in, getBackend, query(0, nil))
+		require.NoError(t, err)
+		require.Empty(t, entries())
+
+		_, err = Handle(t.Context(), "logged", in, getBackend, query(25*time.Millisecond, nil))
+		require.NoError(t, err)
+		logged := entries()
+		require.Len(t, logged, 1)
+		require.Equal(t, "logged", logged[0].Database)
+		require.Equal(t, "SELECT * FROM users WHERE email = ? AND id = ?", logged[0].Query)
+		require.GreaterOrEqual(t, logged[0].DurationMs, 25.0)
+		require.Empty(t, logged[0].Error)
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		SetQueryLog(&buf, -1, true)
+		_, err := Handle(t.Context(), "logged", in, getBackend, query(0, errors.New("relation \"users\" does not exist")))
+		require.Error(t, err)
+		logged := entries()
+		require.Len(t, logged, 1)
+		require.Contains(t, logged[0].Error, "does not exist")
+
+		SetQueryLog(&buf, -1, false)
+		_, err = Handle(t.Context(), "logged", in, getBackend, query(0, errors.New("relation \"users\" does not exist")))
+		require.Error(t, err)
+		require.Empty(t, entries())
+	})
+
+	t.Run("NoStatement", func(t *testing.T) {
+		SetQueryLog(&buf, 0, true)
+		_, err := Handle(t.Context(), "logged", ListTablesIn{}, getBackend, func(SQLBackend, context.Context, ListTablesIn) ([]Table, error) {
+			return nil, nil
+		})
+		require.NoError(t, err)
+		require.Empty(t, entries())
+	})
+}
@@ -0,0 +1,93 @@
+package backend
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tinternet/databaise/internal/config"
+)
+
+// tokenBucket allows up to perMinute calls per minute, refilling continuously.
+// It is guarded by the mutex of the rateLimiter that owns it.
+type tokenBucket struct {
+	perMinute float64
+	tokens    float64
+	last      time.Time
+}
+
+func newTokenBucket(perMinute int, now time.Time) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &tokenBucket{perMinute: float64(perMinute), tokens: float64(perMinute), last: now}
+}
+
+// wait refills the bucket and returns how long to wait for the next token, or zero
+// if one is available. A nil bucket is unlimited.
+func (b *tokenBucket) wait(now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.tokens = min(b.perMinute, b.tokens+now.Sub(b.last).Minutes()*b.perMinute)
+	b.last = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / b.perMinute * float64(time.Minute))
+	}
+	return 0
+}
+
+// take consumes a token that wait reported available.
+func (b *tokenBucket) take() {
+	if b != nil {
+		b.tokens--
+	}
+}
+
+// rateLimiter enforces the per-database tool call quota for each operation level.
+type rateLimiter struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	all   *tokenBucket
+	read  *tokenBucket
+	admin *tokenBucket
+}
+
+func newRateLimiter(cfg *config.RateLimit) *rateLimiter {
+	if cfg == nil {
+		return nil
+	}
+	now := time.Now()
+	return &rateLimiter{
+		now:   time.Now,
+		all:   newTokenBucket(cfg.PerMinute, now),
+		read:  newTokenBucket(cfg.ReadPerMinute, now),
+		admin: newTokenBucket(cfg.AdminPerMinute, now),
+	}
+}
+
+// allow checks the quota for a call at the given level ("read", "admin" or "write").
+// Write calls count against the admin quota. A token is taken from the level and
+// overall quotas only if both have one, so a refused call uses up neither.
+func (r *rateLimiter) allow(databaseName, level string) error {
+	if r == nil {
+		return nil
+	}
+	bucket := r.read
+	if level != "read" {
+		bucket = r.admin
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	if wait := bucket.wait(now); wait > 0 {
+		return fmt.Errorf("%s tool call quota exceeded for database %q, retry in %s", level, databaseName, wait.Round(time.Second))
+	}
+	if wait := r.all.wait(now); wait > 0 {
+		return fmt.Errorf("tool call quota exceeded for database %q, retry in %s", databaseName, wait.Round(time.Second))
+	}
+	bucket.take()
+	r.all.take()
+	return nil
+}
@@ -0,0 +1,74 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinternet/databaise/internal/config"
+)
+
+// newTestRateLimiter returns a limiter whose clock only moves when advance is called.
+func newTestRateLimiter(cfg *config.RateLimit) (*rateLimiter, func(time.Duration)) {
+	r := newRateLimiter(cfg)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	return r, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("Nil", func(t *testing.T) {
+		var r *rateLimiter
+		require.Nil(t, newRateLimiter(nil))
+		require.NoError(t, r.allow("db", "read"))
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		r, _ := newTestRateLimiter(&config.RateLimit{})
+		for range 100 {
+			require.NoError(t, r.allow("db", "admin"))
+		}
+	})
+
+	t.Run("PerLevel", func(t *testing.T) {
+		r, _ := newTestRateLimiter(&config.RateLimit{ReadPerMinute: 2, AdminPerMinute: 1})
+		require.NoError(t, r.allow("db", "read"))
+		require.NoError(t, r.allow("db", "read"))
+		require.ErrorContains(t, r.allow("db", "read"), `read tool call quota exceeded for database "db", retry in 30s`)
+
+		// Write calls share the admin quota.
+		require.NoError(t, r.allow("db", "write"))
+		require.ErrorContains(t, r.allow("db", "admin"), "admin tool call quota exceeded")
+	})
+
+	t.Run("Refill", func(t *testing.T) {
+		r, advance := newTestRateLimiter(&config.RateLimit{ReadPerMinute: 2})
+		require.NoError(t, r.allow("db", "read"))
+		require.NoError(t, r.allow("db", "read"))
+		require.Error(t, r.allow("db", "read"))
+
+		advance(30 * time.Second)
+		require.NoError(t, r.allow("db", "read"))
+		require.Error(t, r.allow("db", "read"))
+
+		// The bucket never holds more than a minute's worth of calls.
+		advance(time.Hour)
+		require.NoError(t, r.allow("db", "read"))
+		require.NoError(t, r.allow("db", "read"))
+		require.Error(t, r.allow("db", "read"))
+	})
+
+	t.Run("Global", func(t *testing.T) {
+		r, advance := newTestRateLimiter(&config.RateLimit{PerMinute: 2, ReadPerMinute: 2})
+		require.NoError(t, r.allow("db", "admin"))
+		require.NoError(t, r.allow("db", "admin"))
+
+		// Refused by the overall quota, which must not use up a read token.
+		require.ErrorContains(t, r.allow("db", "read"), `tool call quota exceeded for database "db"`)
+		require.ErrorContains(t, r.allow("db", "read"), `tool call quota exceeded for database "db"`)
+
+		advance(time.Minute)
+		require.NoError(t, r.allow("db", "read"))
+		require.NoError(t, r.allow("db", "read"))
+	})
+}
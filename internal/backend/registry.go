@@ -2,28 +2,78 @@ package backend
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/tinternet/databaise/internal/config"
 	"github.com/tinternet/databaise/internal/logging"
+	"github.com/tinternet/databaise/internal/server"
+	"github.com/tinternet/databaise/internal/sqlcommon"
+	"github.com/tinternet/databaise/internal/tracing"
+	"golang.org/x/text/encoding"
 )
 
 var log = logging.New("backend")
 
+// defaultMaxRows caps execute_query results of databases that do not set max_rows.
+const defaultMaxRows = 1000
+
 // Instance represents a configured database instance.
 type Instance struct {
 	Name        string
 	Description string
 	Dialect     string
 	HasAdmin    bool
+	HasWrite    bool
+
+	// DisabledTools lists tools that must not be called for this database.
+	DisabledTools []string
+
+	// MaxResultBytes caps the serialized size of query results; zero means uncapped.
+	MaxResultBytes int64
+
+	// MaxRows caps the number of rows execute_query returns; zero or less means uncapped.
+	MaxRows int
+
+	// Readonly is how the read connection is kept from writing, or nil if the backend does not report it.
+	Readonly *ReadonlyStatus
+
+	// IncludeExecutedSQL keeps the executed_sql field in tool results.
+	IncludeExecutedSQL bool
+
+	// NormalizeBooleans returns boolean-like columns as true/false.
+	NormalizeBooleans bool
+
+	// ExportDir is the absolute directory execute_query exports are written to; empty disables exports.
+	ExportDir string
+
+	// resultEncoding decodes result strings that are not valid UTF-8; nil replaces invalid sequences.
+	resultEncoding encoding.Encoding
+
+	limiter *rateLimiter
+	cache   *queryCache
+	schema  *schemaCache
+
+	// readPool, adminPool and writePool are the connection pools behind Read, Admin and Write, for pool_stats.
+	readPool  *sql.DB
+	adminPool *sql.DB
+	writePool *sql.DB
 
 	// Read returns an SQLBackend using the read connection.
 	Read func() SQLBackend
 
 	// Admin returns an SQLBackend using the admin connection, or nil if not configured.
 	Admin func() SQLBackend
+
+	// Write returns an SQLBackend using the write connection, or nil if not configured.
+	Write func() SQLBackend
 }
 
 // registry holds all registered database instances.
@@ -34,8 +84,9 @@ var (
 
 // factoryEntry stores a registered backend factory with its initializer.
 type factoryEntry struct {
-	dialect string
-	init    func(name string, cfg config.Database) error
+	dialect     string
+	unsupported []string
+	init        func(name string, cfg config.Database) error
 }
 
 // factories maps backend type names to their factory entries.
@@ -50,15 +101,60 @@ func RegisterFactory[R, A, DB any](backendType string, factory BackendFactory[DB
 	factoriesMu.Lock()
 	defer factoriesMu.Unlock()
 
-	factories[backendType] = factoryEntry{
+	entry := factoryEntry{
 		dialect: factory.Dialect(),
 		init: func(name string, cfg config.Database) error {
 			return initInstance(name, cfg, factory, connect)
 		},
 	}
+	if ts, ok := any(factory).(ToolSupport); ok {
+		entry.unsupported = ts.UnsupportedTools()
+	}
+	var db DB
+	b := factory.New(db)
+	for tool, supported := range optionalTools {
+		if !supported(b) {
+			entry.unsupported = append(entry.unsupported, tool)
+		}
+	}
+	factories[backendType] = entry
 	log.Printf("Registered backend factory: %s (%s)", backendType, factory.Dialect())
 }
 
+// optionalTools maps the tools served by optional SQLBackend interfaces to a
+// check that a backend implements the interface.
+var optionalTools = map[string]func(SQLBackend) bool{
+	"list_materialized_views":   implements[MaterializedViewLister],
+	"refresh_materialized_view": implements[MaterializedViewRefresher],
+}
+
+// implements reports whether b implements T.
+func implements[T any](b SQLBackend) bool {
+	_, ok := b.(T)
+	return ok
+}
+
+// wrapper is implemented by the SQLBackends that wrap another, such as paginator.
+type wrapper interface {
+	unwrap() SQLBackend
+}
+
+// as returns b as a T, looking through the backends b wraps, so that optional
+// interfaces of the underlying backend can be found.
+func as[T any](b SQLBackend) (T, bool) {
+	for {
+		if t, ok := b.(T); ok {
+			return t, true
+		}
+		w, ok := b.(wrapper)
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		b = w.unwrap()
+	}
+}
+
 // Connector handles database connections for a backend type.
 type Connector[R, A, DB any] interface {
 	// ConnectRead establishes a read-only connection.
@@ -74,6 +170,12 @@ func initInstance[R, A, DB any](name string, cfg config.Database, factory Backen
 		return fmt.Errorf("database %q must have read configuration", name)
 	}
 
+	switch cfg.OnReadonlyViolation {
+	case "", "fail", "warn", "downgrade":
+	default:
+		return fmt.Errorf("invalid on_readonly_violation %q for %q: use fail, warn or downgrade", cfg.OnReadonlyViolation, name)
+	}
+
 	var rCfg R
 	if err := json.Unmarshal(cfg.Read, &rCfg); err != nil {
 		return fmt.Errorf("failed to parse read config for %q: %w", name, err)
@@ -85,19 +187,140 @@ func initInstance[R, A, DB any](name string, cfg config.Database, factory Backen
 		return fmt.Errorf("failed to connect read for %q: %w", name, err)
 	}
 
+	var readonly *ReadonlyStatus
+	if checker, ok := any(connect).(ReadonlyChecker[R, DB]); ok {
+		status := checker.CheckReadonly(rCfg, readDB)
+		if status.Mode == ReadonlyGrantCheck && !status.Verified {
+			if readDB, err = onReadonlyViolation(name, cfg.OnReadonlyViolation, &status, readDB, connect); err != nil {
+				return err
+			}
+		}
+		readonly = &status
+	}
+
+	var resultEncoding encoding.Encoding
+	if cfg.ResultCharset != "" {
+		if resultEncoding, err = sqlcommon.LookupCharset(cfg.ResultCharset); err != nil {
+			return fmt.Errorf("invalid result_charset for %q: %w", name, err)
+		}
+	}
+
+	var policy *sqlcommon.QueryPolicy
+	if cfg.QueryPolicy != nil {
+		if policy, err = sqlcommon.NewQueryPolicy(*cfg.QueryPolicy); err != nil {
+			return fmt.Errorf("invalid query_policy for %q: %w", name, err)
+		}
+	}
+
+	var exportDir string
+	if cfg.ExportDir != "" {
+		if exportDir, err = filepath.Abs(cfg.ExportDir); err != nil {
+			return fmt.Errorf("invalid export_dir for %q: %w", name, err)
+		}
+		if info, err := os.Stat(exportDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("invalid export_dir for %q: %s is not a directory", name, cfg.ExportDir)
+		}
+	}
+
+	for _, tool := range cfg.DisabledTools {
+		if !server.HasTool(tool) {
+			log.Printf("WARN: unknown tool %q in disabled_tools for %q", tool, name)
+		}
+	}
+
+	maxRows := cfg.MaxRows
+	if maxRows == 0 {
+		maxRows = defaultMaxRows
+	}
+
 	inst := &Instance{
-		Name:        name,
-		Description: cfg.Description,
-		Dialect:     factory.Dialect(),
-		HasAdmin:    cfg.HasAdmin(),
-		Read:        func() SQLBackend { return factory.New(readDB) },
+		Name:               name,
+		Description:        cfg.Description,
+		Dialect:            factory.Dialect(),
+		HasAdmin:           cfg.HasAdmin(),
+		HasWrite:           cfg.HasWrite(),
+		DisabledTools:      cfg.DisabledTools,
+		MaxResultBytes:     cfg.MaxResultBytes,
+		MaxRows:            maxRows,
+		IncludeExecutedSQL: cfg.IncludeExecutedSQL,
+		NormalizeBooleans:  cfg.NormalizeBooleans,
+		ExportDir:          exportDir,
+		Readonly:           readonly,
+		Read:               func() SQLBackend { return factory.New(readDB) },
+		limiter:            newRateLimiter(cfg.RateLimit),
+		cache:              newQueryCache(cfg.Cache),
+		schema:             newSchemaCache(cfg.Cache),
+		readPool:           sqlDB(readDB),
+		resultEncoding:     resultEncoding,
+	}
+
+	if cfg.InjectLimit && maxRows > 0 {
+		log.Printf("LIMIT injection enabled for %s (max_rows: %d)", name, maxRows)
+		read := inst.Read
+		inst.Read = func() SQLBackend {
+			return &limitInjector{SQLBackend: read(), maxRows: maxRows, top: inst.Dialect == "T-SQL"}
+		}
+	}
+
+	if cfg.MaxFullScanRows > 0 {
+		log.Printf("Scan guard enabled for %s (max_full_scan_rows: %d)", name, cfg.MaxFullScanRows)
+		read := inst.Read
+		inst.Read = func() SQLBackend {
+			return &scanGuard{SQLBackend: read(), maxRows: cfg.MaxFullScanRows}
+		}
+	}
+
+	read := inst.Read
+	inst.Read = func() SQLBackend {
+		return &paginator{SQLBackend: read(), maxRows: maxRows, top: inst.Dialect == "T-SQL"}
+	}
+
+	excluded := cfg.ExcludedSchemas
+	if excluded == nil {
+		excluded = defaultExcludedSchemas
+	}
+	if len(excluded) > 0 {
+		read := inst.Read
+		inst.Read = func() SQLBackend {
+			return &schemaFilter{SQLBackend: read(), excluded: excluded}
+		}
+	}
+
+	if cfg.SchemaSummary {
+		ctx, cancel := context.WithTimeout(context.Background(), summaryTimeout)
+		summary, err := schemaSummary(ctx, factory.New(readDB), excluded)
+		cancel()
+		if err != nil {
+			log.Printf("WARN: failed to summarize schema for %q: %v", name, err)
+		} else if inst.Description != "" {
+			inst.Description += "\n\n" + summary
+		} else {
+			inst.Description = summary
+		}
+	}
+
+	if inst.cache != nil {
+		log.Printf("Result cache enabled for %s (ttl: %s, max entries: %d, schema ttl: %s)", name, inst.cache.ttl, inst.cache.maxEntries, inst.schema.ttl)
+		read := inst.Read
+		inst.Read = func() SQLBackend {
+			return &cachingBackend{SQLBackend: read(), cache: inst.cache, schema: inst.schema}
+		}
+	}
+
+	// Checked first, so a blocked query is neither explained by the scan guard nor served from the cache.
+	if policy != nil {
+		log.Printf("Query policy enabled for %s (%d allow, %d deny patterns)", name, len(cfg.QueryPolicy.Allow), len(cfg.QueryPolicy.Deny))
+		read := inst.Read
+		inst.Read = func() SQLBackend {
+			return &policyGuard{SQLBackend: read(), policy: policy}
+		}
 	}
 
 	// Connect admin if configured
 	if cfg.HasAdmin() {
 		var aCfg A
-		if err := json.Unmarshal(cfg.Read, &aCfg); err != nil {
-			return fmt.Errorf("failed to parse read config for %q: %w", name, err)
+		if err := cfg.ParseAdminConfig(&aCfg); err != nil {
+			return fmt.Errorf("failed to parse admin config for %q: %w", name, err)
 		}
 
 		adminDB, err := connect.ConnectAdmin(aCfg)
@@ -105,6 +328,26 @@ func initInstance[R, A, DB any](name string, cfg config.Database, factory Backen
 			return fmt.Errorf("failed to connect admin for %q: %w", name, err)
 		}
 		inst.Admin = func() SQLBackend { return factory.New(adminDB) }
+		if policy != nil {
+			admin := inst.Admin
+			inst.Admin = func() SQLBackend { return &policyGuard{SQLBackend: admin(), policy: policy} }
+		}
+		inst.adminPool = sqlDB(adminDB)
+	}
+
+	// Connect write if configured. The write config has the shape of the admin config.
+	if cfg.HasWrite() {
+		var wCfg A
+		if err := cfg.ParseWriteConfig(&wCfg); err != nil {
+			return fmt.Errorf("failed to parse write config for %q: %w", name, err)
+		}
+
+		writeDB, err := connect.ConnectAdmin(wCfg)
+		if err != nil {
+			return fmt.Errorf("failed to connect write for %q: %w", name, err)
+		}
+		inst.Write = func() SQLBackend { return factory.New(writeDB) }
+		inst.writePool = sqlDB(writeDB)
 	}
 
 	instancesMu.Lock()
@@ -115,6 +358,26 @@ func initInstance[R, A, DB any](name string, cfg config.Database, factory Backen
 	return nil
 }
 
+// onReadonlyViolation applies the database's on_readonly_violation policy to a read
+// connection whose grant check failed, updating status to match.
+func onReadonlyViolation[DB any](name, policy string, status *ReadonlyStatus, readDB DB, connect any) (DB, error) {
+	switch policy {
+	case "warn":
+		log.Printf("WARN: %s for %q, continuing (on_readonly_violation: warn)", status.Error, name)
+		return readDB, nil
+	case "downgrade":
+		d, ok := connect.(ReadonlyDowngrader[DB])
+		if !ok {
+			return readDB, fmt.Errorf("failed to connect read for %q: %s, and this backend cannot downgrade to read-only transactions", name, status.Error)
+		}
+		log.Printf("WARN: %s for %q, running reads in read-only transactions (on_readonly_violation: downgrade)", status.Error, name)
+		status.Mode, status.Verified, status.Error, status.Downgraded = ReadonlyTx, true, "", true
+		return d.DowngradeReadonly(readDB), nil
+	default:
+		return readDB, fmt.Errorf("failed to connect read for %q: %s", name, status.Error)
+	}
+}
+
 // Init initializes a database instance from config.
 func Init(name string, cfg config.Database) error {
 	factoriesMu.RLock()
@@ -143,7 +406,7 @@ func GetInstance(name string) (*Instance, error) {
 
 	inst, ok := instances[name]
 	if !ok {
-		return nil, fmt.Errorf("database %q not found", name)
+		return nil, fmt.Errorf("%w: %q", sqlcommon.ErrDatabaseNotFound, name)
 	}
 	return inst, nil
 }
@@ -154,6 +417,9 @@ func GetReadBackend(databaseName string) (SQLBackend, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := inst.limiter.allow(databaseName, "read"); err != nil {
+		return nil, err
+	}
 	return inst.Read(), nil
 }
 
@@ -164,24 +430,147 @@ func GetAdminBackend(databaseName string) (SQLBackend, error) {
 		return nil, err
 	}
 	if inst.Admin == nil {
-		return nil, fmt.Errorf("admin not configured for database %q", databaseName)
+		return nil, fmt.Errorf("%w for database %q: this tool needs the admin connection, which is not configured. Call list_databases and use a database with has_admin set", sqlcommon.ErrAdminNotConfigured, databaseName)
+	}
+	if err := inst.limiter.allow(databaseName, "admin"); err != nil {
+		return nil, err
 	}
 	return inst.Admin(), nil
 }
 
+// GetWriteBackend returns an SQLBackend for write operations.
+func GetWriteBackend(databaseName string) (SQLBackend, error) {
+	inst, err := GetInstance(databaseName)
+	if err != nil {
+		return nil, err
+	}
+	if inst.Write == nil {
+		return nil, fmt.Errorf("%w for database %q: this tool needs the write connection, which is not configured. Call list_databases and use a database with has_write set", sqlcommon.ErrWriteNotConfigured, databaseName)
+	}
+	if err := inst.limiter.allow(databaseName, "write"); err != nil {
+		return nil, err
+	}
+	return inst.Write(), nil
+}
+
 // Handle wraps a backend method call with database routing.
-// getBackend should be either GetReadBackend or GetAdminBackend.
+// getBackend should be GetReadBackend, GetAdminBackend or GetWriteBackend.
 func Handle[In any, Out any](
 	ctx context.Context,
 	databaseName string,
 	in In,
 	getBackend func(string) (SQLBackend, error),
 	fn func(SQLBackend, context.Context, In) (Out, error),
-) (Out, error) {
+) (out Out, err error) {
+	start := time.Now()
+	defer func() { auditLogger.record(ctx, databaseName, in, start, err) }()
+
 	var zero Out
+	inst, err := GetInstance(databaseName)
+	if err != nil {
+		return zero, err
+	}
+	ctx, span := tracing.StartTool(ctx, server.ToolName(ctx), dbSystem(inst.Dialect), databaseName)
+	defer func() { tracing.End(span, err) }()
+	if tool := server.ToolName(ctx); inst.IsToolDisabled(tool) {
+		return zero, fmt.Errorf("tool %s is disabled for database %q", tool, databaseName)
+	}
+	ctx = sqlcommon.WithMaxResultBytes(ctx, inst.MaxResultBytes)
+	ctx = sqlcommon.WithMaxRows(ctx, inst.MaxRows)
+	ctx = sqlcommon.WithResultEncoding(ctx, inst.resultEncoding)
+	ctx = sqlcommon.WithBoolNormalization(ctx, inst.NormalizeBooleans)
+	ctx = withExportDir(ctx, inst.ExportDir)
 	backend, err := getBackend(databaseName)
 	if err != nil {
 		return zero, err
 	}
-	return fn(backend, ctx, in)
+	queryStart := time.Now()
+	out, err = fn(backend, ctx, in)
+	err = sqlcommon.TranslateError(err)
+	if s, ok := any(in).(statementInput); ok {
+		queryLogger.record(databaseName, server.ToolName(ctx), s.statement(), time.Since(queryStart), err)
+	}
+	if r, ok := any(out).(executedSQLResult); ok && !inst.IncludeExecutedSQL {
+		r.clearExecutedSQL()
+	}
+	// A write may have taken effect even if it reported an error.
+	if server.IsMutating(server.ToolName(ctx)) {
+		inst.cache.invalidate()
+		inst.schema.invalidate()
+	}
+	return out, err
+}
+
+// dbSystems maps dialects to OpenTelemetry db.system values.
+var dbSystems = map[string]string{
+	"PostgreSQL": "postgresql",
+	"MySQL":      "mysql",
+	"SQLite":     "sqlite",
+	"T-SQL":      "mssql",
+}
+
+// dbSystem returns the OpenTelemetry db.system value of a dialect.
+func dbSystem(dialect string) string {
+	if system, ok := dbSystems[dialect]; ok {
+		return system
+	}
+	return strings.ToLower(dialect)
+}
+
+// executedSQLResult is a tool result that reports the SQL it ran.
+type executedSQLResult interface {
+	clearExecutedSQL()
+}
+
+func (r *QueryResult) clearExecutedSQL() {
+	if r != nil {
+		r.ExecutedSQL = ""
+	}
+}
+
+func (r *ExplainResult) clearExecutedSQL() {
+	if r != nil {
+		r.ExecutedSQL = ""
+	}
+}
+
+func (r *DDLResult) clearExecutedSQL() {
+	if r != nil {
+		r.ExecutedSQL = ""
+	}
+}
+
+func (r *WriteResult) clearExecutedSQL() {
+	if r != nil {
+		r.ExecutedSQL = ""
+	}
+}
+
+// HasAnyAdmin returns true if at least one initialized database has an admin connection.
+func HasAnyAdmin() bool {
+	instancesMu.RLock()
+	defer instancesMu.RUnlock()
+	for _, inst := range instances {
+		if inst.Admin != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAnyWrite returns true if at least one initialized database has a write connection.
+func HasAnyWrite() bool {
+	instancesMu.RLock()
+	defer instancesMu.RUnlock()
+	for _, inst := range instances {
+		if inst.Write != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// IsToolDisabled returns true if the tool is listed in the instance's disabled_tools.
+func (i *Instance) IsToolDisabled(tool string) bool {
+	return slices.Contains(i.DisabledTools, tool)
 }
@@ -0,0 +1,216 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinternet/databaise/internal/config"
+	"github.com/tinternet/databaise/internal/sqlcommon"
+)
+
+func TestRegistryErrors(t *testing.T) {
+	instancesMu.Lock()
+	instances["readonly"] = &Instance{Name: "readonly", Read: func() SQLBackend { return nil }}
+	instancesMu.Unlock()
+	t.Cleanup(func() {
+		instancesMu.Lock()
+		delete(instances, "readonly")
+		instancesMu.Unlock()
+	})
+
+	t.Run("DatabaseNotFound", func(t *testing.T) {
+		_, err := GetInstance("missing")
+		require.ErrorIs(t, err, sqlcommon.ErrDatabaseNotFound)
+		require.ErrorContains(t, err, `"missing"`)
+
+		_, err = GetReadBackend("missing")
+		require.ErrorIs(t, err, sqlcommon.ErrDatabaseNotFound)
+	})
+
+	t.Run("AdminNotConfigured", func(t *testing.T) {
+		_, err := GetAdminBackend("readonly")
+		require.ErrorIs(t, err, sqlcommon.ErrAdminNotConfigured)
+		require.ErrorContains(t, err, `"readonly"`)
+		require.ErrorContains(t, err, "list_databases")
+	})
+
+	t.Run("WriteNotConfigured", func(t *testing.T) {
+		_, err := GetWriteBackend("readonly")
+		require.ErrorIs(t, err, sqlcommon.ErrWriteNotConfigured)
+		require.ErrorContains(t, err, `"readonly"`)
+		require.ErrorContains(t, err, "has_write")
+	})
+}
+
+func TestHandleExecutedSQL(t *testing.T) {
+	instancesMu.Lock()
+	instances["hidden"] = &Instance{Name: "hidden"}
+	instances["shown"] = &Instance{Name: "shown", IncludeExecutedSQL: true}
+	instancesMu.Unlock()
+	t.Cleanup(func() {
+		instancesMu.Lock()
+		delete(instances, "hidden")
+		delete(instances, "shown")
+		instancesMu.Unlock()
+	})
+
+	getBackend := func(string) (SQLBackend, error) { return nil, nil }
+	run := func(SQLBackend, context.Context, ReadQueryIn) (*QueryResult, error) {
+		return &QueryResult{ExecutedSQL: "SELECT 1"}, nil
+	}
+
+	out, err := Handle(t.Context(), "hidden", ReadQueryIn{}, getBackend, run)
+	require.NoError(t, err)
+	require.Empty(t, out.ExecutedSQL)
+
+	out, err = Handle(t.Context(), "shown", ReadQueryIn{}, getBackend, run)
+	require.NoError(t, err)
+	require.Equal(t, "SELECT 1", out.ExecutedSQL)
+}
+
+// fakeDB stands in for a backend's connection type.
+type fakeDB struct{ readonlyTx bool }
+
+type fakeFactory struct{}
+
+func (fakeFactory) Dialect() string          { return "Fake" }
+func (fakeFactory) New(db fakeDB) SQLBackend { return nil }
+
+// writableConnector connects as a read user that has write permissions.
+type writableConnector struct{}
+
+func (writableConnector) ConnectRead(cfg struct{}) (fakeDB, error)  { return fakeDB{}, nil }
+func (writableConnector) ConnectAdmin(cfg struct{}) (fakeDB, error) { return fakeDB{}, nil }
+func (writableConnector) CheckReadonly(cfg struct{}, db fakeDB) ReadonlyStatus {
+	return ReadonlyStatus{Mode: ReadonlyGrantCheck, Error: "read DSN user has write permissions"}
+}
+func (writableConnector) DowngradeReadonly(db fakeDB) fakeDB {
+	db.readonlyTx = true
+	return db
+}
+
+// dsnConfig is the connection config shape of the SQL backends.
+type dsnConfig struct {
+	DSN string `json:"dsn"`
+}
+
+// dsnConnector records the DSN of each connection it opens.
+type dsnConnector struct{ opened *[]string }
+
+func (c dsnConnector) ConnectRead(cfg dsnConfig) (fakeDB, error) {
+	*c.opened = append(*c.opened, "read "+cfg.DSN)
+	return fakeDB{}, nil
+}
+
+func (c dsnConnector) ConnectAdmin(cfg dsnConfig) (fakeDB, error) {
+	*c.opened = append(*c.opened, "admin "+cfg.DSN)
+	return fakeDB{}, nil
+}
+
+func TestInitConnectionConfigs(t *testing.T) {
+	var opened []string
+	cfg := config.Database{
+		Read:  []byte(`{"dsn": "postgres://reader@db/app"}`),
+		Admin: []byte(`{"dsn": "postgres://admin@db/app"}`),
+		Write: []byte(`{"dsn": "postgres://writer@db/app"}`),
+	}
+	require.NoError(t, initInstance("connection_configs", cfg, fakeFactory{}, dsnConnector{opened: &opened}))
+	t.Cleanup(func() {
+		instancesMu.Lock()
+		delete(instances, "connection_configs")
+		instancesMu.Unlock()
+	})
+	// The write connection takes the shape of the admin config.
+	require.Equal(t, []string{"read postgres://reader@db/app", "admin postgres://admin@db/app", "admin postgres://writer@db/app"}, opened)
+
+	cfg.Admin = []byte(`{"dsn": 42}`)
+	err := initInstance("bad_admin_config", cfg, fakeFactory{}, dsnConnector{opened: &opened})
+	require.ErrorContains(t, err, `failed to parse admin config for "bad_admin_config"`)
+}
+
+func TestOnReadonlyViolation(t *testing.T) {
+	initWith := func(t *testing.T, policy string) (*Instance, error) {
+		t.Helper()
+		name := "violation_" + policy
+		cfg := config.Database{Read: []byte(`{}`), OnReadonlyViolation: policy}
+		if err := initInstance(name, cfg, fakeFactory{}, writableConnector{}); err != nil {
+			return nil, err
+		}
+		t.Cleanup(func() {
+			instancesMu.Lock()
+			delete(instances, name)
+			instancesMu.Unlock()
+		})
+		return GetInstance(name)
+	}
+
+	t.Run("Fail", func(t *testing.T) {
+		_, err := initWith(t, "")
+		require.ErrorContains(t, err, "read DSN user has write permissions")
+	})
+
+	t.Run("Warn", func(t *testing.T) {
+		inst, err := initWith(t, "warn")
+		require.NoError(t, err)
+		require.False(t, inst.Readonly.Verified)
+		require.Equal(t, ReadonlyGrantCheck, inst.Readonly.Mode)
+	})
+
+	t.Run("Downgrade", func(t *testing.T) {
+		inst, err := initWith(t, "downgrade")
+		require.NoError(t, err)
+		require.True(t, inst.Readonly.Verified)
+		require.True(t, inst.Readonly.Downgraded)
+		require.Equal(t, ReadonlyTx, inst.Readonly.Mode)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := initWith(t, "ignore")
+		require.ErrorContains(t, err, "invalid on_readonly_violation")
+	})
+}
+
+func TestMaxRowsDefault(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		maxRows, want int
+	}{
+		{"Default", 0, defaultMaxRows},
+		{"Configured", 50, 50},
+		{"Uncapped", -1, -1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			name := "max_rows_" + tt.name
+			cfg := config.Database{Read: []byte(`{}`), OnReadonlyViolation: "warn", MaxRows: tt.maxRows}
+			require.NoError(t, initInstance(name, cfg, fakeFactory{}, writableConnector{}))
+			t.Cleanup(func() {
+				instancesMu.Lock()
+				delete(instances, name)
+				instancesMu.Unlock()
+			})
+			inst, err := GetInstance(name)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, inst.MaxRows)
+		})
+	}
+}
+
+func TestRegisterFactoryOptionalTools(t *testing.T) {
+	RegisterFactory("fake", fakeFactory{}, dsnConnector{})
+	t.Cleanup(func() {
+		factoriesMu.Lock()
+		delete(factories, "fake")
+		factoriesMu.Unlock()
+	})
+
+	for _, b := range ListBackends().Backends {
+		if b.Type == "fake" {
+			require.Contains(t, b.Tools, "list_tables")
+			require.NotContains(t, b.Tools, "list_materialized_views")
+			require.NotContains(t, b.Tools, "refresh_materialized_view")
+			return
+		}
+	}
+	t.Fatal("fake backend is not registered")
+}
@@ -0,0 +1,42 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// scanGuard wraps a read backend and refuses queries whose plan contains a full
+// scan of a table larger than maxRows, unless the caller explicitly allows it.
+type scanGuard struct {
+	SQLBackend
+	maxRows int64
+}
+
+func (g *scanGuard) unwrap() SQLBackend { return g.SQLBackend }
+
+func (g *scanGuard) ExecuteQuery(ctx context.Context, in ReadQueryIn) (*QueryResult, error) {
+	if in.AllowFullScan {
+		return g.SQLBackend.ExecuteQuery(ctx, in)
+	}
+
+	// Explain in the same database the query runs in, or a tenant query is planned
+	// against the tables of the default database.
+	plan, err := g.ExplainQuery(ctx, ExplainQueryIn{Query: in.Query, Schema: in.Schema})
+	if err != nil {
+		return nil, fmt.Errorf("scan guard could not explain the query (set allow_full_scan: true to skip the check): %w", err)
+	}
+
+	var large []string
+	for _, scan := range plan.FullScans {
+		if scan.EstimatedRows > float64(g.maxRows) {
+			large = append(large, fmt.Sprintf("%s (~%.0f rows)", scan.Table, scan.EstimatedRows))
+		}
+	}
+	if len(large) > 0 {
+		return nil, fmt.Errorf("query refused: it performs a full scan of %s, above the limit of %d rows. Add a selective WHERE clause or set allow_full_scan: true to run it anyway.\n\nPlan (%s):\n%s",
+			strings.Join(large, ", "), g.maxRows, plan.Format, plan.Result)
+	}
+
+	return g.SQLBackend.ExecuteQuery(ctx, in)
+}
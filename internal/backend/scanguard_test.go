@@ -0,0 +1,77 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// planStub returns a fixed plan and records what was explained and executed.
+type planStub struct {
+	queryStub
+	scans      []TableScan
+	explainErr error
+	explained  *ExplainQueryIn
+}
+
+func (s *planStub) ExplainQuery(ctx context.Context, in ExplainQueryIn) (*ExplainResult, error) {
+	s.explained = &in
+	if s.explainErr != nil {
+		return nil, s.explainErr
+	}
+	return &ExplainResult{Format: "text", Result: "Seq Scan on orders", FullScans: s.scans}, nil
+}
+
+func TestScanGuard(t *testing.T) {
+	t.Run("SmallScan", func(t *testing.T) {
+		stub := &planStub{scans: []TableScan{{Table: "orders", EstimatedRows: 1000}}}
+		g := &scanGuard{SQLBackend: stub, maxRows: 1000}
+
+		_, err := g.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM orders"})
+		require.NoError(t, err)
+		require.Equal(t, "SELECT * FROM orders", stub.explained.Query)
+		require.Equal(t, "SELECT * FROM orders", stub.query)
+	})
+
+	t.Run("LargeScan", func(t *testing.T) {
+		stub := &planStub{scans: []TableScan{{Table: "users", EstimatedRows: 10}, {Table: "orders", EstimatedRows: 5000}}}
+		g := &scanGuard{SQLBackend: stub, maxRows: 1000}
+
+		_, err := g.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM orders JOIN users USING (user_id)"})
+		require.ErrorContains(t, err, "full scan of orders (~5000 rows), above the limit of 1000 rows")
+		require.ErrorContains(t, err, "Seq Scan on orders")
+		require.NotContains(t, err.Error(), "users (")
+		require.Empty(t, stub.query, "a refused query must not run")
+	})
+
+	t.Run("AllowFullScan", func(t *testing.T) {
+		stub := &planStub{scans: []TableScan{{Table: "orders", EstimatedRows: 5000}}}
+		g := &scanGuard{SQLBackend: stub, maxRows: 1000}
+
+		_, err := g.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM orders", AllowFullScan: true})
+		require.NoError(t, err)
+		require.Nil(t, stub.explained, "the plan is not needed when full scans are allowed")
+		require.Equal(t, "SELECT * FROM orders", stub.query)
+	})
+
+	t.Run("ExplainError", func(t *testing.T) {
+		stub := &planStub{explainErr: errors.New("syntax error")}
+		g := &scanGuard{SQLBackend: stub, maxRows: 1000}
+
+		_, err := g.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM"})
+		require.ErrorContains(t, err, "scan guard could not explain the query")
+		require.ErrorContains(t, err, "syntax error")
+		require.Empty(t, stub.query)
+	})
+
+	t.Run("Schema", func(t *testing.T) {
+		stub := &planStub{}
+		g := &scanGuard{SQLBackend: stub, maxRows: 1000}
+
+		_, err := g.ExecuteQuery(t.Context(), ReadQueryIn{Query: "SELECT * FROM orders", Schema: "tenant_a"})
+		require.NoError(t, err)
+		require.Equal(t, "tenant_a", stub.explained.Schema, "the query must be explained in the database it runs in")
+	})
+}
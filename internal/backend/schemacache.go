@@ -0,0 +1,166 @@
+package backend
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tinternet/databaise/internal/config"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	defaultSchemaCacheTTL = 5 * time.Minute
+
+	// warmConcurrency bounds the describe_table calls in flight per database while warming.
+	warmConcurrency = 4
+	// warmTimeout bounds the time spent warming a single database.
+	warmTimeout = 2 * time.Minute
+)
+
+type schemaEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// schemaCache holds list_tables and describe_table results of one database.
+// Schemas change rarely, so entries live longer than query results.
+type schemaCache struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	tables map[ListTablesIn]schemaEntry[[]Table]
+	descs  map[DescribeTableIn]schemaEntry[TableDescription]
+}
+
+func newSchemaCache(cfg *config.Cache) *schemaCache {
+	if cfg == nil {
+		return nil
+	}
+	c := &schemaCache{
+		ttl:    time.Duration(cfg.SchemaTTLSeconds) * time.Second,
+		tables: make(map[ListTablesIn]schemaEntry[[]Table]),
+		descs:  make(map[DescribeTableIn]schemaEntry[TableDescription]),
+	}
+	if c.ttl <= 0 {
+		c.ttl = defaultSchemaCacheTTL
+	}
+	return c
+}
+
+// tablesKey keeps only the fields the backend filters on; pattern and paging are
+// applied to the full list afterwards.
+func tablesKey(in ListTablesIn) ListTablesIn {
+	return ListTablesIn{Schema: in.Schema, AllSchemas: in.AllSchemas, WithStats: in.WithStats}
+}
+
+// getTables returns a copy of the cached table list, which callers may filter in place.
+func (c *schemaCache) getTables(in ListTablesIn) ([]Table, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.tables[tablesKey(in)]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return slices.Clone(entry.value), true
+}
+
+func (c *schemaCache) putTables(in ListTablesIn, tables []Table) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tables[tablesKey(in)] = schemaEntry[[]Table]{value: slices.Clone(tables), expires: time.Now().Add(c.ttl)}
+}
+
+func (c *schemaCache) getDescription(in DescribeTableIn) (*TableDescription, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.descs[in]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	desc := entry.value
+	return &desc, true
+}
+
+func (c *schemaCache) putDescription(in DescribeTableIn, desc *TableDescription) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.descs[in] = schemaEntry[TableDescription]{value: *desc, expires: time.Now().Add(c.ttl)}
+}
+
+// invalidate drops every cached result. A nil cache is a no-op.
+func (c *schemaCache) invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.tables)
+	clear(c.descs)
+}
+
+// WarmSchemaCaches fills the schema cache of every database that has caching
+// enabled by listing its tables and describing each of them, so the first
+// discovery calls of a session are served from memory. Failures are logged
+// and otherwise ignored.
+func WarmSchemaCaches(ctx context.Context) {
+	instancesMu.RLock()
+	var targets []*Instance
+	for _, inst := range instances {
+		if inst.schema != nil {
+			targets = append(targets, inst)
+		}
+	}
+	instancesMu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, inst := range targets {
+		wg.Go(func() {
+			start := time.Now()
+			n, err := inst.warmSchema(ctx)
+			if err != nil {
+				log.Printf("WARN: schema warm-up for %s stopped after %d tables: %v", inst.Name, n, err)
+				return
+			}
+			log.Printf("Warmed schema cache for %s: %d tables in %s", inst.Name, n, time.Since(start).Round(time.Millisecond))
+		})
+	}
+	wg.Wait()
+}
+
+// warmSchema caches the table list and up to defaultListLimit table descriptions,
+// returning how many tables were described.
+func (i *Instance) warmSchema(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, warmTimeout)
+	defer cancel()
+
+	b := i.Read()
+	if i.IsToolDisabled("list_tables") {
+		return 0, nil
+	}
+	tables, err := b.ListTables(ctx, ListTablesIn{})
+	if err != nil {
+		return 0, err
+	}
+	if i.IsToolDisabled("describe_table") {
+		return 0, nil
+	}
+
+	var described int
+	var mu sync.Mutex
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(warmConcurrency)
+	for _, t := range tables[:min(len(tables), defaultListLimit)] {
+		g.Go(func() error {
+			if _, err := b.DescribeTable(ctx, DescribeTableIn{Schema: t.Schema, Table: t.Name}); err != nil {
+				return err
+			}
+			mu.Lock()
+			described++
+			mu.Unlock()
+			return nil
+		})
+	}
+	err = g.Wait()
+	return described, err
+}
@@ -0,0 +1,85 @@
+package backend
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinternet/databaise/internal/config"
+)
+
+// schemaStub counts the discovery calls that reach the database.
+type schemaStub struct {
+	SQLBackend
+	lists, describes atomic.Int32
+}
+
+func (s *schemaStub) ListTables(ctx context.Context, in ListTablesIn) ([]Table, error) {
+	s.lists.Add(1)
+	return []Table{{Name: "orders"}, {Name: "users"}}, nil
+}
+
+func (s *schemaStub) DescribeTable(ctx context.Context, in DescribeTableIn) (*TableDescription, error) {
+	s.describes.Add(1)
+	return &TableDescription{CreateTable: "CREATE TABLE " + in.Table}, nil
+}
+
+func TestSchemaCache(t *testing.T) {
+	stub := &schemaStub{}
+	b := &cachingBackend{SQLBackend: stub, schema: newSchemaCache(&config.Cache{})}
+
+	t.Run("ListTablesIgnoresPaging", func(t *testing.T) {
+		tables, err := b.ListTables(t.Context(), ListTablesIn{Limit: 1})
+		require.NoError(t, err)
+
+		// Callers may filter their copy in place.
+		tables[0] = Table{Name: "changed"}
+		tables, err = b.ListTables(t.Context(), ListTablesIn{Pattern: "u%"})
+		require.NoError(t, err)
+		require.Equal(t, "orders", tables[0].Name)
+		require.EqualValues(t, 1, stub.lists.Load())
+	})
+
+	t.Run("DescribeTable", func(t *testing.T) {
+		for range 2 {
+			desc, err := b.DescribeTable(t.Context(), DescribeTableIn{Table: "users"})
+			require.NoError(t, err)
+			require.Equal(t, "CREATE TABLE users", desc.CreateTable)
+		}
+		require.EqualValues(t, 1, stub.describes.Load())
+	})
+
+	t.Run("Invalidate", func(t *testing.T) {
+		b.schema.invalidate()
+		_, err := b.DescribeTable(t.Context(), DescribeTableIn{Table: "users"})
+		require.NoError(t, err)
+		require.EqualValues(t, 2, stub.describes.Load())
+	})
+}
+
+func TestWarmSchema(t *testing.T) {
+	stub := &schemaStub{}
+	schema := newSchemaCache(&config.Cache{})
+	inst := &Instance{
+		Name:   "warm",
+		schema: schema,
+		Read:   func() SQLBackend { return &cachingBackend{SQLBackend: stub, schema: schema} },
+	}
+
+	n, err := inst.warmSchema(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	_, ok := schema.getTables(ListTablesIn{})
+	require.True(t, ok)
+	_, ok = schema.getDescription(DescribeTableIn{Table: "orders"})
+	require.True(t, ok)
+
+	t.Run("DescribeDisabled", func(t *testing.T) {
+		inst.DisabledTools = []string{"describe_table"}
+		n, err := inst.warmSchema(t.Context())
+		require.NoError(t, err)
+		require.Zero(t, n)
+	})
+}
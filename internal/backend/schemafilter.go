@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// defaultExcludedSchemas are the system schemas hidden from list_tables unless
+// the database config sets excluded_schemas.
+var defaultExcludedSchemas = []string{"information_schema", "pg_catalog", "sys", "mysql", "performance_schema"}
+
+// schemaFilter wraps a read backend and drops excluded schemas from list_schemas,
+// and tables and sequences in them from list_tables, list_tables_without_pk and
+// list_sequences, so discovery stays on user data.
+type schemaFilter struct {
+	SQLBackend
+	excluded []string
+}
+
+func (f *schemaFilter) unwrap() SQLBackend { return f.SQLBackend }
+
+func (f *schemaFilter) ListTables(ctx context.Context, in ListTablesIn) ([]Table, error) {
+	tables, err := f.SQLBackend.ListTables(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(tables, func(t Table) bool { return isExcludedSchema(f.excluded, t.Schema) }), nil
+}
+
+// ListSchemas leaves the excluded schemas in when the caller asks for system schemas,
+// since the default exclusions are system schemas.
+func (f *schemaFilter) ListSchemas(ctx context.Context, in ListSchemasIn) ([]string, error) {
+	schemas, err := f.SQLBackend.ListSchemas(ctx, in)
+	if err != nil || in.IncludeSystem {
+		return schemas, err
+	}
+	return slices.DeleteFunc(schemas, func(s string) bool { return isExcludedSchema(f.excluded, s) }), nil
+}
+
+func (f *schemaFilter) ListTablesWithoutPK(ctx context.Context, in ListTablesWithoutPKIn) ([]Table, error) {
+	tables, err := f.SQLBackend.ListTablesWithoutPK(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(tables, func(t Table) bool { return isExcludedSchema(f.excluded, t.Schema) }), nil
+}
+
+func (f *schemaFilter) ListSequences(ctx context.Context, in ListSequencesIn) ([]Sequence, error) {
+	sequences, err := f.SQLBackend.ListSequences(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(sequences, func(s Sequence) bool { return isExcludedSchema(f.excluded, s.Schema) }), nil
+}
+
+func (f *schemaFilter) ListViews(ctx context.Context, in ListViewsIn) ([]View, error) {
+	views, err := f.SQLBackend.ListViews(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(views, func(v View) bool { return isExcludedSchema(f.excluded, v.Schema) }), nil
+}
+
+func (f *schemaFilter) ListMaterializedViews(ctx context.Context, in ListMaterializedViewsIn) ([]MaterializedView, error) {
+	lister, ok := as[MaterializedViewLister](f.SQLBackend)
+	if !ok {
+		return nil, errNoMaterializedViews
+	}
+	views, err := lister.ListMaterializedViews(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(views, func(v MaterializedView) bool { return isExcludedSchema(f.excluded, v.Schema) }), nil
+}
+
+func (f *schemaFilter) ListForeignKeys(ctx context.Context, in ListForeignKeysIn) ([]ForeignKey, error) {
+	foreignKeys, err := f.SQLBackend.ListForeignKeys(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(foreignKeys, func(fk ForeignKey) bool { return isExcludedSchema(f.excluded, fk.Schema) }), nil
+}
+
+// isExcludedSchema reports whether schema is in excluded, ignoring case.
+func isExcludedSchema(excluded []string, schema string) bool {
+	return slices.ContainsFunc(excluded, func(s string) bool { return strings.EqualFold(s, schema) })
+}
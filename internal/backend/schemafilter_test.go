@@ -0,0 +1,105 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type tablesStub struct {
+	SQLBackend
+	schemas     []string
+	tables      []Table
+	sequences   []Sequence
+	views       []View
+	matviews    []MaterializedView
+	foreignKeys []ForeignKey
+}
+
+func (s *tablesStub) ListSchemas(ctx context.Context, in ListSchemasIn) ([]string, error) {
+	return append([]string(nil), s.schemas...), nil
+}
+
+func (s *tablesStub) ListTables(ctx context.Context, in ListTablesIn) ([]Table, error) {
+	return append([]Table(nil), s.tables...), nil
+}
+
+func (s *tablesStub) ListTablesWithoutPK(ctx context.Context, in ListTablesWithoutPKIn) ([]Table, error) {
+	return append([]Table(nil), s.tables...), nil
+}
+
+func (s *tablesStub) ListSequences(ctx context.Context, in ListSequencesIn) ([]Sequence, error) {
+	return append([]Sequence(nil), s.sequences...), nil
+}
+
+func (s *tablesStub) ListViews(ctx context.Context, in ListViewsIn) ([]View, error) {
+	return append([]View(nil), s.views...), nil
+}
+
+func (s *tablesStub) ListMaterializedViews(ctx context.Context, in ListMaterializedViewsIn) ([]MaterializedView, error) {
+	return append([]MaterializedView(nil), s.matviews...), nil
+}
+
+func (s *tablesStub) ListForeignKeys(ctx context.Context, in ListForeignKeysIn) ([]ForeignKey, error) {
+	return append([]ForeignKey(nil), s.foreignKeys...), nil
+}
+
+func TestSchemaFilter(t *testing.T) {
+	stub := &tablesStub{schemas: []string{"information_schema", "pg_catalog", "public"}, tables: []Table{
+		{Schema: "public", Name: "orders"},
+		{Schema: "INFORMATION_SCHEMA", Name: "TABLES"},
+		{Schema: "pg_catalog", Name: "pg_class"},
+		{Name: "users"},
+	}, sequences: []Sequence{
+		{Schema: "public", Name: "orders_id_seq"},
+		{Schema: "SYS", Name: "audit_seq"},
+		{Name: "users"},
+	}, views: []View{
+		{Schema: "public", Name: "active_users"},
+		{Schema: "information_schema", Name: "columns"},
+	}, matviews: []MaterializedView{
+		{Schema: "public", Name: "daily_sales", Populated: true},
+		{Schema: "pg_catalog", Name: "stats"},
+	}, foreignKeys: []ForeignKey{
+		{Schema: "public", Table: "orders", ReferencedTable: "users"},
+		{Schema: "sys", Table: "sysschobjs", ReferencedTable: "sysowners"},
+	}}
+	// The paginator in between stands in for the other wrappers schemaFilter may be stacked on.
+	b := &schemaFilter{SQLBackend: &paginator{SQLBackend: stub}, excluded: defaultExcludedSchemas}
+
+	schemas, err := b.ListSchemas(t.Context(), ListSchemasIn{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"public"}, schemas)
+
+	schemas, err = b.ListSchemas(t.Context(), ListSchemasIn{IncludeSystem: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"information_schema", "pg_catalog", "public"}, schemas)
+
+	tables, err := b.ListTables(t.Context(), ListTablesIn{AllSchemas: true})
+	require.NoError(t, err)
+	require.Equal(t, []Table{{Schema: "public", Name: "orders"}, {Name: "users"}}, tables)
+
+	tables, err = b.ListTablesWithoutPK(t.Context(), ListTablesWithoutPKIn{})
+	require.NoError(t, err)
+	require.Equal(t, []Table{{Schema: "public", Name: "orders"}, {Name: "users"}}, tables)
+
+	sequences, err := b.ListSequences(t.Context(), ListSequencesIn{})
+	require.NoError(t, err)
+	require.Equal(t, []Sequence{{Schema: "public", Name: "orders_id_seq"}, {Name: "users"}}, sequences)
+
+	views, err := b.ListViews(t.Context(), ListViewsIn{})
+	require.NoError(t, err)
+	require.Equal(t, []View{{Schema: "public", Name: "active_users"}}, views)
+
+	matviews, err := b.ListMaterializedViews(t.Context(), ListMaterializedViewsIn{})
+	require.NoError(t, err)
+	require.Equal(t, []MaterializedView{{Schema: "public", Name: "daily_sales", Populated: true}}, matviews)
+
+	_, err = (&schemaFilter{SQLBackend: &paginator{}}).ListMaterializedViews(t.Context(), ListMaterializedViewsIn{})
+	require.ErrorIs(t, err, errNoMaterializedViews)
+
+	foreignKeys, err := b.ListForeignKeys(t.Context(), ListForeignKeysIn{})
+	require.NoError(t, err)
+	require.Equal(t, []ForeignKey{{Schema: "public", Table: "orders", ReferencedTable: "users"}}, foreignKeys)
+}
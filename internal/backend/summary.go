@@ -0,0 +1,85 @@
+package backend
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// summaryTables is how many of the largest tables a schema summary names.
+	summaryTables = 5
+
+	// summaryTimeout bounds the catalog queries behind a schema summary at startup.
+	summaryTimeout = 10 * time.Second
+)
+
+// schemaSummary describes the tables of a database in one line for its
+// list_databases description, e.g. "Schema: 42 tables. Largest: public.orders
+// (~1.2M rows, 350 MB), ...". Tables in excluded schemas are not counted. Sizes come
+// from the database's statistics when the backend implements TableSizer; otherwise,
+// or if they cannot be read, only the table count is given.
+func schemaSummary(ctx context.Context, b SQLBackend, excluded []string) (string, error) {
+	if sizer, ok := b.(TableSizer); ok {
+		sizes, err := sizer.TableSizes(ctx)
+		if err == nil {
+			sizes = slices.DeleteFunc(sizes, func(t TableSize) bool { return isExcludedSchema(excluded, t.Schema) })
+			return formatSummary(sizes), nil
+		}
+		log.Printf("WARN: failed to read table sizes, summarizing table count only: %v", err)
+	}
+
+	tables, err := b.ListTables(ctx, ListTablesIn{AllSchemas: true})
+	if err != nil {
+		return "", err
+	}
+	tables = slices.DeleteFunc(tables, func(t Table) bool { return isExcludedSchema(excluded, t.Schema) })
+	return formatTableCount(len(tables)) + ".", nil
+}
+
+func formatSummary(sizes []TableSize) string {
+	summary := formatTableCount(len(sizes)) + "."
+	if len(sizes) == 0 {
+		return summary
+	}
+
+	slices.SortStableFunc(sizes, func(a, b TableSize) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), cmp.Compare(b.Rows, a.Rows))
+	})
+	largest := make([]string, 0, summaryTables)
+	for _, t := range sizes[:min(len(sizes), summaryTables)] {
+		name := t.Name
+		if t.Schema != "" {
+			name = t.Schema + "." + t.Name
+		}
+		largest = append(largest, fmt.Sprintf("%s (~%s rows, %s)", name, approx(t.Rows, 1000, "", "K", "M", "B"), approx(t.Bytes, 1024, " B", " KB", " MB", " GB", " TB")))
+	}
+	return summary + " Largest: " + strings.Join(largest, ", ") + "."
+}
+
+func formatTableCount(n int) string {
+	switch n {
+	case 0:
+		return "Schema: no tables"
+	case 1:
+		return "Schema: 1 table"
+	default:
+		return fmt.Sprintf("Schema: %d tables", n)
+	}
+}
+
+// approx formats n with the largest unit that keeps it at or above 1, e.g. 1.2M.
+func approx(n int64, base float64, units ...string) string {
+	v, unit := float64(n), 0
+	for v >= base && unit < len(units)-1 {
+		v /= base
+		unit++
+	}
+	if unit == 0 || v >= 10 {
+		return fmt.Sprintf("%.0f%s", v, units[unit])
+	}
+	return fmt.Sprintf("%.1f%s", v, units[unit])
+}
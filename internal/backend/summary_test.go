@@ -0,0 +1,70 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type sizesStub struct {
+	tablesStub
+	sizes []TableSize
+	err   error
+}
+
+func (s *sizesStub) TableSizes(ctx context.Context) ([]TableSize, error) {
+	return append([]TableSize(nil), s.sizes...), s.err
+}
+
+func TestSchemaSummary(t *testing.T) {
+	t.Run("Sizes", func(t *testing.T) {
+		stub := &sizesStub{sizes: []TableSize{
+			{Schema: "public", Name: "users", Rows: 53_000, Bytes: 12 << 20},
+			{Schema: "public", Name: "orders", Rows: 1_234_567, Bytes: 350 << 20},
+			{Schema: "public", Name: "empty"},
+			{Schema: "pg_catalog", Name: "pg_class", Rows: 1 << 40, Bytes: 1 << 40},
+		}}
+		summary, err := schemaSummary(t.Context(), stub, defaultExcludedSchemas)
+		require.NoError(t, err)
+		require.Equal(t, "Schema: 3 tables. Largest: public.orders (~1.2M rows, 350 MB), public.users (~53K rows, 12 MB), public.empty (~0 rows, 0 B).", summary)
+	})
+
+	t.Run("CountOnly", func(t *testing.T) {
+		stub := &tablesStub{tables: []Table{{Name: "users"}}}
+		summary, err := schemaSummary(t.Context(), stub, defaultExcludedSchemas)
+		require.NoError(t, err)
+		require.Equal(t, "Schema: 1 table.", summary)
+	})
+
+	t.Run("SizesFailed", func(t *testing.T) {
+		stub := &sizesStub{tablesStub: tablesStub{tables: []Table{{Name: "a"}, {Name: "b"}}}, err: errors.New("permission denied")}
+		summary, err := schemaSummary(t.Context(), stub, nil)
+		require.NoError(t, err)
+		require.Equal(t, "Schema: 2 tables.", summary)
+	})
+
+	t.Run("Top", func(t *testing.T) {
+		stub := &sizesStub{}
+		for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+			stub.sizes = append(stub.sizes, TableSize{Name: name, Bytes: 1536})
+		}
+		summary, err := schemaSummary(t.Context(), stub, nil)
+		require.NoError(t, err)
+		require.Equal(t, "Schema: 6 tables. Largest: a (~0 rows, 1.5 KB), b (~0 rows, 1.5 KB), c (~0 rows, 1.5 KB), d (~0 rows, 1.5 KB), e (~0 rows, 1.5 KB).", summary)
+	})
+}
+
+func TestAddTableSizes(t *testing.T) {
+	tables := []Table{{Schema: "public", Name: "orders"}, {Schema: "public", Name: "users"}, {Schema: "sales", Name: "orders"}}
+	AddTableSizes(tables, []TableSize{
+		{Schema: "sales", Name: "orders", Rows: 10, Bytes: 8192},
+		{Schema: "public", Name: "users", Rows: 3, Bytes: 16384},
+	})
+	require.Equal(t, []Table{
+		{Schema: "public", Name: "orders"},
+		{Schema: "public", Name: "users", EstimatedRows: 3, SizeBytes: 16384},
+		{Schema: "sales", Name: "orders", EstimatedRows: 10, SizeBytes: 8192},
+	}, tables)
+}
@@ -2,10 +2,17 @@ package backend
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/tinternet/databaise/internal/server"
+	"github.com/tinternet/databaise/internal/sqlcommon"
 )
 
+// errNoMaterializedViews is returned by the materialized view tools for backends
+// that do not implement them.
+var errNoMaterializedViews = errors.New("materialized views are only available for PostgreSQL")
+
 type DatabaseReq struct {
 	DatabaseName string `json:"database_name" jsonschema:"required,The database to operate on"`
 }
@@ -30,13 +37,140 @@ type ExplainQueryReq struct {
 	ExplainQueryIn `json:",inline"`
 }
 
+type KillIdleTransactionsReq struct {
+	DatabaseName           string `json:"database_name" jsonschema:"required,The database to operate on"`
+	KillIdleTransactionsIn `json:",inline"`
+}
+
+type AnalyzeTableReq struct {
+	DatabaseName   string `json:"database_name" jsonschema:"required,The database to operate on"`
+	AnalyzeTableIn `json:",inline"`
+}
+
+type RefreshMaterializedViewReq struct {
+	DatabaseName              string `json:"database_name" jsonschema:"required,The database to operate on"`
+	RefreshMaterializedViewIn `json:",inline"`
+}
+
+type TableProfileReq struct {
+	DatabaseName   string `json:"database_name" jsonschema:"required,The database to operate on"`
+	TableProfileIn `json:",inline"`
+}
+
+// profile_categorical_columns returns columns with at most defaultMaxDistinct
+// distinct values unless the caller asks for more, up to maxDistinctLimit.
+const (
+	defaultMaxDistinct = 20
+	maxDistinctLimit   = 100
+)
+
+type ProfileCategoricalColumnsReq struct {
+	DatabaseName                string `json:"database_name" jsonschema:"required,The database to operate on"`
+	ProfileCategoricalColumnsIn `json:",inline"`
+}
+
+type TableJSONSchemaReq struct {
+	DatabaseName      string `json:"database_name" jsonschema:"required,The database to operate on"`
+	TableJSONSchemaIn `json:",inline"`
+}
+
+// sample_table returns defaultSampleRows rows unless the caller asks for another number.
+const defaultSampleRows = 10
+
+type SampleTableReq struct {
+	DatabaseName  string `json:"database_name" jsonschema:"required,The database to operate on"`
+	SampleTableIn `json:",inline"`
+}
+
+type SetCommentReq struct {
+	DatabaseName string `json:"database_name" jsonschema:"required,The database to operate on"`
+	SetCommentIn `json:",inline"`
+}
+
 type ExecuteDDLReq struct {
 	DatabaseName string `json:"database_name" jsonschema:"required,The database to operate on"`
 	ExecuteDDLIn `json:",inline"`
 }
 
+type ExecuteWriteReq struct {
+	DatabaseName   string `json:"database_name" jsonschema:"required,The database to operate on"`
+	ExecuteWriteIn `json:",inline"`
+}
+
+type CheckDDLReq struct {
+	DatabaseName string `json:"database_name" jsonschema:"required,The database to operate on"`
+	CheckDDLIn   `json:",inline"`
+}
+
+type RecommendIndexesReq struct {
+	DatabaseName       string `json:"database_name" jsonschema:"required,The database to operate on"`
+	RecommendIndexesIn `json:",inline"`
+}
+
+type IndexAdviceOut struct {
+	Recommendations []sqlcommon.IndexRecommendation `json:"recommendations" jsonschema:"One proposed index per table the query filters, joins or sorts on"`
+	Notes           []string                        `json:"notes,omitempty" jsonschema:"Parts of the query that no index can serve, and indexes that already exist"`
+	FullScans       []TableScan                     `json:"full_scans,omitempty" jsonschema:"Full table scans in the query's current plan"`
+}
+
 type ListTablesOut struct {
-	Tables []Table `json:"tables" jsonschema:"The list of tables"`
+	Tables  []Table `json:"tables" jsonschema:"The list of tables"`
+	Total   int     `json:"total" jsonschema:"Total number of tables matching the filters, before paging"`
+	HasMore bool    `json:"has_more,omitempty" jsonschema:"Whether more tables are available at a higher offset"`
+}
+
+type ListSchemasReq struct {
+	DatabaseName  string `json:"database_name" jsonschema:"required,The database to operate on"`
+	ListSchemasIn `json:",inline"`
+}
+
+type ListSequencesReq struct {
+	DatabaseName    string `json:"database_name" jsonschema:"required,The database to operate on"`
+	ListSequencesIn `json:",inline"`
+}
+
+type ListViewsReq struct {
+	DatabaseName string `json:"database_name" jsonschema:"required,The database to operate on"`
+	ListViewsIn  `json:",inline"`
+}
+
+type ListMaterializedViewsReq struct {
+	DatabaseName            string `json:"database_name" jsonschema:"required,The database to operate on"`
+	ListMaterializedViewsIn `json:",inline"`
+}
+
+type ListForeignKeysReq struct {
+	DatabaseName      string `json:"database_name" jsonschema:"required,The database to operate on"`
+	ListForeignKeysIn `json:",inline"`
+}
+
+type ListTablesWithoutPKReq struct {
+	DatabaseName          string `json:"database_name" jsonschema:"required,The database to operate on"`
+	ListTablesWithoutPKIn `json:",inline"`
+}
+
+type TablesWithoutPKOut struct {
+	Tables []Table `json:"tables" jsonschema:"The tables that have no primary key"`
+}
+
+type SequencesOut struct {
+	Sequences []Sequence `json:"sequences" jsonschema:"The sequences and auto-increment counters"`
+}
+
+type SchemasOut struct {
+	Schemas []string `json:"schemas" jsonschema:"The schema names"`
+}
+
+type ViewsOut struct {
+	Views []View `json:"views" jsonschema:"The views with their definitions"`
+}
+
+type MaterializedViewsOut struct {
+	MaterializedViews []MaterializedView `json:"materialized_views" jsonschema:"The materialized views with their definitions"`
+}
+
+type ForeignKeysOut struct {
+	ForeignKeys []ForeignKey `json:"foreign_keys" jsonschema:"The foreign keys"`
 }
 
 type MissingIndexesOut struct {
@@ -47,16 +181,23 @@ type WaitingQueriesOut struct {
 	Queries []WaitingQuery `json:"queries" jsonschema:"List of waiting queries"`
 }
 
+type ConnectionsOut struct {
+	Connections []Connection `json:"connections" jsonschema:"The connected client sessions"`
+}
+
 type DeadlocksOut struct {
 	Deadlocks []Deadlock `json:"deadlocks" jsonschema:"List of deadlock information"`
 }
 
 // DatabaseInfo represents info about a database for list_databases.
 type DatabaseInfo struct {
-	Name        string `json:"name" jsonschema:"The unique identifier for this database"`
-	Dialect     string `json:"dialect" jsonschema:"The SQL dialect (PostgreSQL, MySQL, T-SQL, SQLite)"`
-	Description string `json:"description,omitempty" jsonschema:"Human-readable description"`
-	HasAdmin    bool   `json:"has_admin" jsonschema:"Whether admin tools are available"`
+	Name          string          `json:"name" jsonschema:"The unique identifier for this database"`
+	Dialect       string          `json:"dialect" jsonschema:"The SQL dialect (PostgreSQL, MySQL, T-SQL, SQLite)"`
+	Description   string          `json:"description,omitempty" jsonschema:"Human-readable description"`
+	HasAdmin      bool            `json:"has_admin" jsonschema:"Whether admin tools are available"`
+	HasWrite      bool            `json:"has_write" jsonschema:"Whether write_query is available"`
+	DisabledTools []string        `json:"disabled_tools,omitempty" jsonschema:"Tools that are disabled for this database"`
+	Readonly      *ReadonlyStatus `json:"readonly,omitempty" jsonschema:"How the read connection is kept from writing and whether that was verified"`
 }
 
 // ListDatabasesOut is the output for the list_databases tool.
@@ -72,10 +213,13 @@ func ListDatabases() ListDatabasesOut {
 	result := make([]DatabaseInfo, 0, len(instances))
 	for _, inst := range instances {
 		result = append(result, DatabaseInfo{
-			Name:        inst.Name,
-			Dialect:     inst.Dialect,
-			Description: inst.Description,
-			HasAdmin:    inst.HasAdmin,
+			Name:          inst.Name,
+			Dialect:       inst.Dialect,
+			Description:   inst.Description,
+			HasAdmin:      inst.HasAdmin,
+			HasWrite:      inst.HasWrite,
+			DisabledTools: inst.DisabledTools,
+			Readonly:      inst.Readonly,
 		})
 	}
 	return ListDatabasesOut{Databases: result}
@@ -86,7 +230,28 @@ func init() {
 		return ListDatabases(), nil
 	}, server.Tool{
 		Name:        "list_databases",
-		Description: "Lists all available databases along with their SQL dialects and admin access permissions. This tool is essential for identifying the correct database to interact with before performing any operations. It helps avoid errors due to incorrect or non-existent database names and ensures that you are working within the appropriate environment.",
+		Description: "Lists all available databases along with their SQL dialects, admin and write access and how their read connections are kept read-only. This tool is essential for identifying the correct database to interact with before performing any operations. It helps avoid errors due to incorrect or non-existent database names and ensures that you are working within the appropriate environment.",
+	})
+
+	server.AddTool(func(ctx context.Context, in any) (ListBackendsOut, error) {
+		return ListBackends(), nil
+	}, server.Tool{
+		Name:        "list_backends",
+		Description: "Lists the backend types this server was built with (e.g. postgres, mysql, sqlserver, sqlite), with each backend's SQL dialect and the database tools it supports. Unlike list_databases, this describes what the server can do regardless of which databases are configured.",
+	})
+
+	server.AddTool(func(ctx context.Context, in DatabaseReq) (*PingResult, error) {
+		return PingDatabase(ctx, in.DatabaseName)
+	}, server.Tool{
+		Name:        "ping_database",
+		Description: "Checks that a database is reachable by pinging its read connection, and returns whether it answered and how long the ping took. Use it to tell a connection problem apart from a problem with a query; a failed ping is reported in error rather than failing the call.",
+	})
+
+	server.AddTool(func(ctx context.Context, in any) (PoolStatsOut, error) {
+		return ListPoolStats(), nil
+	}, server.Tool{
+		Name:        "pool_stats",
+		Description: "Returns connection pool statistics of this server for every configured database: open, in-use and idle connections, plus how often and how long callers waited for a free connection. A high wait count with all connections in use means the pool is the bottleneck rather than the database.",
 	})
 
 	// Read tools
@@ -96,40 +261,260 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return &ListTablesOut{Tables: tables}, nil
+			return paginateTables(tables, in), nil
 		})
 	}, server.Tool{
 		Name:        "list_tables",
-		Description: "Lists all tables in a database. Returns table names with their schemas (for PostgreSQL/SQL Server). Use the optional schema parameter to filter results. This is typically the first tool to call when exploring a new database to understand its structure.",
+		Description: "Lists all tables in a database. Returns table names with their schemas (for PostgreSQL/SQL Server). Use the optional schema parameter to filter results (PostgreSQL defaults to public), or set all_schemas=true to list tables across every non-system schema, and pattern to search by name. Results are paged: check has_more and request the next page with offset. Set with_stats=true to also get each table's approximate row count and size from the database's statistics (SQLite counts the rows instead; SQL Server needs VIEW DATABASE STATE). This is typically the first tool to call when exploring a new database to understand its structure.",
+	})
+
+	server.AddTool(func(ctx context.Context, in ListSchemasReq) (*SchemasOut, error) {
+		return Handle(ctx, in.DatabaseName, in.ListSchemasIn, GetReadBackend, func(b SQLBackend, ctx context.Context, in ListSchemasIn) (*SchemasOut, error) {
+			schemas, err := b.ListSchemas(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return &SchemasOut{Schemas: schemas}, nil
+		})
+	}, server.Tool{
+		Name:        "list_schemas",
+		Description: "Lists the schemas of a database, to find out which exist before filtering list_tables, list_views and other tools by schema. For MySQL, these are the databases on the server; SQLite returns main and any attached databases. System schemas (pg_catalog, information_schema, sys, ...) and those hidden by the database's excluded_schemas are left out unless include_system=true.",
+	})
+
+	server.AddTool(func(ctx context.Context, in ListSequencesReq) (*SequencesOut, error) {
+		return Handle(ctx, in.DatabaseName, in.ListSequencesIn, GetReadBackend, func(b SQLBackend, ctx context.Context, in ListSequencesIn) (*SequencesOut, error) {
+			sequences, err := b.ListSequences(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return &SequencesOut{Sequences: sequences}, nil
+		})
+	}, server.Tool{
+		Name:        "list_sequences",
+		Description: "Lists the sequences and auto-increment counters of a database with their current values, to debug gaps in IDs or check how close a counter is to running out. PostgreSQL returns sequences, including those behind serial and identity columns, with the column they fill. SQL Server returns sequences and identity columns. MySQL returns the next AUTO_INCREMENT value of each table, and SQLite that of each AUTOINCREMENT table. Compare last_value or next_value with max_value to spot a counter near exhaustion.",
+	})
+
+	server.AddTool(func(ctx context.Context, in ListViewsReq) (*ViewsOut, error) {
+		return Handle(ctx, in.DatabaseName, in.ListViewsIn, GetReadBackend, func(b SQLBackend, ctx context.Context, in ListViewsIn) (*ViewsOut, error) {
+			views, err := b.ListViews(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return &ViewsOut{Views: views}, nil
+		})
+	}, server.Tool{
+		Name:        "list_views",
+		Description: "Lists the views of a database with the SQL that defines each one, which list_tables and describe_table do not cover. Returns the schema for PostgreSQL and SQL Server. Use the optional schema parameter to filter results (for MySQL, the database); without it, every non-system schema is listed. Query a view like a table with execute_query. Materialized views are listed by list_materialized_views.",
+	})
+
+	server.AddTool(func(ctx context.Context, in ListMaterializedViewsReq) (*MaterializedViewsOut, error) {
+		return Handle(ctx, in.DatabaseName, in.ListMaterializedViewsIn, GetReadBackend, func(b SQLBackend, ctx context.Context, in ListMaterializedViewsIn) (*MaterializedViewsOut, error) {
+			lister, ok := as[MaterializedViewLister](b)
+			if !ok {
+				return nil, errNoMaterializedViews
+			}
+			views, err := lister.ListMaterializedViews(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return &MaterializedViewsOut{MaterializedViews: views}, nil
+		})
+	}, server.Tool{
+		Name:        "list_materialized_views",
+		Description: "Lists the materialized views of a database with the SQL that defines each one and whether it is populated, which list_tables and list_views do not cover. A materialized view stores the result of its query, so its data is only as fresh as its last refresh (see refresh_materialized_view); one that is not populated cannot be queried until it is refreshed. Use the optional schema parameter to filter results; without it, every non-system schema is listed. Only available for PostgreSQL.",
+	})
+
+	server.AddTool(func(ctx context.Context, in ListForeignKeysReq) (*ForeignKeysOut, error) {
+		return Handle(ctx, in.DatabaseName, in.ListForeignKeysIn, GetReadBackend, func(b SQLBackend, ctx context.Context, in ListForeignKeysIn) (*ForeignKeysOut, error) {
+			foreignKeys, err := b.ListForeignKeys(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return &ForeignKeysOut{ForeignKeys: foreignKeys}, nil
+		})
+	}, server.Tool{
+		Name:        "list_foreign_keys",
+		Description: "Lists the foreign keys of a database: for each, the referencing table and columns, the referenced table and columns, and the ON DELETE and ON UPDATE actions. Call it before writing joins to learn how tables relate. Use the optional schema parameter to filter results (for MySQL, the database) and table to list the foreign keys of one table; describe_table also returns the foreign keys of the described table.",
+	})
+
+	server.AddTool(func(ctx context.Context, in ListTablesWithoutPKReq) (*TablesWithoutPKOut, error) {
+		return Handle(ctx, in.DatabaseName, in.ListTablesWithoutPKIn, GetReadBackend, func(b SQLBackend, ctx context.Context, in ListTablesWithoutPKIn) (*TablesWithoutPKOut, error) {
+			tables, err := b.ListTablesWithoutPK(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return &TablesWithoutPKOut{Tables: tables}, nil
+		})
+	}, server.Tool{
+		Name:        "list_tables_without_pk",
+		Description: "Lists the tables that have no primary key, a common audit finding: such tables cannot be replicated logically in PostgreSQL without extra setup, slow down row-based replication in MySQL, and leave ORMs and sync tools unable to address single rows. A unique index does not count as a primary key. It only reads the catalog, so it is cheap even on large databases. In SQLite, tables without a declared primary key still have an implicit rowid.",
 	})
 
 	server.AddTool(func(ctx context.Context, in DescribeTableReq) (*TableDescription, error) {
 		return Handle(ctx, in.DatabaseName, in.DescribeTableIn, GetReadBackend, SQLBackend.DescribeTable)
 	}, server.Tool{
 		Name:        "describe_table",
-		Description: "Returns the complete DDL for a table including the CREATE TABLE statement, all indexes, and constraints. This provides the full schema definition needed to understand column types, primary keys, foreign keys, and existing indexes. For PostgreSQL/SQL Server, you must provide the schema name (e.g., 'public' or 'dbo').",
+		Description: "Returns the complete DDL for a table including the CREATE TABLE statement, all indexes, and constraints. This provides the full schema definition needed to understand column types, primary keys, foreign keys, and existing indexes; foreign_keys lists each foreign key with the table and columns it references. table_comment and column_comments carry the descriptions stored as comments in the database (not available for SQLite), which often explain what a table or column means. For PostgreSQL/SQL Server, you must provide the schema name (e.g., 'public' or 'dbo'). Set include_referenced_by=true to also list foreign keys in other tables that point at this table, which shows join paths and what a delete would cascade to or be blocked by. In PostgreSQL, a table whose name differs only in case is still found, and hint explains how to quote its real name in SQL.",
+	})
+
+	server.AddTool(func(ctx context.Context, in ProfileCategoricalColumnsReq) (*ProfileCategoricalColumnsOut, error) {
+		return Handle(ctx, in.DatabaseName, in.ProfileCategoricalColumnsIn, GetReadBackend, func(b SQLBackend, ctx context.Context, in ProfileCategoricalColumnsIn) (*ProfileCategoricalColumnsOut, error) {
+			switch {
+			case in.MaxDistinct <= 0:
+				in.MaxDistinct = defaultMaxDistinct
+			case in.MaxDistinct > maxDistinctLimit:
+				return nil, fmt.Errorf("max_distinct must be at most %d", maxDistinctLimit)
+			}
+			return b.ProfileCategoricalColumns(ctx, in)
+		})
+	}, server.Tool{
+		Name:        "profile_categorical_columns",
+		Description: "Finds the enum-like columns of a table, those with at most max_distinct (default 20) distinct values, and returns each of their values with how many rows hold it, most frequent first. Use it to learn the exact values of status, type and category columns before filtering on them, instead of guessing spellings or reading the whole table. Columns with more distinct values, or of JSON, binary or spatial types, are listed in skipped. It reads the whole table once, plus once per returned column, so prefer it on tables of moderate size.",
+	})
+
+	server.AddTool(func(ctx context.Context, in TableJSONSchemaReq) (*TableJSONSchemaOut, error) {
+		return Handle(ctx, in.DatabaseName, in.TableJSONSchemaIn, GetReadBackend, SQLBackend.TableJSONSchema)
+	}, server.Tool{
+		Name:        "table_json_schema",
+		Description: "Returns a JSON Schema (draft 2020-12) document describing a row of a table, for generating typed clients, validating payloads or building forms. Column types are mapped to JSON Schema types for the database's dialect (date and time columns become strings with a format, binary columns base64 strings), nullable columns also accept null, and columns that are NOT NULL without a default are required. Enum types (PostgreSQL enums, MySQL ENUM columns) are listed in enum, and column comments become descriptions. Each property keeps the declared type in x-database-type.",
+	})
+
+	server.AddTool(func(ctx context.Context, in SampleTableReq) (*QueryResult, error) {
+		return Handle(ctx, in.DatabaseName, in.SampleTableIn, GetReadBackend, func(b SQLBackend, ctx context.Context, in SampleTableIn) (*QueryResult, error) {
+			switch {
+			case in.Limit == 0:
+				in.Limit = defaultSampleRows
+			case in.Limit < 0:
+				return nil, fmt.Errorf("limit must be positive")
+			}
+			return b.SampleTable(ctx, in)
+		})
+	}, server.Tool{
+		Name:        "sample_table",
+		Description: "Returns the first limit rows (default 10) of a table, in no particular order, to see what its data looks like before writing queries. The dialect's row limit (LIMIT, or TOP in SQL Server) is applied for you, so no SQL is needed. Rows are returned as in execute_query and are subject to the same row and response size caps.",
 	})
 
 	server.AddTool(func(ctx context.Context, in ReadQueryReq) (*QueryResult, error) {
-		return Handle(ctx, in.DatabaseName, in.ReadQueryIn, GetReadBackend, SQLBackend.ExecuteQuery)
+		return Handle(ctx, in.DatabaseName, in.ReadQueryIn, GetReadBackend, func(b SQLBackend, ctx context.Context, in ReadQueryIn) (*QueryResult, error) {
+			if in.Format != "" && in.Format != "json" && in.Format != "markdown" {
+				return nil, fmt.Errorf("unsupported format %q: use json or markdown", in.Format)
+			}
+			var res *QueryResult
+			var err error
+			if in.OutputPath != "" {
+				res, err = exportQuery(ctx, b, in)
+			} else {
+				res, err = b.ExecuteQuery(ctx, in)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !in.IncludeColumnTypes {
+				res.ColumnTypes = nil
+			}
+			if in.Format == "markdown" && in.OutputPath == "" {
+				res.Markdown = renderMarkdown(res.Columns, res.Rows)
+				res.Rows = nil
+			}
+			return res, nil
+		})
 	}, server.Tool{
 		Name:        "execute_query",
-		Description: "Executes a read-only SQL query and returns the results as rows. Use the SQL dialect appropriate for the database (check list_databases to see each database's dialect: PostgreSQL, MySQL, T-SQL, or SQLite). Only SELECT queries are allowed; INSERT/UPDATE/DELETE will fail.",
+		Description: "Executes a read-only SQL query and returns the results as rows. Use the SQL dialect appropriate for the database (check list_databases to see each database's dialect: PostgreSQL, MySQL, T-SQL, or SQLite). Only SELECT queries are allowed; INSERT/UPDATE/DELETE will fail (use write_query on databases with has_write). If the database has a scan guard configured, queries whose plan fully scans a large table are refused with the plan attached; narrow the query or set allow_full_scan=true to run it anyway. If truncated is true, the result exceeded the row cap (max_rows, 1000 unless configured) or the response size cap and only the first row_count rows were returned; add a LIMIT or narrow the query. To page through a large result, set limit (capped at max_rows) and then offset to the next_offset of the previous page until next_offset is omitted; the query must not have its own LIMIT, TOP, OFFSET or FETCH, and should have an ORDER BY so that pages do not overlap. Set format=markdown to get the rows as a markdown table instead of JSON. Set include_column_types=true to also get each result column's database type, which helps with computed columns and joins. On MySQL servers hosting one database per tenant, set schema to run the query in one of the databases allowed by the read config's tenant_databases. For exports too large to return, set output_path to write every row to a CSV or JSON Lines file in the database's export_dir on the server; only the path and row_count are returned, and the row and size caps do not apply.",
+	})
+
+	// Write tools
+	server.AddTool(func(ctx context.Context, in ExecuteWriteReq) (*WriteResult, error) {
+		return Handle(ctx, in.DatabaseName, in.ExecuteWriteIn, GetWriteBackend, SQLBackend.ExecuteWrite)
+	}, server.Tool{
+		Name:        "write_query",
+		Write:       true,
+		Description: "Executes a single INSERT, UPDATE or DELETE statement on the database's write connection and returns the number of rows affected. Use ? placeholders and pass the values in params. Only available for databases with has_write set in list_databases; other statements, including DDL and multiple statements, are refused. Statements chosen as a deadlock victim are retried automatically a few times before an error is returned.",
+		Mutates:     true,
 	})
 
 	// Admin tools
 	server.AddTool(func(ctx context.Context, in ExplainQueryReq) (*ExplainResult, error) {
-		return Handle(ctx, in.DatabaseName, in.ExplainQueryIn, GetAdminBackend, SQLBackend.ExplainQuery)
+		out, err := Handle(ctx, in.DatabaseName, in.ExplainQueryIn, GetAdminBackend, SQLBackend.ExplainQuery)
+		interpretPlan(out)
+		return out, err
 	}, server.Tool{
 		Name:        "explain_query",
-		Description: "Returns the execution plan for a SQL query, showing how the database will execute it. Useful for identifying performance issues like full table scans or inefficient joins. Set analyze=true to actually run the query and get real execution statistics (timing, rows processed). The output format varies by database (JSON for PostgreSQL/MySQL, XML for SQL Server).",
+		Admin:       true,
+		Description: "Returns the execution plan for a SQL query, showing how the database will execute it. Useful for identifying performance issues like full table scans or inefficient joins. Set analyze=true to actually run the query and get real execution statistics (timing, rows processed). For parameterized queries, use ? placeholders and pass the values in params: the planner sees the actual values, so the plan reflects their selectivity (PostgreSQL builds a custom plan for them, SQL Server sniffs them when compiling). The raw output format varies by database (JSON for PostgreSQL/MySQL, XML for SQL Server); plan holds the same plan as a tree of operations with table, index, estimated_rows, actual_rows and cost in a form shared by every database, full_scan_detected and full_scans flag the tables it reads in full, and ResultInfo ends with a short reading of the plan.",
 	})
 
 	server.AddTool(func(ctx context.Context, in ExecuteDDLReq) (*DDLResult, error) {
 		return Handle(ctx, in.DatabaseName, in.ExecuteDDLIn, GetAdminBackend, SQLBackend.ExecuteDDL)
 	}, server.Tool{
 		Name:        "execute_ddl",
-		Description: "Executes a DDL (Data Definition Language) statement to modify database schema. Commonly used for CREATE INDEX, DROP INDEX, and other index management operations. Use the SQL dialect appropriate for the database. Examples: 'CREATE INDEX idx_name ON table(column)' or 'DROP INDEX idx_name ON table' (MySQL/SQL Server) or 'DROP INDEX schema.idx_name' (PostgreSQL).",
+		Admin:       true,
+		Description: "Executes a DDL (Data Definition Language) statement to modify database schema. Commonly used for CREATE INDEX, DROP INDEX, and other index management operations. Use the SQL dialect appropriate for the database. Examples: 'CREATE INDEX idx_name ON table(column)' or 'DROP INDEX idx_name ON table' (MySQL/SQL Server) or 'DROP INDEX schema.idx_name' (PostgreSQL). Statements chosen as a deadlock victim are retried automatically a few times before an error is returned.",
+		Mutates:     true,
+	})
+
+	server.AddTool(func(ctx context.Context, in CheckDDLReq) (*sqlcommon.DDLCheckReport, error) {
+		return Handle(ctx, in.DatabaseName, in.CheckDDLIn, GetAdminBackend, SQLBackend.CheckDDL)
+	}, server.Tool{
+		Name:        "check_ddl",
+		Admin:       true,
+		Description: "Checks whether a schema change would succeed against the data already in the table, without applying it. Supports CREATE UNIQUE INDEX (duplicate keys, honouring a partial index's WHERE clause) and ALTER TABLE adding a UNIQUE, PRIMARY KEY, FOREIGN KEY or CHECK constraint, making a column NOT NULL, or adding a NOT NULL column without a default. Each check reports the number of violations, up to 5 sample violating values and the query that found them, so the data can be fixed before running the statement with execute_ddl. Checks scan the affected tables, and run in a transaction that is rolled back. Other statements and clauses are not checked and are listed in note.",
+	})
+
+	server.AddTool(func(ctx context.Context, in RecommendIndexesReq) (*IndexAdviceOut, error) {
+		return Handle(ctx, in.DatabaseName, in.RecommendIndexesIn, GetAdminBackend, func(b SQLBackend, ctx context.Context, in RecommendIndexesIn) (*IndexAdviceOut, error) {
+			plan, err := b.ExplainQuery(ctx, ExplainQueryIn{Query: in.Query, Params: in.Params})
+			if err != nil {
+				return nil, err
+			}
+			advice, err := b.RecommendIndexes(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return &IndexAdviceOut{Recommendations: advice.Recommendations, Notes: advice.Notes, FullScans: plan.FullScans}, nil
+		})
+	}, server.Tool{
+		Name:        "recommend_index_for_query",
+		Admin:       true,
+		Description: "Proposes a CREATE INDEX statement for each table a SELECT query filters, joins or sorts on, with the reasoning for the column order: columns compared for equality and join keys first, then the ORDER BY columns, then one range-filtered column, plus the other columns the query reads as covering columns when there are few. The query is explained, not executed, and full_scans lists the tables its current plan scans in full. Indexes that already exist, primary key lookups, and conditions no index can serve (OR, functions on columns, leading wildcards) are reported in notes. Only the outer query is analyzed. On PostgreSQL with the hypopg extension installed, each recommendation includes the planner's estimated cost with and without the index. Review a recommendation and create it with execute_ddl; this tool changes nothing.",
+	})
+
+	server.AddTool(func(ctx context.Context, in AnalyzeTableReq) (*AnalyzeTableOut, error) {
+		return Handle(ctx, in.DatabaseName, in.AnalyzeTableIn, GetAdminBackend, SQLBackend.AnalyzeTable)
+	}, server.Tool{
+		Name:        "analyze_table",
+		Admin:       true,
+		Description: "Refreshes the planner statistics of a table (ANALYZE in PostgreSQL/SQLite, ANALYZE TABLE in MySQL, UPDATE STATISTICS in SQL Server) and returns when the statistics were last updated. Leave table empty to refresh every table in the database, or in schema if one is given (sp_updatestats in SQL Server); this can take a while on large databases. Use it before explain_query when a plan's row estimates look far off from reality, which usually means the statistics are stale. It only samples the table and does not change any data.",
+		Mutates:     true,
+	})
+
+	server.AddTool(func(ctx context.Context, in SetCommentReq) (*SetCommentOut, error) {
+		return Handle(ctx, in.DatabaseName, in.SetCommentIn, GetAdminBackend, SQLBackend.SetComment)
+	}, server.Tool{
+		Name:        "set_comment",
+		Admin:       true,
+		Description: "Sets the comment on a table, or on one of its columns if column is given (COMMENT ON in PostgreSQL, ALTER TABLE ... COMMENT in MySQL, the MS_Description extended property in SQL Server). An empty comment removes the existing one. Use it to document a schema: column comments are returned as descriptions by table_json_schema. The table and column must exist. Not available for SQLite, which has no comments.",
+		Mutates:     true,
+	})
+
+	server.AddTool(func(ctx context.Context, in TableProfileReq) (*TableProfile, error) {
+		return Handle(ctx, in.DatabaseName, in.TableProfileIn, GetAdminBackend, func(b SQLBackend, ctx context.Context, in TableProfileIn) (*TableProfile, error) {
+			profile, err := b.TableProfile(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			if profile.SeqScans != nil && profile.IndexScans != nil {
+				if total := *profile.SeqScans + *profile.IndexScans; total > 0 {
+					ratio := float64(*profile.SeqScans) / float64(total)
+					profile.SeqScanRatio = &ratio
+				}
+			}
+			return profile, nil
+		})
+	}, server.Tool{
+		Name:        "table_profile",
+		Admin:       true,
+		Description: "Returns a one-call storage and access profile of a table: estimated row count, table and index sizes, every index with its size and how often it was used, how many scans were full scans versus index scans, and when the table was last vacuumed and analyzed. Use it first when investigating a table's performance: a high seq_scan_ratio on a large table suggests a missing index, unused indexes are candidates for dropping, and an old last_analyze means stale planner statistics (see analyze_table). Scan counts are PostgreSQL/SQL Server only and count from the last statistics reset or server restart. Not available for SQLite.",
 	})
 
 	server.AddTool(func(ctx context.Context, in DatabaseReq) (*MissingIndexesOut, error) {
@@ -142,7 +527,8 @@ func init() {
 		})
 	}, server.Tool{
 		Name:        "list_missing_indexes",
-		Description: "Returns index recommendations with estimated impact scores and suggested CREATE INDEX statements. Only available for SQL Server (uses the missing index DMVs). For MySQL and PostgreSQL, use list_slowest_queries instead to identify queries that may benefit from indexing.",
+		Admin:       true,
+		Description: "Returns index recommendations with estimated impact scores and suggested CREATE INDEX statements. For SQL Server, uses the missing index DMVs, which name the columns to index. For PostgreSQL, analyzes sequential scan statistics: it reports tables of at least 8 MB that are read more often by sequential scans than by index scans, ranked by the rows those scans read, and the columns to index must be found from the queries (list_slowest_queries, explain_query). For MySQL, use list_slowest_queries instead to identify queries that may benefit from indexing. For SQLite, which keeps no query statistics, reports foreign keys that no index starts with, ranked by table row count once ANALYZE has run; use recommend_index_for_query to check individual queries.",
 	})
 
 	server.AddTool(func(ctx context.Context, in DatabaseReq) (*WaitingQueriesOut, error) {
@@ -155,7 +541,22 @@ func init() {
 		})
 	}, server.Tool{
 		Name:        "list_waiting_queries",
-		Description: "Shows queries that are currently blocked or waiting for resources. Useful for diagnosing lock contention and identifying blocking chains. Returns the waiting query, what it's waiting for (lock type, resource), and which process is blocking it. Not available for SQLite.",
+		Admin:       true,
+		Description: "Shows queries that are currently blocked or waiting for resources. Useful for diagnosing lock contention and identifying blocking chains. Returns the waiting query, what it's waiting for (lock type, resource), and which process is blocking it. For PostgreSQL, lists sessions waiting on a lock with every blocking process id. Not available for SQLite.",
+	})
+
+	server.AddTool(func(ctx context.Context, in DatabaseReq) (*ConnectionsOut, error) {
+		return Handle(ctx, in.DatabaseName, struct{}{}, GetAdminBackend, func(b SQLBackend, ctx context.Context, _ struct{}) (*ConnectionsOut, error) {
+			connections, err := b.ListConnections(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return &ConnectionsOut{Connections: connections}, nil
+		})
+	}, server.Tool{
+		Name:        "list_active_connections",
+		Admin:       true,
+		Description: "Lists the client sessions connected to the database server, with user, database, client address, state and current query, to diagnose connection exhaustion or find out who holds a connection. The session running this tool is left out, but the server's other pooled connections are listed. PostgreSQL reads pg_stat_activity (other users' queries need pg_read_all_stats), MySQL the process list (other users' sessions need PROCESS), and SQL Server sys.dm_exec_sessions with sys.dm_exec_requests (other sessions need VIEW SERVER STATE). Not available for SQLite.",
 	})
 
 	server.AddTool(func(ctx context.Context, in DatabaseReq) (*SlowQueryResult, error) {
@@ -164,7 +565,8 @@ func init() {
 		})
 	}, server.Tool{
 		Name:        "list_slowest_queries",
-		Description: "Returns the slowest queries by total execution time from query statistics. Shows database-specific metrics including execution count, timing stats, I/O stats, and the query text. The 'columns' field describes each metric. Useful for identifying queries that need optimization. For PostgreSQL, requires the pg_stat_statements extension. Not available for SQLite.",
+		Admin:       true,
+		Description: "Returns the slowest queries by total execution time from query statistics. Shows database-specific metrics including execution count, timing stats, I/O stats, and the query text. The 'columns' field describes each metric. Useful for identifying queries that need optimization. For PostgreSQL, reads pg_stat_statements, which must be installed with CREATE EXTENSION pg_stat_statements and loaded with shared_preload_libraries. Not available for SQLite.",
 	})
 
 	server.AddTool(func(ctx context.Context, in DatabaseReq) (*DeadlocksOut, error) {
@@ -177,6 +579,31 @@ func init() {
 		})
 	}, server.Tool{
 		Name:        "list_deadlocks",
+		Admin:       true,
 		Description: "Retrieves information about database deadlocks. For SQL Server, returns detailed deadlock graphs from extended events. For PostgreSQL, shows deadlock counts per database. For MySQL, displays the most recent deadlock from InnoDB status. Not available for SQLite.",
 	})
+
+	server.AddTool(func(ctx context.Context, in KillIdleTransactionsReq) (*KillIdleTransactionsOut, error) {
+		return Handle(ctx, in.DatabaseName, in.KillIdleTransactionsIn, GetAdminBackend, SQLBackend.KillIdleTransactions)
+	}, server.Tool{
+		Name:        "kill_idle_transactions",
+		Admin:       true,
+		Description: "Terminates sessions that have been idle inside an open transaction for longer than older_than_sec (default 300), releasing the locks they hold and letting vacuum progress. Returns how many sessions were terminated and which ones. Only available for PostgreSQL, and only when the database's admin config sets allow_terminate_sessions: true.",
+		Mutates:     true,
+	})
+
+	server.AddTool(func(ctx context.Context, in RefreshMaterializedViewReq) (*RefreshMaterializedViewOut, error) {
+		return Handle(ctx, in.DatabaseName, in.RefreshMaterializedViewIn, GetAdminBackend, func(b SQLBackend, ctx context.Context, in RefreshMaterializedViewIn) (*RefreshMaterializedViewOut, error) {
+			refresher, ok := as[MaterializedViewRefresher](b)
+			if !ok {
+				return nil, errNoMaterializedViews
+			}
+			return refresher.RefreshMaterializedView(ctx, in)
+		})
+	}, server.Tool{
+		Name:        "refresh_materialized_view",
+		Admin:       true,
+		Description: "Recomputes the contents of a materialized view (REFRESH MATERIALIZED VIEW), so queries on it see current data. By default the view is locked against reads while it is rebuilt; set concurrently to keep it readable, which needs a unique index on the view and a view that has been populated before. The name must be a materialized view (see list_materialized_views), not a table or plain view. Only available for PostgreSQL.",
+		Mutates:     true,
+	})
 }
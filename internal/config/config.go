@@ -1,9 +1,13 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
+	"slices"
+	"strings"
 )
 
 // Server holds the list of databases in a map.
@@ -11,7 +15,7 @@ type Server map[string]Database
 
 // Database is the top-level config for each database connection.
 type Database struct {
-	// Backend is the database type: "postgres", "sqlite", "sqlserver", "mysql"
+	// Backend is the database type: "postgres", "sqlite", "sqlserver", "mysql", "duckdb"
 	Backend string `json:"type"`
 	// Description is a human-readable description for LLM context
 	Description string `json:"description,omitempty"`
@@ -19,6 +23,80 @@ type Database struct {
 	Read json.RawMessage `json:"read,omitempty"`
 	// Admin config - enables admin tools (explain, DDL, missing indexes, etc.)
 	Admin json.RawMessage `json:"admin,omitempty"`
+	// Write config - enables write_query (INSERT, UPDATE, DELETE). It takes the same
+	// fields as the admin config, typically with a user granted only DML.
+	Write json.RawMessage `json:"write,omitempty"`
+	// OnReadonlyViolation is what to do when the read user turns out to have write
+	// permissions: "fail" (default), "warn" or "downgrade" to read-only transactions.
+	OnReadonlyViolation string `json:"on_readonly_violation,omitempty"`
+	// DisabledTools lists tool names (e.g. "execute_ddl") that are not available for this database
+	DisabledTools []string `json:"disabled_tools,omitempty"`
+	// RateLimit caps the number of tool calls against this database. Optional.
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+	// MaxFullScanRows makes execute_query explain each query first and refuse it
+	// if the plan fully scans a table with more rows than this. Zero disables the check.
+	MaxFullScanRows int64 `json:"max_full_scan_rows,omitempty"`
+	// MaxResultBytes caps the JSON size of execute_query rows. Rows past the cap
+	// are dropped and the result is marked truncated. Zero means no cap.
+	MaxResultBytes int64 `json:"max_result_bytes,omitempty"`
+	// ResultCharset is the charset that result strings which are not valid UTF-8 are
+	// transcoded from (e.g. "latin1"). Without it, invalid sequences are replaced.
+	ResultCharset string `json:"result_charset,omitempty"`
+	// NormalizeBooleans returns boolean-like columns (SQL Server BIT, MySQL TINYINT(1)
+	// and BIT(1), SQLite BOOL) from execute_query as true/false instead of 0/1.
+	NormalizeBooleans bool `json:"normalize_booleans,omitempty"`
+	// ExcludedSchemas lists schemas hidden from list_tables. Nil uses the default
+	// system schemas; an empty list shows every schema.
+	ExcludedSchemas []string `json:"excluded_schemas,omitempty"`
+	// IncludeExecutedSQL adds the exact SQL each tool ran, with parameters bound,
+	// to execute_query, explain_query and execute_ddl results.
+	IncludeExecutedSQL bool `json:"include_executed_sql,omitempty"`
+	// MaxRows caps the number of rows execute_query returns. Rows past the cap are
+	// dropped and the result is marked truncated. Zero uses the default of 1000;
+	// a negative value means no cap.
+	MaxRows int `json:"max_rows,omitempty"`
+	// InjectLimit adds a LIMIT (TOP for SQL Server) of max_rows to SELECT statements
+	// that have none, so the database stops early. Requires max_rows.
+	InjectLimit bool `json:"inject_limit,omitempty"`
+	// ExportDir is the server directory execute_query may write results to with
+	// output_path. Exports are disabled without it.
+	ExportDir string `json:"export_dir,omitempty"`
+	// SchemaSummary appends the table count and largest tables to the description
+	// at startup, so list_databases shows what each database holds.
+	SchemaSummary bool `json:"schema_summary,omitempty"`
+	// Cache enables caching of execute_query, list_tables and describe_table results. Optional.
+	Cache *Cache `json:"cache,omitempty"`
+	// QueryPolicy blocks execute_query and explain_query statements by pattern. Optional.
+	QueryPolicy *QueryPolicy `json:"query_policy,omitempty"`
+}
+
+// QueryPolicy holds regular expressions that queries are matched against,
+// case-insensitively, before they run.
+type QueryPolicy struct {
+	// Allow, if not empty, blocks queries that match none of these patterns
+	Allow []string `json:"allow,omitempty"`
+	// Deny blocks queries that match any of these patterns
+	Deny []string `json:"deny,omitempty"`
+}
+
+// Cache configures the result caches. Zero values use the defaults.
+type Cache struct {
+	// TTLSeconds is how long a query result stays cached (default 30)
+	TTLSeconds int `json:"ttl_seconds,omitempty"`
+	// MaxEntries bounds the number of cached query results (default 100)
+	MaxEntries int `json:"max_entries,omitempty"`
+	// SchemaTTLSeconds is how long list_tables and describe_table results stay cached (default 300)
+	SchemaTTLSeconds int `json:"schema_ttl_seconds,omitempty"`
+}
+
+// RateLimit is a tool call quota. Zero values mean unlimited.
+type RateLimit struct {
+	// PerMinute caps all tool calls for the database
+	PerMinute int `json:"per_minute,omitempty"`
+	// ReadPerMinute caps read tool calls
+	ReadPerMinute int `json:"read_per_minute,omitempty"`
+	// AdminPerMinute caps admin tool calls
+	AdminPerMinute int `json:"admin_per_minute,omitempty"`
 }
 
 // HasRead returns true if read operations are configured.
@@ -31,6 +109,11 @@ func (d Database) HasAdmin() bool {
 	return len(d.Admin) > 0
 }
 
+// HasWrite returns true if write operations are configured.
+func (d Database) HasWrite() bool {
+	return len(d.Write) > 0
+}
+
 // ParseReadConfig unmarshals the read config into the provided struct.
 func (d Database) ParseReadConfig(v any) error {
 	if len(d.Read) == 0 {
@@ -47,6 +130,16 @@ func (d Database) ParseAdminConfig(v any) error {
 	return json.Unmarshal(d.Admin, v)
 }
 
+// ParseWriteConfig unmarshals the write config into the provided struct.
+func (d Database) ParseWriteConfig(v any) error {
+	if len(d.Write) == 0 {
+		return fmt.Errorf("write config not provided")
+	}
+	return json.Unmarshal(d.Write, v)
+}
+
+// LoadFromFile reads the server config from a JSON file. A database name that
+// appears more than once is an error, rather than the last entry silently winning.
 func LoadFromFile(filename string) (Server, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
@@ -57,6 +150,132 @@ func LoadFromFile(filename string) (Server, error) {
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	if err := checkDuplicateNames(data); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	for name, db := range config {
+		if err := db.expandEnv(); err != nil {
+			return nil, fmt.Errorf("database %q: %w", name, err)
+		}
+		config[name] = db
+	}
 
 	return config, nil
 }
+
+// envRef matches a ${NAME} environment variable reference.
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces ${NAME} references in the string values of the read, admin
+// and write configs with the environment variables they name, so DSNs can keep
+// passwords out of the config file. A bare $ is left alone, as it may be part of
+// a password.
+func (d *Database) expandEnv() error {
+	for level, raw := range map[string]*json.RawMessage{"read": &d.Read, "admin": &d.Admin, "write": &d.Write} {
+		if !envRef.Match(*raw) {
+			continue
+		}
+		var v any
+		if err := json.Unmarshal(*raw, &v); err != nil {
+			return fmt.Errorf("failed to parse %s config: %w", level, err)
+		}
+		v, err := expandEnvValue(v)
+		if err != nil {
+			return fmt.Errorf("%s config: %w", level, err)
+		}
+		if *raw, err = json.Marshal(v); err != nil {
+			return fmt.Errorf("%s config: %w", level, err)
+		}
+	}
+	return nil
+}
+
+// expandEnvValue expands the environment variable references in every string of a
+// decoded JSON value.
+func expandEnvValue(v any) (any, error) {
+	switch v := v.(type) {
+	case string:
+		var err error
+		expanded := envRef.ReplaceAllStringFunc(v, func(ref string) string {
+			name := envRef.FindStringSubmatch(ref)[1]
+			value, ok := os.LookupEnv(name)
+			if !ok && err == nil {
+				err = fmt.Errorf("environment variable %s is not set", name)
+			}
+			return value
+		})
+		return expanded, err
+	case map[string]any:
+		for k, item := range v {
+			expanded, err := expandEnvValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = expanded
+		}
+	case []any:
+		for i, item := range v {
+			expanded, err := expandEnvValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+	}
+	return v, nil
+}
+
+// checkDuplicateNames returns an error if a top-level key occurs twice in data,
+// which must be a valid JSON object.
+func checkDuplicateNames(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name := tok.(string)
+		if seen[name] {
+			return fmt.Errorf("database %q is defined more than once", name)
+		}
+		seen[name] = true
+
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SharedConnections returns the groups of databases whose read configs connect
+// to the same place: the same backend with the same DSN, or the same SQLite path.
+// This is usually a copy-paste mistake. Names are sorted within and across groups.
+func (s Server) SharedConnections() [][]string {
+	byConnection := make(map[string][]string)
+	for name, db := range s {
+		var target struct {
+			DSN  string `json:"dsn"`
+			Path string `json:"path"`
+		}
+		if json.Unmarshal(db.Read, &target) != nil || target.DSN+target.Path == "" {
+			continue
+		}
+		key := db.Backend + "\x00" + target.DSN + "\x00" + target.Path
+		byConnection[key] = append(byConnection[key], name)
+	}
+
+	var groups [][]string
+	for _, names := range byConnection {
+		if len(names) > 1 {
+			slices.Sort(names)
+			groups = append(groups, names)
+		}
+	}
+	slices.SortFunc(groups, func(a, b []string) int { return strings.Compare(a[0], b[0]) })
+	return groups
+}
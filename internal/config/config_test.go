@@ -0,0 +1,88 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFromFile(t *testing.T) {
+	write := func(t *testing.T, data string) string {
+		path := filepath.Join(t.TempDir(), "config.json")
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+		return path
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		cfg, err := LoadFromFile(write(t, `{"a": {"type": "sqlite", "read": {"path": "a.db"}}}`))
+		require.NoError(t, err)
+		require.Equal(t, "sqlite", cfg["a"].Backend)
+	})
+
+	t.Run("EnvReferences", func(t *testing.T) {
+		t.Setenv("DATABAISE_TEST_PASSWORD", `p"ss`)
+		cfg, err := LoadFromFile(write(t, `{"a": {"type": "postgres",
+			"read": {"dsn": "postgres://reader:${DATABAISE_TEST_PASSWORD}@db/app"},
+			"admin": {"dsn": "postgres://admin:pa$$word@db/app"}}}`))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"dsn": "postgres://reader:p\"ss@db/app"}`, string(cfg["a"].Read))
+		require.JSONEq(t, `{"dsn": "postgres://admin:pa$$word@db/app"}`, string(cfg["a"].Admin))
+	})
+
+	t.Run("EnvReferenceUnset", func(t *testing.T) {
+		_, err := LoadFromFile(write(t, `{"a": {"type": "postgres", "write": {"dsn": "postgres://writer:${DATABAISE_TEST_UNSET}@db/app"}}}`))
+		require.ErrorContains(t, err, `database "a": write config: environment variable DATABAISE_TEST_UNSET is not set`)
+	})
+
+	t.Run("DuplicateName", func(t *testing.T) {
+		_, err := LoadFromFile(write(t, `{"a": {"type": "sqlite"}, "b": {"type": "sqlite"}, "a": {"type": "postgres"}}`))
+		require.ErrorContains(t, err, `database "a" is defined more than once`)
+	})
+}
+
+func TestSharedConnections(t *testing.T) {
+	cfg := Server{
+		"orders":      {Backend: "postgres", Read: []byte(`{"dsn": "postgres://db/orders"}`)},
+		"orders_copy": {Backend: "postgres", Read: []byte(`{"dsn": "postgres://db/orders"}`)},
+		"users":       {Backend: "postgres", Read: []byte(`{"dsn": "postgres://db/users"}`)},
+		"local":       {Backend: "sqlite", Read: []byte(`{"path": "app.db"}`)},
+		"local_too":   {Backend: "sqlite", Read: []byte(`{"path": "app.db"}`)},
+		"admin_only":  {Backend: "postgres"},
+	}
+	require.Equal(t, [][]string{{"local", "local_too"}, {"orders", "orders_copy"}}, cfg.SharedConnections())
+}
+
+func TestPoolApply(t *testing.T) {
+	open := func(t *testing.T) *sql.DB {
+		db := sql.OpenDB(nopConnector{})
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+
+	db := open(t)
+	Pool{}.Apply(db)
+	require.Equal(t, DefaultMaxOpenConns, db.Stats().MaxOpenConnections)
+
+	db = open(t)
+	Pool{MaxOpenConns: 25, MaxIdleConns: 2, ConnMaxLifetimeSeconds: 60}.Apply(db)
+	require.Equal(t, 25, db.Stats().MaxOpenConnections)
+
+	db = open(t)
+	Pool{MaxOpenConns: -1}.Apply(db)
+	require.Zero(t, db.Stats().MaxOpenConnections)
+}
+
+// nopConnector lets a *sql.DB be created without a driver. It never connects.
+type nopConnector struct{}
+
+func (nopConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("not connected")
+}
+
+func (nopConnector) Driver() driver.Driver { return nil }
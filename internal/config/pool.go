@@ -0,0 +1,38 @@
+package config
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Default connection pool limits, used when a Pool field is zero.
+const (
+	DefaultMaxOpenConns = 10
+	DefaultMaxIdleConns = 5
+)
+
+// Pool tunes the connection pool of a read, write or admin connection. Each
+// backend embeds it in its connection configs. Zero values use the defaults.
+type Pool struct {
+	// MaxOpenConns caps the open connections (default 10). A negative value means no cap.
+	MaxOpenConns int `json:"max_open_conns,omitempty"`
+	// MaxIdleConns caps the idle connections kept for reuse (default 5). A negative
+	// value keeps none.
+	MaxIdleConns int `json:"max_idle_conns,omitempty"`
+	// ConnMaxLifetimeSeconds closes connections older than this. Zero keeps them open.
+	ConnMaxLifetimeSeconds int `json:"conn_max_lifetime_seconds,omitempty"`
+}
+
+// Apply sets the pool limits on db.
+func (p Pool) Apply(db *sql.DB) {
+	open, idle := p.MaxOpenConns, p.MaxIdleConns
+	if open == 0 {
+		open = DefaultMaxOpenConns
+	}
+	if idle == 0 {
+		idle = DefaultMaxIdleConns
+	}
+	db.SetMaxOpenConns(max(open, 0))
+	db.SetMaxIdleConns(max(idle, 0))
+	db.SetConnMaxLifetime(time.Duration(max(p.ConnMaxLifetimeSeconds, 0)) * time.Second)
+}
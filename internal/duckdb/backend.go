@@ -0,0 +1,359 @@
+//go:build cgo
+
+// Package duckdb is a read-only backend for DuckDB database files. The DuckDB
+// driver links the DuckDB library through cgo, so the package is left out of
+// builds with CGO_ENABLED=0.
+package duckdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	_ "github.com/duckdb/duckdb-go/v2"
+	"github.com/tinternet/databaise/internal/backend"
+	"github.com/tinternet/databaise/internal/config"
+	"github.com/tinternet/databaise/internal/logging"
+	"github.com/tinternet/databaise/internal/sqlcommon"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var log = logging.New("duckdb")
+
+// ReadConfig for read connections.
+type ReadConfig struct {
+	config.Pool
+	Path string `json:"path"`
+}
+
+// AdminConfig for admin connections. DuckDB refuses to open a file a second time
+// in the same process with a different access mode, so the admin connection is
+// read-only as well, and serves explain_query.
+type AdminConfig struct {
+	config.Pool
+	Path string `json:"path"`
+}
+
+// Factory implements backend.BackendFactory for DuckDB.
+type Factory struct{}
+
+func (Factory) Dialect() string { return "DuckDB" }
+
+func (Factory) UnsupportedTools() []string {
+	return []string{
+		"list_sequences", "list_foreign_keys", "list_tables_without_pk", "table_json_schema",
+		"write_query", "execute_ddl", "check_ddl", "recommend_index_for_query", "analyze_table", "set_comment", "table_profile",
+		"list_missing_indexes", "list_waiting_queries", "list_active_connections", "list_slowest_queries", "list_deadlocks", "kill_idle_transactions",
+	}
+}
+
+func (Factory) New(db *gorm.DB) backend.SQLBackend {
+	return &Backend{db: db}
+}
+
+// Connector implements backend.Connector for DuckDB.
+type Connector struct{}
+
+func (Connector) ConnectRead(c ReadConfig) (*gorm.DB, error) {
+	log.Printf("Opening readonly connection [path=%s]", c.Path)
+	return open(c.Path, c.Pool)
+}
+
+// CheckReadonly reports the read connection as read-only: it is opened with
+// access_mode=read_only, which DuckDB enforces for every statement.
+func (Connector) CheckReadonly(c ReadConfig, db *gorm.DB) backend.ReadonlyStatus {
+	return backend.ReadonlyStatus{Mode: backend.ReadonlyFile, Verified: true, CheckedAt: time.Now()}
+}
+
+func (Connector) ConnectAdmin(c AdminConfig) (*gorm.DB, error) {
+	log.Printf("Opening admin connection [path=%s]", c.Path)
+	return open(c.Path, c.Pool)
+}
+
+// dialector is the PostgreSQL dialector on the DuckDB driver, which takes the same
+// $n placeholders and double-quoted identifiers. It reports its own name, so that
+// the PostgreSQL catalog queries in sqlcommon are not run against DuckDB.
+type dialector struct {
+	postgres.Dialector
+}
+
+func (dialector) Name() string { return "duckdb" }
+
+func open(path string, pool config.Pool) (*gorm.DB, error) {
+	dsn := path + "?access_mode=read_only"
+	d := dialector{postgres.Dialector{Config: &postgres.Config{DriverName: "duckdb", DSN: dsn}}}
+	db, err := gorm.Open(d, &gorm.Config{Logger: logging.NewGormLogger()})
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlcommon.ApplyPool(db, pool); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func init() {
+	backend.RegisterFactory("duckdb", Factory{}, Connector{})
+}
+
+// Backend implements backend.SQLBackend for DuckDB.
+type Backend struct {
+	db *gorm.DB
+}
+
+// ListTables returns the tables of the schema, or of every schema of the file with
+// AllSchemas. Row counts are DuckDB's own estimates, kept in duckdb_tables().
+func (b *Backend) ListTables(ctx context.Context, in backend.ListTablesIn) ([]backend.Table, error) {
+	var tables []struct {
+		SchemaName    string
+		TableName     string
+		EstimatedSize int64
+	}
+	err := b.db.WithContext(ctx).Raw(`SELECT schema_name, table_name, estimated_size
+FROM duckdb_tables()
+WHERE database_name = current_database() AND NOT internal AND NOT temporary
+  AND (? OR schema_name = COALESCE(NULLIF(?, ''), current_schema()))
+ORDER BY schema_name, table_name`, in.AllSchemas, in.Schema).Scan(&tables).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]backend.Table, len(tables))
+	for i, t := range tables {
+		result[i] = backend.Table{Schema: t.SchemaName, Name: t.TableName}
+		if in.WithStats {
+			result[i].EstimatedRows = t.EstimatedSize
+		}
+	}
+	return result, nil
+}
+
+// ListSchemas returns the schemas of the database file, and with IncludeSystem
+// those of DuckDB's system catalog.
+func (b *Backend) ListSchemas(ctx context.Context, in backend.ListSchemasIn) ([]string, error) {
+	schemas := []string{}
+	err := b.db.WithContext(ctx).Raw(`SELECT schema_name
+FROM duckdb_schemas()
+WHERE database_name = current_database() OR (? AND database_name = 'system')
+ORDER BY database_name = 'system', schema_name`, in.IncludeSystem).Scan(&schemas).Error
+	return schemas, err
+}
+
+func (b *Backend) ListViews(ctx context.Context, in backend.ListViewsIn) ([]backend.View, error) {
+	views := []backend.View{}
+	err := b.db.WithContext(ctx).Raw(`SELECT schema_name AS schema, view_name AS name, sql AS definition
+FROM duckdb_views()
+WHERE database_name = current_database() AND NOT internal AND NOT temporary
+  AND (? = '' OR schema_name = ?)
+ORDER BY schema_name, view_name`, in.Schema, in.Schema).Scan(&views).Error
+	return views, err
+}
+
+// DescribeTable returns the CREATE TABLE and CREATE INDEX statements DuckDB keeps
+// for the table in duckdb_tables() and duckdb_indexes(). DuckDB has no
+// information_schema view of the statements, and rebuilding them from the column
+// list would lose the constraints.
+func (b *Backend) DescribeTable(ctx context.Context, in backend.DescribeTableIn) (*backend.TableDescription, error) {
+	db := b.db.WithContext(ctx)
+	var table struct {
+		SchemaName string
+		SQL        string
+		Comment    *string
+	}
+	err := db.Raw(`SELECT schema_name, sql, comment
+FROM duckdb_tables()
+WHERE database_name = current_database() AND schema_name = COALESCE(NULLIF(?, ''), current_schema()) AND table_name = ?`, in.Schema, in.Table).Scan(&table).Error
+	if err != nil {
+		return nil, err
+	}
+	if table.SQL == "" {
+		return nil, sqlcommon.ErrTableNotFound
+	}
+
+	out := backend.TableDescription{CreateTable: table.SQL}
+	if table.Comment != nil {
+		out.TableComment = *table.Comment
+	}
+
+	var columns []struct {
+		ColumnName string
+		Comment    string
+	}
+	err = db.Raw(`SELECT column_name, comment
+FROM duckdb_columns()
+WHERE database_name = current_database() AND schema_name = ? AND table_name = ? AND comment IS NOT NULL
+ORDER BY column_index`, table.SchemaName, in.Table).Scan(&columns).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range columns {
+		if out.ColumnComments == nil {
+			out.ColumnComments = make(map[string]string)
+		}
+		out.ColumnComments[c.ColumnName] = c.Comment
+	}
+
+	err = db.Raw(`SELECT sql
+FROM duckdb_indexes()
+WHERE database_name = current_database() AND schema_name = ? AND table_name = ? AND sql IS NOT NULL
+ORDER BY index_name`, table.SchemaName, in.Table).Scan(&out.CreateIndexes).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (b *Backend) ProfileCategoricalColumns(ctx context.Context, in backend.ProfileCategoricalColumnsIn) (*backend.ProfileCategoricalColumnsOut, error) {
+	columns, skipped, err := sqlcommon.ProfileCategoricalColumns(ctx, b.db, tableName(in.Schema, in.Table), in.MaxDistinct)
+	if err != nil {
+		return nil, err
+	}
+	return &backend.ProfileCategoricalColumnsOut{Columns: columns, Skipped: skipped}, nil
+}
+
+func (b *Backend) SampleTable(ctx context.Context, in backend.SampleTableIn) (*backend.QueryResult, error) {
+	query := fmt.Sprintf("SELECT * FROM %s LIMIT %d", b.db.Statement.Quote(tableName(in.Schema, in.Table)), in.Limit)
+	return b.ExecuteQuery(ctx, backend.ReadQueryIn{Query: query})
+}
+
+// tableName returns the table as a clause.Table, qualified with its schema if one is given.
+func tableName(schema, table string) clause.Table {
+	if schema != "" {
+		return clause.Table{Name: schema + "." + table}
+	}
+	return clause.Table{Name: table}
+}
+
+func (b *Backend) ExecuteQuery(ctx context.Context, in backend.ReadQueryIn) (*backend.QueryResult, error) {
+	if in.Schema != "" {
+		return nil, fmt.Errorf("the schema parameter of execute_query is only supported for MySQL: qualify table names with their schema instead")
+	}
+	rows, err := sqlcommon.QueryRows(ctx, b.db, in.Query)
+	if err != nil {
+		return nil, err
+	}
+	formatValues(rows)
+	return backend.NewQueryResult(in.Query, rows), nil
+}
+
+func (b *Backend) ExplainQuery(ctx context.Context, in backend.ExplainQueryIn) (*backend.ExplainResult, error) {
+	explainQuery := "EXPLAIN (FORMAT JSON) " + in.Query
+	if in.Analyze {
+		explainQuery = "EXPLAIN (ANALYZE, FORMAT JSON) " + in.Query
+	}
+	var plan struct {
+		ExplainKey   string
+		ExplainValue string
+	}
+	if err := b.db.WithContext(ctx).Raw(explainQuery, in.Params...).Scan(&plan).Error; err != nil {
+		return nil, err
+	}
+	nodes, err := parsePlan(plan.ExplainValue)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the DuckDB plan: %w", err)
+	}
+	scans, err := b.fullScans(ctx, nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &backend.ExplainResult{
+		Format:           "json",
+		Result:           plan.ExplainValue,
+		ResultInfo:       "The DuckDB query plan as returned from the database",
+		FullScanDetected: len(scans) > 0,
+		FullScans:        scans,
+		Plan:             planTree(nodes),
+		ExecutedSQL:      sqlcommon.BoundSQL(b.db, explainQuery, in.Params...),
+	}, nil
+}
+
+// fullScans returns the tables the plan reads with a sequential scan. The plan
+// estimates rows after filtering, so the size of each table is taken from the row
+// estimate in duckdb_tables().
+func (b *Backend) fullScans(ctx context.Context, nodes []planNode) ([]backend.TableScan, error) {
+	var scans []backend.TableScan
+	for _, table := range scannedTables(nodes) {
+		var rows int64
+		err := b.db.WithContext(ctx).Raw(`SELECT estimated_size FROM duckdb_tables()
+WHERE database_name || '.' || schema_name || '.' || table_name = ?`, table).Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		scans = append(scans, backend.TableScan{Table: unqualified(table), EstimatedRows: float64(rows)})
+	}
+	return scans, nil
+}
+
+// DuckDB is an embedded library, with no server sessions to monitor.
+func (b *Backend) ListWaitingQueries(ctx context.Context) ([]backend.WaitingQuery, error) {
+	return nil, fmt.Errorf("waiting query monitoring is not available for DuckDB")
+}
+
+func (b *Backend) ListConnections(ctx context.Context) ([]backend.Connection, error) {
+	return nil, fmt.Errorf("connection listing is not available for DuckDB")
+}
+
+func (b *Backend) ListSlowestQueries(ctx context.Context) (*backend.SlowQueryResult, error) {
+	return nil, fmt.Errorf("slow query statistics are not available for DuckDB")
+}
+
+func (b *Backend) ListDeadlocks(ctx context.Context) ([]backend.Deadlock, error) {
+	return nil, fmt.Errorf("deadlock detection is not available for DuckDB")
+}
+
+func (b *Backend) KillIdleTransactions(ctx context.Context, in backend.KillIdleTransactionsIn) (*backend.KillIdleTransactionsOut, error) {
+	return nil, fmt.Errorf("killing idle transactions is not available for DuckDB")
+}
+
+// The database file is opened read-only, so nothing that changes it is available.
+
+func (b *Backend) ExecuteDDL(ctx context.Context, in backend.ExecuteDDLIn) (*backend.DDLResult, error) {
+	return nil, fmt.Errorf("DDL is not available for DuckDB: the database file is opened read-only")
+}
+
+func (b *Backend) ExecuteWrite(ctx context.Context, in backend.ExecuteWriteIn) (*backend.WriteResult, error) {
+	return nil, fmt.Errorf("writes are not available for DuckDB: the database file is opened read-only")
+}
+
+func (b *Backend) CheckDDL(ctx context.Context, in backend.CheckDDLIn) (*sqlcommon.DDLCheckReport, error) {
+	return nil, fmt.Errorf("DDL checks are not available for DuckDB: the database file is opened read-only")
+}
+
+func (b *Backend) AnalyzeTable(ctx context.Context, in backend.AnalyzeTableIn) (*backend.AnalyzeTableOut, error) {
+	return nil, fmt.Errorf("refreshing statistics is not available for DuckDB: the database file is opened read-only")
+}
+
+func (b *Backend) SetComment(ctx context.Context, in backend.SetCommentIn) (*backend.SetCommentOut, error) {
+	return nil, fmt.Errorf("comments cannot be set for DuckDB: the database file is opened read-only")
+}
+
+func (b *Backend) ListSequences(ctx context.Context, in backend.ListSequencesIn) ([]backend.Sequence, error) {
+	return nil, fmt.Errorf("sequence listing is not available for DuckDB")
+}
+
+func (b *Backend) ListForeignKeys(ctx context.Context, in backend.ListForeignKeysIn) ([]backend.ForeignKey, error) {
+	return nil, fmt.Errorf("foreign key listing is not available for DuckDB")
+}
+
+func (b *Backend) ListTablesWithoutPK(ctx context.Context, in backend.ListTablesWithoutPKIn) ([]backend.Table, error) {
+	return nil, fmt.Errorf("primary key checks are not available for DuckDB")
+}
+
+func (b *Backend) TableJSONSchema(ctx context.Context, in backend.TableJSONSchemaIn) (*backend.TableJSONSchemaOut, error) {
+	return nil, fmt.Errorf("table JSON schemas are not available for DuckDB")
+}
+
+func (b *Backend) RecommendIndexes(ctx context.Context, in backend.RecommendIndexesIn) (*sqlcommon.IndexAdvice, error) {
+	return nil, fmt.Errorf("index recommendations are not available for DuckDB")
+}
+
+func (b *Backend) ListMissingIndexes(ctx context.Context) ([]backend.MissingIndex, error) {
+	return nil, fmt.Errorf("missing index detection is not available for DuckDB")
+}
+
+func (b *Backend) TableProfile(ctx context.Context, in backend.TableProfileIn) (*backend.TableProfile, error) {
+	return nil, fmt.Errorf("table profiles are not available for DuckDB")
+}
@@ -0,0 +1,183 @@
+//go:build cgo && integration
+
+package duckdb
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinternet/databaise/internal/backend"
+	"github.com/tinternet/databaise/internal/sqlcommon"
+)
+
+// createFile creates a database file with a users and an orders table. The file
+// is written through its own connection, closed before the backend opens it,
+// since DuckDB does not open a file read-write and read-only in one process.
+func createFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.duckdb")
+	db, err := sql.Open("duckdb", path)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`
+CREATE TABLE users (id INTEGER PRIMARY KEY, email VARCHAR NOT NULL, status VARCHAR, external_id UUID, balance DECIMAL(10, 2));
+CREATE UNIQUE INDEX idx_users_email ON users (email);
+COMMENT ON TABLE users IS 'Registered accounts';
+COMMENT ON COLUMN users.email IS 'Login address';
+CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users (id), total DECIMAL(10, 2));
+CREATE SCHEMA archive;
+CREATE TABLE archive.orders (id INTEGER, total DECIMAL(10, 2));
+CREATE VIEW active_users AS SELECT * FROM users WHERE status = 'active';
+INSERT INTO users VALUES
+    (1, 'a@example.com', 'active', '6f1c2a53-6a43-4b8e-9f5e-0a3c1c8e2d11', 10.50),
+    (2, 'b@example.com', 'active', NULL, 0),
+    (3, 'c@example.com', 'banned', NULL, NULL);
+INSERT INTO orders SELECT i, i % 3 + 1, i * 1.25 FROM range(1, 101) t(i);
+`)
+	require.NoError(t, err)
+	return path
+}
+
+func openTestConnection(t *testing.T) *Backend {
+	t.Helper()
+	db, err := Connector{}.ConnectRead(ReadConfig{Path: createFile(t)})
+	require.NoError(t, err)
+	return &Backend{db: db}
+}
+
+func TestConnect(t *testing.T) {
+	t.Parallel()
+	file := createFile(t)
+
+	db, err := Connector{}.ConnectRead(ReadConfig{Path: file})
+	require.NoError(t, err)
+	require.Equal(t, backend.ReadonlyFile, Connector{}.CheckReadonly(ReadConfig{Path: file}, db).Mode)
+	require.ErrorContains(t, db.Exec("INSERT INTO users (id, email) VALUES (4, 'd@example.com')").Error, "read-only")
+
+	// The admin connection opens the file the same way, so both can be configured.
+	admin, err := Connector{}.ConnectAdmin(AdminConfig{Path: file})
+	require.NoError(t, err)
+	require.NoError(t, admin.Exec("SELECT 1").Error)
+}
+
+func TestListTables(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	tables, err := b.ListTables(t.Context(), backend.ListTablesIn{})
+	require.NoError(t, err)
+	require.Equal(t, []backend.Table{{Schema: "main", Name: "orders"}, {Schema: "main", Name: "users"}}, tables)
+
+	tables, err = b.ListTables(t.Context(), backend.ListTablesIn{Schema: "archive"})
+	require.NoError(t, err)
+	require.Equal(t, []backend.Table{{Schema: "archive", Name: "orders"}}, tables)
+
+	tables, err = b.ListTables(t.Context(), backend.ListTablesIn{AllSchemas: true, WithStats: true})
+	require.NoError(t, err)
+	require.Len(t, tables, 3)
+	require.Equal(t, backend.Table{Schema: "main", Name: "users", EstimatedRows: 3}, tables[2])
+}
+
+func TestListSchemas(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	schemas, err := b.ListSchemas(t.Context(), backend.ListSchemasIn{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"archive", "main"}, schemas)
+
+	schemas, err = b.ListSchemas(t.Context(), backend.ListSchemasIn{IncludeSystem: true})
+	require.NoError(t, err)
+	require.Contains(t, schemas, "information_schema")
+}
+
+func TestListViews(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	views, err := b.ListViews(t.Context(), backend.ListViewsIn{})
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	require.Equal(t, "active_users", views[0].Name)
+	require.Contains(t, views[0].Definition, "status = 'active'")
+}
+
+func TestDescribeTable(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	desc, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Table: "users"})
+	require.NoError(t, err)
+	require.Contains(t, desc.CreateTable, "CREATE TABLE users")
+	require.Contains(t, desc.CreateTable, "PRIMARY KEY")
+	require.Equal(t, []string{"CREATE UNIQUE INDEX idx_users_email ON users(email);"}, desc.CreateIndexes)
+	require.Equal(t, "Registered accounts", desc.TableComment)
+	require.Equal(t, map[string]string{"email": "Login address"}, desc.ColumnComments)
+
+	desc, err = b.DescribeTable(t.Context(), backend.DescribeTableIn{Schema: "archive", Table: "orders"})
+	require.NoError(t, err)
+	require.NotContains(t, desc.CreateTable, "user_id")
+
+	_, err = b.DescribeTable(t.Context(), backend.DescribeTableIn{Table: "missing"})
+	require.ErrorIs(t, err, sqlcommon.ErrTableNotFound)
+}
+
+func TestExecuteQuery(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	res, err := b.ExecuteQuery(t.Context(), backend.ReadQueryIn{Query: "SELECT * FROM users ORDER BY id"})
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 3)
+	require.Equal(t, "a@example.com", res.Rows[0]["email"])
+	require.Equal(t, "6f1c2a53-6a43-4b8e-9f5e-0a3c1c8e2d11", res.Rows[0]["external_id"])
+	require.Equal(t, "10.5", res.Rows[0]["balance"])
+	require.Nil(t, res.Rows[2]["balance"])
+
+	_, err = b.ExecuteQuery(t.Context(), backend.ReadQueryIn{Query: "SELECT 1", Schema: "archive"})
+	require.ErrorContains(t, err, "only supported for MySQL")
+
+	res, err = b.SampleTable(t.Context(), backend.SampleTableIn{Schema: "archive", Table: "orders", Limit: 5})
+	require.NoError(t, err)
+	require.Empty(t, res.Rows)
+}
+
+func TestProfileCategoricalColumns(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	out, err := b.ProfileCategoricalColumns(t.Context(), backend.ProfileCategoricalColumnsIn{Table: "users", MaxDistinct: 2})
+	require.NoError(t, err)
+	require.NotEmpty(t, out.Columns)
+	require.Equal(t, "status", out.Columns[0].Name)
+	require.Len(t, out.Columns[0].Values, 2)
+	require.Contains(t, out.Skipped, "email")
+}
+
+func TestExplainQuery(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	t.Run("Explain", func(t *testing.T) {
+		t.Parallel()
+		res, err := b.ExplainQuery(t.Context(), backend.ExplainQueryIn{Query: "SELECT * FROM orders WHERE total > ?", Params: []any{10}})
+		require.NoError(t, err)
+		require.Equal(t, "json", res.Format)
+		require.True(t, res.FullScanDetected)
+		require.Equal(t, []backend.TableScan{{Table: "main.orders", EstimatedRows: 100}}, res.FullScans)
+		require.NotEmpty(t, res.Plan)
+	})
+	t.Run("Analyze", func(t *testing.T) {
+		t.Parallel()
+		res, err := b.ExplainQuery(t.Context(), backend.ExplainQueryIn{Query: "SELECT * FROM orders WHERE total > 10", Analyze: true})
+		require.NoError(t, err)
+		require.Equal(t, []backend.TableScan{{Table: "main.orders", EstimatedRows: 100}}, res.FullScans)
+		require.NotEmpty(t, res.Plan)
+		require.NotNil(t, res.Plan[0].ActualRows)
+	})
+	t.Run("MalformedQuery", func(t *testing.T) {
+		t.Parallel()
+		_, err := b.ExplainQuery(t.Context(), backend.ExplainQueryIn{Query: "SELECT NOT SELECT"})
+		require.ErrorContains(t, err, "syntax error")
+	})
+}
@@ -0,0 +1,103 @@
+//go:build cgo
+
+package duckdb
+
+import (
+	"cmp"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/tinternet/databaise/internal/backend"
+)
+
+// planNode is an operator of a JSON plan. EXPLAIN names it in name, EXPLAIN
+// ANALYZE in operator_name along with the rows it returned.
+type planNode struct {
+	Name                string         `json:"name"`
+	OperatorName        string         `json:"operator_name"`
+	OperatorType        string         `json:"operator_type"`
+	OperatorCardinality *float64       `json:"operator_cardinality"`
+	ExtraInfo           map[string]any `json:"extra_info"`
+	Children            []planNode     `json:"children"`
+}
+
+// parsePlan returns the top-level operators of an EXPLAIN (FORMAT JSON) result.
+// EXPLAIN returns an array of operators. EXPLAIN ANALYZE returns the query's
+// profile, whose single child is the EXPLAIN_ANALYZE operator over the plan.
+func parsePlan(planJSON string) ([]planNode, error) {
+	if strings.HasPrefix(strings.TrimSpace(planJSON), "[") {
+		var nodes []planNode
+		err := json.Unmarshal([]byte(planJSON), &nodes)
+		return nodes, err
+	}
+	var profile planNode
+	if err := json.Unmarshal([]byte(planJSON), &profile); err != nil {
+		return nil, err
+	}
+	nodes := profile.Children
+	if len(nodes) == 1 && nodes[0].OperatorType == "EXPLAIN_ANALYZE" {
+		nodes = nodes[0].Children
+	}
+	return nodes, nil
+}
+
+// operation returns the operator name, followed by how it reads its table for scans,
+// such as "SEQ_SCAN (Index Scan)".
+func (n planNode) operation() string {
+	name := cmp.Or(n.Name, n.OperatorName)
+	if typ, _ := n.ExtraInfo["Type"].(string); typ != "" {
+		return name + " (" + typ + ")"
+	}
+	return name
+}
+
+// table returns the table the operator reads as catalog.schema.table, or "".
+func (n planNode) table() string {
+	table, _ := n.ExtraInfo["Table"].(string)
+	return table
+}
+
+// planTree converts DuckDB plan operators to backend.PlanNode trees.
+func planTree(nodes []planNode) []backend.PlanNode {
+	var tree []backend.PlanNode
+	for _, n := range nodes {
+		node := backend.PlanNode{
+			Operation:  n.operation(),
+			Table:      unqualified(n.table()),
+			ActualRows: n.OperatorCardinality,
+		}
+		if s, _ := n.ExtraInfo["Estimated Cardinality"].(string); s != "" {
+			if rows, err := strconv.ParseFloat(s, 64); err == nil {
+				node.EstimatedRows = &rows
+			}
+		}
+		for _, child := range planTree(n.Children) {
+			node.Children = append(node.Children, child)
+		}
+		tree = append(tree, node)
+	}
+	return tree
+}
+
+// scannedTables returns the tables that the plan reads with a sequential scan, as
+// catalog.schema.table, in plan order.
+func scannedTables(nodes []planNode) []string {
+	var tables []string
+	for _, n := range nodes {
+		if typ, _ := n.ExtraInfo["Type"].(string); typ == "Sequential Scan" && n.table() != "" {
+			tables = append(tables, n.table())
+		}
+		tables = append(tables, scannedTables(n.Children)...)
+	}
+	return tables
+}
+
+// unqualified drops the catalog, the database file's name, from a table in a plan,
+// leaving schema.table.
+func unqualified(table string) string {
+	if _, rest, ok := strings.Cut(table, "."); ok {
+		return rest
+	}
+	return table
+}
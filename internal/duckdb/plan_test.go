@@ -0,0 +1,122 @@
+//go:build cgo
+
+package duckdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinternet/databaise/internal/backend"
+)
+
+// joinPlan is the EXPLAIN (FORMAT JSON) of a join of orders to users, as DuckDB 1.5
+// reports it, without the projections.
+const joinPlan = `[
+    {
+        "name": "PROJECTION",
+        "children": [
+            {
+                "name": "HASH_JOIN",
+                "children": [
+                    {
+                        "name": "SEQ_SCAN",
+                        "children": [],
+                        "extra_info": {"Table": "test.main.orders", "Type": "Sequential Scan", "Filters": "user_id=1", "Estimated Cardinality": "34"}
+                    },
+                    {
+                        "name": "SEQ_SCAN",
+                        "children": [],
+                        "extra_info": {"Table": "test.main.users", "Type": "Index Scan", "Filters": "id=1", "Estimated Cardinality": "1"}
+                    }
+                ],
+                "extra_info": {"Join Type": "INNER", "Conditions": "user_id = id", "Estimated Cardinality": "11"}
+            }
+        ],
+        "extra_info": {"Estimated Cardinality": "11"}
+    }
+]`
+
+// analyzePlan is the EXPLAIN (ANALYZE, FORMAT JSON) of a filtered read of orders,
+// without the timings.
+const analyzePlan = `{
+    "query_name": "EXPLAIN (ANALYZE, FORMAT JSON) SELECT * FROM orders WHERE total > 10",
+    "extra_info": {},
+    "children": [
+        {
+            "operator_type": "EXPLAIN_ANALYZE",
+            "operator_name": "EXPLAIN_ANALYZE",
+            "operator_cardinality": 0,
+            "extra_info": {},
+            "children": [
+                {
+                    "operator_type": "PROJECTION",
+                    "operator_name": "PROJECTION",
+                    "operator_cardinality": 92,
+                    "extra_info": {"Estimated Cardinality": "20"},
+                    "children": [
+                        {
+                            "operator_type": "TABLE_SCAN",
+                            "operator_name": "SEQ_SCAN",
+                            "operator_cardinality": 92,
+                            "extra_info": {"Table": "test.main.orders", "Type": "Sequential Scan", "Filters": "total>10.00", "Estimated Cardinality": "20"},
+                            "children": []
+                        }
+                    ]
+                }
+            ]
+        }
+    ]
+}`
+
+func TestPlanTree(t *testing.T) {
+	t.Run("Explain", func(t *testing.T) {
+		nodes, err := parsePlan(joinPlan)
+		require.NoError(t, err)
+		tree := planTree(nodes)
+		require.Len(t, tree, 1)
+		require.Equal(t, "PROJECTION", tree[0].Operation)
+		join := tree[0].Children[0].(backend.PlanNode)
+		require.Equal(t, "HASH_JOIN", join.Operation)
+		require.Equal(t, 11.0, *join.EstimatedRows)
+		scan := join.Children[0].(backend.PlanNode)
+		require.Equal(t, "SEQ_SCAN (Sequential Scan)", scan.Operation)
+		require.Equal(t, "main.orders", scan.Table)
+		require.Nil(t, scan.ActualRows)
+	})
+
+	t.Run("Analyze", func(t *testing.T) {
+		nodes, err := parsePlan(analyzePlan)
+		require.NoError(t, err)
+		tree := planTree(nodes)
+		require.Len(t, tree, 1)
+		require.Equal(t, "PROJECTION", tree[0].Operation, "the EXPLAIN_ANALYZE operator is not part of the plan")
+		scan := tree[0].Children[0].(backend.PlanNode)
+		require.Equal(t, "main.orders", scan.Table)
+		require.Equal(t, 20.0, *scan.EstimatedRows)
+		require.Equal(t, 92.0, *scan.ActualRows)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		_, err := parsePlan("[{")
+		require.Error(t, err)
+	})
+}
+
+func TestScannedTables(t *testing.T) {
+	tests := []struct {
+		name string
+		plan string
+		want []string
+	}{
+		{"Join", joinPlan, []string{"test.main.orders"}},
+		{"Analyze", analyzePlan, []string{"test.main.orders"}},
+		{"Constant", `[{"name": "COLUMN_DATA_SCAN", "children": [], "extra_info": {"Estimated Cardinality": "1"}}]`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := parsePlan(tt.plan)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, scannedTables(nodes))
+		})
+	}
+}
@@ -0,0 +1,29 @@
+//go:build cgo
+
+package duckdb
+
+import (
+	"github.com/duckdb/duckdb-go/v2"
+	"github.com/tinternet/databaise/internal/sqlcommon"
+)
+
+// formatValues replaces the driver's DECIMAL and UUID values, which would be
+// encoded as a struct and as bytes, with their text form.
+func formatValues(rows *sqlcommon.Rows) {
+	for i, ct := range rows.ColumnTypes {
+		name := rows.Columns[i]
+		for _, row := range rows.Rows {
+			switch v := row[name].(type) {
+			case duckdb.Decimal:
+				row[name] = v.String()
+			case *duckdb.Decimal:
+				row[name] = v.String()
+			case []byte:
+				if ct.DatabaseTypeName() == "UUID" && len(v) == len(duckdb.UUID{}) {
+					u := duckdb.UUID(v)
+					row[name] = u.String()
+				}
+			}
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package logging
 
 import (
+	"context"
 	"time"
 
+	"github.com/tinternet/databaise/internal/tracing"
 	"gorm.io/gorm/logger"
 )
 
@@ -15,8 +17,9 @@ func SetGormLogLevel(level logger.LogLevel) {
 
 // NewGormLogger creates a GORM logger that writes to the app's logging output.
 // In STDIO mode, this will be stderr (set via SetOutput before backends init).
+// Every statement is also recorded as a trace span.
 func NewGormLogger() logger.Interface {
-	return logger.New(
+	return tracingLogger{logger.New(
 		std,
 		logger.Config{
 			SlowThreshold:             200 * time.Millisecond,
@@ -24,7 +27,22 @@ func NewGormLogger() logger.Interface {
 			IgnoreRecordNotFoundError: true,
 			Colorful:                  false,
 		},
-	)
+	)}
+}
+
+// tracingLogger records a span for every statement before passing it on to the
+// wrapped logger.
+type tracingLogger struct {
+	logger.Interface
+}
+
+func (l tracingLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return tracingLogger{l.Interface.LogMode(level)}
+}
+
+func (l tracingLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	tracing.Statement(ctx, begin, fc, err)
+	l.Interface.Trace(ctx, begin, fc, err)
 }
 
 // ParseGormLogLevel converts a string log level to GORM's LogLevel type.
@@ -1,13 +1,24 @@
 package mysql
 
 import (
+	"cmp"
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	_ "embed"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	gomysql "github.com/go-sql-driver/mysql"
 	"github.com/tinternet/databaise/internal/backend"
+	"github.com/tinternet/databaise/internal/config"
 	"github.com/tinternet/databaise/internal/logging"
+	"github.com/tinternet/databaise/internal/sqlcommon"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -17,69 +28,135 @@ var log = logging.New("mysql")
 
 // ReadConfig for read connections.
 type ReadConfig struct {
+	config.Pool
 	DSN                 string `json:"dsn"`
 	BypassReadonlyCheck bool   `json:"bypass_readonly_check,omitempty"`
+	StatementTimeoutMs  int    `json:"statement_timeout_ms,omitempty"`
+	UseReadonlyTx       bool   `json:"use_readonly_tx,omitempty"`
+	// TenantDatabases lists the other databases on the server that execute_query may be
+	// routed to with its schema parameter.
+	TenantDatabases []string `json:"tenant_databases,omitempty"`
 }
 
 // AdminConfig for admin connections.
 type AdminConfig struct {
+	config.Pool
 	DSN string `json:"dsn"`
 }
 
+// DB wraps gorm.DB with MySQL-specific settings.
+type DB struct {
+	*gorm.DB
+	UseReadonlyTx   bool
+	TenantDatabases []string
+	// boolColumns caches the boolean columns of each database for normalize_booleans.
+	boolColumns *boolColumnCache
+}
+
+// Pool returns the connection pool, for pool_stats.
+func (db DB) Pool() (*sql.DB, error) {
+	return db.DB.DB()
+}
+
 // Factory implements backend.BackendFactory for MySQL.
 type Factory struct{}
 
 func (Factory) Dialect() string { return "MySQL" }
 
-func (Factory) New(db *gorm.DB) backend.SQLBackend {
+func (Factory) UnsupportedTools() []string {
+	return []string{"list_missing_indexes", "kill_idle_transactions"}
+}
+
+func (Factory) New(db DB) backend.SQLBackend {
 	return &Backend{db: db}
 }
 
 // Connector implements backend.Connector for MySQL.
 type Connector struct{}
 
-func (Connector) ConnectRead(cfg ReadConfig) (*gorm.DB, error) {
+func (Connector) ConnectRead(cfg ReadConfig) (DB, error) {
 	log.Printf("Opening read connection")
 	dsn := enableParseTime(cfg.DSN)
+	if cfg.StatementTimeoutMs > 0 {
+		// The driver sends unknown DSN params as SET statements on every new connection,
+		// so each pooled session gets the cap regardless of how a query is issued.
+		dsn = withParam(dsn, "max_execution_time", strconv.Itoa(cfg.StatementTimeoutMs))
+		log.Printf("Using max_execution_time of %dms (statement_timeout_ms)", cfg.StatementTimeoutMs)
+	}
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logging.NewGormLogger()})
 	if err != nil {
-		return nil, err
+		return DB{}, err
+	}
+	if err := sqlcommon.ApplyPool(db, cfg.Pool); err != nil {
+		return DB{}, err
 	}
 
-	if !cfg.BypassReadonlyCheck {
-		var grants []string
-		if err := db.Raw("SHOW GRANTS FOR CURRENT_USER;").Scan(&grants).Error; err != nil {
-			return nil, fmt.Errorf("could not verify user permissions: %w", err)
-		}
-		for _, g := range grants {
-			for _, p := range []string{"INSERT", "UPDATE", "DELETE", "DROP", "CREATE", "ALTER"} {
-				if strings.Contains(g, p) {
-					return nil, fmt.Errorf("read DSN user has write permissions (set bypass_readonly_check: true to bypass)")
-				}
+	return DB{DB: db, UseReadonlyTx: cfg.UseReadonlyTx, TenantDatabases: cfg.TenantDatabases, boolColumns: newBoolColumnCache()}, nil
+}
+
+func (Connector) CheckReadonly(cfg ReadConfig, db DB) backend.ReadonlyStatus {
+	status := backend.ReadonlyStatus{CheckedAt: time.Now()}
+	if cfg.UseReadonlyTx {
+		log.Println("Using MySQL readonly transactions (use_readonly_tx: true)")
+		status.Mode, status.Verified = backend.ReadonlyTx, true
+		return status
+	}
+	if cfg.BypassReadonlyCheck {
+		log.Printf("Skipping readonly verification (bypass_readonly_check: true)")
+		status.Mode = backend.ReadonlyNone
+		return status
+	}
+
+	status.Mode = backend.ReadonlyGrantCheck
+	var grants []string
+	if err := db.Raw("SHOW GRANTS FOR CURRENT_USER;").Scan(&grants).Error; err != nil {
+		status.Error = fmt.Sprintf("could not verify user permissions: %v", err)
+		return status
+	}
+	for _, g := range grants {
+		for _, p := range []string{"INSERT", "UPDATE", "DELETE", "DROP", "CREATE", "ALTER"} {
+			if strings.Contains(g, p) {
+				status.Error = "read DSN user has write permissions (set bypass_readonly_check: true to bypass)"
+				return status
 			}
 		}
-		log.Printf("Verified read connection is readonly")
-	} else {
-		log.Printf("Skipping readonly verification (bypass_readonly_check: true)")
 	}
+	log.Printf("Verified read connection is readonly")
+	status.Verified = true
+	return status
+}
 
-	return db, nil
+func (Connector) DowngradeReadonly(db DB) DB {
+	db.UseReadonlyTx = true
+	return db
 }
 
-func (Connector) ConnectAdmin(cfg AdminConfig) (*gorm.DB, error) {
+func (Connector) ConnectAdmin(cfg AdminConfig) (DB, error) {
 	log.Printf("Opening admin connection")
 	dsn := enableParseTime(cfg.DSN)
-	return gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logging.NewGormLogger()})
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logging.NewGormLogger()})
+	if err != nil {
+		return DB{}, err
+	}
+	if err := sqlcommon.ApplyPool(db, cfg.Pool); err != nil {
+		return DB{}, err
+	}
+	return DB{DB: db, boolColumns: newBoolColumnCache()}, nil
 }
 
 func enableParseTime(dsn string) string {
-	if strings.Contains(dsn, "parseTime=true") {
+	return withParam(dsn, "parseTime", "true")
+}
+
+// withParam appends key=value to the DSN unless the key is already set.
+func withParam(dsn, key, value string) string {
+	if strings.Contains(dsn, "?"+key+"=") || strings.Contains(dsn, "&"+key+"=") {
 		return dsn
 	}
 	if strings.Contains(dsn, "&") || strings.Contains(dsn, "?") {
-		return dsn + "&parseTime=true"
+		return dsn + "&" + key + "=" + value
 	}
-	return dsn + "?parseTime=true"
+	return dsn + "?" + key + "=" + value
 }
 
 func init() {
@@ -88,7 +165,7 @@ func init() {
 
 // Backend implements backend.SQLBackend for MySQL.
 type Backend struct {
-	db *gorm.DB
+	db DB
 }
 
 func (b *Backend) ListTables(ctx context.Context, in backend.ListTablesIn) ([]backend.Table, error) {
@@ -101,9 +178,133 @@ func (b *Backend) ListTables(ctx context.Context, in backend.ListTablesIn) ([]ba
 	for i, t := range tables {
 		result[i] = backend.Table{Name: t}
 	}
+	if in.WithStats {
+		sizes, err := b.TableSizes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		backend.AddTableSizes(result, sizes)
+	}
 	return result, nil
 }
 
+func (b *Backend) ListTablesWithoutPK(ctx context.Context, in backend.ListTablesWithoutPKIn) ([]backend.Table, error) {
+	names, err := sqlcommon.TablesWithoutPrimaryKey(ctx, b.db.DB, in.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]backend.Table, len(names))
+	for i, name := range names {
+		tables[i] = backend.Table{Schema: name.Schema, Name: name.Name}
+	}
+	return tables, nil
+}
+
+// ListSequences returns the AUTO_INCREMENT counter of each table in the schema,
+// or in the current database.
+func (b *Backend) ListSequences(ctx context.Context, in backend.ListSequencesIn) ([]backend.Sequence, error) {
+	var rows []struct {
+		Name       string
+		Column     string
+		NextValue  *int64
+		Increment  int64
+		DataType   string
+		IsUnsigned bool
+	}
+	err := b.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		// MySQL 8 caches AUTO_INCREMENT in information_schema for a day by default.
+		// Older servers and MariaDB have no cache, and no such variable.
+		err := conn.Exec("SET SESSION information_schema_stats_expiry = 0").Error
+		var mysqlErr *gomysql.MySQLError
+		switch {
+		case err == nil:
+			defer conn.Exec("SET SESSION information_schema_stats_expiry = DEFAULT")
+		case !errors.As(err, &mysqlErr) || mysqlErr.Number != 1193:
+			return err
+		}
+		return conn.Raw(`SELECT t.TABLE_NAME AS name, c.COLUMN_NAME AS `+"`column`"+`, t.AUTO_INCREMENT AS next_value,
+	@@auto_increment_increment AS increment, c.DATA_TYPE AS data_type, c.COLUMN_TYPE LIKE '%unsigned%' AS is_unsigned
+FROM information_schema.TABLES t
+JOIN information_schema.COLUMNS c ON c.TABLE_SCHEMA = t.TABLE_SCHEMA AND c.TABLE_NAME = t.TABLE_NAME AND c.EXTRA LIKE '%auto_increment%'
+WHERE t.TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND t.TABLE_TYPE = 'BASE TABLE'
+ORDER BY t.TABLE_NAME`, in.Schema).Scan(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sequences := make([]backend.Sequence, len(rows))
+	for i, r := range rows {
+		sequences[i] = backend.Sequence{
+			Schema:    in.Schema,
+			Name:      r.Name,
+			Table:     r.Name,
+			Column:    r.Column,
+			NextValue: r.NextValue,
+			Increment: r.Increment,
+			MaxValue:  integerMax(r.DataType, r.IsUnsigned),
+		}
+	}
+	return sequences, nil
+}
+
+// ListSchemas returns the databases on the server that the user can see.
+func (b *Backend) ListSchemas(ctx context.Context, in backend.ListSchemasIn) ([]string, error) {
+	schemas := []string{}
+	err := b.db.WithContext(ctx).Raw(`SELECT SCHEMA_NAME FROM information_schema.SCHEMATA
+WHERE ? OR SCHEMA_NAME NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')
+ORDER BY SCHEMA_NAME`, in.IncludeSystem).Scan(&schemas).Error
+	return schemas, err
+}
+
+// ListViews returns the views of the schema, or of the current database. MySQL
+// only shows the definition to users with SHOW VIEW on the view.
+func (b *Backend) ListViews(ctx context.Context, in backend.ListViewsIn) ([]backend.View, error) {
+	views := []backend.View{}
+	err := b.db.WithContext(ctx).Raw(`SELECT NULLIF(?, '') AS `+"`schema`"+`, TABLE_NAME AS name, VIEW_DEFINITION AS definition
+FROM information_schema.VIEWS
+WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE())
+ORDER BY TABLE_NAME`, in.Schema, in.Schema).Scan(&views).Error
+	return views, err
+}
+
+// integerMax returns the largest value of a MySQL integer type, or nil if it
+// does not fit in an int64.
+func integerMax(dataType string, unsigned bool) *int64 {
+	bits := map[string]int{"tinyint": 8, "smallint": 16, "mediumint": 24, "int": 32, "bigint": 64}[dataType]
+	if bits == 0 || bits == 64 && unsigned {
+		return nil
+	}
+	if !unsigned {
+		bits--
+	}
+	limit := int64(1)<<bits - 1
+	return &limit
+}
+
+// TableSizes implements backend.TableSizer. InnoDB row counts are estimates.
+func (b *Backend) TableSizes(ctx context.Context) ([]backend.TableSize, error) {
+	var sizes []backend.TableSize
+	err := b.db.WithContext(ctx).Raw(`SELECT TABLE_NAME AS name, COALESCE(TABLE_ROWS, 0) AS row_estimate, COALESCE(DATA_LENGTH, 0) + COALESCE(INDEX_LENGTH, 0) AS bytes
+FROM information_schema.TABLES
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE'`).Scan(&sizes).Error
+	return sizes, err
+}
+
+//go:embed inbound_foreign_keys.sql
+var inboundForeignKeysQuery string
+
+//go:embed list_foreign_keys.sql
+var listForeignKeysQuery string
+
+// ListForeignKeys returns the foreign keys of the tables in the schema, or in the current database.
+func (b *Backend) ListForeignKeys(ctx context.Context, in backend.ListForeignKeysIn) ([]backend.ForeignKey, error) {
+	foreignKeys := []backend.ForeignKey{}
+	err := b.db.WithContext(ctx).Raw(listForeignKeysQuery, in.Schema, in.Table, in.Table).Scan(&foreignKeys).Error
+	return foreignKeys, err
+}
+
 func (b *Backend) DescribeTable(ctx context.Context, in backend.DescribeTableIn) (*backend.TableDescription, error) {
 	var result struct {
 		Table       string `gorm:"column:Table"`
@@ -112,18 +313,149 @@ func (b *Backend) DescribeTable(ctx context.Context, in backend.DescribeTableIn)
 	if err := b.db.WithContext(ctx).Raw("SHOW CREATE TABLE ?", clause.Table{Name: in.Table}).Scan(&result).Error; err != nil {
 		return nil, err
 	}
-	return &backend.TableDescription{CreateTable: result.CreateTable}, nil
+	out := &backend.TableDescription{CreateTable: result.CreateTable}
+
+	var err error
+	if out.TableComment, out.ColumnComments, err = sqlcommon.GetComments(ctx, b.db.DB, "", in.Table); err != nil {
+		return nil, err
+	}
+
+	if err := b.db.WithContext(ctx).Raw(listForeignKeysQuery, "", in.Table, in.Table).Scan(&out.ForeignKeys).Error; err != nil {
+		return nil, err
+	}
+
+	if in.IncludeReferencedBy {
+		if err := b.db.WithContext(ctx).Raw(inboundForeignKeysQuery, in.Table).Scan(&out.ReferencedBy).Error; err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
 }
 
-func (b *Backend) ExecuteQuery(ctx context.Context, in backend.ReadQueryIn) (*backend.QueryResult, error) {
-	var rows []map[string]any
-	if err := b.db.WithContext(ctx).Raw(in.Query).Scan(&rows).Error; err != nil {
+func (b *Backend) ProfileCategoricalColumns(ctx context.Context, in backend.ProfileCategoricalColumnsIn) (*backend.ProfileCategoricalColumnsOut, error) {
+	name := in.Table
+	if in.Schema != "" {
+		name = in.Schema + "." + in.Table
+	}
+	columns, skipped, err := sqlcommon.ProfileCategoricalColumns(ctx, b.db.DB, clause.Table{Name: name}, in.MaxDistinct)
+	if err != nil {
 		return nil, err
 	}
-	return &backend.QueryResult{Rows: rows}, nil
+	return &backend.ProfileCategoricalColumnsOut{Columns: columns, Skipped: skipped}, nil
+}
+
+func (b *Backend) TableJSONSchema(ctx context.Context, in backend.TableJSONSchemaIn) (*backend.TableJSONSchemaOut, error) {
+	columns, err := sqlcommon.GetColumns(ctx, b.db.DB, in.Schema, in.Table)
+	if err != nil {
+		return nil, err
+	}
+	title := in.Table
+	if in.Schema != "" {
+		title = in.Schema + "." + in.Table
+	}
+	return &backend.TableJSONSchemaOut{JSONSchema: sqlcommon.TableJSONSchema("mysql", title, columns)}, nil
+}
+
+func (b *Backend) SampleTable(ctx context.Context, in backend.SampleTableIn) (*backend.QueryResult, error) {
+	name := in.Table
+	if in.Schema != "" {
+		name = in.Schema + "." + in.Table
+	}
+	return b.ExecuteQuery(ctx, backend.ReadQueryIn{Query: fmt.Sprintf("SELECT * FROM %s LIMIT %d", b.db.Statement.Quote(clause.Table{Name: name}), in.Limit)})
+}
+
+func (b *Backend) ExecuteQuery(ctx context.Context, in backend.ReadQueryIn) (*backend.QueryResult, error) {
+	db := b.db.WithContext(ctx)
+	if in.Schema != "" {
+		tenant, release, err := b.useDatabase(db, in.Schema)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		db = tenant
+	}
+
+	if sqlcommon.BoolNormalization(ctx) {
+		// The driver reports TINYINT(1) like any TINYINT and does not say which table a
+		// result column comes from, so boolean columns are found by name in the tables
+		// the query reads.
+		names, err := boolColumnNames(in.Query, in.Schema, func(schema string) (tableBoolColumns, error) {
+			return b.db.boolColumns.get(ctx, b.db.DB, schema)
+		})
+		if err != nil {
+			return nil, err
+		}
+		ctx = sqlcommon.WithBoolColumns(ctx, names)
+	}
+
+	if b.db.UseReadonlyTx {
+		// DDL commits the transaction implicitly before it runs, so only plain reads are let in.
+		if err := sqlcommon.CheckReadonlyTxStatement(in.Query); err != nil {
+			return nil, err
+		}
+		tx := db.Begin(&sql.TxOptions{ReadOnly: true})
+		if tx.Error != nil {
+			return nil, tx.Error
+		}
+		defer tx.Rollback()
+
+		rows, err := sqlcommon.QueryRows(ctx, tx, in.Query)
+		if err != nil {
+			return nil, sqlcommon.HighlightSyntaxError(err, in.Query, 0)
+		}
+		return backend.NewQueryResult(in.Query, rows), nil
+	}
+
+	rows, err := sqlcommon.QueryRows(ctx, db, in.Query)
+	if err != nil {
+		return nil, sqlcommon.HighlightSyntaxError(err, in.Query, 0)
+	}
+	return backend.NewQueryResult(in.Query, rows), nil
+}
+
+// useDatabase returns db pinned to a single connection whose default database is
+// name, which must be listed in tenant_databases. release discards the connection
+// instead of returning it to the pool, so no later query runs in the tenant database.
+func (b *Backend) useDatabase(db *gorm.DB, name string) (*gorm.DB, func(), error) {
+	if !slices.Contains(b.db.TenantDatabases, name) {
+		if len(b.db.TenantDatabases) == 0 {
+			return nil, nil, fmt.Errorf("schema %q is not allowed: this database has no tenant_databases configured", name)
+		}
+		return nil, nil, fmt.Errorf("schema %q is not allowed: use one of %s", name, strings.Join(b.db.TenantDatabases, ", "))
+	}
+
+	pool, err := db.DB()
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := pool.Conn(db.Statement.Context)
+	if err != nil {
+		return nil, nil, err
+	}
+	release := func() {
+		conn.Raw(func(any) error { return driver.ErrBadConn })
+		conn.Close()
+	}
+
+	db.Statement.ConnPool = conn
+	if err := db.Exec("USE ?", clause.Table{Name: name}).Error; err != nil {
+		release()
+		return nil, nil, err
+	}
+	return db, release, nil
 }
 
 func (b *Backend) ExplainQuery(ctx context.Context, in backend.ExplainQueryIn) (*backend.ExplainResult, error) {
+	db := b.db.WithContext(ctx)
+	if in.Schema != "" {
+		tenant, release, err := b.useDatabase(db, in.Schema)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		db = tenant
+	}
+
 	var explainQuery string
 	if in.Analyze {
 		explainQuery = fmt.Sprintf("EXPLAIN ANALYZE FORMAT=JSON %s", in.Query)
@@ -132,22 +464,79 @@ func (b *Backend) ExplainQuery(ctx context.Context, in backend.ExplainQueryIn) (
 	}
 
 	var planJSON string
-	if err := b.db.WithContext(ctx).Raw(explainQuery).Scan(&planJSON).Error; err != nil {
-		return nil, err
+	if err := db.Raw(explainQuery, in.Params...).Scan(&planJSON).Error; err != nil {
+		// MySQL reports syntax errors by line, which the EXPLAIN prefix does not shift.
+		return nil, sqlcommon.HighlightSyntaxError(err, in.Query, 0)
 	}
 
+	scans := fullScans(planJSON)
 	return &backend.ExplainResult{
-		Format:     "json",
-		Result:     planJSON,
-		ResultInfo: "The MySQL query plan as returned from the database",
+		Format:           "json",
+		Result:           planJSON,
+		ResultInfo:       "The MySQL query plan as returned from the database",
+		FullScanDetected: len(scans) > 0,
+		FullScans:        scans,
+		Plan:             planTree(planJSON),
+		ExecutedSQL:      sqlcommon.BoundSQL(b.db.DB, explainQuery, in.Params...),
 	}, nil
 }
 
+// fullScans returns the tables accessed with access_type ALL in a JSON plan.
+// Tables can be nested under nested_loop, ordering_operation, subqueries, etc.,
+// so the whole document is walked looking for "table" objects.
+func fullScans(planJSON string) []backend.TableScan {
+	var plan any
+	if err := json.Unmarshal([]byte(planJSON), &plan); err != nil {
+		return nil
+	}
+
+	var scans []backend.TableScan
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if t, ok := v["table"].(map[string]any); ok && t["access_type"] == "ALL" {
+				name, _ := t["table_name"].(string)
+				rows, _ := t["rows_examined_per_scan"].(float64)
+				scans = append(scans, backend.TableScan{Table: name, EstimatedRows: rows})
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(plan)
+	return scans
+}
+
 func (b *Backend) ExecuteDDL(ctx context.Context, in backend.ExecuteDDLIn) (*backend.DDLResult, error) {
-	if err := b.db.WithContext(ctx).Exec(in.DDL).Error; err != nil {
+	err := sqlcommon.RetryOnDeadlock(ctx, func() error {
+		return b.db.WithContext(ctx).Exec(in.DDL).Error
+	})
+	if err != nil {
+		return nil, sqlcommon.HighlightSyntaxError(err, in.DDL, 0)
+	}
+	return &backend.DDLResult{Success: true, Message: "DDL executed successfully", ExecutedSQL: in.DDL}, nil
+}
+
+func (b *Backend) ExecuteWrite(ctx context.Context, in backend.ExecuteWriteIn) (*backend.WriteResult, error) {
+	affected, err := sqlcommon.ExecuteWrite(ctx, b.db.DB, in.Query, in.Params)
+	if err != nil {
 		return nil, err
 	}
-	return &backend.DDLResult{Success: true, Message: "DDL executed successfully"}, nil
+	return &backend.WriteResult{RowsAffected: affected, ExecutedSQL: sqlcommon.BoundSQL(b.db.DB, in.Query, in.Params...)}, nil
+}
+
+func (b *Backend) CheckDDL(ctx context.Context, in backend.CheckDDLIn) (*sqlcommon.DDLCheckReport, error) {
+	return sqlcommon.CheckDDL(ctx, b.db.DB, in.DDL)
+}
+
+func (b *Backend) RecommendIndexes(ctx context.Context, in backend.RecommendIndexesIn) (*sqlcommon.IndexAdvice, error) {
+	return sqlcommon.RecommendIndexes(ctx, b.db.DB, in.Query)
 }
 
 func (b *Backend) ListMissingIndexes(ctx context.Context) ([]backend.MissingIndex, error) {
@@ -194,6 +583,43 @@ func (b *Backend) ListWaitingQueries(ctx context.Context) ([]backend.WaitingQuer
 	return result, nil
 }
 
+//go:embed list_connections.sql
+var connectionsQuery string
+
+func (b *Backend) ListConnections(ctx context.Context) ([]backend.Connection, error) {
+	var threads []struct {
+		ThreadID      int64   `gorm:"column:thread_id"`
+		Username      string  `gorm:"column:username"`
+		DatabaseName  string  `gorm:"column:database_name"`
+		ClientAddress string  `gorm:"column:client_address"`
+		Command       string  `gorm:"column:command"`
+		State         string  `gorm:"column:state"`
+		TimeSeconds   float64 `gorm:"column:time_seconds"`
+		QueryText     string  `gorm:"column:query_text"`
+	}
+	if err := b.db.WithContext(ctx).Raw(connectionsQuery).Scan(&threads).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]backend.Connection, len(threads))
+	for i, t := range threads {
+		state := t.Command
+		if t.State != "" {
+			state += ": " + t.State
+		}
+		result[i] = backend.Connection{
+			ID:               fmt.Sprintf("%d", t.ThreadID),
+			Username:         t.Username,
+			Database:         t.DatabaseName,
+			ClientAddress:    t.ClientAddress,
+			State:            state,
+			Query:            t.QueryText,
+			QueryDurationSec: t.TimeSeconds,
+		}
+	}
+	return result, nil
+}
+
 //go:embed list_slowest_queries.sql
 var slowestQueriesQuery string
 
@@ -205,6 +631,7 @@ func (b *Backend) ListSlowestQueries(ctx context.Context) (*backend.SlowQueryRes
 
 	return &backend.SlowQueryResult{
 		Columns: map[string]string{
+			"query_hash":         "Unique identifier for the normalized query (statement digest)",
 			"query":              "The normalized SQL query text",
 			"schema_name":        "Database schema name",
 			"calls":              "Number of times this query was executed",
@@ -248,3 +675,239 @@ func (b *Backend) ListDeadlocks(ctx context.Context) ([]backend.Deadlock, error)
 		},
 	}, nil
 }
+
+func (b *Backend) SetComment(ctx context.Context, in backend.SetCommentIn) (*backend.SetCommentOut, error) {
+	if err := sqlcommon.CheckColumn(ctx, b.db.DB, in.Schema, in.Table, in.Column); err != nil {
+		return nil, err
+	}
+	name := in.Table
+	if in.Schema != "" {
+		name = in.Schema + "." + in.Table
+	}
+
+	// COMMENT takes a literal rather than a bind parameter, so have the server quote it.
+	var literal string
+	if err := b.db.WithContext(ctx).Raw("SELECT QUOTE(?)", in.Comment).Scan(&literal).Error; err != nil {
+		return nil, err
+	}
+
+	// The statement is run without arguments: gorm would otherwise read @names in
+	// the comment as named parameters.
+	quoted := b.db.Statement.Quote(clause.Table{Name: name})
+	kind := "table"
+	stmt := "ALTER TABLE " + quoted + " COMMENT = " + literal
+	if in.Column != "" {
+		// MySQL only changes a column comment by restating the whole column definition,
+		// so take it from SHOW CREATE TABLE with the old comment cut out.
+		var result struct {
+			CreateTable string `gorm:"column:Create Table"`
+		}
+		if err := b.db.WithContext(ctx).Raw("SHOW CREATE TABLE ?", clause.Table{Name: name}).Scan(&result).Error; err != nil {
+			return nil, err
+		}
+		definition, ok := columnDefinition(result.CreateTable, in.Column)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", sqlcommon.ErrColumnNotFound, in.Column)
+		}
+		stmt = "ALTER TABLE " + quoted + " MODIFY COLUMN " + definition + " COMMENT " + literal
+	}
+	if err := b.db.WithContext(ctx).Exec(stmt).Error; err != nil {
+		return nil, err
+	}
+
+	if in.Column != "" {
+		kind, name = "column", name+"."+in.Column
+	}
+
+	out := &backend.SetCommentOut{Success: true, Message: fmt.Sprintf("Comment set on %s %s", kind, name)}
+	if in.Comment == "" {
+		out.Message = fmt.Sprintf("Comment removed from %s %s", kind, name)
+	}
+	return out, nil
+}
+
+// columnDefinition returns the definition of column in the output of SHOW CREATE
+// TABLE, without its trailing comma and COMMENT clause.
+func columnDefinition(createTable, column string) (string, bool) {
+	prefix := "`" + strings.ReplaceAll(column, "`", "``") + "` "
+	for line := range strings.Lines(createTable) {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		line = strings.TrimSuffix(line, ",")
+		start := strings.Index(line, " COMMENT '")
+		if start < 0 {
+			return line, true
+		}
+		// Skip to the quote closing the comment; quotes inside it are doubled or escaped.
+		end := start + len(" COMMENT '")
+		for end < len(line) {
+			switch {
+			case line[end] == '\\':
+				end += 2
+			case line[end] == '\'' && end+1 < len(line) && line[end+1] == '\'':
+				end += 2
+			case line[end] == '\'':
+				return line[:start] + line[end+1:], true
+			default:
+				end++
+			}
+		}
+		return line[:start], true
+	}
+	return "", false
+}
+
+func (b *Backend) KillIdleTransactions(ctx context.Context, in backend.KillIdleTransactionsIn) (*backend.KillIdleTransactionsOut, error) {
+	return nil, fmt.Errorf("killing idle transactions is only available for PostgreSQL")
+}
+
+func (b *Backend) AnalyzeTable(ctx context.Context, in backend.AnalyzeTableIn) (*backend.AnalyzeTableOut, error) {
+	if in.Table == "" {
+		return b.analyzeAll(ctx, in.Schema)
+	}
+	name := in.Table
+	if in.Schema != "" {
+		name = in.Schema + "." + in.Table
+	}
+	if err := b.analyze(ctx, name, b.db.Statement.Quote(clause.Table{Name: name})); err != nil {
+		return nil, err
+	}
+
+	out := &backend.AnalyzeTableOut{Success: true, Message: fmt.Sprintf("Statistics refreshed for %s", name)}
+
+	// Persistent InnoDB statistics record when they were collected; reading them needs
+	// SELECT on the mysql schema, so a failure here only drops the timestamp.
+	var updated sql.NullTime
+	err := b.db.WithContext(ctx).Raw(
+		"SELECT last_update FROM mysql.innodb_table_stats WHERE database_name = COALESCE(NULLIF(?, ''), DATABASE()) AND table_name = ?",
+		in.Schema, in.Table,
+	).Row().Scan(&updated)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Could not read statistics timestamp for %s: %v", name, err)
+	}
+	if updated.Valid {
+		out.StatsUpdatedAt = &updated.Time
+	}
+	return out, nil
+}
+
+// analyzeAll refreshes the statistics of every table in schema, or in the current
+// database if schema is empty.
+func (b *Backend) analyzeAll(ctx context.Context, schema string) (*backend.AnalyzeTableOut, error) {
+	var tables []string
+	err := b.db.WithContext(ctx).Raw(
+		"SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME",
+		schema,
+	).Scan(&tables).Error
+	if err != nil {
+		return nil, err
+	}
+	scope := "the database"
+	if schema != "" {
+		scope = schema
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("%s has no tables", scope)
+	}
+
+	quoted := make([]string, len(tables))
+	for i, t := range tables {
+		name := t
+		if schema != "" {
+			name = schema + "." + t
+		}
+		quoted[i] = b.db.Statement.Quote(clause.Table{Name: name})
+	}
+	if err := b.analyze(ctx, scope, strings.Join(quoted, ", ")); err != nil {
+		return nil, err
+	}
+	return &backend.AnalyzeTableOut{Success: true, Message: fmt.Sprintf("Statistics refreshed for %d tables in %s", len(tables), scope)}, nil
+}
+
+// analyze runs ANALYZE TABLE on a quoted, comma-separated list of tables. name
+// describes the tables in errors.
+func (b *Backend) analyze(ctx context.Context, name, tables string) error {
+	// ANALYZE TABLE reports failures (e.g. a missing table) as result rows, not errors.
+	var results []struct {
+		MsgType string `gorm:"column:Msg_type"`
+		MsgText string `gorm:"column:Msg_text"`
+	}
+	if err := b.db.WithContext(ctx).Raw("ANALYZE TABLE " + tables).Scan(&results).Error; err != nil {
+		return err
+	}
+	for _, r := range results {
+		if strings.EqualFold(r.MsgType, "error") {
+			return fmt.Errorf("analyze table %s: %s", name, r.MsgText)
+		}
+	}
+	return nil
+}
+
+func (b *Backend) TableProfile(ctx context.Context, in backend.TableProfileIn) (*backend.TableProfile, error) {
+	db := b.db.WithContext(ctx)
+
+	var stats []struct {
+		Schema      string `gorm:"column:schema_name"`
+		Table       string `gorm:"column:table_name"`
+		RowEstimate int64  `gorm:"column:row_estimate"`
+		TableBytes  int64  `gorm:"column:table_bytes"`
+		IndexBytes  int64  `gorm:"column:index_bytes"`
+	}
+	err := db.Raw(`SELECT TABLE_SCHEMA AS schema_name, TABLE_NAME AS table_name, COALESCE(TABLE_ROWS, 0) AS row_estimate,
+	COALESCE(DATA_LENGTH, 0) AS table_bytes, COALESCE(INDEX_LENGTH, 0) AS index_bytes
+FROM information_schema.TABLES
+WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ? AND TABLE_TYPE = 'BASE TABLE'`, in.Schema, in.Table).Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return nil, sqlcommon.ErrTableNotFound
+	}
+	t := stats[0]
+	out := &backend.TableProfile{
+		Schema:      t.Schema,
+		Table:       t.Table,
+		RowEstimate: t.RowEstimate,
+		TableBytes:  t.TableBytes,
+		IndexBytes:  t.IndexBytes,
+		TotalBytes:  t.TableBytes + t.IndexBytes,
+	}
+
+	err = db.Raw(`SELECT DISTINCT INDEX_NAME AS name FROM information_schema.STATISTICS
+WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY name`, t.Schema, t.Table).Scan(&out.Indexes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Per-index sizes and the statistics timestamp come from the persistent InnoDB
+	// statistics, which need SELECT on the mysql schema, so failures only drop them.
+	var sizes []struct {
+		Name  string `gorm:"column:index_name"`
+		Bytes int64  `gorm:"column:bytes"`
+	}
+	err = db.Raw(`SELECT index_name, stat_value * @@innodb_page_size AS bytes FROM mysql.innodb_index_stats
+WHERE database_name = ? AND table_name = ? AND stat_name = 'size'`, t.Schema, t.Table).Scan(&sizes).Error
+	if err != nil {
+		log.Printf("Could not read index sizes for %s.%s: %v", t.Schema, t.Table, err)
+	}
+	for _, size := range sizes {
+		for i := range out.Indexes {
+			if out.Indexes[i].Name == size.Name {
+				out.Indexes[i].Bytes = size.Bytes
+			}
+		}
+	}
+	slices.SortStableFunc(out.Indexes, func(a, b backend.IndexProfile) int { return cmp.Compare(b.Bytes, a.Bytes) })
+
+	var updated sql.NullTime
+	err = db.Raw("SELECT last_update FROM mysql.innodb_table_stats WHERE database_name = ? AND table_name = ?", t.Schema, t.Table).Row().Scan(&updated)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Could not read statistics timestamp for %s.%s: %v", t.Schema, t.Table, err)
+	}
+	if updated.Valid {
+		out.LastAnalyze = &updated.Time
+	}
+	return out, nil
+}
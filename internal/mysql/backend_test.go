@@ -0,0 +1,20 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinternet/databaise/internal/backend"
+)
+
+func TestListBackends(t *testing.T) {
+	for _, b := range backend.ListBackends().Backends {
+		if b.Type == "mysql" {
+			require.NotContains(t, b.Tools, "list_missing_indexes")
+			require.NotContains(t, b.Tools, "list_materialized_views")
+			require.NotContains(t, b.Tools, "refresh_materialized_view")
+			return
+		}
+	}
+	t.Fatal("mysql backend is not registered")
+}
@@ -0,0 +1,7 @@
+SELECT
+    TABLE_NAME AS table_name,
+    COLUMN_NAME AS column_name,
+    COLUMN_TYPE IN ('tinyint(1)', 'bit(1)') AS is_bool
+FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE())
+  AND DATA_TYPE IN ('tinyint', 'bit')
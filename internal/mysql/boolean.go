@@ -0,0 +1,117 @@
+package mysql
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tinternet/databaise/internal/sqlcommon"
+	"gorm.io/gorm"
+)
+
+// boolColumnsTTL is how long the boolean columns of a database are cached for
+// normalize_booleans before they are read from the catalog again.
+const boolColumnsTTL = time.Minute
+
+// boolColumnsQuery lists the TINYINT and BIT columns of a database (the
+// connection's default one if empty), with whether each is a TINYINT(1) or BIT(1)
+// boolean.
+//
+//go:embed bool_columns.sql
+var boolColumnsQuery string
+
+// tableBoolColumns maps the TINYINT and BIT columns of each table, by lower-cased
+// table name, to whether they hold booleans.
+type tableBoolColumns map[string]map[string]bool
+
+// boolColumnCache holds the tableBoolColumns of each database, so execute_query
+// does not read the catalog on every call.
+type boolColumnCache struct {
+	mu      sync.Mutex
+	entries map[string]boolColumnsEntry
+}
+
+type boolColumnsEntry struct {
+	tables  tableBoolColumns
+	expires time.Time
+}
+
+func newBoolColumnCache() *boolColumnCache {
+	return &boolColumnCache{entries: make(map[string]boolColumnsEntry)}
+}
+
+// get returns the columns of database schema ("" for the connection's default
+// one), reading them from the catalog if they are not cached. A nil cache reads
+// them every time.
+func (c *boolColumnCache) get(ctx context.Context, db *gorm.DB, schema string) (tableBoolColumns, error) {
+	if c != nil {
+		c.mu.Lock()
+		entry, ok := c.entries[schema]
+		c.mu.Unlock()
+		if ok && time.Now().Before(entry.expires) {
+			return entry.tables, nil
+		}
+	}
+
+	var rows []struct {
+		Table  string `gorm:"column:table_name"`
+		Column string `gorm:"column:column_name"`
+		IsBool bool   `gorm:"column:is_bool"`
+	}
+	if err := db.WithContext(ctx).Raw(boolColumnsQuery, schema).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	tables := make(tableBoolColumns)
+	for _, r := range rows {
+		table := strings.ToLower(r.Table)
+		if tables[table] == nil {
+			tables[table] = make(map[string]bool)
+		}
+		tables[table][r.Column] = r.IsBool
+	}
+
+	if c != nil {
+		c.mu.Lock()
+		c.entries[schema] = boolColumnsEntry{tables: tables, expires: time.Now().Add(boolColumnsTTL)}
+		c.mu.Unlock()
+	}
+	return tables, nil
+}
+
+// boolColumnNames returns the result columns of query to normalize: the columns
+// that are TINYINT(1) or BIT(1) in a table the query reads and a TINYINT or BIT of
+// another width in none of them, so a TINYINT(4) that shares its name with a
+// boolean elsewhere is left alone. Unqualified tables are looked up in
+// defaultSchema, and load returns the columns of a database.
+func boolColumnNames(query, defaultSchema string, load func(schema string) (tableBoolColumns, error)) ([]string, error) {
+	bySchema := make(map[string]tableBoolColumns)
+	isBool := make(map[string]bool)
+	for _, t := range sqlcommon.QueryTables(query) {
+		schema := cmp.Or(t.Schema, defaultSchema)
+		tables, ok := bySchema[schema]
+		if !ok {
+			var err error
+			if tables, err = load(schema); err != nil {
+				return nil, err
+			}
+			bySchema[schema] = tables
+		}
+		for column, boolean := range tables[strings.ToLower(t.Name)] {
+			prev, seen := isBool[column]
+			isBool[column] = boolean && (!seen || prev)
+		}
+	}
+
+	var names []string
+	for column, boolean := range isBool {
+		if boolean {
+			names = append(names, column)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
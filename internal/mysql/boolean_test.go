@@ -0,0 +1,50 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoolColumnNames(t *testing.T) {
+	catalog := map[string]tableBoolColumns{
+		"": {
+			"flags":  {"active": true, "level": false},
+			"levels": {"level": true},
+			"users":  {"active": false},
+		},
+		"tenant_a": {"flags": {"level": true}},
+	}
+	var loaded []string
+	load := func(schema string) (tableBoolColumns, error) {
+		loaded = append(loaded, schema)
+		return catalog[schema], nil
+	}
+
+	for _, tt := range []struct {
+		query, schema string
+		want          []string
+	}{
+		{"SELECT * FROM flags", "", []string{"active"}},
+		// level is TINYINT(1) in levels but not in flags, and active the other way round.
+		{"SELECT * FROM levels", "", []string{"level"}},
+		{"SELECT * FROM flags JOIN levels USING (level)", "", []string{"active"}},
+		{"SELECT * FROM flags f, users u", "", nil},
+		{"SELECT * FROM Flags", "", []string{"active"}},
+		{"SELECT * FROM flags", "tenant_a", []string{"level"}},
+		{"SELECT * FROM tenant_a.flags JOIN levels ON 1 = 1", "", []string{"level"}},
+		{"SELECT 1 AS active", "", nil},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			names, err := boolColumnNames(tt.query, tt.schema, load)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, names)
+		})
+	}
+
+	// Each database is loaded once per query.
+	loaded = nil
+	_, err := boolColumnNames("SELECT * FROM flags JOIN levels JOIN users", "", load)
+	require.NoError(t, err)
+	require.Equal(t, []string{""}, loaded)
+}
@@ -3,10 +3,13 @@
 package mysql
 
 import (
+	"slices"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"github.com/tinternet/databaise/internal/backend"
+	"github.com/tinternet/databaise/internal/sqlcommon"
 	"github.com/tinternet/databaise/internal/sqltest"
 )
 
@@ -15,7 +18,7 @@ func openTestConnection(t *testing.T) *Backend {
 	dsn := sqltest.SetupMySqlContainer(t)
 	db, err := Connector{}.ConnectAdmin(AdminConfig{DSN: dsn})
 	require.NoError(t, err)
-	sqltest.Seed(t, db)
+	sqltest.Seed(t, db.DB)
 	return &Backend{db: db}
 }
 
@@ -25,8 +28,12 @@ func TestConnect(t *testing.T) {
 
 	t.Run("ReadOnly With BypassReadonlyCheck=false", func(t *testing.T) {
 		t.Parallel()
-		_, err := Connector{}.ConnectRead(ReadConfig{DSN: dsn})
-		require.ErrorContains(t, err, "read DSN user has write permissions")
+		db, err := Connector{}.ConnectRead(ReadConfig{DSN: dsn})
+		require.NoError(t, err)
+		status := Connector{}.CheckReadonly(ReadConfig{DSN: dsn}, db)
+		require.Equal(t, backend.ReadonlyGrantCheck, status.Mode)
+		require.False(t, status.Verified)
+		require.Contains(t, status.Error, "read DSN user has write permissions")
 	})
 
 	t.Run("ReadOnly With BypassReadonlyCheck=true", func(t *testing.T) {
@@ -34,6 +41,7 @@ func TestConnect(t *testing.T) {
 		db, err := Connector{}.ConnectRead(ReadConfig{DSN: dsn, BypassReadonlyCheck: true})
 		require.NotNil(t, db)
 		require.NoError(t, err)
+		require.Equal(t, backend.ReadonlyNone, Connector{}.CheckReadonly(ReadConfig{DSN: dsn, BypassReadonlyCheck: true}, db).Mode)
 	})
 
 	t.Run("Admin", func(t *testing.T) {
@@ -55,6 +63,103 @@ func TestListTables(t *testing.T) {
 	})
 }
 
+func TestListTablesWithStats(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	tables, err := b.ListTables(t.Context(), backend.ListTablesIn{WithStats: true})
+	require.NoError(t, err)
+	i := slices.IndexFunc(tables, func(tbl backend.Table) bool { return tbl.Name == "users" })
+	require.GreaterOrEqual(t, i, 0)
+	require.Positive(t, tables[i].SizeBytes)
+}
+
+func TestListTablesWithoutPK(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	require.NoError(t, b.db.Exec("CREATE TABLE events (payload text)").Error)
+	require.NoError(t, b.db.Exec("CREATE TABLE tags (name varchar(50) UNIQUE)").Error)
+
+	tables, err := b.ListTablesWithoutPK(t.Context(), backend.ListTablesWithoutPKIn{})
+	require.NoError(t, err)
+	require.Equal(t, []backend.Table{{Name: "events"}, {Name: "tags"}}, tables)
+}
+
+func TestListSequences(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	sequences, err := b.ListSequences(t.Context(), backend.ListSequencesIn{})
+	require.NoError(t, err)
+	require.Len(t, sequences, 2)
+	require.Equal(t, "users", sequences[1].Name)
+	require.Equal(t, "id", sequences[1].Column)
+	require.EqualValues(t, 4, *sequences[1].NextValue)
+	require.EqualValues(t, 1, sequences[1].Increment)
+	// gorm.Model IDs are BIGINT UNSIGNED, whose maximum does not fit in max_value.
+	require.Nil(t, sequences[1].MaxValue)
+
+	require.NoError(t, b.db.Exec("CREATE TABLE tickets (id TINYINT AUTO_INCREMENT PRIMARY KEY)").Error)
+	require.NoError(t, b.db.Exec("INSERT INTO tickets VALUES (), ()").Error)
+	sequences, err = b.ListSequences(t.Context(), backend.ListSequencesIn{})
+	require.NoError(t, err)
+	require.Equal(t, "tickets", sequences[1].Name)
+	require.EqualValues(t, 3, *sequences[1].NextValue)
+	require.EqualValues(t, 127, *sequences[1].MaxValue)
+}
+
+func TestListSchemas(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	var current string
+	require.NoError(t, b.db.Raw("SELECT DATABASE()").Scan(&current).Error)
+
+	schemas, err := b.ListSchemas(t.Context(), backend.ListSchemasIn{})
+	require.NoError(t, err)
+	require.Contains(t, schemas, current)
+	require.NotContains(t, schemas, "information_schema")
+
+	schemas, err = b.ListSchemas(t.Context(), backend.ListSchemasIn{IncludeSystem: true})
+	require.NoError(t, err)
+	require.Contains(t, schemas, "information_schema")
+}
+
+func TestListViews(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	require.NoError(t, b.db.Exec("CREATE VIEW admins AS SELECT username, email FROM users WHERE role = 'admin'").Error)
+
+	views, err := b.ListViews(t.Context(), backend.ListViewsIn{})
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	require.Equal(t, "admins", views[0].Name)
+	require.Contains(t, views[0].Definition, "users")
+}
+
+func TestListForeignKeys(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	foreignKeys, err := b.ListForeignKeys(t.Context(), backend.ListForeignKeysIn{})
+	require.NoError(t, err)
+	require.Len(t, foreignKeys, 1)
+	fk := foreignKeys[0]
+	require.Equal(t, "orders", fk.Table)
+	require.Equal(t, "user_id", fk.Columns)
+	require.Equal(t, "users", fk.ReferencedTable)
+	require.Equal(t, "id", fk.ReferencedColumns)
+	require.NotEmpty(t, fk.OnUpdate)
+	require.NotEmpty(t, fk.OnDelete)
+
+	foreignKeys, err = b.ListForeignKeys(t.Context(), backend.ListForeignKeysIn{Table: "users"})
+	require.NoError(t, err)
+	require.Empty(t, foreignKeys)
+
+	res, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Table: "orders"})
+	require.NoError(t, err)
+	require.Len(t, res.ForeignKeys, 1)
+	require.Equal(t, "users", res.ForeignKeys[0].ReferencedTable)
+}
+
 func TestDescribeTable(t *testing.T) {
 	t.Parallel()
 	b := openTestConnection(t)
@@ -67,6 +172,16 @@ func TestDescribeTable(t *testing.T) {
 		require.Contains(t, res.CreateTable, "CREATE TABLE")
 		require.Contains(t, res.CreateTable, "KEY") // indexes are included in the table DDL
 	})
+	t.Run("ReferencedBy", func(t *testing.T) {
+		t.Parallel()
+		res, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Table: "users", IncludeReferencedBy: true})
+		require.NoError(t, err)
+		require.Len(t, res.ReferencedBy, 1)
+		require.Equal(t, "orders", res.ReferencedBy[0].Table)
+		require.Equal(t, "user_id", res.ReferencedBy[0].Columns)
+		require.Equal(t, "id", res.ReferencedBy[0].ReferencedColumns)
+		require.NotEmpty(t, res.ReferencedBy[0].OnDelete)
+	})
 	t.Run("NonExistentTable", func(t *testing.T) {
 		t.Parallel()
 		res, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Table: "nonexistent"})
@@ -94,6 +209,72 @@ func TestExecuteQuery(t *testing.T) {
 		require.NoError(t, err)
 		require.Len(t, res.Rows, 0)
 	})
+
+	t.Run("UseReadonlyTx", func(t *testing.T) {
+		t.Parallel()
+		b := &Backend{db: DB{DB: b.db.DB, UseReadonlyTx: true}}
+
+		res, err := b.ExecuteQuery(t.Context(), backend.ReadQueryIn{Query: "SELECT * FROM orders"})
+		require.NoError(t, err)
+		require.Len(t, res.Rows, 2)
+
+		_, err = b.ExecuteQuery(t.Context(), backend.ReadQueryIn{Query: "DELETE FROM orders"})
+		require.ErrorIs(t, err, sqlcommon.ErrReadonlyViolation)
+
+		_, err = b.ExecuteQuery(t.Context(), backend.ReadQueryIn{Query: "SELECT 1; COMMIT"})
+		require.ErrorIs(t, err, sqlcommon.ErrReadonlyViolation)
+	})
+
+	t.Run("Booleans", func(t *testing.T) {
+		t.Parallel()
+		require.NoError(t, b.db.Exec("CREATE TABLE flags (active TINYINT(1), level TINYINT, bit1 BIT(1))").Error)
+		require.NoError(t, b.db.Exec("INSERT INTO flags VALUES (1, 1, b'1')").Error)
+		// level is a boolean here, but not in flags.
+		require.NoError(t, b.db.Exec("CREATE TABLE flag_levels (level TINYINT(1))").Error)
+		require.NoError(t, b.db.Exec("INSERT INTO flag_levels VALUES (1)").Error)
+
+		ctx := sqlcommon.WithBoolNormalization(t.Context(), true)
+		res, err := b.ExecuteQuery(ctx, backend.ReadQueryIn{Query: "SELECT * FROM flags"})
+		require.NoError(t, err)
+		require.Equal(t, true, res.Rows[0]["active"])
+		require.EqualValues(t, 1, res.Rows[0]["level"])
+		require.Equal(t, true, res.Rows[0]["bit1"])
+
+		res, err = b.ExecuteQuery(ctx, backend.ReadQueryIn{Query: "SELECT * FROM flag_levels"})
+		require.NoError(t, err)
+		require.Equal(t, true, res.Rows[0]["level"])
+	})
+
+	t.Run("Schema", func(t *testing.T) {
+		t.Parallel()
+		require.NoError(t, b.db.Exec("CREATE DATABASE tenant_a").Error)
+		require.NoError(t, b.db.Exec("CREATE TABLE tenant_a.orders (id INT PRIMARY KEY)").Error)
+		require.NoError(t, b.db.Exec("INSERT INTO tenant_a.orders VALUES (1), (2), (3)").Error)
+		b := &Backend{db: DB{DB: b.db.DB, TenantDatabases: []string{"tenant_a"}}}
+
+		res, err := b.ExecuteQuery(t.Context(), backend.ReadQueryIn{Query: "SELECT * FROM orders", Schema: "tenant_a"})
+		require.NoError(t, err)
+		require.Len(t, res.Rows, 3)
+
+		// The tenant connection is not reused for queries without a schema.
+		for range 5 {
+			res, err = b.ExecuteQuery(t.Context(), backend.ReadQueryIn{Query: "SELECT DATABASE() AS db"})
+			require.NoError(t, err)
+			require.NotEqual(t, "tenant_a", res.Rows[0]["db"])
+		}
+
+		_, err = b.ExecuteQuery(t.Context(), backend.ReadQueryIn{Query: "SELECT 1", Schema: "mysql"})
+		require.ErrorContains(t, err, "use one of tenant_a")
+
+		// Tables only the tenant database has are explained in it.
+		require.NoError(t, b.db.Exec("CREATE TABLE tenant_a.invoices (id INT PRIMARY KEY)").Error)
+		plan, err := b.ExplainQuery(t.Context(), backend.ExplainQueryIn{Query: "SELECT * FROM invoices", Schema: "tenant_a"})
+		require.NoError(t, err)
+		require.Contains(t, plan.Result, "invoices")
+
+		_, err = b.ExplainQuery(t.Context(), backend.ExplainQueryIn{Query: "SELECT * FROM invoices"})
+		require.Error(t, err)
+	})
 }
 
 func TestExecuteDDL(t *testing.T) {
@@ -123,6 +304,93 @@ func TestExecuteDDL(t *testing.T) {
 	})
 }
 
+func TestExecuteWrite(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	res, err := b.ExecuteWrite(t.Context(), backend.ExecuteWriteIn{
+		Query:  "UPDATE users SET bio = ? WHERE username = ?",
+		Params: []any{"updated", "admin_user"},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, res.RowsAffected)
+
+	t.Run("RefusesDDL", func(t *testing.T) {
+		_, err := b.ExecuteWrite(t.Context(), backend.ExecuteWriteIn{Query: "DROP TABLE orders"})
+		require.ErrorContains(t, err, "only INSERT, UPDATE and DELETE")
+	})
+}
+
+func TestCheckDDL(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	t.Run("UniqueIndexWithDuplicates", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "CREATE UNIQUE INDEX ix_users_active ON users (active)"})
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+		require.Len(t, report.Checks, 1)
+		require.EqualValues(t, 1, report.Checks[0].Violations)
+		require.Len(t, report.Checks[0].Samples, 1)
+	})
+
+	t.Run("UniqueIndex", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "CREATE UNIQUE INDEX ix_users_email ON users (email)"})
+		require.NoError(t, err)
+		require.True(t, report.WouldSucceed)
+	})
+
+	t.Run("ModifyNotNull", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "ALTER TABLE orders MODIFY COLUMN shipped_at datetime(3) NOT NULL"})
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+		require.EqualValues(t, 2, report.Checks[0].Violations)
+	})
+
+	t.Run("Check", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "ALTER TABLE users ADD CONSTRAINT ck_users_salary CHECK (salary > 0)"})
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+		require.EqualValues(t, 1, report.Checks[0].Violations)
+	})
+
+	t.Run("ForeignKeyToPrimaryKey", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "ALTER TABLE users ADD CONSTRAINT fk_users_age FOREIGN KEY (age) REFERENCES orders (id)"})
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+		require.EqualValues(t, 3, report.Checks[0].Violations)
+	})
+
+	t.Run("NotApplied", func(t *testing.T) {
+		res, err := b.ExecuteDDL(t.Context(), backend.ExecuteDDLIn{DDL: "CREATE UNIQUE INDEX ix_users_email ON users (email)"})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+	})
+}
+
+func TestRecommendIndexes(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	t.Run("EqualitySortRange", func(t *testing.T) {
+		t.Parallel()
+		advice, err := b.RecommendIndexes(t.Context(), backend.RecommendIndexesIn{Query: "SELECT username FROM users WHERE role = ? AND age > ? ORDER BY salary", Params: []any{"admin", 18}})
+		require.NoError(t, err)
+		require.Len(t, advice.Recommendations, 1)
+		rec := advice.Recommendations[0]
+		require.Equal(t, []string{"role", "salary", "age"}, rec.Columns[:3])
+		require.Contains(t, rec.CreateStatement, "CREATE INDEX")
+	})
+	t.Run("ExistingIndex", func(t *testing.T) {
+		t.Parallel()
+		advice, err := b.RecommendIndexes(t.Context(), backend.RecommendIndexesIn{Query: "SELECT id FROM orders WHERE user_id = ?", Params: []any{1}})
+		require.NoError(t, err)
+		require.Empty(t, advice.Recommendations)
+		require.Len(t, advice.Notes, 1)
+		require.Contains(t, advice.Notes[0], "idx_orders_user_id")
+	})
+}
+
 func TestExplainQuery(t *testing.T) {
 	t.Parallel()
 	b := openTestConnection(t)
@@ -133,6 +401,12 @@ func TestExplainQuery(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "json", res.Format)
 		require.Greater(t, len(res.Result), 1)
+		require.Len(t, res.Plan, 1)
+		require.Len(t, res.Plan[0].Children, 1)
+		scan := res.Plan[0].Children[0].(backend.PlanNode)
+		require.Equal(t, "Full Table Scan", scan.Operation)
+		require.Equal(t, "orders", scan.Table)
+		require.True(t, res.FullScanDetected)
 	})
 	t.Run("ExplainAnalyze", func(t *testing.T) {
 		t.Parallel()
@@ -140,6 +414,14 @@ func TestExplainQuery(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "json", res.Format)
 		require.Greater(t, len(res.Result), 1)
+		require.NotEmpty(t, res.Plan)
+	})
+	t.Run("ExplainWithParams", func(t *testing.T) {
+		t.Parallel()
+		res, err := b.ExplainQuery(t.Context(), backend.ExplainQueryIn{Query: "SELECT * FROM orders WHERE id = ?", Params: []any{1}})
+		require.NoError(t, err)
+		require.Equal(t, "json", res.Format)
+		require.Greater(t, len(res.Result), 1)
 	})
 
 	t.Run("MalformedQuery", func(t *testing.T) {
@@ -164,6 +446,24 @@ func TestListWaitingQueries(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestListConnections(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	// Hold a second session open in a transaction.
+	tx := b.db.Begin()
+	defer tx.Rollback()
+	var id int
+	require.NoError(t, tx.Raw("SELECT CONNECTION_ID()").Scan(&id).Error)
+
+	connections, err := b.ListConnections(t.Context())
+	require.NoError(t, err)
+	idx := slices.IndexFunc(connections, func(c backend.Connection) bool { return c.ID == strconv.Itoa(id) })
+	require.NotEqual(t, -1, idx, "the held session is listed")
+	require.NotEmpty(t, connections[idx].Username)
+	require.Equal(t, "Sleep", connections[idx].State)
+}
+
 func TestListSlowestQueries(t *testing.T) {
 	t.Parallel()
 	b := openTestConnection(t)
@@ -173,6 +473,7 @@ func TestListSlowestQueries(t *testing.T) {
 	require.NotNil(t, res.Columns)
 	require.NotNil(t, res.Queries)
 	// Verify expected columns are documented
+	require.Contains(t, res.Columns, "query_hash")
 	require.Contains(t, res.Columns, "query")
 	require.Contains(t, res.Columns, "calls")
 	require.Contains(t, res.Columns, "total_time_sec")
@@ -187,3 +488,153 @@ func TestListDeadlocks(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, res)
 }
+
+func TestSampleTable(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	res, err := b.SampleTable(t.Context(), backend.SampleTableIn{Table: "users", Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, 2, res.RowCount)
+	require.Contains(t, res.Columns, "username")
+	require.NotEmpty(t, res.Rows[0]["username"])
+
+	_, err = b.SampleTable(t.Context(), backend.SampleTableIn{Table: "nonexistent", Limit: 2})
+	require.Error(t, err)
+}
+
+func TestAnalyzeTable(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	t.Run("Success", func(t *testing.T) {
+		res, err := b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{Table: "users"})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+	})
+
+	t.Run("WholeDatabase", func(t *testing.T) {
+		res, err := b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+		require.Contains(t, res.Message, "tables in the database")
+	})
+
+	t.Run("NonExistentTable", func(t *testing.T) {
+		res, err := b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{Table: "nonexistent"})
+		require.Nil(t, res)
+		require.ErrorContains(t, err, "doesn't exist")
+	})
+}
+
+func TestGetPrimaryKey(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	columns, err := sqlcommon.GetPrimaryKey(t.Context(), b.db.DB, "", "users")
+	require.NoError(t, err)
+	require.Equal(t, []string{"id"}, columns)
+
+	_, err = sqlcommon.GetPrimaryKey(t.Context(), b.db.DB, "", "nonexistent")
+	require.ErrorIs(t, sqlcommon.TranslateError(err), sqlcommon.ErrTableNotFound)
+}
+
+func TestTableProfile(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	profile, err := b.TableProfile(t.Context(), backend.TableProfileIn{Schema: "", Table: "orders"})
+	require.NoError(t, err)
+	require.Equal(t, "orders", profile.Table)
+	require.Positive(t, profile.TotalBytes)
+	require.NotEmpty(t, profile.Indexes)
+	_, err = b.TableProfile(t.Context(), backend.TableProfileIn{Schema: "", Table: "nonexistent"})
+	require.ErrorIs(t, sqlcommon.TranslateError(err), sqlcommon.ErrTableNotFound)
+}
+
+func TestProfileCategoricalColumns(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	out, err := b.ProfileCategoricalColumns(t.Context(), backend.ProfileCategoricalColumnsIn{Schema: "", Table: "users", MaxDistinct: 2})
+	require.NoError(t, err)
+	require.Contains(t, out.Skipped, "email")
+
+	var active *sqlcommon.CategoricalColumn
+	for i, c := range out.Columns {
+		if c.Name == "active" {
+			active = &out.Columns[i]
+		}
+	}
+	require.NotNil(t, active)
+	require.Len(t, active.Values, 1)
+	require.EqualValues(t, 3, active.Values[0].Count)
+}
+
+func TestTableJSONSchema(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	out, err := b.TableJSONSchema(t.Context(), backend.TableJSONSchemaIn{Schema: "", Table: "users"})
+	require.NoError(t, err)
+	require.Equal(t, "object", out.JSONSchema["type"])
+	require.Subset(t, out.JSONSchema["required"], []string{"username", "email"})
+	require.NotContains(t, out.JSONSchema["required"], "role")
+
+	properties := out.JSONSchema["properties"].(map[string]any)
+	require.Equal(t, "integer", properties["id"].(map[string]any)["type"])
+	require.Equal(t, 50, properties["username"].(map[string]any)["maxLength"])
+	require.Equal(t, "date-time", properties["created_at"].(map[string]any)["format"])
+
+	t.Run("TableNotFound", func(t *testing.T) {
+		_, err := b.TableJSONSchema(t.Context(), backend.TableJSONSchemaIn{Schema: "", Table: "missing"})
+		require.ErrorIs(t, err, sqlcommon.ErrTableNotFound)
+	})
+}
+
+func TestSetComment(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	description := func() any {
+		out, err := b.TableJSONSchema(t.Context(), backend.TableJSONSchemaIn{Schema: "", Table: "users"})
+		require.NoError(t, err)
+		return out.JSONSchema["properties"].(map[string]any)["email"].(map[string]any)["description"]
+	}
+
+	t.Run("Column", func(t *testing.T) {
+		comment := "Login address, e.g. o'brien@example.com"
+		out, err := b.SetComment(t.Context(), backend.SetCommentIn{Schema: "", Table: "users", Column: "email", Comment: comment})
+		require.NoError(t, err)
+		require.True(t, out.Success)
+		require.Equal(t, comment, description())
+
+		_, err = b.SetComment(t.Context(), backend.SetCommentIn{Schema: "", Table: "users", Column: "email", Comment: "Updated"})
+		require.NoError(t, err)
+		require.Equal(t, "Updated", description())
+
+		desc, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Schema: "", Table: "users"})
+		require.NoError(t, err)
+		require.Equal(t, "Updated", desc.ColumnComments["email"])
+
+		out, err = b.SetComment(t.Context(), backend.SetCommentIn{Schema: "", Table: "users", Column: "email"})
+		require.NoError(t, err)
+		require.Contains(t, out.Message, "removed")
+		require.Nil(t, description())
+	})
+
+	t.Run("Table", func(t *testing.T) {
+		out, err := b.SetComment(t.Context(), backend.SetCommentIn{Schema: "", Table: "users", Comment: "Registered accounts"})
+		require.NoError(t, err)
+		require.True(t, out.Success)
+
+		desc, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Schema: "", Table: "users"})
+		require.NoError(t, err)
+		require.Equal(t, "Registered accounts", desc.TableComment)
+	})
+
+	t.Run("ColumnNotFound", func(t *testing.T) {
+		_, err := b.SetComment(t.Context(), backend.SetCommentIn{Schema: "", Table: "users", Column: "missing", Comment: "x"})
+		require.ErrorIs(t, err, sqlcommon.ErrColumnNotFound)
+	})
+}
@@ -0,0 +1,13 @@
+SELECT
+    ID AS thread_id,
+    USER AS username,
+    COALESCE(DB, '') AS database_name,
+    HOST AS client_address,
+    COMMAND AS command,
+    COALESCE(STATE, '') AS state,
+    TIME AS time_seconds,
+    COALESCE(INFO, '') AS query_text
+FROM information_schema.PROCESSLIST
+WHERE ID != CONNECTION_ID()
+  AND COMMAND != 'Daemon'
+ORDER BY ID ASC
@@ -0,0 +1,18 @@
+SELECT
+  k.CONSTRAINT_NAME AS constraint_name,
+  k.TABLE_SCHEMA AS schema_name,
+  k.TABLE_NAME AS table_name,
+  GROUP_CONCAT(k.COLUMN_NAME ORDER BY k.ORDINAL_POSITION SEPARATOR ', ') AS columns,
+  k.REFERENCED_TABLE_SCHEMA AS referenced_schema,
+  k.REFERENCED_TABLE_NAME AS referenced_table,
+  GROUP_CONCAT(k.REFERENCED_COLUMN_NAME ORDER BY k.ORDINAL_POSITION SEPARATOR ', ') AS referenced_columns,
+  r.DELETE_RULE AS on_delete,
+  r.UPDATE_RULE AS on_update
+FROM information_schema.KEY_COLUMN_USAGE k
+JOIN information_schema.REFERENTIAL_CONSTRAINTS r
+  ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME
+WHERE k.TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE())
+  AND (? = '' OR k.TABLE_NAME = ?)
+  AND k.REFERENCED_TABLE_NAME IS NOT NULL
+GROUP BY k.CONSTRAINT_NAME, k.TABLE_SCHEMA, k.TABLE_NAME, k.REFERENCED_TABLE_SCHEMA, k.REFERENCED_TABLE_NAME, r.DELETE_RULE, r.UPDATE_RULE
+ORDER BY k.TABLE_NAME, k.CONSTRAINT_NAME;
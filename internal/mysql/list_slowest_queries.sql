@@ -1,4 +1,5 @@
 SELECT
+    digest AS query_hash,
     SUBSTRING(digest_text, 1, 500) AS query,
     schema_name,
     count_star AS calls,
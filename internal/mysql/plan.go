@@ -0,0 +1,186 @@
+package mysql
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tinternet/databaise/internal/backend"
+)
+
+// accessTypes names the access_type values of EXPLAIN FORMAT=JSON.
+var accessTypes = map[string]string{
+	"ALL":             "Full Table Scan",
+	"index":           "Full Index Scan",
+	"range":           "Index Range Scan",
+	"ref":             "Index Lookup",
+	"ref_or_null":     "Index Lookup (or NULL)",
+	"eq_ref":          "Unique Index Lookup",
+	"const":           "Constant Lookup",
+	"system":          "Constant Lookup",
+	"fulltext":        "Fulltext Index Lookup",
+	"index_merge":     "Index Merge",
+	"unique_subquery": "Subquery Index Lookup",
+	"index_subquery":  "Subquery Index Lookup",
+}
+
+// planTree converts the output of EXPLAIN FORMAT=JSON to backend.PlanNode trees.
+// It reads both the query_block document of explain_json_format_version 1 and the
+// operation tree of version 2, which EXPLAIN ANALYZE returns on recent servers.
+func planTree(planJSON string) []backend.PlanNode {
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(planJSON), &doc); err != nil {
+		return nil
+	}
+	if _, ok := doc["operation"]; ok {
+		return []backend.PlanNode{operationNode(doc)}
+	}
+	if qb, ok := doc["query_block"].(map[string]any); ok {
+		return []backend.PlanNode{queryBlockNode(qb)}
+	}
+	return nil
+}
+
+// operationNode converts a node of the version 2 format.
+func operationNode(op map[string]any) backend.PlanNode {
+	node := backend.PlanNode{
+		Operation:     stringField(op, "operation"),
+		Table:         stringField(op, "table_name"),
+		Index:         stringField(op, "index_name"),
+		EstimatedRows: number(op["estimated_rows"]),
+		ActualRows:    number(op["actual_rows"]),
+		Cost:          number(op["estimated_total_cost"]),
+	}
+	for _, input := range objects(op["inputs"]) {
+		node.Children = append(node.Children, operationNode(input))
+	}
+	return node
+}
+
+func queryBlockNode(qb map[string]any) backend.PlanNode {
+	node := backend.PlanNode{Operation: "Query Block", Cost: cost(qb, "query_cost")}
+	if msg := stringField(qb, "message"); msg != "" {
+		node.Operation += ": " + msg
+	}
+	node.Children = operations(qb)
+	return node
+}
+
+// operations returns the operations nested in a query block or in one of the
+// wrapper objects (ordering_operation, grouping_operation, ...) of the version 1 format.
+func operations(obj map[string]any) []any {
+	var nodes []any
+	if t, ok := obj["table"].(map[string]any); ok {
+		nodes = append(nodes, tableNode(t))
+	}
+	if loop := objects(obj["nested_loop"]); len(loop) > 0 {
+		node := backend.PlanNode{Operation: "Nested Loop"}
+		for _, step := range loop {
+			node.Children = append(node.Children, operations(step)...)
+		}
+		nodes = append(nodes, node)
+	}
+	wrappers := []struct{ key, operation, flag, flagged string }{
+		{"ordering_operation", "Order", "using_filesort", "Sort"},
+		{"grouping_operation", "Group", "using_temporary_table", "Group (temporary table)"},
+		{"duplicates_removal", "Distinct", "using_temporary_table", "Distinct (temporary table)"},
+		{"windowing", "Window", "using_temporary_table", "Window (temporary table)"},
+		{"buffer_result", "Buffer", "", ""},
+	}
+	for _, w := range wrappers {
+		inner, ok := obj[w.key].(map[string]any)
+		if !ok {
+			continue
+		}
+		node := backend.PlanNode{Operation: w.operation, Children: operations(inner)}
+		if flag, _ := inner[w.flag].(bool); flag {
+			node.Operation = w.flagged
+		}
+		nodes = append(nodes, node)
+	}
+	if union, ok := obj["union_result"].(map[string]any); ok {
+		node := backend.PlanNode{Operation: "Union", Table: stringField(union, "table_name")}
+		for _, spec := range objects(union["query_specifications"]) {
+			if qb, ok := spec["query_block"].(map[string]any); ok {
+				node.Children = append(node.Children, queryBlockNode(qb))
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return append(nodes, subqueries(obj)...)
+}
+
+func tableNode(t map[string]any) backend.PlanNode {
+	access := stringField(t, "access_type")
+	operation, ok := accessTypes[access]
+	if !ok {
+		operation = "Table Access"
+	}
+	node := backend.PlanNode{
+		Operation:     operation,
+		Table:         stringField(t, "table_name"),
+		Index:         stringField(t, "key"),
+		EstimatedRows: number(t["rows_produced_per_join"]),
+		Cost:          cost(t, "prefix_cost"),
+	}
+	if sub, ok := t["materialized_from_subquery"].(map[string]any); ok {
+		if qb, ok := sub["query_block"].(map[string]any); ok {
+			node.Children = append(node.Children, queryBlockNode(qb))
+		}
+	}
+	node.Children = append(node.Children, subqueries(t)...)
+	return node
+}
+
+// subqueries returns the query blocks listed under attached_subqueries,
+// select_list_subqueries, having_subqueries and the like.
+func subqueries(obj map[string]any) []any {
+	var nodes []any
+	for _, key := range slices.Sorted(maps.Keys(obj)) {
+		if !strings.HasSuffix(key, "_subqueries") {
+			continue
+		}
+		for _, sub := range objects(obj[key]) {
+			if qb, ok := sub["query_block"].(map[string]any); ok {
+				nodes = append(nodes, queryBlockNode(qb))
+			}
+		}
+	}
+	return nodes
+}
+
+// cost returns a field of an object's cost_info, which MySQL reports as strings.
+func cost(obj map[string]any, field string) *float64 {
+	info, _ := obj["cost_info"].(map[string]any)
+	return number(info[field])
+}
+
+func number(v any) *float64 {
+	switch v := v.(type) {
+	case float64:
+		return &v
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return &f
+		}
+	}
+	return nil
+}
+
+func stringField(obj map[string]any, key string) string {
+	s, _ := obj[key].(string)
+	return s
+}
+
+func objects(v any) []map[string]any {
+	list, _ := v.([]any)
+	var out []map[string]any
+	for _, item := range list {
+		if obj, ok := item.(map[string]any); ok {
+			out = append(out, obj)
+		}
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+package mysql
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinternet/databaise/internal/backend"
+)
+
+func readPlan(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestPlanTree(t *testing.T) {
+	nodes := planTree(readPlan(t, "full_scan.json"))
+	require.Len(t, nodes, 1)
+	require.Equal(t, "Query Block", nodes[0].Operation)
+	require.Equal(t, 1.45, *nodes[0].Cost)
+	require.Len(t, nodes[0].Children, 1)
+
+	loop := nodes[0].Children[0].(backend.PlanNode)
+	require.Equal(t, "Nested Loop", loop.Operation)
+	require.Len(t, loop.Children, 2)
+
+	scan := loop.Children[0].(backend.PlanNode)
+	require.Equal(t, "Full Table Scan", scan.Operation)
+	require.Equal(t, "o", scan.Table)
+	require.Equal(t, 0.55, *scan.Cost)
+
+	lookup := loop.Children[1].(backend.PlanNode)
+	require.Equal(t, "Unique Index Lookup", lookup.Operation)
+	require.Equal(t, "PRIMARY", lookup.Index)
+}
+
+func TestFullScans(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []backend.TableScan
+	}{
+		{"full_scan.json", []backend.TableScan{{Table: "o", EstimatedRows: 3}}},
+		{"index_lookup.json", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			require.Equal(t, tt.want, fullScans(readPlan(t, tt.fixture)))
+		})
+	}
+}
@@ -4,34 +4,53 @@ import (
 	"context"
 	"database/sql"
 	_ "embed"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/tinternet/databaise/internal/backend"
+	"github.com/tinternet/databaise/internal/config"
 	"github.com/tinternet/databaise/internal/logging"
 	"github.com/tinternet/databaise/internal/sqlcommon"
 	"golang.org/x/sync/errgroup"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var log = logging.New("postgres")
 
 // ReadConfig extends the base read config with PostgreSQL-specific options.
 type ReadConfig struct {
+	config.Pool
 	DSN                 string `json:"dsn"`
 	UseReadonlyTx       bool   `json:"use_readonly_tx,omitempty"`
 	BypassReadonlyCheck bool   `json:"bypass_readonly_check"`
+	StatementTimeoutMs  int    `json:"statement_timeout_ms,omitempty"`
 }
 
 // AdminConfig for admin connections.
 type AdminConfig struct {
-	DSN string `json:"dsn"`
+	config.Pool
+	DSN                    string `json:"dsn"`
+	AllowTerminateSessions bool   `json:"allow_terminate_sessions,omitempty"`
 }
 
 // DB wraps gorm.DB with PostgreSQL-specific settings.
 type DB struct {
 	*gorm.DB
-	UseReadonlyTx bool
+	UseReadonlyTx          bool
+	AllowTerminateSessions bool
+}
+
+// Pool returns the connection pool, for pool_stats.
+func (db DB) Pool() (*sql.DB, error) {
+	return db.DB.DB()
 }
 
 // Factory implements backend.BackendFactory for PostgreSQL.
@@ -39,6 +58,10 @@ type Factory struct{}
 
 func (Factory) Dialect() string { return "PostgreSQL" }
 
+func (Factory) UnsupportedTools() []string {
+	return nil
+}
+
 func (Factory) New(db DB) backend.SQLBackend {
 	return &Backend{db: db}
 }
@@ -48,26 +71,50 @@ type Connector struct{}
 
 func (Connector) ConnectRead(c ReadConfig) (DB, error) {
 	log.Printf("Opening read connection")
-	db, err := gorm.Open(postgres.Open(c.DSN), &gorm.Config{Logger: logging.NewGormLogger()})
+	dialector := postgres.Open(c.DSN)
+	if c.StatementTimeoutMs > 0 {
+		// Set statement_timeout as a startup parameter so every session in the pool
+		// is capped, including queries that don't carry a handler context deadline.
+		pgCfg, err := pgx.ParseConfig(c.DSN)
+		if err != nil {
+			return DB{}, err
+		}
+		pgCfg.RuntimeParams["statement_timeout"] = strconv.Itoa(c.StatementTimeoutMs)
+		dialector = postgres.New(postgres.Config{Conn: stdlib.OpenDB(*pgCfg)})
+		log.Printf("Using statement_timeout of %dms (statement_timeout_ms)", c.StatementTimeoutMs)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logging.NewGormLogger()})
 	if err != nil {
 		return DB{}, err
 	}
-
-	if c.UseReadonlyTx {
-		log.Println("Using PostgreSQL readonly transactions (use_readonly_tx: true)")
-		return DB{DB: db, UseReadonlyTx: true}, nil
+	if err := sqlcommon.ApplyPool(db, c.Pool); err != nil {
+		return DB{}, err
 	}
 
-	if !c.BypassReadonlyCheck {
-		if !sqlcommon.VerifyReadonly(db, sqlcommon.PostgreSQLVerifyReadonlySQL) {
-			return DB{}, fmt.Errorf("read DSN user has write permissions (set bypass_readonly_check: true to bypass)")
-		}
-		log.Printf("Verified read connection is readonly")
-	} else {
+	return DB{DB: db, UseReadonlyTx: c.UseReadonlyTx}, nil
+}
+
+func (Connector) CheckReadonly(c ReadConfig, db DB) backend.ReadonlyStatus {
+	status := backend.ReadonlyStatus{CheckedAt: time.Now()}
+	switch {
+	case c.UseReadonlyTx:
+		log.Println("Using PostgreSQL readonly transactions (use_readonly_tx: true)")
+		status.Mode, status.Verified = backend.ReadonlyTx, true
+	case c.BypassReadonlyCheck:
 		log.Printf("Skipping readonly verification (bypass_readonly_check: true)")
+		status.Mode = backend.ReadonlyNone
+	case !sqlcommon.VerifyReadonly(db.DB, sqlcommon.PostgreSQLVerifyReadonlySQL):
+		status.Mode, status.Error = backend.ReadonlyGrantCheck, "read DSN user has write permissions (set bypass_readonly_check: true to bypass)"
+	default:
+		log.Printf("Verified read connection is readonly")
+		status.Mode, status.Verified = backend.ReadonlyGrantCheck, true
 	}
+	return status
+}
 
-	return DB{DB: db, UseReadonlyTx: false}, nil
+func (Connector) DowngradeReadonly(db DB) DB {
+	db.UseReadonlyTx = true
+	return db
 }
 
 func (Connector) ConnectAdmin(c AdminConfig) (DB, error) {
@@ -76,8 +123,14 @@ func (Connector) ConnectAdmin(c AdminConfig) (DB, error) {
 	if err != nil {
 		return DB{}, err
 	}
+	if err := sqlcommon.ApplyPool(db, c.Pool); err != nil {
+		return DB{}, err
+	}
 
-	return DB{DB: db, UseReadonlyTx: false}, nil
+	if c.AllowTerminateSessions {
+		log.Println("Session termination enabled (allow_terminate_sessions: true)")
+	}
+	return DB{DB: db, UseReadonlyTx: false, AllowTerminateSessions: c.AllowTerminateSessions}, nil
 }
 
 func init() {
@@ -97,7 +150,7 @@ func (b *Backend) ListTables(ctx context.Context, in backend.ListTablesIn) ([]ba
 		Schema string `gorm:"column:schema"`
 		Name   string `gorm:"column:name"`
 	}
-	if err := b.db.WithContext(ctx).Raw(listTablesQuery, in.Schema).Scan(&tables).Error; err != nil {
+	if err := b.db.WithContext(ctx).Raw(listTablesQuery, in.Schema, in.AllSchemas).Scan(&tables).Error; err != nil {
 		return nil, err
 	}
 
@@ -105,9 +158,83 @@ func (b *Backend) ListTables(ctx context.Context, in backend.ListTablesIn) ([]ba
 	for i, t := range tables {
 		result[i] = backend.Table{Schema: t.Schema, Name: t.Name}
 	}
+	if in.WithStats {
+		sizes, err := b.TableSizes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		backend.AddTableSizes(result, sizes)
+	}
 	return result, nil
 }
 
+func (b *Backend) ListTablesWithoutPK(ctx context.Context, in backend.ListTablesWithoutPKIn) ([]backend.Table, error) {
+	names, err := sqlcommon.TablesWithoutPrimaryKey(ctx, b.db.DB, in.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]backend.Table, len(names))
+	for i, name := range names {
+		tables[i] = backend.Table{Schema: name.Schema, Name: name.Name}
+	}
+	return tables, nil
+}
+
+//go:embed list_sequences.sql
+var listSequencesQuery string
+
+func (b *Backend) ListSequences(ctx context.Context, in backend.ListSequencesIn) ([]backend.Sequence, error) {
+	sequences := []backend.Sequence{}
+	err := b.db.WithContext(ctx).Raw(listSequencesQuery, in.Schema).Scan(&sequences).Error
+	return sequences, err
+}
+
+func (b *Backend) ListSchemas(ctx context.Context, in backend.ListSchemasIn) ([]string, error) {
+	schemas := []string{}
+	err := b.db.WithContext(ctx).Raw(`SELECT schema_name FROM information_schema.schemata
+WHERE ? OR (schema_name NOT IN ('pg_catalog', 'information_schema') AND schema_name NOT LIKE 'pg\_toast%' AND schema_name NOT LIKE 'pg\_temp\_%')
+ORDER BY schema_name`, in.IncludeSystem).Scan(&schemas).Error
+	return schemas, err
+}
+
+//go:embed list_views.sql
+var listViewsQuery string
+
+func (b *Backend) ListViews(ctx context.Context, in backend.ListViewsIn) ([]backend.View, error) {
+	views := []backend.View{}
+	err := b.db.WithContext(ctx).Raw(listViewsQuery, in.Schema).Scan(&views).Error
+	return views, err
+}
+
+//go:embed list_materialized_views.sql
+var listMaterializedViewsQuery string
+
+func (b *Backend) ListMaterializedViews(ctx context.Context, in backend.ListMaterializedViewsIn) ([]backend.MaterializedView, error) {
+	views := []backend.MaterializedView{}
+	err := b.db.WithContext(ctx).Raw(listMaterializedViewsQuery, in.Schema).Scan(&views).Error
+	return views, err
+}
+
+//go:embed list_foreign_keys.sql
+var listForeignKeysQuery string
+
+func (b *Backend) ListForeignKeys(ctx context.Context, in backend.ListForeignKeysIn) ([]backend.ForeignKey, error) {
+	foreignKeys := []backend.ForeignKey{}
+	err := b.db.WithContext(ctx).Raw(listForeignKeysQuery, in.Schema, in.Table).Scan(&foreignKeys).Error
+	return foreignKeys, err
+}
+
+//go:embed table_sizes.sql
+var tableSizesQuery string
+
+// TableSizes implements backend.TableSizer using the planner's row estimates.
+func (b *Backend) TableSizes(ctx context.Context) ([]backend.TableSize, error) {
+	var sizes []backend.TableSize
+	err := b.db.WithContext(ctx).Raw(tableSizesQuery).Scan(&sizes).Error
+	return sizes, err
+}
+
 //go:embed ddl_table.sql
 var queryTableDDL string
 
@@ -117,13 +244,67 @@ var queryIndexesDDL string
 //go:embed ddl_constraints.sql
 var queryConstraintsDDL string
 
+//go:embed inbound_foreign_keys.sql
+var queryInboundForeignKeys string
+
+// resolveTable finds the table named schema.table, falling back to a case-insensitive
+// match so that names created quoted in mixed case (e.g. by an ORM) are still found.
+// It returns the quoted name to use in SQL, and a hint when the real name differs in case.
+func (b *Backend) resolveTable(ctx context.Context, schema, table string) (name, hint string, err error) {
+	schema, table, hint, err = b.lookupTable(ctx, schema, table)
+	if err != nil {
+		return "", "", err
+	}
+	return pgx.Identifier{schema, table}.Sanitize(), hint, nil
+}
+
+// lookupTable is resolveTable returning the real schema and table names unquoted.
+func (b *Backend) lookupTable(ctx context.Context, schema, table string) (realSchema, realTable, hint string, err error) {
+	if schema == "" {
+		schema = "public"
+	}
+
+	var candidates []struct {
+		Schema string `gorm:"column:schema_name"`
+		Table  string `gorm:"column:table_name"`
+	}
+	err = b.db.WithContext(ctx).Raw(
+		"SELECT n.nspname AS schema_name, c.relname AS table_name FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace WHERE c.relkind = 'r' AND lower(n.nspname) = lower(?) AND lower(c.relname) = lower(?)",
+		schema, table,
+	).Scan(&candidates).Error
+	if err != nil {
+		return "", "", "", err
+	}
+
+	for _, c := range candidates {
+		if c.Schema == schema && c.Table == table {
+			return c.Schema, c.Table, "", nil
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return "", "", "", sqlcommon.ErrTableNotFound
+	case 1:
+		name := pgx.Identifier{candidates[0].Schema, candidates[0].Table}.Sanitize()
+		hint = fmt.Sprintf("%s.%s matched %s case-insensitively. PostgreSQL folds unquoted names to lowercase, so write it quoted exactly as %s in SQL", schema, table, name, name)
+		return candidates[0].Schema, candidates[0].Table, hint, nil
+	}
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		names[i] = pgx.Identifier{c.Schema, c.Table}.Sanitize()
+	}
+	return "", "", "", fmt.Errorf("%s.%s is ambiguous, it matches %s case-insensitively: pass the exact name", schema, table, strings.Join(names, ", "))
+}
+
 func (b *Backend) DescribeTable(ctx context.Context, in backend.DescribeTableIn) (*backend.TableDescription, error) {
-	var out backend.TableDescription
-	g, ctx := errgroup.WithContext(ctx)
-	if in.Schema == "" {
-		in.Schema = "public"
+	schema, table, hint, err := b.lookupTable(ctx, in.Schema, in.Table)
+	if err != nil {
+		return nil, err
 	}
-	tableName := fmt.Sprintf("%s.%s", in.Schema, in.Table)
+	tableName := pgx.Identifier{schema, table}.Sanitize()
+
+	out := backend.TableDescription{Hint: hint}
+	g, ctx := errgroup.WithContext(ctx)
 
 	g.Go(func() error {
 		return b.db.WithContext(ctx).Raw(queryTableDDL, tableName).Scan(&out.CreateTable).Error
@@ -134,6 +315,19 @@ func (b *Backend) DescribeTable(ctx context.Context, in backend.DescribeTableIn)
 	g.Go(func() error {
 		return b.db.WithContext(ctx).Raw(queryConstraintsDDL, tableName).Scan(&out.CreateConstraints).Error
 	})
+	g.Go(func() error {
+		return b.db.WithContext(ctx).Raw(listForeignKeysQuery, schema, table).Scan(&out.ForeignKeys).Error
+	})
+	g.Go(func() error {
+		var err error
+		out.TableComment, out.ColumnComments, err = sqlcommon.GetComments(ctx, b.db.DB, schema, table)
+		return err
+	})
+	if in.IncludeReferencedBy {
+		g.Go(func() error {
+			return b.db.WithContext(ctx).Raw(queryInboundForeignKeys, tableName).Scan(&out.ReferencedBy).Error
+		})
+	}
 
 	if err := g.Wait(); err != nil {
 		return nil, err
@@ -144,23 +338,60 @@ func (b *Backend) DescribeTable(ctx context.Context, in backend.DescribeTableIn)
 	return &out, nil
 }
 
-func (b *Backend) ExecuteQuery(ctx context.Context, in backend.ReadQueryIn) (*backend.QueryResult, error) {
-	var rows []map[string]any
+func (b *Backend) ProfileCategoricalColumns(ctx context.Context, in backend.ProfileCategoricalColumnsIn) (*backend.ProfileCategoricalColumnsOut, error) {
+	name, hint, err := b.resolveTable(ctx, in.Schema, in.Table)
+	if err != nil {
+		return nil, err
+	}
+	columns, skipped, err := sqlcommon.ProfileCategoricalColumns(ctx, b.db.DB, clause.Expr{SQL: name}, in.MaxDistinct)
+	if err != nil {
+		return nil, err
+	}
+	return &backend.ProfileCategoricalColumnsOut{Columns: columns, Skipped: skipped, Hint: hint}, nil
+}
+
+func (b *Backend) TableJSONSchema(ctx context.Context, in backend.TableJSONSchemaIn) (*backend.TableJSONSchemaOut, error) {
+	schema, table, hint, err := b.lookupTable(ctx, in.Schema, in.Table)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := sqlcommon.GetColumns(ctx, b.db.DB, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	return &backend.TableJSONSchemaOut{JSONSchema: sqlcommon.TableJSONSchema("postgres", schema+"."+table, columns), Hint: hint}, nil
+}
+
+func (b *Backend) SampleTable(ctx context.Context, in backend.SampleTableIn) (*backend.QueryResult, error) {
+	name, _, err := b.resolveTable(ctx, in.Schema, in.Table)
+	if err != nil {
+		return nil, err
+	}
+	return b.ExecuteQuery(ctx, backend.ReadQueryIn{Query: fmt.Sprintf("SELECT * FROM %s LIMIT %d", name, in.Limit)})
+}
 
+func (b *Backend) ExecuteQuery(ctx context.Context, in backend.ReadQueryIn) (*backend.QueryResult, error) {
+	if in.Schema != "" {
+		return nil, fmt.Errorf("the schema parameter of execute_query is only supported for MySQL: qualify table names with their schema instead")
+	}
 	if b.db.UseReadonlyTx {
+		var rows *sqlcommon.Rows
 		err := b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-			return tx.Raw(in.Query).Scan(&rows).Error
+			var err error
+			rows, err = sqlcommon.QueryRows(ctx, tx, in.Query)
+			return err
 		}, &sql.TxOptions{ReadOnly: true})
 		if err != nil {
-			return nil, err
+			return nil, sqlcommon.HighlightSyntaxError(err, in.Query, 0)
 		}
-		return &backend.QueryResult{Rows: rows}, nil
+		return backend.NewQueryResult(in.Query, rows), nil
 	}
 
-	if err := b.db.WithContext(ctx).Raw(in.Query).Scan(&rows).Error; err != nil {
-		return nil, err
+	rows, err := sqlcommon.QueryRows(ctx, b.db.DB, in.Query)
+	if err != nil {
+		return nil, sqlcommon.HighlightSyntaxError(err, in.Query, 0)
 	}
-	return &backend.QueryResult{Rows: rows}, nil
+	return backend.NewQueryResult(in.Query, rows), nil
 }
 
 func (b *Backend) ExplainQuery(ctx context.Context, in backend.ExplainQueryIn) (*backend.ExplainResult, error) {
@@ -169,28 +400,181 @@ func (b *Backend) ExplainQuery(ctx context.Context, in backend.ExplainQueryIn) (
 		analyzeStr = "ANALYZE, "
 	}
 
+	db := b.db.WithContext(ctx)
+	if in.Analyze {
+		// ANALYZE executes the statement; roll it back so an explained UPDATE or DELETE changes nothing.
+		db = db.Begin()
+		if err := db.Error; err != nil {
+			return nil, err
+		}
+		defer db.Rollback()
+	}
+
+	prefix := fmt.Sprintf("EXPLAIN (%sFORMAT JSON) ", analyzeStr)
 	var planJSON string
-	err := b.db.WithContext(ctx).Raw(fmt.Sprintf("EXPLAIN (%sFORMAT JSON) %s", analyzeStr, in.Query)).Scan(&planJSON).Error
+	err := db.Raw(prefix+in.Query, in.Params...).Scan(&planJSON).Error
+	if err != nil {
+		return nil, sqlcommon.HighlightSyntaxError(err, in.Query, len(prefix))
+	}
+
+	plans, err := parsePlan(planJSON)
+	if err != nil {
+		return nil, err
+	}
+	scans, err := b.fullScans(ctx, in.Query, plans)
 	if err != nil {
 		return nil, err
 	}
 
 	return &backend.ExplainResult{
-		Format:     "json",
-		Result:     planJSON,
-		ResultInfo: "The postgresql query plan as returned by the database",
+		Format:           "json",
+		Result:           planJSON,
+		ResultInfo:       "The postgresql query plan as returned by the database",
+		FullScanDetected: len(scans) > 0,
+		FullScans:        scans,
+		Plan:             planTree(plans),
+		ExecutedSQL:      sqlcommon.BoundSQL(b.db.DB, prefix+in.Query, in.Params...),
 	}, nil
 }
 
+// fullScans returns the sequential scans in the plan. The plan only estimates the
+// rows a scan outputs after filtering, so the table size is read from pg_class.
+func (b *Backend) fullScans(ctx context.Context, query string, plans []explainPlan) ([]backend.TableScan, error) {
+	tables := sqlcommon.QueryTables(query)
+	var scans []backend.TableScan
+	for _, n := range seqScans(plans) {
+		var reltuples float64
+		err := b.db.WithContext(ctx).Raw("SELECT COALESCE(MAX(reltuples), -1) FROM pg_class WHERE oid = to_regclass(?) AND relkind IN ('r', 'm', 'p')", relationName(n, tables)).Scan(&reltuples).Error
+		if err != nil {
+			return nil, err
+		}
+		// reltuples is -1 for tables that were never vacuumed or analyzed
+		if reltuples < 0 {
+			reltuples = n.PlanRows
+		}
+		scans = append(scans, backend.TableScan{Table: n.RelationName, EstimatedRows: reltuples})
+	}
+	return scans, nil
+}
+
 func (b *Backend) ExecuteDDL(ctx context.Context, in backend.ExecuteDDLIn) (*backend.DDLResult, error) {
-	if err := b.db.WithContext(ctx).Exec(in.DDL).Error; err != nil {
+	err := sqlcommon.RetryOnDeadlock(ctx, func() error {
+		return b.db.WithContext(ctx).Exec(in.DDL).Error
+	})
+	if err != nil {
+		return nil, sqlcommon.HighlightSyntaxError(err, in.DDL, 0)
+	}
+	return &backend.DDLResult{Success: true, Message: "DDL executed successfully", ExecutedSQL: in.DDL}, nil
+}
+
+func (b *Backend) ExecuteWrite(ctx context.Context, in backend.ExecuteWriteIn) (*backend.WriteResult, error) {
+	affected, err := sqlcommon.ExecuteWrite(ctx, b.db.DB, in.Query, in.Params)
+	if err != nil {
+		return nil, err
+	}
+	return &backend.WriteResult{RowsAffected: affected, ExecutedSQL: sqlcommon.BoundSQL(b.db.DB, in.Query, in.Params...)}, nil
+}
+
+func (b *Backend) CheckDDL(ctx context.Context, in backend.CheckDDLIn) (*sqlcommon.DDLCheckReport, error) {
+	return sqlcommon.CheckDDL(ctx, b.db.DB, in.DDL)
+}
+
+// RecommendIndexes adds to each recommendation the planner's estimated cost of the
+// query with the index, created as a hypothetical index when hypopg is installed.
+func (b *Backend) RecommendIndexes(ctx context.Context, in backend.RecommendIndexesIn) (*sqlcommon.IndexAdvice, error) {
+	advice, err := sqlcommon.RecommendIndexes(ctx, b.db.DB, in.Query)
+	if err != nil || len(advice.Recommendations) == 0 {
+		return advice, err
+	}
+
+	var hypopg bool
+	if err := b.db.WithContext(ctx).Raw("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'hypopg')").Scan(&hypopg).Error; err != nil {
 		return nil, err
 	}
-	return &backend.DDLResult{Success: true, Message: "DDL executed successfully"}, nil
+	if !hypopg {
+		advice.Notes = append(advice.Notes, "Install the hypopg extension to get the planner's estimated cost of the query with each index.")
+		return advice, nil
+	}
+
+	// Hypothetical indexes live in the session that creates them, outside any transaction.
+	err = b.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		defer conn.Exec("SELECT hypopg_reset()")
+		before, err := explainCost(conn, in)
+		if err != nil {
+			return err
+		}
+		for i := range advice.Recommendations {
+			rec := &advice.Recommendations[i]
+			if err := conn.Exec("SELECT hypopg_create_index(?)", rec.CreateStatement).Error; err != nil {
+				return err
+			}
+			after, err := explainCost(conn, in)
+			if err != nil {
+				return err
+			}
+			if err := conn.Exec("SELECT hypopg_reset()").Error; err != nil {
+				return err
+			}
+			rec.CostBefore, rec.CostAfter = &before, &after
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return advice, nil
 }
 
+// explainCost returns the planner's estimated total cost of a query.
+func explainCost(db *gorm.DB, in backend.RecommendIndexesIn) (float64, error) {
+	var planJSON string
+	if err := db.Raw("EXPLAIN (FORMAT JSON) "+in.Query, in.Params...).Scan(&planJSON).Error; err != nil {
+		return 0, err
+	}
+	plans, err := parsePlan(planJSON)
+	if err != nil || len(plans) == 0 {
+		return 0, err
+	}
+	return plans[0].Plan.TotalCost, nil
+}
+
+//go:embed missing_indexes.sql
+var missingIndexesQuery string
+
+// minMissingIndexTableBytes keeps small tables out of the index recommendations:
+// scanning a table of a few pages is as cheap as using an index on it.
+const minMissingIndexTableBytes = 8 << 20
+
+// ListMissingIndexes reports the tables that are read mostly by sequential scans.
+// PostgreSQL does not record which predicates the scans evaluated, so the
+// columns to index have to be found from the queries that hit the table.
 func (b *Backend) ListMissingIndexes(ctx context.Context) ([]backend.MissingIndex, error) {
-	return nil, fmt.Errorf("PostgreSQL does not provide automatic index recommendations. Use list_slowest_queries to identify queries that may benefit from indexing - look for queries with low cache_hit_pct or high temp_blks_read")
+	var tables []struct {
+		Schema          string `gorm:"column:schema"`
+		TableName       string `gorm:"column:table_name"`
+		SeqScan         int64  `gorm:"column:seq_scan"`
+		SeqTupRead      int64  `gorm:"column:seq_tup_read"`
+		IdxScan         int64  `gorm:"column:idx_scan"`
+		NLiveTup        int64  `gorm:"column:n_live_tup"`
+		TableBytes      int64  `gorm:"column:table_bytes"`
+		CreateStatement string `gorm:"column:create_statement"`
+	}
+	if err := b.db.WithContext(ctx).Raw(missingIndexesQuery, minMissingIndexTableBytes).Scan(&tables).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]backend.MissingIndex, len(tables))
+	for i, t := range tables {
+		result[i] = backend.MissingIndex{
+			Schema:    t.Schema,
+			TableName: t.TableName,
+			Reason: fmt.Sprintf("%d sequential scans read %d rows in total, against %d index scans (%d live rows, %d MB); find the filtered columns with list_slowest_queries or explain_query",
+				t.SeqScan, t.SeqTupRead, t.IdxScan, t.NLiveTup, t.TableBytes>>20),
+			EstimatedImpact: float64(t.SeqTupRead),
+			Suggestion:      t.CreateStatement,
+		}
+	}
+	return result, nil
 }
 
 //go:embed list_waiting_queries.sql
@@ -207,7 +591,7 @@ func (b *Backend) ListWaitingQueries(ctx context.Context) ([]backend.WaitingQuer
 		WaitEventType    string  `gorm:"column:wait_event_type"`
 		QueryStart       string  `gorm:"column:query_start"`
 		QueryDurationSec float64 `gorm:"column:query_duration_sec"`
-		BlockingPID      *int    `gorm:"column:blocking_pid"`
+		BlockedBy        string  `gorm:"column:blocked_by"`
 		QueryText        string  `gorm:"column:query_text"`
 	}
 	if err := b.db.WithContext(ctx).Raw(waitingQueriesQuery).Scan(&queries).Error; err != nil {
@@ -216,17 +600,13 @@ func (b *Backend) ListWaitingQueries(ctx context.Context) ([]backend.WaitingQuer
 
 	result := make([]backend.WaitingQuery, len(queries))
 	for i, q := range queries {
-		blockedBy := ""
-		if q.BlockingPID != nil {
-			blockedBy = fmt.Sprintf("%d", *q.BlockingPID)
-		}
 		result[i] = backend.WaitingQuery{
 			ID:               fmt.Sprintf("%d", q.PID),
 			Username:         q.Username,
 			Database:         q.DatabaseName,
 			State:            q.State,
 			WaitType:         fmt.Sprintf("%s: %s", q.WaitEventType, q.WaitEvent),
-			BlockedBy:        blockedBy,
+			BlockedBy:        q.BlockedBy,
 			Query:            q.QueryText,
 			QueryDurationSec: q.QueryDurationSec,
 		}
@@ -234,12 +614,58 @@ func (b *Backend) ListWaitingQueries(ctx context.Context) ([]backend.WaitingQuer
 	return result, nil
 }
 
+//go:embed list_connections.sql
+var connectionsQuery string
+
+func (b *Backend) ListConnections(ctx context.Context) ([]backend.Connection, error) {
+	var sessions []struct {
+		PID              int     `gorm:"column:pid"`
+		Username         string  `gorm:"column:username"`
+		DatabaseName     string  `gorm:"column:database_name"`
+		ClientAddress    string  `gorm:"column:client_address"`
+		ApplicationName  string  `gorm:"column:application_name"`
+		State            string  `gorm:"column:state"`
+		ConnectedSec     float64 `gorm:"column:connected_sec"`
+		QueryDurationSec float64 `gorm:"column:query_duration_sec"`
+		QueryText        string  `gorm:"column:query_text"`
+	}
+	if err := b.db.WithContext(ctx).Raw(connectionsQuery).Scan(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]backend.Connection, len(sessions))
+	for i, s := range sessions {
+		result[i] = backend.Connection{
+			ID:               fmt.Sprintf("%d", s.PID),
+			Username:         s.Username,
+			Database:         s.DatabaseName,
+			ClientAddress:    s.ClientAddress,
+			ApplicationName:  s.ApplicationName,
+			State:            s.State,
+			ConnectedSec:     s.ConnectedSec,
+			Query:            s.QueryText,
+			QueryDurationSec: s.QueryDurationSec,
+		}
+	}
+	return result, nil
+}
+
 //go:embed list_slowest_queries.sql
 var slowestQueriesQuery string
 
 func (b *Backend) ListSlowestQueries(ctx context.Context) (*backend.SlowQueryResult, error) {
 	var queries []map[string]any
 	if err := b.db.WithContext(ctx).Raw(slowestQueriesQuery).Scan(&queries).Error; err != nil {
+		// Not wrapped: the generic translation of these codes (table not found) would hide the cause.
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "42P01": // undefined_table
+				return nil, errors.New("query statistics need the pg_stat_statements extension, which is not installed in this database: run CREATE EXTENSION pg_stat_statements (the server must also have pg_stat_statements in shared_preload_libraries)")
+			case "55000": // object_not_in_prerequisite_state
+				return nil, errors.New("pg_stat_statements is installed but not loaded: add it to shared_preload_libraries in postgresql.conf and restart the server")
+			}
+		}
 		return nil, err
 	}
 
@@ -290,3 +716,200 @@ func (b *Backend) ListDeadlocks(ctx context.Context) ([]backend.Deadlock, error)
 	}
 	return result, nil
 }
+
+//go:embed kill_idle_transactions.sql
+var killIdleTransactionsQuery string
+
+func (b *Backend) SetComment(ctx context.Context, in backend.SetCommentIn) (*backend.SetCommentOut, error) {
+	schema, table, hint, err := b.lookupTable(ctx, in.Schema, in.Table)
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlcommon.CheckColumn(ctx, b.db.DB, schema, table, in.Column); err != nil {
+		return nil, err
+	}
+
+	kind, name := "table", pgx.Identifier{schema, table}.Sanitize()
+	if in.Column != "" {
+		kind, name = "column", pgx.Identifier{schema, table, in.Column}.Sanitize()
+	}
+	// COMMENT ON takes a literal rather than a bind parameter, so have the server quote it.
+	var literal string
+	if err := b.db.WithContext(ctx).Raw("SELECT quote_nullable(NULLIF(?, ''))", in.Comment).Scan(&literal).Error; err != nil {
+		return nil, err
+	}
+	if err := b.db.WithContext(ctx).Exec("COMMENT ON " + kind + " " + name + " IS " + literal).Error; err != nil {
+		return nil, err
+	}
+
+	out := &backend.SetCommentOut{Success: true, Message: fmt.Sprintf("Comment set on %s %s", kind, name)}
+	if in.Comment == "" {
+		out.Message = fmt.Sprintf("Comment removed from %s %s", kind, name)
+	}
+	if hint != "" {
+		out.Message += ". " + hint
+	}
+	return out, nil
+}
+
+func (b *Backend) KillIdleTransactions(ctx context.Context, in backend.KillIdleTransactionsIn) (*backend.KillIdleTransactionsOut, error) {
+	if !b.db.AllowTerminateSessions {
+		return nil, fmt.Errorf("terminating sessions is not enabled for this database: set allow_terminate_sessions: true in its admin config")
+	}
+	if in.OlderThanSec <= 0 {
+		in.OlderThanSec = 300
+	}
+
+	var sessions []struct {
+		PID             int     `gorm:"column:pid"`
+		Username        string  `gorm:"column:username"`
+		DatabaseName    string  `gorm:"column:database_name"`
+		ApplicationName string  `gorm:"column:application_name"`
+		IdleSec         float64 `gorm:"column:idle_sec"`
+		Query           string  `gorm:"column:query"`
+		Terminated      bool    `gorm:"column:terminated"`
+	}
+	if err := b.db.WithContext(ctx).Raw(killIdleTransactionsQuery, in.OlderThanSec).Scan(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	out := &backend.KillIdleTransactionsOut{}
+	for _, s := range sessions {
+		if !s.Terminated {
+			continue
+		}
+		log.Printf("Terminated idle-in-transaction session %d (user %s, idle %.0fs)", s.PID, s.Username, s.IdleSec)
+		out.Sessions = append(out.Sessions, backend.TerminatedSession{
+			PID:             s.PID,
+			Username:        s.Username,
+			Database:        s.DatabaseName,
+			ApplicationName: s.ApplicationName,
+			IdleSec:         s.IdleSec,
+			Query:           s.Query,
+		})
+	}
+	out.Terminated = len(out.Sessions)
+	return out, nil
+}
+
+func (b *Backend) AnalyzeTable(ctx context.Context, in backend.AnalyzeTableIn) (*backend.AnalyzeTableOut, error) {
+	if in.Table == "" {
+		return b.analyzeAll(ctx, in.Schema)
+	}
+	name, hint, err := b.resolveTable(ctx, in.Schema, in.Table)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.db.WithContext(ctx).Exec("ANALYZE " + name).Error; err != nil {
+		return nil, err
+	}
+
+	var updated sql.NullTime
+	err = b.db.WithContext(ctx).Raw("SELECT GREATEST(last_analyze, last_autoanalyze) FROM pg_stat_all_tables WHERE relid = ?::regclass", name).Row().Scan(&updated)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	out := &backend.AnalyzeTableOut{Success: true, Message: fmt.Sprintf("Statistics refreshed for %s", name)}
+	if hint != "" {
+		out.Message += ". " + hint
+	}
+	if updated.Valid {
+		out.StatsUpdatedAt = &updated.Time
+	}
+	return out, nil
+}
+
+// analyzeAll refreshes the statistics of every table in schema, or in the whole
+// database if schema is empty.
+func (b *Backend) analyzeAll(ctx context.Context, schema string) (*backend.AnalyzeTableOut, error) {
+	if schema == "" {
+		if err := b.db.WithContext(ctx).Exec("ANALYZE").Error; err != nil {
+			return nil, err
+		}
+		return &backend.AnalyzeTableOut{Success: true, Message: "Statistics refreshed for every table in the database"}, nil
+	}
+
+	var tables []string
+	if err := b.db.WithContext(ctx).Raw("SELECT format('%I.%I', schemaname, tablename) FROM pg_tables WHERE schemaname = ? ORDER BY tablename", schema).Scan(&tables).Error; err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("schema %q has no tables", schema)
+	}
+	if err := b.db.WithContext(ctx).Exec("ANALYZE " + strings.Join(tables, ", ")).Error; err != nil {
+		return nil, err
+	}
+	return &backend.AnalyzeTableOut{Success: true, Message: fmt.Sprintf("Statistics refreshed for %d tables in %s", len(tables), schema)}, nil
+}
+
+//go:embed table_profile.sql
+var tableProfileQuery string
+
+//go:embed table_profile_indexes.sql
+var tableProfileIndexesQuery string
+
+func (b *Backend) TableProfile(ctx context.Context, in backend.TableProfileIn) (*backend.TableProfile, error) {
+	name, hint, err := b.resolveTable(ctx, in.Schema, in.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats struct {
+		Schema      string     `gorm:"column:schema_name"`
+		Table       string     `gorm:"column:table_name"`
+		RowEstimate int64      `gorm:"column:row_estimate"`
+		TableBytes  int64      `gorm:"column:table_bytes"`
+		IndexBytes  int64      `gorm:"column:index_bytes"`
+		TotalBytes  int64      `gorm:"column:total_bytes"`
+		SeqScan     *int64     `gorm:"column:seq_scan"`
+		IdxScan     *int64     `gorm:"column:idx_scan"`
+		LastVacuum  *time.Time `gorm:"column:last_vacuum"`
+		LastAnalyze *time.Time `gorm:"column:last_analyze"`
+	}
+	out := &backend.TableProfile{Hint: hint}
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return b.db.WithContext(gctx).Raw(tableProfileQuery, name).Scan(&stats).Error
+	})
+	g.Go(func() error {
+		return b.db.WithContext(gctx).Raw(tableProfileIndexesQuery, name).Scan(&out.Indexes).Error
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	out.Schema, out.Table = stats.Schema, stats.Table
+	out.RowEstimate, out.TableBytes, out.IndexBytes, out.TotalBytes = stats.RowEstimate, stats.TableBytes, stats.IndexBytes, stats.TotalBytes
+	out.SeqScans, out.IndexScans = stats.SeqScan, stats.IdxScan
+	out.LastVacuum, out.LastAnalyze = stats.LastVacuum, stats.LastAnalyze
+	return out, nil
+}
+
+func (b *Backend) RefreshMaterializedView(ctx context.Context, in backend.RefreshMaterializedViewIn) (*backend.RefreshMaterializedViewOut, error) {
+	if in.Schema == "" {
+		in.Schema = "public"
+	}
+	name := pgx.Identifier{in.Schema, in.Name}.Sanitize()
+
+	var populated []bool
+	if err := b.db.WithContext(ctx).Raw("SELECT ispopulated FROM pg_matviews WHERE schemaname = ? AND matviewname = ?", in.Schema, in.Name).Scan(&populated).Error; err != nil {
+		return nil, err
+	}
+	if len(populated) == 0 {
+		return nil, fmt.Errorf("%s is not a materialized view: pg_matviews has no such entry", name)
+	}
+
+	stmt := "REFRESH MATERIALIZED VIEW " + name
+	if in.Concurrently {
+		// PostgreSQL rejects this too, but not in a way that says what to do instead.
+		if !populated[0] {
+			return nil, fmt.Errorf("%s has never been populated, so it cannot be refreshed concurrently: refresh it once without concurrently", name)
+		}
+		stmt = "REFRESH MATERIALIZED VIEW CONCURRENTLY " + name
+	}
+	if err := b.db.WithContext(ctx).Exec(stmt).Error; err != nil {
+		return nil, err
+	}
+	return &backend.RefreshMaterializedViewOut{Success: true, Message: fmt.Sprintf("Materialized view %s refreshed", name)}, nil
+}
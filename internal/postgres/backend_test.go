@@ -0,0 +1,20 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinternet/databaise/internal/backend"
+)
+
+func TestListBackends(t *testing.T) {
+	for _, b := range backend.ListBackends().Backends {
+		if b.Type == "postgres" {
+			require.Contains(t, b.Tools, "list_missing_indexes")
+			require.Contains(t, b.Tools, "list_materialized_views")
+			require.Contains(t, b.Tools, "refresh_materialized_view")
+			return
+		}
+	}
+	t.Fatal("postgres backend is not registered")
+}
@@ -4,12 +4,15 @@ package postgres
 
 import (
 	"slices"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tinternet/databaise/internal/backend"
+	"github.com/tinternet/databaise/internal/sqlcommon"
 	"github.com/tinternet/databaise/internal/sqltest"
 )
 
@@ -28,8 +31,12 @@ func TestConnect(t *testing.T) {
 
 	t.Run("ReadOnly With BypassReadonlyCheck=false", func(t *testing.T) {
 		t.Parallel()
-		_, err := Connector{}.ConnectRead(ReadConfig{DSN: dsn})
-		require.ErrorContains(t, err, "read DSN user has write permissions")
+		db, err := Connector{}.ConnectRead(ReadConfig{DSN: dsn})
+		require.NoError(t, err)
+		status := Connector{}.CheckReadonly(ReadConfig{DSN: dsn}, db)
+		require.Equal(t, backend.ReadonlyGrantCheck, status.Mode)
+		require.False(t, status.Verified)
+		require.Contains(t, status.Error, "read DSN user has write permissions")
 	})
 
 	t.Run("ReadOnly With BypassReadonlyCheck=true", func(t *testing.T) {
@@ -37,6 +44,7 @@ func TestConnect(t *testing.T) {
 		db, err := Connector{}.ConnectRead(ReadConfig{DSN: dsn, BypassReadonlyCheck: true})
 		require.NotNil(t, db)
 		require.NoError(t, err)
+		require.Equal(t, backend.ReadonlyNone, Connector{}.CheckReadonly(ReadConfig{DSN: dsn, BypassReadonlyCheck: true}, db).Mode)
 	})
 
 	t.Run("ReadOnly With UseReadonlyTx", func(t *testing.T) {
@@ -44,6 +52,9 @@ func TestConnect(t *testing.T) {
 		db, err := Connector{}.ConnectRead(ReadConfig{DSN: dsn, UseReadonlyTx: true})
 		require.NotNil(t, db)
 		require.NoError(t, err)
+		status := Connector{}.CheckReadonly(ReadConfig{DSN: dsn, UseReadonlyTx: true}, db)
+		require.Equal(t, backend.ReadonlyTx, status.Mode)
+		require.True(t, status.Verified)
 	})
 
 	t.Run("Admin", func(t *testing.T) {
@@ -65,6 +76,144 @@ func TestListTables(t *testing.T) {
 	})
 }
 
+func TestListTablesWithStats(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	require.NoError(t, b.db.Exec("ANALYZE").Error)
+
+	tables, err := b.ListTables(t.Context(), backend.ListTablesIn{WithStats: true})
+	require.NoError(t, err)
+	i := slices.IndexFunc(tables, func(tbl backend.Table) bool { return tbl.Name == "users" })
+	require.GreaterOrEqual(t, i, 0)
+	require.EqualValues(t, 3, tables[i].EstimatedRows)
+	require.Positive(t, tables[i].SizeBytes)
+}
+
+func TestListTablesAllSchemas(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	require.NoError(t, b.db.Exec("CREATE SCHEMA sales; CREATE TABLE sales.invoices (id int PRIMARY KEY)").Error)
+
+	tables, err := b.ListTables(t.Context(), backend.ListTablesIn{AllSchemas: true})
+	require.NoError(t, err)
+	require.Equal(t, []backend.Table{
+		{Schema: "public", Name: "orders"},
+		{Schema: "public", Name: "users"},
+		{Schema: "sales", Name: "invoices"},
+	}, tables)
+}
+
+func TestListTablesWithoutPK(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	require.NoError(t, b.db.Exec("CREATE TABLE public.events (payload text)").Error)
+	require.NoError(t, b.db.Exec("CREATE TABLE public.tags (name text UNIQUE)").Error)
+
+	tables, err := b.ListTablesWithoutPK(t.Context(), backend.ListTablesWithoutPKIn{})
+	require.NoError(t, err)
+	require.Equal(t, []backend.Table{{Schema: "public", Name: "events"}, {Schema: "public", Name: "tags"}}, tables)
+}
+
+func TestListSequences(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	require.NoError(t, b.db.Exec("CREATE SEQUENCE public.invoice_numbers INCREMENT BY 10").Error)
+
+	sequences, err := b.ListSequences(t.Context(), backend.ListSequencesIn{})
+	require.NoError(t, err)
+	require.Len(t, sequences, 3)
+	require.Equal(t, backend.Sequence{Schema: "public", Name: "invoice_numbers", StartValue: sequences[0].StartValue, Increment: 10, MaxValue: sequences[0].MaxValue}, sequences[0])
+	require.EqualValues(t, 1, *sequences[0].StartValue)
+	require.Equal(t, "users_id_seq", sequences[2].Name)
+	require.Equal(t, "users", sequences[2].Table)
+	require.Equal(t, "id", sequences[2].Column)
+	require.EqualValues(t, 3, *sequences[2].LastValue)
+	require.EqualValues(t, 1, sequences[2].Increment)
+
+	sequences, err = b.ListSequences(t.Context(), backend.ListSequencesIn{Schema: "other"})
+	require.NoError(t, err)
+	require.Empty(t, sequences)
+}
+
+func TestListSchemas(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	schemas, err := b.ListSchemas(t.Context(), backend.ListSchemasIn{})
+	require.NoError(t, err)
+	require.Contains(t, schemas, "public")
+	require.NotContains(t, schemas, "pg_catalog")
+	require.NotContains(t, schemas, "information_schema")
+
+	schemas, err = b.ListSchemas(t.Context(), backend.ListSchemasIn{IncludeSystem: true})
+	require.NoError(t, err)
+	require.Contains(t, schemas, "pg_catalog")
+}
+
+func TestListViews(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	require.NoError(t, b.db.Exec("CREATE VIEW public.admins AS SELECT username, email FROM public.users WHERE role = 'admin'").Error)
+
+	views, err := b.ListViews(t.Context(), backend.ListViewsIn{})
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	require.Equal(t, "admins", views[0].Name)
+	require.Equal(t, "public", views[0].Schema)
+	require.Contains(t, views[0].Definition, "users")
+
+	views, err = b.ListViews(t.Context(), backend.ListViewsIn{Schema: "other"})
+	require.NoError(t, err)
+	require.Empty(t, views)
+}
+
+func TestListMaterializedViews(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	require.NoError(t, b.db.Exec("CREATE MATERIALIZED VIEW public.user_roles AS SELECT role, count(*) AS n FROM public.users GROUP BY role").Error)
+	require.NoError(t, b.db.Exec("CREATE MATERIALIZED VIEW public.pending_roles AS SELECT DISTINCT role FROM public.users WITH NO DATA").Error)
+
+	views, err := b.ListMaterializedViews(t.Context(), backend.ListMaterializedViewsIn{})
+	require.NoError(t, err)
+	require.Len(t, views, 2)
+	require.Equal(t, "pending_roles", views[0].Name)
+	require.False(t, views[0].Populated)
+	require.Equal(t, "user_roles", views[1].Name)
+	require.Equal(t, "public", views[1].Schema)
+	require.True(t, views[1].Populated)
+	require.Contains(t, views[1].Definition, "users")
+
+	views, err = b.ListMaterializedViews(t.Context(), backend.ListMaterializedViewsIn{Schema: "other"})
+	require.NoError(t, err)
+	require.Empty(t, views)
+}
+
+func TestListForeignKeys(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	foreignKeys, err := b.ListForeignKeys(t.Context(), backend.ListForeignKeysIn{Schema: "public"})
+	require.NoError(t, err)
+	require.Len(t, foreignKeys, 1)
+	fk := foreignKeys[0]
+	require.Equal(t, "orders", fk.Table)
+	require.Equal(t, "user_id", fk.Columns)
+	require.Equal(t, "users", fk.ReferencedTable)
+	require.Equal(t, "id", fk.ReferencedColumns)
+	require.NotEmpty(t, fk.OnUpdate)
+	require.NotEmpty(t, fk.OnDelete)
+	require.Equal(t, "public", fk.ReferencedSchema)
+
+	foreignKeys, err = b.ListForeignKeys(t.Context(), backend.ListForeignKeysIn{Schema: "public", Table: "users"})
+	require.NoError(t, err)
+	require.Empty(t, foreignKeys)
+
+	res, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Schema: "public", Table: "orders"})
+	require.NoError(t, err)
+	require.Len(t, res.ForeignKeys, 1)
+	require.Equal(t, "users", res.ForeignKeys[0].ReferencedTable)
+}
+
 func TestDescribeTable(t *testing.T) {
 	t.Parallel()
 	b := openTestConnection(t)
@@ -90,6 +239,16 @@ func TestDescribeTable(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, res)
 	})
+	t.Run("ReferencedBy", func(t *testing.T) {
+		t.Parallel()
+		res, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Schema: "public", Table: "users", IncludeReferencedBy: true})
+		require.NoError(t, err)
+		require.Len(t, res.ReferencedBy, 1)
+		require.Equal(t, "orders", res.ReferencedBy[0].Table)
+		require.Equal(t, "user_id", res.ReferencedBy[0].Columns)
+		require.Equal(t, "id", res.ReferencedBy[0].ReferencedColumns)
+		require.NotEmpty(t, res.ReferencedBy[0].OnDelete)
+	})
 	t.Run("NonExistentTable", func(t *testing.T) {
 		t.Parallel()
 		res, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Schema: "public", Table: "nonexistent"})
@@ -98,6 +257,26 @@ func TestDescribeTable(t *testing.T) {
 	})
 }
 
+func TestDescribeTableMixedCase(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	require.NoError(t, b.db.Exec(`CREATE TABLE "UserAccounts" (id int PRIMARY KEY)`).Error)
+
+	t.Run("ExactName", func(t *testing.T) {
+		res, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Schema: "public", Table: "UserAccounts"})
+		require.NoError(t, err)
+		require.Contains(t, res.CreateTable, `public."UserAccounts"`)
+		require.Empty(t, res.Hint)
+	})
+
+	t.Run("FoldedName", func(t *testing.T) {
+		res, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Schema: "public", Table: "useraccounts"})
+		require.NoError(t, err)
+		require.Contains(t, res.CreateTable, `public."UserAccounts"`)
+		require.Contains(t, res.Hint, `"public"."UserAccounts"`)
+	})
+}
+
 func TestExecuteQuery(t *testing.T) {
 	t.Parallel()
 	b := openTestConnection(t)
@@ -117,6 +296,15 @@ func TestExecuteQuery(t *testing.T) {
 		require.NoError(t, err)
 		require.Len(t, res.Rows, 0)
 	})
+
+	t.Run("Column Types", func(t *testing.T) {
+		res, err := b.ExecuteQuery(t.Context(), backend.ReadQueryIn{Query: "SELECT id, amount * 2 AS doubled FROM orders"})
+		require.NoError(t, err)
+		require.Len(t, res.ColumnTypes, 2)
+		require.Equal(t, "id", res.ColumnTypes[0].Name)
+		require.Equal(t, "INT8", res.ColumnTypes[0].DatabaseType)
+		require.Equal(t, "doubled", res.ColumnTypes[1].Name)
+	})
 }
 
 func TestExecuteDDL(t *testing.T) {
@@ -146,6 +334,96 @@ func TestExecuteDDL(t *testing.T) {
 	})
 }
 
+func TestExecuteWrite(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	res, err := b.ExecuteWrite(t.Context(), backend.ExecuteWriteIn{
+		Query:  "UPDATE users SET bio = ? WHERE username = ?",
+		Params: []any{"updated", "admin_user"},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, res.RowsAffected)
+
+	t.Run("RefusesDDL", func(t *testing.T) {
+		_, err := b.ExecuteWrite(t.Context(), backend.ExecuteWriteIn{Query: "DROP TABLE orders"})
+		require.ErrorContains(t, err, "only INSERT, UPDATE and DELETE")
+	})
+}
+
+func TestCheckDDL(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	t.Run("UniqueIndexWithDuplicates", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "CREATE UNIQUE INDEX ix_users_active ON public.users (active)"})
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+		require.Len(t, report.Checks, 1)
+		require.EqualValues(t, 1, report.Checks[0].Violations)
+		require.Len(t, report.Checks[0].Samples, 1)
+	})
+
+	t.Run("UniqueIndex", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "CREATE UNIQUE INDEX ix_users_email ON public.users (email)"})
+		require.NoError(t, err)
+		require.True(t, report.WouldSucceed)
+	})
+
+	t.Run("SetNotNull", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "ALTER TABLE public.orders ALTER COLUMN shipped_at SET NOT NULL"})
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+		require.EqualValues(t, 2, report.Checks[0].Violations)
+	})
+
+	t.Run("Check", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "ALTER TABLE public.users ADD CONSTRAINT ck_users_salary CHECK (salary > 0)"})
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+		require.EqualValues(t, 1, report.Checks[0].Violations)
+	})
+
+	t.Run("ForeignKeyToPrimaryKey", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "ALTER TABLE public.users ADD CONSTRAINT fk_users_age FOREIGN KEY (age) REFERENCES public.orders"})
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+		require.EqualValues(t, 3, report.Checks[0].Violations)
+	})
+
+	t.Run("NotApplied", func(t *testing.T) {
+		res, err := b.ExecuteDDL(t.Context(), backend.ExecuteDDLIn{DDL: "CREATE UNIQUE INDEX ix_users_email ON public.users (email)"})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+	})
+}
+
+func TestRecommendIndexes(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	t.Run("EqualitySortRange", func(t *testing.T) {
+		t.Parallel()
+		advice, err := b.RecommendIndexes(t.Context(), backend.RecommendIndexesIn{Query: "SELECT username FROM public.users WHERE role = ? AND age > ? ORDER BY salary", Params: []any{"admin", 18}})
+		require.NoError(t, err)
+		require.Len(t, advice.Recommendations, 1)
+		rec := advice.Recommendations[0]
+		require.Equal(t, []string{"role", "salary", "age"}, rec.Columns[:3])
+		require.Contains(t, rec.CreateStatement, "CREATE INDEX")
+		if rec.CostAfter == nil {
+			require.Contains(t, advice.Notes, "Install the hypopg extension to get the planner's estimated cost of the query with each index.")
+		}
+	})
+	t.Run("ExistingIndex", func(t *testing.T) {
+		t.Parallel()
+		advice, err := b.RecommendIndexes(t.Context(), backend.RecommendIndexesIn{Query: "SELECT id FROM public.orders WHERE user_id = ?", Params: []any{1}})
+		require.NoError(t, err)
+		require.Empty(t, advice.Recommendations)
+		require.Len(t, advice.Notes, 1)
+		require.Contains(t, advice.Notes[0], "idx_orders_user_id")
+	})
+}
+
 func TestExplainQuery(t *testing.T) {
 	t.Parallel()
 	b := openTestConnection(t)
@@ -156,6 +434,12 @@ func TestExplainQuery(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "json", res.Format)
 		require.Greater(t, len(res.Result), 1)
+		require.Len(t, res.Plan, 1)
+		require.Equal(t, "Seq Scan", res.Plan[0].Operation)
+		require.Equal(t, "public.orders", res.Plan[0].Table)
+		require.NotNil(t, res.Plan[0].Cost)
+		require.Nil(t, res.Plan[0].ActualRows)
+		require.True(t, res.FullScanDetected)
 	})
 	t.Run("ExplainAnalyze", func(t *testing.T) {
 		t.Parallel()
@@ -163,6 +447,26 @@ func TestExplainQuery(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "json", res.Format)
 		require.Greater(t, len(res.Result), 1)
+		require.Len(t, res.Plan, 1)
+		require.NotNil(t, res.Plan[0].ActualRows)
+		require.EqualValues(t, 2, *res.Plan[0].ActualRows)
+	})
+	t.Run("ExplainAnalyzeRollsBack", func(t *testing.T) {
+		t.Parallel()
+		res, err := b.ExplainQuery(t.Context(), backend.ExplainQueryIn{Query: "UPDATE public.users SET bio = 'explained' WHERE username = 'admin_user'", Analyze: true})
+		require.NoError(t, err)
+		require.Greater(t, len(res.Result), 1)
+
+		var count int64
+		require.NoError(t, b.db.Raw("SELECT COUNT(*) FROM public.users WHERE bio = 'explained'").Scan(&count).Error)
+		require.Zero(t, count)
+	})
+	t.Run("ExplainWithParams", func(t *testing.T) {
+		t.Parallel()
+		res, err := b.ExplainQuery(t.Context(), backend.ExplainQueryIn{Query: "SELECT * FROM public.orders WHERE id = ?", Params: []any{1}})
+		require.NoError(t, err)
+		require.Equal(t, "json", res.Format)
+		require.Greater(t, len(res.Result), 1)
 	})
 
 	t.Run("MalformedQuery", func(t *testing.T) {
@@ -209,8 +513,12 @@ func TestReadonlyTransactionEnforcement(t *testing.T) {
 func TestListMissingIndexes(t *testing.T) {
 	t.Parallel()
 	b := openTestConnection(t)
-	_, err := b.ListMissingIndexes(t.Context())
-	require.ErrorContains(t, err, "does not provide automatic index recommendations")
+	res, err := b.ListMissingIndexes(t.Context())
+	require.NoError(t, err)
+	// The seeded tables are far below the size worth indexing.
+	for _, idx := range res {
+		require.NotContains(t, []string{"users", "orders"}, idx.TableName)
+	}
 }
 
 func TestListWaitingQueries(t *testing.T) {
@@ -218,12 +526,57 @@ func TestListWaitingQueries(t *testing.T) {
 	b := openTestConnection(t)
 	res, err := b.ListWaitingQueries(t.Context())
 	require.NoError(t, err)
-	require.NotNil(t, res)
+	require.Empty(t, res)
+
+	// Hold a row lock and make a second session wait for it.
+	tx := b.db.Begin()
+	defer tx.Rollback()
+	require.NoError(t, tx.Exec("UPDATE users SET bio = 'locked' WHERE username = 'admin_user'").Error)
+	var pid int
+	require.NoError(t, tx.Raw("SELECT pg_backend_pid()").Scan(&pid).Error)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- b.db.Exec("UPDATE users SET bio = 'waiting' WHERE username = 'admin_user'").Error
+	}()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		res, err := b.ListWaitingQueries(t.Context())
+		require.NoError(c, err)
+		require.Len(c, res, 1)
+		require.Equal(c, strconv.Itoa(pid), res[0].BlockedBy)
+		require.Contains(c, res[0].WaitType, "Lock")
+		require.Contains(c, res[0].Query, "'waiting'")
+	}, 10*time.Second, 100*time.Millisecond)
+
+	require.NoError(t, tx.Rollback().Error)
+	require.NoError(t, <-done)
+}
+
+func TestListConnections(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	// Hold a second session open in a transaction.
+	tx := b.db.Begin()
+	defer tx.Rollback()
+	var id int
+	require.NoError(t, tx.Raw("SELECT pg_backend_pid()").Scan(&id).Error)
+
+	connections, err := b.ListConnections(t.Context())
+	require.NoError(t, err)
+	idx := slices.IndexFunc(connections, func(c backend.Connection) bool { return c.ID == strconv.Itoa(id) })
+	require.NotEqual(t, -1, idx, "the held session is listed")
+	require.NotEmpty(t, connections[idx].Username)
+	require.Equal(t, "idle in transaction", connections[idx].State)
 }
 
 func TestListSlowestQueries(t *testing.T) {
 	t.Parallel()
 	b := openTestConnection(t)
+	_, err := b.ListSlowestQueries(t.Context())
+	require.ErrorContains(t, err, "CREATE EXTENSION pg_stat_statements")
+
 	require.NoError(t, b.db.Exec("CREATE EXTENSION IF NOT EXISTS pg_stat_statements").Error)
 	res, err := b.ListSlowestQueries(t.Context())
 	require.NoError(t, err)
@@ -231,6 +584,7 @@ func TestListSlowestQueries(t *testing.T) {
 	require.NotNil(t, res.Columns)
 	require.NotNil(t, res.Queries)
 	// Verify expected columns are documented
+	require.Contains(t, res.Columns, "query_hash")
 	require.Contains(t, res.Columns, "query")
 	require.Contains(t, res.Columns, "calls")
 	require.Contains(t, res.Columns, "total_time_sec")
@@ -244,3 +598,225 @@ func TestListDeadlocks(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, res)
 }
+
+func TestKillIdleTransactions(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	t.Run("NotEnabled", func(t *testing.T) {
+		_, err := b.KillIdleTransactions(t.Context(), backend.KillIdleTransactionsIn{})
+		require.ErrorContains(t, err, "allow_terminate_sessions")
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		enabled := &Backend{db: DB{DB: b.db.DB, AllowTerminateSessions: true}}
+		res, err := enabled.KillIdleTransactions(t.Context(), backend.KillIdleTransactionsIn{OlderThanSec: 3600})
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Terminated)
+	})
+}
+
+func TestRefreshMaterializedView(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	require.NoError(t, b.db.Exec("CREATE MATERIALIZED VIEW public.user_counts AS SELECT username, count(*) AS n FROM public.users GROUP BY username WITH NO DATA").Error)
+
+	t.Run("NotPopulated", func(t *testing.T) {
+		_, err := b.RefreshMaterializedView(t.Context(), backend.RefreshMaterializedViewIn{Name: "user_counts", Concurrently: true})
+		require.ErrorContains(t, err, "never been populated")
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		res, err := b.RefreshMaterializedView(t.Context(), backend.RefreshMaterializedViewIn{Schema: "public", Name: "user_counts"})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+		require.Contains(t, res.Message, "public.user_counts")
+	})
+
+	t.Run("Concurrently", func(t *testing.T) {
+		require.NoError(t, b.db.Exec("CREATE UNIQUE INDEX ON public.user_counts (username)").Error)
+		res, err := b.RefreshMaterializedView(t.Context(), backend.RefreshMaterializedViewIn{Name: "user_counts", Concurrently: true})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+	})
+
+	t.Run("NotMaterializedView", func(t *testing.T) {
+		_, err := b.RefreshMaterializedView(t.Context(), backend.RefreshMaterializedViewIn{Name: "users"})
+		require.ErrorContains(t, err, "not a materialized view")
+	})
+}
+
+func TestSampleTable(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	res, err := b.SampleTable(t.Context(), backend.SampleTableIn{Schema: "public", Table: "users", Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, 2, res.RowCount)
+	require.Contains(t, res.Columns, "username")
+	require.NotEmpty(t, res.Rows[0]["username"])
+
+	_, err = b.SampleTable(t.Context(), backend.SampleTableIn{Schema: "public", Table: "nonexistent", Limit: 2})
+	require.Error(t, err)
+}
+
+func TestAnalyzeTable(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	t.Run("Success", func(t *testing.T) {
+		res, err := b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{Schema: "public", Table: "users"})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+		require.NotNil(t, res.StatsUpdatedAt)
+	})
+
+	t.Run("WholeDatabase", func(t *testing.T) {
+		res, err := b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+
+		res, err = b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{Schema: "public"})
+		require.NoError(t, err)
+		require.Contains(t, res.Message, "tables in public")
+
+		_, err = b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{Schema: "nope"})
+		require.ErrorContains(t, err, "has no tables")
+	})
+
+	t.Run("NonExistentTable", func(t *testing.T) {
+		res, err := b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{Schema: "public", Table: "nonexistent"})
+		require.Nil(t, res)
+		require.ErrorContains(t, err, "does not exist")
+	})
+}
+
+func TestGetPrimaryKey(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	columns, err := sqlcommon.GetPrimaryKey(t.Context(), b.db.DB, "public", "users")
+	require.NoError(t, err)
+	require.Equal(t, []string{"id"}, columns)
+
+	_, err = sqlcommon.GetPrimaryKey(t.Context(), b.db.DB, "public", "nonexistent")
+	require.ErrorIs(t, sqlcommon.TranslateError(err), sqlcommon.ErrTableNotFound)
+}
+
+func TestTableSizes(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	sizes, err := b.TableSizes(t.Context())
+	require.NoError(t, err)
+	require.Len(t, sizes, 2)
+	for _, s := range sizes {
+		require.Equal(t, "public", s.Schema)
+		require.Contains(t, []string{"users", "orders"}, s.Name)
+		require.Positive(t, s.Bytes)
+	}
+}
+
+func TestTableProfile(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	profile, err := b.TableProfile(t.Context(), backend.TableProfileIn{Schema: "public", Table: "orders"})
+	require.NoError(t, err)
+	require.Equal(t, "public", profile.Schema)
+	require.Equal(t, "orders", profile.Table)
+	require.Positive(t, profile.TotalBytes)
+	require.NotEmpty(t, profile.Indexes)
+	require.NotNil(t, profile.SeqScans)
+	require.NotNil(t, profile.IndexScans)
+
+	_, err = b.TableProfile(t.Context(), backend.TableProfileIn{Schema: "public", Table: "nonexistent"})
+	require.ErrorIs(t, sqlcommon.TranslateError(err), sqlcommon.ErrTableNotFound)
+}
+
+func TestProfileCategoricalColumns(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	out, err := b.ProfileCategoricalColumns(t.Context(), backend.ProfileCategoricalColumnsIn{Schema: "public", Table: "users", MaxDistinct: 2})
+	require.NoError(t, err)
+	require.Contains(t, out.Skipped, "email")
+
+	var active *sqlcommon.CategoricalColumn
+	for i, c := range out.Columns {
+		if c.Name == "active" {
+			active = &out.Columns[i]
+		}
+	}
+	require.NotNil(t, active)
+	require.Len(t, active.Values, 1)
+	require.EqualValues(t, 3, active.Values[0].Count)
+}
+
+func TestTableJSONSchema(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	out, err := b.TableJSONSchema(t.Context(), backend.TableJSONSchemaIn{Schema: "public", Table: "users"})
+	require.NoError(t, err)
+	require.Equal(t, "object", out.JSONSchema["type"])
+	require.Subset(t, out.JSONSchema["required"], []string{"username", "email"})
+	require.NotContains(t, out.JSONSchema["required"], "role")
+
+	properties := out.JSONSchema["properties"].(map[string]any)
+	require.Equal(t, "integer", properties["id"].(map[string]any)["type"])
+	require.Equal(t, 50, properties["username"].(map[string]any)["maxLength"])
+	require.Equal(t, "date-time", properties["created_at"].(map[string]any)["format"])
+
+	t.Run("TableNotFound", func(t *testing.T) {
+		_, err := b.TableJSONSchema(t.Context(), backend.TableJSONSchemaIn{Schema: "public", Table: "missing"})
+		require.ErrorIs(t, err, sqlcommon.ErrTableNotFound)
+	})
+}
+
+func TestSetComment(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	description := func() any {
+		out, err := b.TableJSONSchema(t.Context(), backend.TableJSONSchemaIn{Schema: "public", Table: "users"})
+		require.NoError(t, err)
+		return out.JSONSchema["properties"].(map[string]any)["email"].(map[string]any)["description"]
+	}
+
+	t.Run("Column", func(t *testing.T) {
+		comment := "Login address, e.g. o'brien@example.com"
+		out, err := b.SetComment(t.Context(), backend.SetCommentIn{Schema: "public", Table: "users", Column: "email", Comment: comment})
+		require.NoError(t, err)
+		require.True(t, out.Success)
+		require.Equal(t, comment, description())
+
+		_, err = b.SetComment(t.Context(), backend.SetCommentIn{Schema: "public", Table: "users", Column: "email", Comment: "Updated"})
+		require.NoError(t, err)
+		require.Equal(t, "Updated", description())
+
+		desc, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Schema: "public", Table: "users"})
+		require.NoError(t, err)
+		require.Equal(t, "Updated", desc.ColumnComments["email"])
+
+		out, err = b.SetComment(t.Context(), backend.SetCommentIn{Schema: "public", Table: "users", Column: "email"})
+		require.NoError(t, err)
+		require.Contains(t, out.Message, "removed")
+		require.Nil(t, description())
+	})
+
+	t.Run("Table", func(t *testing.T) {
+		out, err := b.SetComment(t.Context(), backend.SetCommentIn{Schema: "public", Table: "users", Comment: "Registered accounts"})
+		require.NoError(t, err)
+		require.True(t, out.Success)
+
+		desc, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Schema: "public", Table: "users"})
+		require.NoError(t, err)
+		require.Equal(t, "Registered accounts", desc.TableComment)
+	})
+
+	t.Run("ColumnNotFound", func(t *testing.T) {
+		_, err := b.SetComment(t.Context(), backend.SetCommentIn{Schema: "public", Table: "users", Column: "missing", Comment: "x"})
+		require.ErrorIs(t, err, sqlcommon.ErrColumnNotFound)
+	})
+}
@@ -0,0 +1,13 @@
+SELECT
+  pid,
+  usename AS username,
+  datname AS database_name,
+  application_name,
+  EXTRACT(EPOCH FROM (now() - state_change))::float8 AS idle_sec,
+  query,
+  pg_terminate_backend(pid) AS terminated
+FROM pg_stat_activity
+WHERE state IN ('idle in transaction', 'idle in transaction (aborted)')
+  AND state_change < now() - make_interval(secs => ?)
+  AND datname = current_database()
+  AND pid <> pg_backend_pid();
@@ -0,0 +1,14 @@
+SELECT
+    pid,
+    usename AS username,
+    datname AS database_name,
+    COALESCE(host(client_addr), '') AS client_address,
+    application_name,
+    COALESCE(state, '') AS state,
+    COALESCE(EXTRACT(EPOCH FROM (NOW() - backend_start)), 0) AS connected_sec,
+    COALESCE(EXTRACT(EPOCH FROM (NOW() - query_start)), 0) AS query_duration_sec,
+    COALESCE(query, '') AS query_text
+FROM pg_stat_activity
+WHERE backend_type = 'client backend'
+  AND pid != pg_backend_pid()
+ORDER BY backend_start ASC
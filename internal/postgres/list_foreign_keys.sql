@@ -0,0 +1,30 @@
+SELECT
+  c.conname AS constraint_name,
+  n.nspname AS schema_name,
+  t.relname AS table_name,
+  (SELECT string_agg(a.attname, ', ' ORDER BY k.ord)
+     FROM unnest(c.conkey) WITH ORDINALITY AS k(attnum, ord)
+     JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum) AS columns,
+  rn.nspname AS referenced_schema,
+  rt.relname AS referenced_table,
+  (SELECT string_agg(a.attname, ', ' ORDER BY k.ord)
+     FROM unnest(c.confkey) WITH ORDINALITY AS k(attnum, ord)
+     JOIN pg_attribute a ON a.attrelid = c.confrelid AND a.attnum = k.attnum) AS referenced_columns,
+  CASE c.confdeltype
+    WHEN 'a' THEN 'NO ACTION' WHEN 'r' THEN 'RESTRICT' WHEN 'c' THEN 'CASCADE'
+    WHEN 'n' THEN 'SET NULL' WHEN 'd' THEN 'SET DEFAULT' END AS on_delete,
+  CASE c.confupdtype
+    WHEN 'a' THEN 'NO ACTION' WHEN 'r' THEN 'RESTRICT' WHEN 'c' THEN 'CASCADE'
+    WHEN 'n' THEN 'SET NULL' WHEN 'd' THEN 'SET DEFAULT' END AS on_update
+FROM pg_constraint c
+JOIN pg_class t ON t.oid = c.conrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+JOIN pg_class rt ON rt.oid = c.confrelid
+JOIN pg_namespace rn ON rn.oid = rt.relnamespace
+WHERE c.contype = 'f'
+  AND CASE WHEN $1 = ''
+      THEN n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname NOT LIKE 'pg\_toast%' AND n.nspname NOT LIKE 'pg\_temp\_%'
+      ELSE n.nspname = $1
+  END
+  AND ($2 = '' OR t.relname = $2)
+ORDER BY n.nspname, t.relname, c.conname
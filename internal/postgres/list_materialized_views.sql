@@ -0,0 +1,7 @@
+SELECT m.schemaname AS schema, m.matviewname AS name, m.definition, m.ispopulated AS populated
+FROM pg_matviews m
+WHERE CASE WHEN $1 = ''
+      THEN m.schemaname NOT IN ('pg_catalog', 'information_schema') AND m.schemaname NOT LIKE 'pg\_toast%' AND m.schemaname NOT LIKE 'pg\_temp\_%'
+      ELSE m.schemaname = $1
+  END
+ORDER BY m.schemaname, m.matviewname
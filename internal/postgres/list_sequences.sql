@@ -0,0 +1,15 @@
+SELECT s.schemaname AS schema, s.sequencename AS name, t.relname AS "table", a.attname AS "column",
+       s.start_value, s.last_value, s.increment_by AS increment, s.max_value
+FROM pg_sequences s
+JOIN pg_namespace n ON n.nspname = s.schemaname
+JOIN pg_class c ON c.relnamespace = n.oid AND c.relname = s.sequencename
+-- 'a' links a serial column's sequence, 'i' an identity column's
+LEFT JOIN pg_depend d ON d.classid = 'pg_class'::regclass AND d.objid = c.oid
+    AND d.refclassid = 'pg_class'::regclass AND d.deptype IN ('a', 'i')
+LEFT JOIN pg_class t ON t.oid = d.refobjid
+LEFT JOIN pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+WHERE CASE WHEN $1 = ''
+      THEN s.schemaname NOT IN ('pg_catalog', 'information_schema') AND s.schemaname NOT LIKE 'pg\_toast%' AND s.schemaname NOT LIKE 'pg\_temp\_%'
+      ELSE s.schemaname = $1
+  END
+ORDER BY s.schemaname, s.sequencename
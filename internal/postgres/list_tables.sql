@@ -1,4 +1,8 @@
 SELECT table_schema as schema, table_name as name
 FROM information_schema.tables
-WHERE table_schema = COALESCE(NULLIF($1, ''), 'public') AND table_type = 'BASE TABLE'
-ORDER BY table_name
+WHERE table_type = 'BASE TABLE'
+  AND CASE WHEN $2
+      THEN table_schema NOT IN ('pg_catalog', 'information_schema') AND table_schema NOT LIKE 'pg\_toast%' AND table_schema NOT LIKE 'pg\_temp\_%'
+      ELSE table_schema = COALESCE(NULLIF($1, ''), 'public')
+  END
+ORDER BY table_schema, table_name
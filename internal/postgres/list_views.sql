@@ -0,0 +1,9 @@
+-- pg_views rather than information_schema.views, which hides the definition of
+-- views the current user does not own.
+SELECT v.schemaname AS schema, v.viewname AS name, v.definition
+FROM pg_views v
+WHERE CASE WHEN $1 = ''
+      THEN v.schemaname NOT IN ('pg_catalog', 'information_schema') AND v.schemaname NOT LIKE 'pg\_toast%' AND v.schemaname NOT LIKE 'pg\_temp\_%'
+      ELSE v.schemaname = $1
+  END
+ORDER BY v.schemaname, v.viewname
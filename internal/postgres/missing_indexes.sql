@@ -0,0 +1,15 @@
+SELECT
+    schemaname AS schema,
+    relname AS table_name,
+    seq_scan,
+    seq_tup_read,
+    COALESCE(idx_scan, 0) AS idx_scan,
+    n_live_tup,
+    pg_relation_size(relid) AS table_bytes,
+    'CREATE INDEX ON ' || quote_ident(schemaname) || '.' || quote_ident(relname) || ' (...)' AS create_statement
+FROM pg_stat_user_tables
+WHERE seq_scan > COALESCE(idx_scan, 0)
+  AND seq_tup_read > 0
+  AND pg_relation_size(relid) >= $1
+ORDER BY seq_tup_read DESC
+LIMIT 25
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tinternet/databaise/internal/backend"
+	"github.com/tinternet/databaise/internal/sqlcommon"
+)
+
+// explainPlan is a statement of the output of EXPLAIN (FORMAT JSON).
+type explainPlan struct {
+	Plan planNode `json:"Plan"`
+}
+
+type planNode struct {
+	NodeType     string     `json:"Node Type"`
+	JoinType     string     `json:"Join Type"`
+	RelationName string     `json:"Relation Name"`
+	Schema       string     `json:"Schema"`
+	IndexName    string     `json:"Index Name"`
+	PlanRows     float64    `json:"Plan Rows"`
+	TotalCost    float64    `json:"Total Cost"`
+	ActualRows   *float64   `json:"Actual Rows"`
+	Plans        []planNode `json:"Plans"`
+}
+
+func parsePlan(planJSON string) ([]explainPlan, error) {
+	var plans []explainPlan
+	if err := json.Unmarshal([]byte(planJSON), &plans); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+	return plans, nil
+}
+
+// planTree converts the plans of EXPLAIN (FORMAT JSON) to backend.PlanNode trees.
+func planTree(plans []explainPlan) []backend.PlanNode {
+	nodes := make([]backend.PlanNode, len(plans))
+	for i, p := range plans {
+		nodes[i] = p.Plan.normalize()
+	}
+	return nodes
+}
+
+func (n planNode) normalize() backend.PlanNode {
+	node := backend.PlanNode{
+		Operation:     n.NodeType,
+		Table:         n.RelationName,
+		Index:         n.IndexName,
+		EstimatedRows: &n.PlanRows,
+		ActualRows:    n.ActualRows,
+		Cost:          &n.TotalCost,
+	}
+	// "Hash Join" alone does not say it is a left or anti join.
+	if n.JoinType != "" && n.JoinType != "Inner" {
+		node.Operation += " (" + n.JoinType + ")"
+	}
+	if n.Schema != "" && n.RelationName != "" {
+		node.Table = n.Schema + "." + n.RelationName
+	}
+	for _, child := range n.Plans {
+		node.Children = append(node.Children, child.normalize())
+	}
+	return node
+}
+
+// seqScans returns the Seq Scan nodes of the plans, in plan order.
+func seqScans(plans []explainPlan) []planNode {
+	var scans []planNode
+	var walk func(n planNode)
+	walk = func(n planNode) {
+		if n.NodeType == "Seq Scan" {
+			scans = append(scans, n)
+		}
+		for _, child := range n.Plans {
+			walk(child)
+		}
+	}
+	for _, p := range plans {
+		walk(p.Plan)
+	}
+	return scans
+}
+
+// relationName returns the name of the relation a scan node reads, for
+// to_regclass. A plan without VERBOSE leaves out the schema, so the relation is
+// qualified with the schema the query names it under, or left for the search_path
+// to resolve as the planner did.
+func relationName(n planNode, tables []sqlcommon.TableName) string {
+	schema := n.Schema
+	for _, t := range tables {
+		if schema == "" && t.Schema != "" && strings.EqualFold(t.Name, n.RelationName) {
+			schema = t.Schema
+		}
+	}
+	if schema == "" {
+		return pgx.Identifier{n.RelationName}.Sanitize()
+	}
+	return pgx.Identifier{schema, n.RelationName}.Sanitize()
+}
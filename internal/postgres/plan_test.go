@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinternet/databaise/internal/backend"
+	"github.com/tinternet/databaise/internal/sqlcommon"
+)
+
+func readPlan(t *testing.T, name string) []explainPlan {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	plans, err := parsePlan(string(data))
+	require.NoError(t, err)
+	return plans
+}
+
+func TestPlanTree(t *testing.T) {
+	nodes := planTree(readPlan(t, "seq_scan.json"))
+	require.Len(t, nodes, 1)
+	require.Equal(t, "Nested Loop", nodes[0].Operation)
+	require.Equal(t, 52.61, *nodes[0].Cost)
+	require.Len(t, nodes[0].Children, 2)
+
+	scan := nodes[0].Children[0].(backend.PlanNode)
+	require.Equal(t, "Seq Scan", scan.Operation)
+	require.Equal(t, "orders", scan.Table)
+	require.Equal(t, 453.0, *scan.EstimatedRows)
+	require.Nil(t, scan.ActualRows)
+
+	lookup := nodes[0].Children[1].(backend.PlanNode)
+	require.Equal(t, "Index Scan", lookup.Operation)
+	require.Equal(t, "users_pkey", lookup.Index)
+}
+
+func TestSeqScans(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []string
+	}{
+		{"seq_scan.json", []string{"orders"}},
+		{"index_scan.json", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			var tables []string
+			for _, n := range seqScans(readPlan(t, tt.fixture)) {
+				tables = append(tables, n.RelationName)
+			}
+			require.Equal(t, tt.want, tables)
+		})
+	}
+}
+
+func TestRelationName(t *testing.T) {
+	tests := []struct {
+		name  string
+		node  planNode
+		query string
+		want  string
+	}{
+		{"Unqualified", planNode{RelationName: "orders"}, "SELECT * FROM orders", `"orders"`},
+		{"QualifiedInQuery", planNode{RelationName: "orders"}, "SELECT * FROM archive.orders o JOIN users u ON u.id = o.user_id", `"archive"."orders"`},
+		{"OtherTableQualified", planNode{RelationName: "orders"}, "SELECT * FROM orders o JOIN archive.users u ON u.id = o.user_id", `"orders"`},
+		{"VerbosePlan", planNode{RelationName: "orders", Schema: "sales"}, "SELECT * FROM orders", `"sales"."orders"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, relationName(tt.node, sqlcommon.QueryTables(tt.query)))
+		})
+	}
+}
@@ -0,0 +1,6 @@
+SELECT i.relname AS name, pg_relation_size(i.oid) AS bytes, s.idx_scan AS scans
+FROM pg_index x
+JOIN pg_class i ON i.oid = x.indexrelid
+LEFT JOIN pg_stat_all_indexes s ON s.indexrelid = x.indexrelid
+WHERE x.indrelid = $1::regclass
+ORDER BY bytes DESC, name
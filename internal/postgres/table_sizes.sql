@@ -0,0 +1,7 @@
+SELECT n.nspname AS schema, c.relname AS name,
+       GREATEST(c.reltuples, 0)::bigint AS row_estimate,
+       pg_total_relation_size(c.oid) AS bytes
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p') AND NOT c.relispartition
+  AND n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname NOT LIKE 'pg\_toast%' AND n.nspname NOT LIKE 'pg\_temp\_%'
@@ -0,0 +1,47 @@
+package provision
+
+import (
+	"net/url"
+	"strings"
+
+	gomysql "github.com/go-sql-driver/mysql"
+)
+
+// replaceURLCredentials sets the user info of a URL-style DSN.
+func replaceURLCredentials(dsn, user, pass string) (string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", err
+	}
+	u.User = url.UserPassword(user, pass)
+	return u.String(), nil
+}
+
+// postgresUserDSN swaps the credentials in a PostgreSQL URL or keyword/value DSN.
+func postgresUserDSN(dsn, user, pass string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		return replaceURLCredentials(dsn, user, pass)
+	}
+	// In keyword/value form a later setting overrides an earlier one.
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return dsn + " user='" + quote.Replace(user) + "' password='" + quote.Replace(pass) + "'", nil
+}
+
+// mysqlUserDSN swaps the credentials in a Go MySQL driver DSN.
+func mysqlUserDSN(dsn, user, pass string) (string, error) {
+	cfg, err := gomysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	cfg.User, cfg.Passwd = user, pass
+	return cfg.FormatDSN(), nil
+}
+
+// sqlserverUserDSN swaps the credentials in a SQL Server URL or ADO-style DSN.
+func sqlserverUserDSN(dsn, user, pass string) (string, error) {
+	if strings.HasPrefix(dsn, "sqlserver://") {
+		return replaceURLCredentials(dsn, user, pass)
+	}
+	// In ADO form a later setting overrides an earlier one.
+	return strings.TrimSuffix(dsn, ";") + ";user id=" + user + ";password=" + pass, nil
+}
@@ -0,0 +1,49 @@
+package provision
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/microsoft/go-mssqldb/msdsn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserDSN(t *testing.T) {
+	t.Run("PostgresURL", func(t *testing.T) {
+		dsn, err := postgresUserDSN("postgres://admin:secret@db:5432/app?sslmode=require", "reader", "p@ss/word")
+		require.NoError(t, err)
+		require.Equal(t, "postgres://reader:p%40ss%2Fword@db:5432/app?sslmode=require", dsn)
+	})
+
+	t.Run("PostgresKeywordValue", func(t *testing.T) {
+		dsn, err := postgresUserDSN("host=db user=admin password=secret dbname=app", "reader", `it's\x`)
+		require.NoError(t, err)
+		cfg, err := pgx.ParseConfig(dsn)
+		require.NoError(t, err)
+		require.Equal(t, "reader", cfg.User)
+		require.Equal(t, `it's\x`, cfg.Password)
+		require.Equal(t, "app", cfg.Database)
+	})
+
+	t.Run("MySQL", func(t *testing.T) {
+		dsn, err := mysqlUserDSN("root:secret@tcp(db:3306)/app?parseTime=true", "reader", "p@ss")
+		require.NoError(t, err)
+		require.Equal(t, "reader:p@ss@tcp(db:3306)/app?parseTime=true", dsn)
+	})
+
+	t.Run("SqlServerURL", func(t *testing.T) {
+		dsn, err := sqlserverUserDSN("sqlserver://sa:secret@db:1433?database=app", "reader", "p@ss")
+		require.NoError(t, err)
+		require.Equal(t, "sqlserver://reader:p%40ss@db:1433?database=app", dsn)
+	})
+
+	t.Run("SqlServerADO", func(t *testing.T) {
+		dsn, err := sqlserverUserDSN("server=db;user id=sa;password=secret;database=app;", "reader", "p@ss")
+		require.NoError(t, err)
+		cfg, err := msdsn.Parse(dsn)
+		require.NoError(t, err)
+		require.Equal(t, "reader", cfg.User)
+		require.Equal(t, "p@ss", cfg.Password)
+		require.Equal(t, "app", cfg.Database)
+	})
+}
@@ -3,7 +3,9 @@
 package provision
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
@@ -105,6 +107,28 @@ func testBadInputs(t *testing.T, provisioner Provisioner, dsn string) {
 		Resources: []string{"'"},
 	}))
 
+	// Injection attempts are rejected before reaching the database
+	require.Error(t, provisioner.CreateUser(t.Context(), "x; DROP TABLE test_data; --", password))
+	require.Error(t, provisioner.DropUser(t.Context(), "testuser; DROP TABLE test_data"))
+	require.Error(t, provisioner.RevokeGrants(t.Context(), "testuser; DROP TABLE test_data"))
+	require.Error(t, provisioner.GrantReadOnly(t.Context(), "testuser", AccessScope{
+		Groups: []string{"public; DROP TABLE test_data"},
+	}))
+	require.Error(t, provisioner.GrantReadOnly(t.Context(), "testuser", AccessScope{
+		Resources: []string{"test_data TO PUBLIC; --"},
+	}))
+	require.Error(t, provisioner.GrantReadOnly(t.Context(), "testuser'", AccessScope{}))
+	exists, err := provisioner.UserExists(t.Context(), "x")
+	require.NoError(t, err)
+	require.False(t, *exists)
+
+	// A password with quotes and backslashes is stored verbatim
+	require.NoError(t, provisioner.CreateUser(t.Context(), "quoteuser", `it's'); DROP TABLE test_data; --\`))
+	exists, err = provisioner.UserExists(t.Context(), "quoteuser")
+	require.NoError(t, err)
+	require.True(t, *exists)
+	require.NoError(t, provisioner.DropUser(t.Context(), "quoteuser"))
+
 	// For coverage
 	require.NoError(t, provisioner.Close())
 	require.NoError(t, provisioner.Close())
@@ -138,3 +162,203 @@ func testDropUser(t *testing.T, provisioner Provisioner, dsn string) {
 	require.NoError(t, err)
 	require.False(t, *exists)
 }
+
+func testBulkRevoke(t *testing.T, provisioner Provisioner, dsn string) {
+	require.NoError(t, provisioner.Connect(dsn))
+	for _, user := range []string{"ro_alpha", "ro_beta", "keepuser"} {
+		password, err := GeneratePassword()
+		require.NoError(t, err)
+		require.NoError(t, provisioner.CreateUser(t.Context(), user, password))
+	}
+
+	_, err := UsersWithPrefix(t.Context(), provisioner, "")
+	require.Error(t, err)
+
+	users, err := UsersWithPrefix(t.Context(), provisioner, "ro_")
+	require.NoError(t, err)
+	require.Equal(t, []string{"ro_alpha", "ro_beta"}, users)
+
+	for _, r := range DropUsers(t.Context(), provisioner, users) {
+		require.NoError(t, r.Err, r.User)
+	}
+
+	all, err := provisioner.ListUsers(t.Context())
+	require.NoError(t, err)
+	require.Contains(t, all, "keepuser")
+	require.NotContains(t, all, "ro_alpha")
+	require.NotContains(t, all, "ro_beta")
+}
+
+func testListGrants(t *testing.T, provisioner Provisioner, admin *gorm.DB, group string) {
+	t.Helper()
+	migrateGormDatabase(t, admin)
+	password, err := GeneratePassword()
+	require.NoError(t, err)
+	require.NoError(t, provisioner.CreateUser(t.Context(), "ro_lister", password))
+
+	grants, err := provisioner.ListGrants(t.Context(), "ro_lister")
+	require.NoError(t, err)
+	require.Empty(t, grants)
+
+	require.NoError(t, provisioner.GrantReadOnly(t.Context(), "ro_lister", AccessScope{Groups: []string{group}}))
+	users, err := ListUserGrants(t.Context(), provisioner, "ro_")
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.Equal(t, "ro_lister", users[0].User)
+	require.NotEmpty(t, users[0].Grants)
+	for _, grant := range users[0].Grants {
+		require.Contains(t, grant, group)
+	}
+	require.Condition(t, func() bool {
+		for _, grant := range users[0].Grants {
+			if strings.HasPrefix(grant, "SELECT ON") {
+				return true
+			}
+		}
+		return false
+	}, "no SELECT grant in %v", users[0].Grants)
+}
+
+func testRotatePassword(t *testing.T, provisioner Provisioner, admin *gorm.DB, group string) {
+	t.Helper()
+	migrateGormDatabase(t, admin)
+	scope := AccessScope{Groups: []string{group}}
+	result, err := EnsureUser(t.Context(), provisioner, "rotateuser", scope, Options{})
+	require.NoError(t, err)
+	require.NoError(t, provisioner.VerifyReadOnly(t.Context(), "rotateuser", result.Password, scope))
+
+	require.Error(t, provisioner.RotatePassword(t.Context(), "rotateuser", ""))
+	require.Error(t, provisioner.RotatePassword(t.Context(), "rotateuser'", "secret"))
+
+	password, err := GeneratePassword()
+	require.NoError(t, err)
+	require.NoError(t, provisioner.RotatePassword(t.Context(), "rotateuser", password))
+
+	// The new password works with the grants intact, and the old one is refused
+	require.NoError(t, provisioner.VerifyReadOnly(t.Context(), "rotateuser", password, scope))
+	require.Error(t, provisioner.VerifyReadOnly(t.Context(), "rotateuser", result.Password, scope))
+}
+
+func testExpiry(t *testing.T, provisioner Provisioner) {
+	t.Helper()
+	expires := time.Now().Add(48 * time.Hour)
+	result, err := EnsureUser(t.Context(), provisioner, "tempuser", AccessScope{}, Options{Expires: expires})
+	require.NoError(t, err)
+	require.True(t, result.Created)
+	require.Equal(t, expires.Unix(), result.Expires.Unix())
+
+	expiry, err := provisioner.UserExpiry(t.Context(), "tempuser")
+	require.NoError(t, err)
+	require.Equal(t, result.Expires.Unix(), expiry.Unix())
+
+	users, err := ListUserGrants(t.Context(), provisioner, "tempuser")
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.Equal(t, expiry.Unix(), users[0].Expires.Unix())
+
+	// Rotating the password keeps the expiry
+	password, err := GeneratePassword()
+	require.NoError(t, err)
+	require.NoError(t, provisioner.RotatePassword(t.Context(), "tempuser", password))
+	rotated, err := provisioner.UserExpiry(t.Context(), "tempuser")
+	require.NoError(t, err)
+	require.Equal(t, expiry.Unix(), rotated.Unix())
+
+	// A user without an expiry has none
+	_, err = EnsureUser(t.Context(), provisioner, "keptuser", AccessScope{}, Options{})
+	require.NoError(t, err)
+	expiry, err = provisioner.UserExpiry(t.Context(), "keptuser")
+	require.NoError(t, err)
+	require.True(t, expiry.IsZero())
+}
+
+// testColumnGrants grants SELECT on the id column of table, the schema-qualified
+// test_data table, and checks that its text column stays out of reach.
+func testColumnGrants(t *testing.T, provisioner Provisioner, admin *gorm.DB, table string, open func(dsn string) gorm.Dialector) {
+	t.Helper()
+	migrateGormDatabase(t, admin)
+	password, err := GeneratePassword()
+	require.NoError(t, err)
+	require.NoError(t, provisioner.CreateUser(t.Context(), "columnuser", password))
+	require.NoError(t, provisioner.GrantReadOnly(t.Context(), "columnuser", AccessScope{
+		Columns: map[string][]string{table: {"id"}},
+	}))
+
+	dsn, err := provisioner.UserDSN("columnuser", password)
+	require.NoError(t, err)
+	db, err := gorm.Open(open(dsn))
+	require.NoError(t, err)
+	defer closeDB(db)
+
+	var ids []int
+	require.NoError(t, db.Raw("SELECT id FROM "+table+" ORDER BY id").Scan(&ids).Error)
+	require.Equal(t, []int{1, 2}, ids)
+
+	var texts []string
+	require.Error(t, db.Raw("SELECT text FROM "+table).Scan(&texts).Error)
+	require.Error(t, db.Raw("SELECT * FROM "+table).Scan(&[]TestData{}).Error)
+}
+
+func testVerifyReadOnly(t *testing.T, provisioner Provisioner, admin *gorm.DB, group, grantInsert string) {
+	t.Helper()
+	password, err := GeneratePassword()
+	require.NoError(t, err)
+	require.NoError(t, provisioner.CreateUser(t.Context(), "verifyuser", password))
+	scope := AccessScope{Groups: []string{group}}
+
+	// Nothing to check against before any table exists
+	require.ErrorIs(t, provisioner.VerifyReadOnly(t.Context(), "verifyuser", password, scope), ErrNoTableToVerify)
+
+	migrateGormDatabase(t, admin)
+
+	// Not granted yet
+	require.ErrorContains(t, provisioner.VerifyReadOnly(t.Context(), "verifyuser", password, scope), "cannot SELECT")
+
+	require.NoError(t, provisioner.GrantReadOnly(t.Context(), "verifyuser", scope))
+	require.NoError(t, provisioner.VerifyReadOnly(t.Context(), "verifyuser", password, scope))
+
+	// A user wrongly granted INSERT must fail the check
+	require.NoError(t, admin.Exec(grantInsert).Error)
+	require.ErrorContains(t, provisioner.VerifyReadOnly(t.Context(), "verifyuser", password, scope), "INSERT")
+}
+
+func testResourceScope(t *testing.T, provisioner Provisioner, admin *gorm.DB, group string) {
+	t.Helper()
+	migrateGormDatabase(t, admin)
+	password, err := GeneratePassword()
+	require.NoError(t, err)
+	require.NoError(t, provisioner.CreateUser(t.Context(), "resourceuser", password))
+
+	// Schema-qualified resources are granted table by table
+	scope := AccessScope{Resources: []string{group + ".test_data"}}
+	require.NoError(t, provisioner.GrantReadOnly(t.Context(), "resourceuser", scope))
+	require.NoError(t, provisioner.VerifyReadOnly(t.Context(), "resourceuser", password, scope))
+
+	// Tables outside the resources stay hidden
+	secret := AccessScope{Resources: []string{group + ".test_data_secrets"}}
+	require.ErrorContains(t, provisioner.VerifyReadOnly(t.Context(), "resourceuser", password, secret), "cannot SELECT")
+}
+
+func testEnsureUser(t *testing.T, provisioner Provisioner, admin *gorm.DB, group string) {
+	t.Helper()
+	migrateGormDatabase(t, admin)
+	scope := AccessScope{Groups: []string{group}}
+
+	created, err := EnsureUser(t.Context(), provisioner, "ensureuser", scope, Options{})
+	require.NoError(t, err)
+	require.True(t, created.Created)
+	require.NotEmpty(t, created.Password)
+	require.NoError(t, provisioner.VerifyReadOnly(t.Context(), "ensureuser", created.Password, scope))
+
+	// Existing user keeps its password and grants
+	updated, err := EnsureUser(t.Context(), provisioner, "ensureuser", scope, Options{})
+	require.NoError(t, err)
+	require.False(t, updated.Created)
+	require.Empty(t, updated.Password)
+	require.NoError(t, provisioner.VerifyReadOnly(t.Context(), "ensureuser", created.Password, scope))
+
+	// Pruning to an empty scope revokes the schema grant
+	_, err = EnsureUser(t.Context(), provisioner, "ensureuser", AccessScope{}, Options{Prune: true})
+	require.NoError(t, err)
+	require.ErrorContains(t, provisioner.VerifyReadOnly(t.Context(), "ensureuser", created.Password, scope), "cannot SELECT")
+}
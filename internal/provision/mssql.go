@@ -2,15 +2,20 @@ package provision
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/microsoft/go-mssqldb/msdsn"
 	"gorm.io/driver/sqlserver"
 	"gorm.io/gorm"
 )
 
 type SqlServerProvisioner struct {
-	db *gorm.DB
+	db  *gorm.DB
+	dsn string
 }
 
 func (p *SqlServerProvisioner) Connect(dsn string) error {
@@ -19,6 +24,7 @@ func (p *SqlServerProvisioner) Connect(dsn string) error {
 		return err
 	}
 	p.db = db
+	p.dsn = dsn
 	return nil
 }
 
@@ -30,7 +36,16 @@ func (p *SqlServerProvisioner) Close() error {
 	return db.Close()
 }
 
+func (p *SqlServerProvisioner) ConnectionEncrypted(ctx context.Context) (bool, error) {
+	var encrypted bool
+	err := p.db.WithContext(ctx).Raw("SELECT CASE WHEN encrypt_option = 'TRUE' THEN 1 ELSE 0 END FROM sys.dm_exec_connections WHERE session_id = @@SPID").Scan(&encrypted).Error
+	return encrypted, err
+}
+
 func (p *SqlServerProvisioner) DropUser(ctx context.Context, user string) error {
+	if err := validateIdentifier("user", user); err != nil {
+		return err
+	}
 	err := p.db.WithContext(ctx).Exec(fmt.Sprintf("IF USER_ID('%s') IS NOT NULL DROP USER [%s]", user, user)).Error
 	if err != nil {
 		return err
@@ -38,6 +53,15 @@ func (p *SqlServerProvisioner) DropUser(ctx context.Context, user string) error
 	return p.db.WithContext(ctx).Exec(fmt.Sprintf("IF EXISTS (SELECT * FROM sys.server_principals WHERE name = '%s') DROP LOGIN [%s]", user, user)).Error
 }
 
+// RevokeGrants recreates the database user, which drops its permissions while the
+// login, and so the password, stays in place.
+func (p *SqlServerProvisioner) RevokeGrants(ctx context.Context, user string) error {
+	if err := validateIdentifier("user", user); err != nil {
+		return err
+	}
+	return p.db.WithContext(ctx).Exec(fmt.Sprintf("IF USER_ID('%s') IS NOT NULL DROP USER [%s]; CREATE USER [%s] FOR LOGIN [%s];", user, user, user, user)).Error
+}
+
 func (p *SqlServerProvisioner) UserExists(ctx context.Context, user string) (*bool, error) {
 	var exists bool
 	err := p.db.WithContext(ctx).Raw("SELECT CASE WHEN EXISTS(SELECT 1 FROM sys.server_principals WHERE name = ?) THEN 1 ELSE 0 END", user).Find(&exists).Error
@@ -47,40 +71,154 @@ func (p *SqlServerProvisioner) UserExists(ctx context.Context, user string) (*bo
 	return &exists, nil
 }
 
+func (p *SqlServerProvisioner) ListUsers(ctx context.Context) ([]string, error) {
+	var users []string
+	err := p.db.WithContext(ctx).Raw("SELECT name FROM sys.server_principals WHERE type = 'S' AND name NOT LIKE '##%' ORDER BY name").Scan(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// ListGrants returns the permissions granted to the user in the connected database,
+// leaving out the CONNECT every database user has.
+func (p *SqlServerProvisioner) ListGrants(ctx context.Context, user string) ([]string, error) {
+	var grants []string
+	err := p.db.WithContext(ctx).Raw(`
+SELECT pe.permission_name + ' ON ' + CASE pe.class
+		WHEN 0 THEN 'DATABASE'
+		WHEN 3 THEN 'SCHEMA::' + SCHEMA_NAME(pe.major_id)
+		ELSE OBJECT_SCHEMA_NAME(pe.major_id) + '.' + OBJECT_NAME(pe.major_id)
+	END AS privilege
+FROM sys.database_permissions pe
+JOIN sys.database_principals pr ON pr.principal_id = pe.grantee_principal_id
+WHERE pr.name = ? AND pe.state IN ('G', 'W') AND NOT (pe.class = 0 AND pe.permission_name = 'CONNECT')
+ORDER BY privilege`, user).Scan(&grants).Error
+	if err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
 func (p *SqlServerProvisioner) CreateUser(ctx context.Context, user, pass string) error {
-	err := p.db.WithContext(ctx).Exec(fmt.Sprintf("CREATE LOGIN [%s] WITH PASSWORD = N'%s'", user, pass)).Error
+	if err := validateCredentials(user, pass); err != nil {
+		return err
+	}
+	err := p.db.WithContext(ctx).Exec(fmt.Sprintf("CREATE LOGIN [%s] WITH PASSWORD = N%s", user, quoteLiteral(pass))).Error
 	if err != nil {
 		return err
 	}
 	return p.db.WithContext(ctx).Exec(fmt.Sprintf("CREATE USER [%s] FOR LOGIN [%s];", user, user)).Error
 }
 
-func (p *SqlServerProvisioner) GrantReadOnly(ctx context.Context, user string, scope AccessScope) error {
-	if len(scope.Groups) > 0 {
-		if err := p.grantSchemas(ctx, user, scope.Groups); err != nil {
-			return err
-		}
+// RotatePassword changes the password of the user's login; the database user and its
+// permissions are untouched.
+func (p *SqlServerProvisioner) RotatePassword(ctx context.Context, user, pass string) error {
+	if err := validateCredentials(user, pass); err != nil {
+		return err
 	}
-	if len(scope.Resources) > 0 {
-		if err := p.grantTables(ctx, user, scope.Resources); err != nil {
-			return err
-		}
+	return p.db.WithContext(ctx).Exec(fmt.Sprintf("ALTER LOGIN [%s] WITH PASSWORD = N%s", user, quoteLiteral(pass))).Error
+}
+
+// expiryProperty names the user's expiry where the provisioner records it: an
+// extended property of the database user in SQL Server, whose logins have none,
+// and a key of the account's attributes in MySQL.
+const expiryProperty = "databaise_expires"
+
+// SetExpiry records the expiry as an extended property of the database user. The
+// server does not enforce it, see EnsureResult.Expires.
+func (p *SqlServerProvisioner) SetExpiry(ctx context.Context, user string, at time.Time) error {
+	return p.db.WithContext(ctx).Exec(`
+IF EXISTS (
+	SELECT 1 FROM sys.extended_properties ep
+	JOIN sys.database_principals pr ON pr.principal_id = ep.major_id
+	WHERE ep.class = 4 AND ep.name = @prop AND pr.name = @user
+)
+	EXEC sp_updateextendedproperty @name = @prop, @value = @value, @level0type = N'USER', @level0name = @user
+ELSE
+	EXEC sp_addextendedproperty @name = @prop, @value = @value, @level0type = N'USER', @level0name = @user`,
+		sql.Named("prop", expiryProperty),
+		sql.Named("value", at.UTC().Format(time.RFC3339)),
+		sql.Named("user", user)).Error
+}
+
+func (p *SqlServerProvisioner) UserExpiry(ctx context.Context, user string) (time.Time, error) {
+	var values []string
+	err := p.db.WithContext(ctx).Raw(`
+SELECT CAST(ep.value AS nvarchar(64)) FROM sys.extended_properties ep
+JOIN sys.database_principals pr ON pr.principal_id = ep.major_id
+WHERE ep.class = 4 AND ep.name = ? AND pr.name = ?`, expiryProperty, user).Scan(&values).Error
+	if err != nil || len(values) == 0 {
+		return time.Time{}, err
 	}
-	return nil
+	return time.Parse(time.RFC3339, values[0])
 }
 
-func (p *SqlServerProvisioner) grantTables(ctx context.Context, user string, tables []string) error {
-	var query strings.Builder
-	for _, table := range tables {
-		fmt.Fprintf(&query, "GRANT SELECT ON [%s] TO [%s];\n", table, user)
+// GrantStatements returns the statements GrantReadOnly runs to grant scope to the user.
+func (p *SqlServerProvisioner) GrantStatements(user string, scope AccessScope) ([]string, error) {
+	if err := validateScope(user, scope); err != nil {
+		return nil, err
+	}
+	var stmts []string
+	for _, schema := range scope.Groups {
+		stmts = append(stmts, fmt.Sprintf("GRANT SELECT ON SCHEMA::[%s] TO [%s];", schema, user))
+	}
+	for _, table := range scope.Resources {
+		stmts = append(stmts, fmt.Sprintf("GRANT SELECT ON %s TO [%s];", quoteName(table, "[", "]"), user))
 	}
-	return p.db.WithContext(ctx).Exec(query.String()).Error
+	for _, table := range scope.columnTables() {
+		columns := "[" + strings.Join(scope.Columns[table], "], [") + "]"
+		stmts = append(stmts, fmt.Sprintf("GRANT SELECT (%s) ON %s TO [%s];", columns, quoteName(table, "[", "]"), user))
+	}
+	for _, fn := range scope.Functions {
+		stmts = append(stmts, fmt.Sprintf("GRANT EXECUTE ON OBJECT::%s TO [%s];", fn, user))
+	}
+	return stmts, nil
 }
 
-func (p *SqlServerProvisioner) grantSchemas(ctx context.Context, user string, schemas []string) error {
-	var query strings.Builder
-	for _, schema := range schemas {
-		fmt.Fprintf(&query, "GRANT SELECT ON SCHEMA::[%s] TO [%s];\n", schema, user)
+func (p *SqlServerProvisioner) GrantReadOnly(ctx context.Context, user string, scope AccessScope) error {
+	stmts, err := p.GrantStatements(user, scope)
+	if err != nil || len(stmts) == 0 {
+		return err
 	}
-	return p.db.WithContext(ctx).Exec(query.String()).Error
+	return p.db.WithContext(ctx).Exec(strings.Join(stmts, "\n")).Error
+}
+
+func (p *SqlServerProvisioner) UserDSN(user, pass string) (string, error) {
+	return sqlserverUserDSN(p.dsn, user, pass)
+}
+
+func (p *SqlServerProvisioner) VerifyReadOnly(ctx context.Context, user, pass string, scope AccessScope) error {
+	var table string
+	if len(scope.Resources) > 0 {
+		table = quoteName(scope.Resources[0], "[", "]")
+	} else {
+		for _, schema := range scope.Groups {
+			err := p.db.WithContext(ctx).Raw("SELECT TOP 1 QUOTENAME(TABLE_SCHEMA) + '.' + QUOTENAME(TABLE_NAME) FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME", schema).Scan(&table).Error
+			if err != nil {
+				return err
+			}
+			if table != "" {
+				break
+			}
+		}
+	}
+	if table == "" {
+		return ErrNoTableToVerify
+	}
+
+	cfg, err := msdsn.Parse(p.dsn)
+	if err != nil {
+		return err
+	}
+	cfg.User, cfg.Password = user, pass
+	db, err := gorm.Open(sqlserver.New(sqlserver.Config{Conn: sql.OpenDB(mssql.NewConnectorConfig(cfg))}))
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+
+	return checkReadOnly(ctx, db,
+		fmt.Sprintf("SELECT TOP 1 1 FROM %s", table),
+		fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", table))
 }
@@ -59,6 +59,79 @@ func TestSqlServer_DropUser(t *testing.T) {
 	testDropUser(t, &provisioner, dsn)
 }
 
+func TestSqlServer_BulkRevoke(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupSqlServerContainer(t)
+	provisioner := SqlServerProvisioner{}
+	testBulkRevoke(t, &provisioner, dsn)
+}
+
+func TestSqlServer_VerifyReadOnly(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupSqlServerContainer(t)
+	provisioner := SqlServerProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testVerifyReadOnly(t, &provisioner, provisioner.db, "dbo", "GRANT INSERT ON SCHEMA::dbo TO [verifyuser]")
+}
+
+func TestSqlServer_ResourceScope(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupSqlServerContainer(t)
+	provisioner := SqlServerProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testResourceScope(t, &provisioner, provisioner.db, "dbo")
+}
+
+func TestSqlServer_EnsureUser(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupSqlServerContainer(t)
+	provisioner := SqlServerProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testEnsureUser(t, &provisioner, provisioner.db, "dbo")
+}
+
+func TestSqlServer_ColumnGrants(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupSqlServerContainer(t)
+	provisioner := SqlServerProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testColumnGrants(t, &provisioner, provisioner.db, "dbo.test_data", sqlserver.Open)
+}
+
+func TestSqlServer_Expiry(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupSqlServerContainer(t)
+	provisioner := SqlServerProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testExpiry(t, &provisioner)
+}
+
+func TestSqlServer_RotatePassword(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupSqlServerContainer(t)
+	provisioner := SqlServerProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testRotatePassword(t, &provisioner, provisioner.db, "dbo")
+}
+
+func TestSqlServer_ListGrants(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupSqlServerContainer(t)
+	provisioner := SqlServerProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testListGrants(t, &provisioner, provisioner.db, "dbo")
+}
+
+func TestSqlServer_ConnectionEncrypted(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupSqlServerContainer(t)
+	provisioner := SqlServerProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	// Whether TLS is negotiated depends on the server image defaults, so only check the query works.
+	_, err := provisioner.ConnectionEncrypted(t.Context())
+	require.NoError(t, err)
+}
+
 func TestSqlServer_BypassAttempts(t *testing.T) {
 	t.Parallel()
 	db := setupSqlServerDatabase(t)
@@ -132,3 +205,27 @@ func TestSqlServer_BypassAttempts(t *testing.T) {
 			EXEC sp_executesql @sql;`, "Cannot alter the role")
 	})
 }
+
+func TestSqlServer_GrantFunctions(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupSqlServerContainer(t)
+	provisioner := SqlServerProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	require.NoError(t, provisioner.db.Exec("CREATE PROCEDURE dbo.answer AS SELECT 42 AS answer").Error)
+
+	password, err := GeneratePassword()
+	require.NoError(t, err)
+	require.NoError(t, provisioner.CreateUser(t.Context(), "testuser", password))
+
+	db, err := gorm.Open(sqlserver.Open(sqltest.ReplaceURLCredentials(t, dsn, "testuser", password)))
+	require.NoError(t, err)
+
+	var answer int
+	require.Error(t, db.Raw("EXEC dbo.answer").Scan(&answer).Error)
+
+	require.NoError(t, provisioner.GrantReadOnly(t.Context(), "testuser", AccessScope{
+		Functions: []string{"dbo.answer"},
+	}))
+	require.NoError(t, db.Raw("EXEC dbo.answer").Scan(&answer).Error)
+	require.Equal(t, 42, answer)
+}
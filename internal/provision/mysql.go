@@ -2,16 +2,18 @@ package provision
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	gomysql "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
 type MySqlProvisioner struct {
-	db *gorm.DB
+	db  *gorm.DB
+	dsn string
 }
 
 func (p *MySqlProvisioner) Connect(dsn string) error {
@@ -20,6 +22,7 @@ func (p *MySqlProvisioner) Connect(dsn string) error {
 		return err
 	}
 	p.db = db
+	p.dsn = dsn
 	return nil
 }
 
@@ -31,10 +34,28 @@ func (p *MySqlProvisioner) Close() error {
 	return db.Close()
 }
 
+func (p *MySqlProvisioner) ConnectionEncrypted(ctx context.Context) (bool, error) {
+	var status struct {
+		Value string `gorm:"column:Value"`
+	}
+	err := p.db.WithContext(ctx).Raw("SHOW SESSION STATUS LIKE 'Ssl_cipher'").Scan(&status).Error
+	return status.Value != "", err
+}
+
 func (p *MySqlProvisioner) DropUser(ctx context.Context, user string) error {
+	if err := validateIdentifier("user", user); err != nil {
+		return err
+	}
 	return p.db.WithContext(ctx).Exec(fmt.Sprintf("DROP USER IF EXISTS '%s'@'%%';", user)).Error
 }
 
+func (p *MySqlProvisioner) RevokeGrants(ctx context.Context, user string) error {
+	if err := validateIdentifier("user", user); err != nil {
+		return err
+	}
+	return p.db.WithContext(ctx).Exec(fmt.Sprintf("REVOKE ALL PRIVILEGES, GRANT OPTION FROM '%s'@'%%';", user)).Error
+}
+
 func (p *MySqlProvisioner) UserExists(ctx context.Context, user string) (*bool, error) {
 	var exists bool
 	err := p.db.WithContext(ctx).Raw("SELECT EXISTS (SELECT 1 FROM mysql.user WHERE user = ? AND host = '%');", user).Find(&exists).Error
@@ -44,41 +65,146 @@ func (p *MySqlProvisioner) UserExists(ctx context.Context, user string) (*bool,
 	return &exists, nil
 }
 
-func (p *MySqlProvisioner) CreateUser(ctx context.Context, user, pass string) error {
-	if user == "" || pass == "" {
-		return errors.New("user and password are required")
+func (p *MySqlProvisioner) ListUsers(ctx context.Context) ([]string, error) {
+	var users []string
+	err := p.db.WithContext(ctx).Raw("SELECT user FROM mysql.user WHERE host = '%' ORDER BY user").Scan(&users).Error
+	if err != nil {
+		return nil, err
 	}
-	return p.db.WithContext(ctx).Exec(fmt.Sprintf("CREATE USER '%s'@'%%' IDENTIFIED BY '%s'", user, pass)).Error
+	return users, nil
 }
 
-func (p *MySqlProvisioner) GrantReadOnly(ctx context.Context, user string, scope AccessScope) error {
-	if len(scope.Groups) > 0 {
-		if err := p.grantSchemas(ctx, user, scope.Groups); err != nil {
-			return err
-		}
+// ListGrants returns the user's privileges from SHOW GRANTS, leaving out the USAGE
+// grant every user has.
+func (p *MySqlProvisioner) ListGrants(ctx context.Context, user string) ([]string, error) {
+	if err := validateIdentifier("user", user); err != nil {
+		return nil, err
 	}
-	if len(scope.Resources) > 0 {
-		if err := p.grantTables(ctx, user, scope.Resources); err != nil {
-			return err
+	var rows []string
+	err := p.db.WithContext(ctx).Raw(fmt.Sprintf("SHOW GRANTS FOR '%s'@'%%'", user)).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	var grants []string
+	for _, row := range rows {
+		grant, _, _ := strings.Cut(strings.TrimPrefix(row, "GRANT "), " TO ")
+		if grant != "USAGE ON *.*" {
+			grants = append(grants, grant)
 		}
 	}
-	return nil
+	return grants, nil
+}
+
+func (p *MySqlProvisioner) CreateUser(ctx context.Context, user, pass string) error {
+	if err := validateCredentials(user, pass); err != nil {
+		return err
+	}
+	return p.db.WithContext(ctx).Exec(fmt.Sprintf("CREATE USER '%s'@'%%' IDENTIFIED BY %s", user, mysqlQuoteLiteral(pass))).Error
+}
+
+func (p *MySqlProvisioner) RotatePassword(ctx context.Context, user, pass string) error {
+	if err := validateCredentials(user, pass); err != nil {
+		return err
+	}
+	return p.db.WithContext(ctx).Exec(fmt.Sprintf("ALTER USER '%s'@'%%' IDENTIFIED BY %s", user, mysqlQuoteLiteral(pass))).Error
+}
+
+// SetExpiry records the expiry as an attribute of the account. The server does not
+// enforce it, see EnsureResult.Expires: PASSWORD EXPIRE would only make the user set
+// a new password, which renews its access.
+func (p *MySqlProvisioner) SetExpiry(ctx context.Context, user string, at time.Time) error {
+	if err := validateIdentifier("user", user); err != nil {
+		return err
+	}
+	attribute := fmt.Sprintf(`{"%s": "%s"}`, expiryProperty, at.UTC().Format(time.RFC3339))
+	return p.db.WithContext(ctx).Exec(fmt.Sprintf("ALTER USER '%s'@'%%' ATTRIBUTE %s", user, mysqlQuoteLiteral(attribute))).Error
+}
+
+func (p *MySqlProvisioner) UserExpiry(ctx context.Context, user string) (time.Time, error) {
+	var values []string
+	path := "$." + expiryProperty
+	err := p.db.WithContext(ctx).Raw("SELECT JSON_UNQUOTE(JSON_EXTRACT(ATTRIBUTE, ?)) FROM information_schema.USER_ATTRIBUTES WHERE USER = ? AND HOST = '%' AND JSON_EXTRACT(ATTRIBUTE, ?) IS NOT NULL", path, user, path).Scan(&values).Error
+	if err != nil || len(values) == 0 {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, values[0])
 }
 
-func (p *MySqlProvisioner) grantTables(ctx context.Context, user string, tables []string) error {
-	var query strings.Builder
-	for _, table := range tables {
-		fmt.Fprintf(&query, "GRANT SELECT ON `%s` TO '%s'@'%%';\n", table, user)
+// GrantStatements returns the statements GrantReadOnly runs to grant scope to the user.
+func (p *MySqlProvisioner) GrantStatements(user string, scope AccessScope) ([]string, error) {
+	if err := validateScope(user, scope); err != nil {
+		return nil, err
+	}
+	var stmts []string
+	for _, schema := range scope.Groups {
+		stmts = append(stmts, fmt.Sprintf("GRANT SELECT ON `%s`.* TO '%s'@'%%';", schema, user))
+	}
+	for _, table := range scope.Resources {
+		stmts = append(stmts, fmt.Sprintf("GRANT SELECT ON %s TO '%s'@'%%';", quoteName(table, "`", "`"), user))
 	}
-	fmt.Fprintf(&query, "FLUSH PRIVILEGES;")
-	return p.db.WithContext(ctx).Exec(query.String()).Error
+	for _, table := range scope.columnTables() {
+		columns := "`" + strings.Join(scope.Columns[table], "`, `") + "`"
+		stmts = append(stmts, fmt.Sprintf("GRANT SELECT (%s) ON %s TO '%s'@'%%';", columns, quoteName(table, "`", "`"), user))
+	}
+	if len(stmts) > 0 {
+		stmts = append(stmts, "FLUSH PRIVILEGES;")
+	}
+	for _, fn := range scope.Functions {
+		stmts = append(stmts, fmt.Sprintf("GRANT EXECUTE ON FUNCTION %s TO '%s'@'%%';", fn, user))
+	}
+	return stmts, nil
 }
 
-func (p *MySqlProvisioner) grantSchemas(ctx context.Context, user string, schemas []string) error {
-	var query strings.Builder
-	for _, schema := range schemas {
-		fmt.Fprintf(&query, "GRANT SELECT ON `%s`.* TO '%s'@'%%';\n", schema, user)
+func (p *MySqlProvisioner) GrantReadOnly(ctx context.Context, user string, scope AccessScope) error {
+	stmts, err := p.GrantStatements(user, scope)
+	if err != nil || len(stmts) == 0 {
+		return err
 	}
-	fmt.Fprintf(&query, "FLUSH PRIVILEGES;")
-	return p.db.WithContext(ctx).Exec(query.String()).Error
+	return p.db.WithContext(ctx).Exec(strings.Join(stmts, "\n")).Error
+}
+
+// mysqlQuoteLiteral quotes s as a MySQL string literal. Backslash is an escape
+// character in MySQL strings unless NO_BACKSLASH_ESCAPES is set, so it is escaped
+// along with the quote.
+func mysqlQuoteLiteral(s string) string {
+	return quoteLiteral(strings.ReplaceAll(s, `\`, `\\`))
+}
+
+func (p *MySqlProvisioner) UserDSN(user, pass string) (string, error) {
+	return mysqlUserDSN(p.dsn, user, pass)
+}
+
+func (p *MySqlProvisioner) VerifyReadOnly(ctx context.Context, user, pass string, scope AccessScope) error {
+	var table string
+	if len(scope.Resources) > 0 {
+		table = quoteName(scope.Resources[0], "`", "`")
+	} else {
+		for _, schema := range scope.Groups {
+			err := p.db.WithContext(ctx).Raw("SELECT CONCAT('`', table_schema, '`.`', table_name, '`') FROM information_schema.tables WHERE table_schema = ? AND table_type = 'BASE TABLE' ORDER BY table_name LIMIT 1", schema).Scan(&table).Error
+			if err != nil {
+				return err
+			}
+			if table != "" {
+				break
+			}
+		}
+	}
+	if table == "" {
+		return ErrNoTableToVerify
+	}
+
+	cfg, err := gomysql.ParseDSN(p.dsn)
+	if err != nil {
+		return err
+	}
+	cfg.User, cfg.Passwd = user, pass
+	db, err := gorm.Open(mysql.Open(cfg.FormatDSN()))
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+
+	return checkReadOnly(ctx, db,
+		fmt.Sprintf("SELECT 1 FROM %s LIMIT 1", table),
+		fmt.Sprintf("INSERT INTO %s () VALUES ()", table))
 }
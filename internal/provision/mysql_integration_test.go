@@ -56,6 +56,79 @@ func TestMySql_DropUser(t *testing.T) {
 	testDropUser(t, &provisioner, dsn)
 }
 
+func TestMySql_BulkRevoke(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupMySqlContainer(t)
+	provisioner := MySqlProvisioner{}
+	testBulkRevoke(t, &provisioner, dsn)
+}
+
+func TestMySql_VerifyReadOnly(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupMySqlContainer(t)
+	provisioner := MySqlProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testVerifyReadOnly(t, &provisioner, provisioner.db, "test", "GRANT INSERT ON `test`.* TO 'verifyuser'@'%'")
+}
+
+func TestMySql_ResourceScope(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupMySqlContainer(t)
+	provisioner := MySqlProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testResourceScope(t, &provisioner, provisioner.db, "test")
+}
+
+func TestMySql_EnsureUser(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupMySqlContainer(t)
+	provisioner := MySqlProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testEnsureUser(t, &provisioner, provisioner.db, "test")
+}
+
+func TestMySql_ColumnGrants(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupMySqlContainer(t)
+	provisioner := MySqlProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testColumnGrants(t, &provisioner, provisioner.db, "test.test_data", mysql.Open)
+}
+
+func TestMySql_Expiry(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupMySqlContainer(t)
+	provisioner := MySqlProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testExpiry(t, &provisioner)
+}
+
+func TestMySql_RotatePassword(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupMySqlContainer(t)
+	provisioner := MySqlProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testRotatePassword(t, &provisioner, provisioner.db, "test")
+}
+
+func TestMySql_ListGrants(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupMySqlContainer(t)
+	provisioner := MySqlProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testListGrants(t, &provisioner, provisioner.db, "test")
+}
+
+func TestMySql_ConnectionEncrypted(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupMySqlContainer(t)
+	provisioner := MySqlProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	// Whether TLS is negotiated depends on the server image defaults, so only check the query works.
+	_, err := provisioner.ConnectionEncrypted(t.Context())
+	require.NoError(t, err)
+}
+
 func TestMySQL_BypassAttempts(t *testing.T) {
 	t.Parallel()
 	db := setupMySqlDatabase(t)
@@ -2,15 +2,20 @@ package provision
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 type PostgresProvisioner struct {
-	db *gorm.DB
+	db  *gorm.DB
+	dsn string
 }
 
 func (p *PostgresProvisioner) Connect(dsn string) error {
@@ -19,6 +24,7 @@ func (p *PostgresProvisioner) Connect(dsn string) error {
 		return err
 	}
 	p.db = db
+	p.dsn = dsn
 	return nil
 }
 
@@ -30,7 +36,16 @@ func (p *PostgresProvisioner) Close() error {
 	return db.Close()
 }
 
+func (p *PostgresProvisioner) ConnectionEncrypted(ctx context.Context) (bool, error) {
+	var encrypted bool
+	err := p.db.WithContext(ctx).Raw("SELECT COALESCE((SELECT ssl FROM pg_stat_ssl WHERE pid = pg_backend_pid()), false)").Scan(&encrypted).Error
+	return encrypted, err
+}
+
 func (p *PostgresProvisioner) DropUser(ctx context.Context, user string) error {
+	if err := validateIdentifier("user", user); err != nil {
+		return err
+	}
 	err := p.db.WithContext(ctx).Exec(fmt.Sprintf("DROP OWNED BY %s", user)).Error
 	if err != nil {
 		return err
@@ -38,6 +53,15 @@ func (p *PostgresProvisioner) DropUser(ctx context.Context, user string) error {
 	return p.db.WithContext(ctx).Exec(fmt.Sprintf("DROP USER IF EXISTS %s", user)).Error
 }
 
+// RevokeGrants revokes the user's privileges in the connected database. DROP OWNED
+// also removes its default privileges, and a read-only user owns no objects.
+func (p *PostgresProvisioner) RevokeGrants(ctx context.Context, user string) error {
+	if err := validateIdentifier("user", user); err != nil {
+		return err
+	}
+	return p.db.WithContext(ctx).Exec(fmt.Sprintf("DROP OWNED BY %s", user)).Error
+}
+
 func (p *PostgresProvisioner) UserExists(ctx context.Context, user string) (*bool, error) {
 	var exists bool
 	err := p.db.WithContext(ctx).Raw("SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = ?);", user).First(&exists).Error
@@ -47,42 +71,157 @@ func (p *PostgresProvisioner) UserExists(ctx context.Context, user string) (*boo
 	return &exists, nil
 }
 
+func (p *PostgresProvisioner) ListUsers(ctx context.Context) ([]string, error) {
+	var users []string
+	err := p.db.WithContext(ctx).Raw("SELECT rolname FROM pg_roles WHERE rolcanlogin ORDER BY rolname").Scan(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// listGrantsQuery lists the table, schema and function privileges granted directly to
+// a role, plus the default privileges that cover tables created later.
+const listGrantsQuery = `
+SELECT a.privilege_type || ' ON ' || n.nspname || '.' || c.relname AS privilege
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+CROSS JOIN LATERAL aclexplode(c.relacl) a
+JOIN pg_roles r ON r.oid = a.grantee
+WHERE r.rolname = @user
+UNION ALL
+SELECT a.privilege_type || ' ON SCHEMA ' || n.nspname
+FROM pg_namespace n
+CROSS JOIN LATERAL aclexplode(n.nspacl) a
+JOIN pg_roles r ON r.oid = a.grantee
+WHERE r.rolname = @user
+UNION ALL
+SELECT a.privilege_type || ' ON FUNCTION ' || p.oid::regprocedure::text
+FROM pg_proc p
+CROSS JOIN LATERAL aclexplode(p.proacl) a
+JOIN pg_roles r ON r.oid = a.grantee
+WHERE r.rolname = @user
+UNION ALL
+SELECT a.privilege_type || ' ON FUTURE TABLES IN SCHEMA ' || n.nspname
+FROM pg_default_acl d
+JOIN pg_namespace n ON n.oid = d.defaclnamespace
+CROSS JOIN LATERAL aclexplode(d.defaclacl) a
+JOIN pg_roles r ON r.oid = a.grantee
+WHERE r.rolname = @user
+ORDER BY 1`
+
+// ListGrants returns the privileges granted to the user in the connected database.
+func (p *PostgresProvisioner) ListGrants(ctx context.Context, user string) ([]string, error) {
+	var grants []string
+	err := p.db.WithContext(ctx).Raw(listGrantsQuery, sql.Named("user", user)).Scan(&grants).Error
+	if err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
 func (p *PostgresProvisioner) CreateUser(ctx context.Context, user, pass string) error {
-	err := p.db.WithContext(ctx).Exec(fmt.Sprintf("CREATE USER %s WITH PASSWORD '%s'", user, pass)).Error
+	if err := validateCredentials(user, pass); err != nil {
+		return err
+	}
+	err := p.db.WithContext(ctx).Exec(fmt.Sprintf("CREATE USER %s WITH PASSWORD %s", user, quoteLiteral(pass))).Error
 	if err != nil {
 		return err
 	}
 	return p.db.WithContext(ctx).Exec(fmt.Sprintf("ALTER USER %s SET default_transaction_read_only = on;", user)).Error
 }
 
-func (p *PostgresProvisioner) GrantReadOnly(ctx context.Context, user string, scope AccessScope) error {
-	if len(scope.Groups) > 0 {
-		if err := p.grantSchemas(ctx, user, scope.Groups); err != nil {
-			return err
-		}
+func (p *PostgresProvisioner) RotatePassword(ctx context.Context, user, pass string) error {
+	if err := validateCredentials(user, pass); err != nil {
+		return err
 	}
-	if len(scope.Resources) > 0 {
-		if err := p.grantTables(ctx, user, scope.Resources); err != nil {
-			return err
-		}
+	return p.db.WithContext(ctx).Exec(fmt.Sprintf("ALTER USER %s WITH PASSWORD %s", user, quoteLiteral(pass))).Error
+}
+
+// SetExpiry sets the role's VALID UNTIL, after which the server refuses its login.
+func (p *PostgresProvisioner) SetExpiry(ctx context.Context, user string, at time.Time) error {
+	if err := validateIdentifier("user", user); err != nil {
+		return err
 	}
-	return nil
+	return p.db.WithContext(ctx).Exec(fmt.Sprintf("ALTER USER %s VALID UNTIL %s", user, quoteLiteral(at.UTC().Format(time.RFC3339)))).Error
+}
+
+func (p *PostgresProvisioner) UserExpiry(ctx context.Context, user string) (time.Time, error) {
+	var epochs []int64
+	err := p.db.WithContext(ctx).Raw("SELECT EXTRACT(EPOCH FROM rolvaliduntil)::bigint FROM pg_roles WHERE rolname = ? AND rolvaliduntil IS NOT NULL AND rolvaliduntil <> 'infinity'", user).Scan(&epochs).Error
+	if err != nil || len(epochs) == 0 {
+		return time.Time{}, err
+	}
+	return time.Unix(epochs[0], 0), nil
+}
+
+// GrantStatements returns the statements GrantReadOnly runs to grant scope to the user.
+func (p *PostgresProvisioner) GrantStatements(user string, scope AccessScope) ([]string, error) {
+	if err := validateScope(user, scope); err != nil {
+		return nil, err
+	}
+	var stmts []string
+	for _, schema := range scope.Groups {
+		stmts = append(stmts,
+			fmt.Sprintf("GRANT USAGE ON SCHEMA %s TO %s;", schema, user),
+			fmt.Sprintf("GRANT SELECT ON ALL TABLES IN SCHEMA %s TO %s;", schema, user),
+			fmt.Sprintf("ALTER DEFAULT PRIVILEGES IN SCHEMA %s GRANT SELECT ON TABLES TO %s;", schema, user))
+	}
+	for _, table := range scope.Resources {
+		stmts = append(stmts, fmt.Sprintf("GRANT SELECT ON %s TO %s;", table, user))
+	}
+	for _, table := range scope.columnTables() {
+		stmts = append(stmts, fmt.Sprintf("GRANT SELECT (%s) ON %s TO %s;", strings.Join(scope.Columns[table], ", "), table, user))
+	}
+	for _, fn := range scope.Functions {
+		stmts = append(stmts, fmt.Sprintf("GRANT EXECUTE ON FUNCTION %s TO %s;", fn, user))
+	}
+	return stmts, nil
 }
 
-func (p *PostgresProvisioner) grantTables(ctx context.Context, user string, tables []string) error {
-	var query strings.Builder
-	for _, table := range tables {
-		fmt.Fprintf(&query, "GRANT SELECT ON %s TO %s;\n", table, user)
+func (p *PostgresProvisioner) GrantReadOnly(ctx context.Context, user string, scope AccessScope) error {
+	stmts, err := p.GrantStatements(user, scope)
+	if err != nil || len(stmts) == 0 {
+		return err
 	}
-	return p.db.WithContext(ctx).Exec(query.String()).Error
+	return p.db.WithContext(ctx).Exec(strings.Join(stmts, "\n")).Error
+}
+
+func (p *PostgresProvisioner) UserDSN(user, pass string) (string, error) {
+	return postgresUserDSN(p.dsn, user, pass)
 }
 
-func (p *PostgresProvisioner) grantSchemas(ctx context.Context, user string, schemas []string) error {
-	var query strings.Builder
-	for _, schema := range schemas {
-		fmt.Fprintf(&query, "GRANT USAGE ON SCHEMA %s TO %s;\n", schema, user)
-		fmt.Fprintf(&query, "GRANT SELECT ON ALL TABLES IN SCHEMA %s TO %s;\n", schema, user)
-		fmt.Fprintf(&query, "ALTER DEFAULT PRIVILEGES IN SCHEMA %s GRANT SELECT ON TABLES TO %s;\n", schema, user)
+func (p *PostgresProvisioner) VerifyReadOnly(ctx context.Context, user, pass string, scope AccessScope) error {
+	var table string
+	if len(scope.Resources) > 0 {
+		table = scope.Resources[0]
+	} else {
+		for _, schema := range scope.Groups {
+			err := p.db.WithContext(ctx).Raw("SELECT quote_ident(schemaname) || '.' || quote_ident(tablename) FROM pg_tables WHERE schemaname = ? ORDER BY tablename LIMIT 1", schema).Scan(&table).Error
+			if err != nil {
+				return err
+			}
+			if table != "" {
+				break
+			}
+		}
+	}
+	if table == "" {
+		return ErrNoTableToVerify
+	}
+
+	cfg, err := pgx.ParseConfig(p.dsn)
+	if err != nil {
+		return err
 	}
-	return p.db.WithContext(ctx).Exec(query.String()).Error
+	cfg.User, cfg.Password = user, pass
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: stdlib.OpenDB(*cfg)}))
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+
+	return checkReadOnly(ctx, db,
+		fmt.Sprintf("SELECT 1 FROM %s LIMIT 1", table),
+		fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", table))
 }
@@ -50,6 +50,105 @@ func TestPostgres_DropUser(t *testing.T) {
 	testDropUser(t, &provisioner, dsn)
 }
 
+func TestPostgres_BulkRevoke(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupPostgresContainer(t)
+	provisioner := PostgresProvisioner{}
+	testBulkRevoke(t, &provisioner, dsn)
+}
+
+func TestPostgres_VerifyReadOnly(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupPostgresContainer(t)
+	provisioner := PostgresProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testVerifyReadOnly(t, &provisioner, provisioner.db, "public", "GRANT INSERT ON ALL TABLES IN SCHEMA public TO verifyuser")
+}
+
+func TestPostgres_ResourceScope(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupPostgresContainer(t)
+	provisioner := PostgresProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testResourceScope(t, &provisioner, provisioner.db, "public")
+}
+
+func TestPostgres_EnsureUser(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupPostgresContainer(t)
+	provisioner := PostgresProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testEnsureUser(t, &provisioner, provisioner.db, "public")
+}
+
+func TestPostgres_ColumnGrants(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupPostgresContainer(t)
+	provisioner := PostgresProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testColumnGrants(t, &provisioner, provisioner.db, "public.test_data", postgres.Open)
+}
+
+func TestPostgres_Expiry(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupPostgresContainer(t)
+	provisioner := PostgresProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testExpiry(t, &provisioner)
+}
+
+func TestPostgres_RotatePassword(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupPostgresContainer(t)
+	provisioner := PostgresProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testRotatePassword(t, &provisioner, provisioner.db, "public")
+}
+
+func TestPostgres_ListGrants(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupPostgresContainer(t)
+	provisioner := PostgresProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	testListGrants(t, &provisioner, provisioner.db, "public")
+}
+
+func TestPostgres_ConnectionEncrypted(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupPostgresContainer(t)
+	provisioner := PostgresProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	encrypted, err := provisioner.ConnectionEncrypted(t.Context())
+	require.NoError(t, err)
+	// The test container does not enable SSL
+	require.False(t, encrypted)
+}
+
+func TestPostgres_GrantFunctions(t *testing.T) {
+	t.Parallel()
+	dsn := sqltest.SetupPostgresContainer(t)
+	provisioner := PostgresProvisioner{}
+	require.NoError(t, provisioner.Connect(dsn))
+	require.NoError(t, provisioner.db.Exec("CREATE FUNCTION public.answer() RETURNS int LANGUAGE sql AS 'SELECT 42'; REVOKE EXECUTE ON FUNCTION public.answer() FROM PUBLIC").Error)
+	require.NoError(t, provisioner.CreateUser(t.Context(), "testuser", "testpass"))
+
+	db, err := gorm.Open(postgres.Open(sqltest.ReplaceURLCredentials(t, dsn, "testuser", "testpass")))
+	require.NoError(t, err)
+
+	var answer int
+	require.ErrorContains(t, db.Raw("SELECT public.answer()").Scan(&answer).Error, "permission denied")
+
+	require.ErrorContains(t, provisioner.GrantReadOnly(t.Context(), "testuser", AccessScope{
+		Functions: []string{"public.answer(); DROP TABLE x"},
+	}), "invalid function name")
+
+	require.NoError(t, provisioner.GrantReadOnly(t.Context(), "testuser", AccessScope{
+		Functions: []string{"public.answer()"},
+	}))
+	require.NoError(t, db.Raw("SELECT public.answer()").Scan(&answer).Error)
+	require.Equal(t, 42, answer)
+}
+
 func TestPostgres_BypassAttempts(t *testing.T) {
 	t.Parallel()
 	db := setupPostgresDatabase(t)
@@ -5,20 +5,311 @@ import (
 	"crypto/rand"
 	_ "embed"
 	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 )
 
+// ErrNotSupported is returned for operations a backend has no equivalent for.
+var ErrNotSupported = errors.New("not supported")
+
+// ErrUserExpired is returned by EnsureUser for an existing user whose access has
+// expired, unless a new expiry is given.
+var ErrUserExpired = errors.New("user access has expired")
+
 type AccessScope struct {
 	Groups    []string
 	Resources []string
+	// Functions the user may EXECUTE, as schema.name with an optional argument list.
+	Functions []string
+	// Columns maps a table, as schema.table, to the only columns the user may SELECT.
+	Columns map[string][]string
+}
+
+// columnTables returns the tables of a column-level scope in a stable order.
+func (s AccessScope) columnTables() []string {
+	return slices.Sorted(maps.Keys(s.Columns))
 }
 
 type Provisioner interface {
 	Connect(string) error
 	Close() error
+	// ConnectionEncrypted reports whether the admin connection negotiated TLS.
+	ConnectionEncrypted(context.Context) (bool, error)
 	DropUser(context.Context, string) error
 	UserExists(context.Context, string) (*bool, error)
+	ListUsers(context.Context) ([]string, error)
+	// ListGrants returns the privileges held by the user, one per entry, such as
+	// "SELECT ON public.users".
+	ListGrants(context.Context, string) ([]string, error)
 	CreateUser(context.Context, string, string) error
+	// RotatePassword sets a new password for an existing user, keeping its grants.
+	RotatePassword(ctx context.Context, user, pass string) error
+	// SetExpiry makes the user's access end at the given time. Enforcement differs
+	// per backend, see EnsureResult.Expires.
+	SetExpiry(ctx context.Context, user string, at time.Time) error
+	// UserExpiry returns when the user's access ends, or the zero time if it doesn't.
+	UserExpiry(ctx context.Context, user string) (time.Time, error)
 	GrantReadOnly(context.Context, string, AccessScope) error
+	// GrantStatements returns the statements GrantReadOnly runs, without running them.
+	GrantStatements(user string, scope AccessScope) ([]string, error)
+	// RevokeGrants removes every privilege granted to the user, keeping the user itself.
+	RevokeGrants(context.Context, string) error
+	// UserDSN returns the admin DSN with the user's credentials substituted.
+	UserDSN(user, pass string) (string, error)
+	// VerifyReadOnly connects as the user and checks that it can SELECT from a
+	// table in scope but cannot INSERT into it.
+	VerifyReadOnly(ctx context.Context, user, pass string, scope AccessScope) error
+}
+
+// Options tune how EnsureUser provisions a user.
+type Options struct {
+	// Prune revokes the grants of an existing user that fall outside the scope.
+	Prune bool
+	// Expires, if set, is when the user's access ends.
+	Expires time.Time
+	// DryRun only looks the user up and returns the grant statements in
+	// EnsureResult.Grants; nothing is created, revoked or granted.
+	DryRun bool
+}
+
+// EnsureResult is the outcome of EnsureUser.
+type EnsureResult struct {
+	// Created is true if the user was created, false if it existed and its grants were updated.
+	Created bool
+	// Password is the generated password of a created user.
+	Password string
+	// Expires is when the user's access ends as recorded by the backend, zero if
+	// Options.Expires was not set. Backends enforce it differently:
+	//   - PostgreSQL sets VALID UNTIL, and the server refuses the login after it.
+	//   - MySQL and SQL Server accounts cannot expire. The time is stored as an
+	//     attribute of the MySQL account or an extended property of the SQL Server
+	//     database user, and the server keeps accepting the login; only the
+	//     provisioner honours it, by refusing to update an expired user and
+	//     flagging it when listing, so it has to be revoked. (MySQL's password
+	//     expiry is not used: an expired password can still be changed by the
+	//     user, which renews its access.)
+	Expires time.Time
+	// Grants are the grant statements for the scope, set on a dry run only.
+	Grants []string
+}
+
+// EnsureUser makes the user exist with read-only access to scope. A missing user is
+// created with a generated password; an existing one keeps its password and gets any
+// missing grants. With opts.Prune, grants outside scope are revoked first, so the user
+// briefly has no access while its grants are rebuilt. An existing user whose access
+// has expired is refused with ErrUserExpired unless opts.Expires renews it.
+func EnsureUser(ctx context.Context, p Provisioner, user string, scope AccessScope, opts Options) (*EnsureResult, error) {
+	exists, err := p.UserExists(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &EnsureResult{Created: !*exists}
+	if !result.Created && opts.Expires.IsZero() {
+		expiry, err := p.UserExpiry(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if !expiry.IsZero() && expiry.Before(time.Now()) {
+			return nil, fmt.Errorf("%s: %w at %s", user, ErrUserExpired, expiry.Format(time.RFC3339))
+		}
+	}
+	if opts.DryRun {
+		if result.Grants, err = p.GrantStatements(user, scope); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	if result.Created {
+		if result.Password, err = GeneratePassword(); err != nil {
+			return nil, err
+		}
+		if err := p.CreateUser(ctx, user, result.Password); err != nil {
+			return nil, err
+		}
+	} else if opts.Prune {
+		if err := p.RevokeGrants(ctx, user); err != nil {
+			return nil, fmt.Errorf("revoking existing grants: %w", err)
+		}
+	}
+
+	if err := p.GrantReadOnly(ctx, user, scope); err != nil {
+		return nil, err
+	}
+	if !opts.Expires.IsZero() {
+		if err := p.SetExpiry(ctx, user, opts.Expires); err != nil {
+			return nil, fmt.Errorf("setting expiry: %w", err)
+		}
+		if result.Expires, err = p.UserExpiry(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// RevokeResult is the outcome of dropping one user in a bulk revoke.
+type RevokeResult struct {
+	User string
+	Err  error
+}
+
+// DropUsers drops each user in turn, carrying on past failures so that one
+// bad user doesn't leave the rest in place.
+func DropUsers(ctx context.Context, p Provisioner, users []string) []RevokeResult {
+	results := make([]RevokeResult, 0, len(users))
+	for _, user := range users {
+		results = append(results, RevokeResult{User: user, Err: p.DropUser(ctx, user)})
+	}
+	return results
+}
+
+// UsersWithPrefix returns the login users whose name starts with prefix.
+// The prefix must not be empty, so that it can't match every user on the server.
+func UsersWithPrefix(ctx context.Context, p Provisioner, prefix string) ([]string, error) {
+	if prefix == "" {
+		return nil, errors.New("a user name prefix is required")
+	}
+	users, err := p.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matched []string
+	for _, user := range users {
+		if strings.HasPrefix(user, prefix) {
+			matched = append(matched, user)
+		}
+	}
+	return matched, nil
+}
+
+// UserGrants is a login user and the privileges it holds.
+type UserGrants struct {
+	User   string
+	Grants []string
+	// Expires is when the user's access ends, zero if it doesn't.
+	Expires time.Time
+}
+
+// ListUserGrants returns every login user with its grants. A non-empty prefix
+// limits the listing to users whose name starts with it.
+func ListUserGrants(ctx context.Context, p Provisioner, prefix string) ([]UserGrants, error) {
+	users, err := p.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []UserGrants
+	for _, user := range users {
+		if !strings.HasPrefix(user, prefix) {
+			continue
+		}
+		grants, err := p.ListGrants(ctx, user)
+		if err != nil {
+			return nil, fmt.Errorf("listing grants of %s: %w", user, err)
+		}
+		expires, err := p.UserExpiry(ctx, user)
+		if err != nil {
+			return nil, fmt.Errorf("looking up expiry of %s: %w", user, err)
+		}
+		result = append(result, UserGrants{User: user, Grants: grants, Expires: expires})
+	}
+	return result, nil
+}
+
+// functionNameRe matches a possibly schema-qualified function name with an optional
+// argument type list, e.g. reporting.monthly_totals(integer, date).
+var functionNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?(\([A-Za-z0-9_ ,\[\]]*\))?$`)
+
+// validateFunctions rejects function names that could smuggle SQL into a GRANT statement.
+func validateFunctions(functions []string) error {
+	for _, fn := range functions {
+		if !functionNameRe.MatchString(fn) {
+			return fmt.Errorf("invalid function name %q", fn)
+		}
+	}
+	return nil
+}
+
+// identifierRe matches the user, schema and table names provisioning interpolates into
+// statements that cannot take bound parameters.
+var identifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// validateIdentifier rejects names that could smuggle SQL into a user or GRANT statement.
+func validateIdentifier(kind, name string) error {
+	if !identifierRe.MatchString(name) {
+		return fmt.Errorf("invalid %s name %q", kind, name)
+	}
+	return nil
+}
+
+// validateScope checks every name in scope before any of it is granted, so a bad
+// entry fails the whole grant instead of leaving it half applied. Resources may be
+// schema-qualified.
+func validateScope(user string, scope AccessScope) error {
+	if err := validateIdentifier("user", user); err != nil {
+		return err
+	}
+	for _, schema := range scope.Groups {
+		if err := validateIdentifier("schema", schema); err != nil {
+			return err
+		}
+	}
+	for _, table := range scope.Resources {
+		if err := validateTable(table); err != nil {
+			return err
+		}
+	}
+	for table, columns := range scope.Columns {
+		if err := validateTable(table); err != nil {
+			return err
+		}
+		if len(columns) == 0 {
+			return fmt.Errorf("no columns given for table %q", table)
+		}
+		for _, column := range columns {
+			if err := validateIdentifier("column", column); err != nil {
+				return err
+			}
+		}
+	}
+	return validateFunctions(scope.Functions)
+}
+
+// validateTable checks a table name that may be schema-qualified.
+func validateTable(table string) error {
+	for part := range strings.SplitSeq(table, ".") {
+		if err := validateIdentifier("table", part); err != nil {
+			return fmt.Errorf("invalid table name %q", table)
+		}
+	}
+	return nil
+}
+
+// quoteName quotes each part of a validated, possibly qualified name between open
+// and close, e.g. [dbo].[users].
+func quoteName(name, open, close string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = open + part + close
+	}
+	return strings.Join(parts, ".")
+}
+
+// validateCredentials checks the user and password passed to CreateUser.
+func validateCredentials(user, pass string) error {
+	if user == "" || pass == "" {
+		return errors.New("user and password are required")
+	}
+	return validateIdentifier("user", user)
+}
+
+// quoteLiteral quotes s as a standard SQL string literal, doubling embedded quotes.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
 }
 
 func GeneratePassword() (string, error) {
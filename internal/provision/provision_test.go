@@ -0,0 +1,214 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateScope(t *testing.T) {
+	require.NoError(t, validateScope("reader", AccessScope{
+		Groups:    []string{"public", "reporting"},
+		Resources: []string{"users", "reporting.monthly_totals"},
+		Functions: []string{"reporting.total(integer)"},
+		Columns:   map[string][]string{"public.users": {"id", "name"}},
+	}))
+
+	for _, tc := range []struct {
+		user  string
+		scope AccessScope
+	}{
+		{user: ""},
+		{user: "reader; DROP TABLE users"},
+		{user: "reader'"},
+		{user: "reader", scope: AccessScope{Groups: []string{"public; DROP TABLE users"}}},
+		{user: "reader", scope: AccessScope{Groups: []string{"[dbo]"}}},
+		{user: "reader", scope: AccessScope{Resources: []string{"users TO PUBLIC; --"}}},
+		{user: "reader", scope: AccessScope{Resources: []string{"a.b.c.d`"}}},
+		{user: "reader", scope: AccessScope{Resources: []string{"public."}}},
+		{user: "reader", scope: AccessScope{Functions: []string{"f(); DROP TABLE users"}}},
+		{user: "reader", scope: AccessScope{Columns: map[string][]string{"public.users": {}}}},
+		{user: "reader", scope: AccessScope{Columns: map[string][]string{"public.users": {"id) ON x TO PUBLIC; --"}}}},
+		{user: "reader", scope: AccessScope{Columns: map[string][]string{"users; --": {"id"}}}},
+	} {
+		require.Error(t, validateScope(tc.user, tc.scope), "%+v", tc)
+	}
+}
+
+func TestValidateCredentials(t *testing.T) {
+	require.NoError(t, validateCredentials("reader", "secret"))
+	require.Error(t, validateCredentials("", "secret"))
+	require.Error(t, validateCredentials("reader", ""))
+	require.Error(t, validateCredentials("x' IDENTIFIED BY 'y", "secret"))
+}
+
+func TestQuoteLiteral(t *testing.T) {
+	require.Equal(t, `'plain'`, quoteLiteral("plain"))
+	require.Equal(t, `'it''s''; DROP USER x; --'`, quoteLiteral("it's'; DROP USER x; --"))
+	require.Equal(t, `'a\\''b'`, mysqlQuoteLiteral(`a\'b`))
+}
+
+// grantsProvisioner serves ListUsers and ListGrants from a fixed map.
+type grantsProvisioner struct {
+	Provisioner
+	users  []string
+	grants map[string][]string
+}
+
+func (p *grantsProvisioner) ListUsers(context.Context) ([]string, error) {
+	return p.users, nil
+}
+
+func (p *grantsProvisioner) ListGrants(_ context.Context, user string) ([]string, error) {
+	if user == "broken" {
+		return nil, errors.New("boom")
+	}
+	return p.grants[user], nil
+}
+
+func (p *grantsProvisioner) UserExpiry(context.Context, string) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func TestListUserGrants(t *testing.T) {
+	p := &grantsProvisioner{
+		users: []string{"admin", "ro_alpha", "ro_beta"},
+		grants: map[string][]string{
+			"ro_alpha": {"SELECT ON public.users"},
+		},
+	}
+
+	all, err := ListUserGrants(t.Context(), p, "")
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+
+	managed, err := ListUserGrants(t.Context(), p, "ro_")
+	require.NoError(t, err)
+	require.Equal(t, []UserGrants{
+		{User: "ro_alpha", Grants: []string{"SELECT ON public.users"}},
+		{User: "ro_beta"},
+	}, managed)
+
+	p.users = append(p.users, "broken")
+	_, err = ListUserGrants(t.Context(), p, "")
+	require.ErrorContains(t, err, "listing grants of broken")
+}
+
+// expiringProvisioner is an existing user with a fixed expiry. The embedded
+// Provisioner is nil, so any call that would change the user panics.
+type expiringProvisioner struct {
+	Provisioner
+	expiry time.Time
+	set    time.Time
+}
+
+func (p *expiringProvisioner) UserExists(context.Context, string) (*bool, error) {
+	exists := true
+	return &exists, nil
+}
+
+func (p *expiringProvisioner) UserExpiry(context.Context, string) (time.Time, error) {
+	return p.expiry, nil
+}
+
+func (p *expiringProvisioner) GrantReadOnly(context.Context, string, AccessScope) error {
+	return nil
+}
+
+func (p *expiringProvisioner) SetExpiry(_ context.Context, _ string, at time.Time) error {
+	p.expiry, p.set = at, at
+	return nil
+}
+
+func (p *expiringProvisioner) GrantStatements(user string, scope AccessScope) ([]string, error) {
+	return (&PostgresProvisioner{}).GrantStatements(user, scope)
+}
+
+func TestEnsureUserExpiry(t *testing.T) {
+	p := &expiringProvisioner{expiry: time.Now().Add(-time.Hour)}
+	_, err := EnsureUser(t.Context(), p, "reader", AccessScope{}, Options{})
+	require.ErrorIs(t, err, ErrUserExpired)
+
+	renewed := time.Now().Add(24 * time.Hour)
+	result, err := EnsureUser(t.Context(), p, "reader", AccessScope{}, Options{Expires: renewed})
+	require.NoError(t, err)
+	require.False(t, result.Created)
+	require.Equal(t, renewed, p.set)
+	require.Equal(t, renewed, result.Expires)
+
+	result, err = EnsureUser(t.Context(), p, "reader", AccessScope{}, Options{})
+	require.NoError(t, err)
+	require.True(t, result.Expires.IsZero())
+}
+
+func TestEnsureUserDryRun(t *testing.T) {
+	// Nothing but the lookup runs: SetExpiry would record the expiry
+	p := &expiringProvisioner{}
+	result, err := EnsureUser(t.Context(), p, "reader", AccessScope{Groups: []string{"public"}}, Options{
+		DryRun:  true,
+		Prune:   true,
+		Expires: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.False(t, result.Created)
+	require.Len(t, result.Grants, 3)
+	require.True(t, p.set.IsZero())
+
+	_, err = EnsureUser(t.Context(), p, "reader", AccessScope{Groups: []string{"public; DROP"}}, Options{DryRun: true})
+	require.Error(t, err)
+}
+
+func TestGrantStatements(t *testing.T) {
+	scope := AccessScope{
+		Groups:    []string{"reporting"},
+		Resources: []string{"users", "sales.orders"},
+		Functions: []string{"reporting.total(integer)"},
+		Columns: map[string][]string{
+			"public.users":  {"id", "name"},
+			"public.orders": {"id"},
+		},
+	}
+
+	stmts, err := (&PostgresProvisioner{}).GrantStatements("reader", scope)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"GRANT USAGE ON SCHEMA reporting TO reader;",
+		"GRANT SELECT ON ALL TABLES IN SCHEMA reporting TO reader;",
+		"ALTER DEFAULT PRIVILEGES IN SCHEMA reporting GRANT SELECT ON TABLES TO reader;",
+		"GRANT SELECT ON users TO reader;",
+		"GRANT SELECT ON sales.orders TO reader;",
+		"GRANT SELECT (id) ON public.orders TO reader;",
+		"GRANT SELECT (id, name) ON public.users TO reader;",
+		"GRANT EXECUTE ON FUNCTION reporting.total(integer) TO reader;",
+	}, stmts)
+
+	stmts, err = (&MySqlProvisioner{}).GrantStatements("reader", scope)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"GRANT SELECT ON `reporting`.* TO 'reader'@'%';",
+		"GRANT SELECT ON `users` TO 'reader'@'%';",
+		"GRANT SELECT ON `sales`.`orders` TO 'reader'@'%';",
+		"GRANT SELECT (`id`) ON `public`.`orders` TO 'reader'@'%';",
+		"GRANT SELECT (`id`, `name`) ON `public`.`users` TO 'reader'@'%';",
+		"FLUSH PRIVILEGES;",
+		"GRANT EXECUTE ON FUNCTION reporting.total(integer) TO 'reader'@'%';",
+	}, stmts)
+
+	stmts, err = (&SqlServerProvisioner{}).GrantStatements("reader", scope)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"GRANT SELECT ON SCHEMA::[reporting] TO [reader];",
+		"GRANT SELECT ON [users] TO [reader];",
+		"GRANT SELECT ON [sales].[orders] TO [reader];",
+		"GRANT SELECT ([id]) ON [public].[orders] TO [reader];",
+		"GRANT SELECT ([id], [name]) ON [public].[users] TO [reader];",
+		"GRANT EXECUTE ON OBJECT::reporting.total(integer) TO [reader];",
+	}, stmts)
+
+	stmts, err = (&PostgresProvisioner{}).GrantStatements("reader", AccessScope{})
+	require.NoError(t, err)
+	require.Empty(t, stmts)
+}
@@ -0,0 +1,147 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// SqliteProvisioner provisions read-only access to a SQLite database file.
+// SQLite has no users or grants: read-only access is a connection opened with
+// mode=ro, so the user operations return ErrNotSupported.
+type SqliteProvisioner struct {
+	db   *gorm.DB
+	path string
+}
+
+func (p *SqliteProvisioner) Connect(dsn string) error {
+	path := strings.TrimPrefix(dsn, "file:")
+	path, _, _ = strings.Cut(path, "?")
+	// Opening a missing file would silently create an empty database.
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	db, err := gorm.Open(sqlite.Open("file:" + path + "?mode=rw"))
+	if err != nil {
+		return err
+	}
+	p.db = db
+	p.path = path
+	return nil
+}
+
+func (p *SqliteProvisioner) Close() error {
+	if p.db == nil {
+		return nil
+	}
+	db, err := p.db.DB()
+	if err != nil {
+		return err
+	}
+	return db.Close()
+}
+
+// ConnectionEncrypted always reports true: a SQLite file is opened locally, so no
+// credentials cross the network.
+func (p *SqliteProvisioner) ConnectionEncrypted(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+// ReadOnlyDSN returns the DSN that opens the database read-only.
+func (p *SqliteProvisioner) ReadOnlyDSN() string {
+	return "file:" + p.path + "?mode=ro"
+}
+
+// UserDSN returns the read-only DSN; SQLite has no credentials.
+func (p *SqliteProvisioner) UserDSN(user, pass string) (string, error) {
+	return p.ReadOnlyDSN(), nil
+}
+
+func (p *SqliteProvisioner) DropUser(ctx context.Context, user string) error {
+	return fmt.Errorf("dropping users: %w, SQLite has no users", ErrNotSupported)
+}
+
+func (p *SqliteProvisioner) RevokeGrants(ctx context.Context, user string) error {
+	return fmt.Errorf("revoking grants: %w, SQLite has no users", ErrNotSupported)
+}
+
+func (p *SqliteProvisioner) UserExists(ctx context.Context, user string) (*bool, error) {
+	return nil, fmt.Errorf("looking up users: %w, SQLite has no users", ErrNotSupported)
+}
+
+func (p *SqliteProvisioner) ListUsers(ctx context.Context) ([]string, error) {
+	return nil, fmt.Errorf("listing users: %w, SQLite has no users", ErrNotSupported)
+}
+
+func (p *SqliteProvisioner) ListGrants(ctx context.Context, user string) ([]string, error) {
+	return nil, fmt.Errorf("listing grants: %w, SQLite has no users", ErrNotSupported)
+}
+
+func (p *SqliteProvisioner) CreateUser(ctx context.Context, user, pass string) error {
+	return fmt.Errorf("creating users: %w, SQLite has no users (use the read-only DSN instead)", ErrNotSupported)
+}
+
+func (p *SqliteProvisioner) RotatePassword(ctx context.Context, user, pass string) error {
+	return fmt.Errorf("rotating passwords: %w, SQLite has no users", ErrNotSupported)
+}
+
+func (p *SqliteProvisioner) SetExpiry(ctx context.Context, user string, at time.Time) error {
+	return fmt.Errorf("expiring users: %w, SQLite has no users", ErrNotSupported)
+}
+
+func (p *SqliteProvisioner) UserExpiry(ctx context.Context, user string) (time.Time, error) {
+	return time.Time{}, fmt.Errorf("looking up expiry: %w, SQLite has no users", ErrNotSupported)
+}
+
+// GrantStatements returns no statements: read-only access is a connection mode.
+func (p *SqliteProvisioner) GrantStatements(user string, scope AccessScope) ([]string, error) {
+	if len(scope.Groups) > 0 || len(scope.Resources) > 0 || len(scope.Functions) > 0 || len(scope.Columns) > 0 {
+		return nil, fmt.Errorf("scoped grants: %w, SQLite read-only access always covers the whole file", ErrNotSupported)
+	}
+	return nil, nil
+}
+
+// GrantReadOnly verifies the database can be opened read-only. SQLite cannot
+// restrict access to individual schemas or tables, so a non-empty scope is rejected.
+func (p *SqliteProvisioner) GrantReadOnly(ctx context.Context, user string, scope AccessScope) error {
+	if _, err := p.GrantStatements(user, scope); err != nil {
+		return err
+	}
+	db, err := gorm.Open(sqlite.Open(p.ReadOnlyDSN()))
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	return db.WithContext(ctx).Exec("SELECT count(*) FROM sqlite_master").Error
+}
+
+// VerifyReadOnly checks the read-only DSN; SQLite has no credentials, so user and pass are ignored.
+func (p *SqliteProvisioner) VerifyReadOnly(ctx context.Context, user, pass string, scope AccessScope) error {
+	var table string
+	err := p.db.WithContext(ctx).Raw(`SELECT '"' || replace(name, '"', '""') || '"' FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name LIMIT 1`).Scan(&table).Error
+	if err != nil {
+		return err
+	}
+	if table == "" {
+		return ErrNoTableToVerify
+	}
+
+	db, err := gorm.Open(sqlite.Open(p.ReadOnlyDSN()))
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+
+	return checkReadOnly(ctx, db,
+		fmt.Sprintf("SELECT 1 FROM %s LIMIT 1", table),
+		fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", table))
+}
@@ -0,0 +1,95 @@
+//go:build integration
+
+package provision
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupSqliteDatabase(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := gorm.Open(sqlite.Open(path))
+	require.NoError(t, err)
+	migrateGormDatabase(t, db)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+	return path
+}
+
+func TestSqlite_ReadOnlyAccess(t *testing.T) {
+	t.Parallel()
+	path := setupSqliteDatabase(t)
+	provisioner := SqliteProvisioner{}
+	require.NoError(t, provisioner.Connect(path))
+	t.Cleanup(func() { provisioner.Close() })
+	require.NoError(t, provisioner.GrantReadOnly(t.Context(), "", AccessScope{}))
+
+	db, err := gorm.Open(sqlite.Open(provisioner.ReadOnlyDSN()))
+	require.NoError(t, err)
+
+	count, err := gorm.G[TestData](db).Count(t.Context(), "id")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, count)
+
+	err = db.Create([]*TestData{{ID: 4}}).Error
+	require.Error(t, err)
+}
+
+func TestSqlite_VerifyReadOnly(t *testing.T) {
+	t.Parallel()
+	path := setupSqliteDatabase(t)
+	provisioner := SqliteProvisioner{}
+	require.NoError(t, provisioner.Connect(path))
+	t.Cleanup(func() { provisioner.Close() })
+	require.NoError(t, provisioner.VerifyReadOnly(t.Context(), "", "", AccessScope{}))
+
+	// A writable connection must fail the check, and the probe insert must be rolled back.
+	err := checkReadOnly(t.Context(), provisioner.db, "SELECT 1 FROM test_data LIMIT 1", "INSERT INTO test_data DEFAULT VALUES")
+	require.ErrorContains(t, err, "can INSERT")
+	count, err := gorm.G[TestData](provisioner.db).Count(t.Context(), "id")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, count)
+}
+
+func TestSqlite_NotSupported(t *testing.T) {
+	t.Parallel()
+	path := setupSqliteDatabase(t)
+	provisioner := SqliteProvisioner{}
+	require.NoError(t, provisioner.Connect(path))
+	t.Cleanup(func() { provisioner.Close() })
+
+	encrypted, err := provisioner.ConnectionEncrypted(t.Context())
+	require.NoError(t, err)
+	require.True(t, encrypted)
+
+	require.ErrorIs(t, provisioner.CreateUser(t.Context(), "testuser", "testpass"), ErrNotSupported)
+	require.ErrorIs(t, provisioner.DropUser(t.Context(), "testuser"), ErrNotSupported)
+	_, err = provisioner.UserExists(t.Context(), "testuser")
+	require.ErrorIs(t, err, ErrNotSupported)
+	require.ErrorIs(t, provisioner.RotatePassword(t.Context(), "testuser", "testpass"), ErrNotSupported)
+	_, err = provisioner.ListGrants(t.Context(), "testuser")
+	require.ErrorIs(t, err, ErrNotSupported)
+	require.ErrorIs(t, provisioner.SetExpiry(t.Context(), "testuser", time.Now()), ErrNotSupported)
+	_, err = provisioner.UserExpiry(t.Context(), "testuser")
+	require.ErrorIs(t, err, ErrNotSupported)
+	require.ErrorIs(t, provisioner.GrantReadOnly(t.Context(), "testuser", AccessScope{Groups: []string{"main"}}), ErrNotSupported)
+}
+
+func TestSqlite_BadInputs(t *testing.T) {
+	t.Parallel()
+	provisioner := SqliteProvisioner{}
+	missing := filepath.Join(t.TempDir(), "missing.db")
+	require.ErrorIs(t, provisioner.Connect(missing), os.ErrNotExist)
+	_, err := os.Stat(missing)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.NoError(t, provisioner.Close())
+}
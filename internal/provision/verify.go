@@ -0,0 +1,64 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tinternet/databaise/internal/sqlcommon"
+	"gorm.io/gorm"
+)
+
+// ErrNoTableToVerify is returned by VerifyReadOnly when the scope grants access
+// to no existing table, so there is nothing to test the grants against.
+var ErrNoTableToVerify = errors.New("no granted table to verify against")
+
+// errInsertSucceeded rolls back the probe insert in checkReadOnly when it went through.
+var errInsertSucceeded = errors.New("insert succeeded")
+
+// checkReadOnly runs selectSQL and insertSQL on a connection opened as the provisioned
+// user. The select must succeed, and the insert must be refused for lack of privileges.
+// The insert runs in a transaction that is always rolled back.
+func checkReadOnly(ctx context.Context, db *gorm.DB, selectSQL, insertSQL string) error {
+	if err := db.WithContext(ctx).Exec(selectSQL).Error; err != nil {
+		return fmt.Errorf("read-only user cannot SELECT from a granted table: %w", err)
+	}
+
+	dialect := db.Dialector.Name()
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// PostgreSQL users are created with default_transaction_read_only, which would
+		// refuse the insert whatever the user's grants, so the probe opts out of it.
+		if dialect == "postgres" {
+			if err := tx.Exec("SET TRANSACTION READ WRITE").Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec(insertSQL).Error; err != nil {
+			return err
+		}
+		return errInsertSucceeded
+	})
+
+	// The SQLite read-only DSN opens the file read-only rather than using grants.
+	refused := sqlcommon.ErrPermissionDenied
+	if dialect == "sqlite" {
+		refused = sqlcommon.ErrReadonlyViolation
+	}
+	switch {
+	case errors.Is(err, errInsertSucceeded):
+		return errors.New("read-only user can INSERT into a granted table")
+	case errors.Is(sqlcommon.TranslateError(err), refused):
+		return nil
+	default:
+		// Privileges are checked before constraints, so any other failure means the
+		// insert got past the privilege check.
+		return fmt.Errorf("could not confirm read-only user cannot INSERT: %w", err)
+	}
+}
+
+// closeDB closes the connection pool behind db.
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
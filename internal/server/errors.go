@@ -0,0 +1,42 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+// protocolError maps a class of handler errors to a JSON-RPC error code.
+type protocolError struct {
+	target error
+	code   int64
+}
+
+// protocolErrors lists the handler errors reported as JSON-RPC errors.
+var protocolErrors []protocolError
+
+// MapProtocolError makes handler errors matching target (by errors.Is) fail the
+// call with a JSON-RPC error carrying code, instead of an isError tool result.
+//
+// By default every handler error becomes a tool result with isError set, which the
+// model sees and can act on. Reserve protocol errors for genuine protocol failures;
+// a mistake in the arguments the model can correct, such as an unknown database
+// name, belongs in the tool result.
+func MapProtocolError(target error, code int64) {
+	protocolErrors = append(protocolErrors, protocolError{target: target, code: code})
+}
+
+// toolError converts a handler error for the SDK, which reports *jsonrpc.Error
+// values as protocol errors and wraps any other error in an isError tool result.
+func toolError(err error) error {
+	var wireErr *jsonrpc.Error
+	if errors.As(err, &wireErr) {
+		return wireErr
+	}
+	for _, p := range protocolErrors {
+		if errors.Is(err, p.target) {
+			return &jsonrpc.Error{Code: p.code, Message: err.Error()}
+		}
+	}
+	return err
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolError(t *testing.T) {
+	errMissing := errors.New("missing")
+	MapProtocolError(errMissing, jsonrpc.CodeInvalidParams)
+	t.Cleanup(func() { protocolErrors = nil })
+
+	t.Run("Mapped", func(t *testing.T) {
+		err := toolError(fmt.Errorf("%w: %q", errMissing, "db"))
+		var wireErr *jsonrpc.Error
+		require.ErrorAs(t, err, &wireErr)
+		require.EqualValues(t, jsonrpc.CodeInvalidParams, wireErr.Code)
+		require.Equal(t, `missing: "db"`, wireErr.Message)
+	})
+
+	t.Run("ToolResult", func(t *testing.T) {
+		err := errors.New("query failed")
+		require.Same(t, err, toolError(err))
+	})
+}
@@ -2,8 +2,13 @@ package server
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"net/http"
 	"os"
+	"runtime/debug"
+	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/tinternet/databaise/internal/logging"
@@ -11,43 +16,254 @@ import (
 
 var log = logging.New("server")
 
-var server = mcp.NewServer(&mcp.Implementation{
+// implementation is advertised to clients during initialization. The server keeps
+// a pointer to it, so SetImplementation can override it before the server starts.
+var implementation = &mcp.Implementation{
 	Name:    "databaise",
-	Version: "2.0.0",
-}, &mcp.ServerOptions{})
+	Version: buildVersion(),
+}
+
+var server = mcp.NewServer(implementation, &mcp.ServerOptions{})
+
+// SetImplementation overrides the server name and version advertised to MCP clients.
+// Empty values keep the current setting. Must be called before the server starts.
+func SetImplementation(name, version string) {
+	if name != "" {
+		implementation.Name = name
+	}
+	if version != "" {
+		implementation.Version = version
+	}
+}
+
+// buildVersion returns the module version embedded in the binary by the Go
+// toolchain, falling back to the last release for local (devel) builds.
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "2.0.0"
+}
+
+// healthCheck reports whether the server can do its work, for the /healthz route.
+var healthCheck func(context.Context) error
+
+// healthCheckTimeout bounds a /healthz request, so probes fail rather than hang.
+const healthCheckTimeout = 5 * time.Second
+
+// shutdownTimeout bounds how long StartHTTP waits for in-flight requests once ctx
+// is done. Open SSE streams never finish on their own, so they are closed after it.
+const shutdownTimeout = 5 * time.Second
+
+// SetHealthCheck sets the check behind the HTTP /healthz route, which answers 200
+// when it returns nil and 503 otherwise. Must be called before the server starts.
+func SetHealthCheck(check func(context.Context) error) {
+	healthCheck = check
+}
 
 type Tool struct {
 	Name        string
 	Description string
+	// Mutates marks tools that change the database (DDL, writes, maintenance).
+	// They are removed when the server runs in read-only mode.
+	Mutates bool
+	// Admin marks tools that run on the admin connection. They are removed when
+	// no database has an admin connection configured.
+	Admin bool
+	// Write marks tools that run on the write connection. They are removed when
+	// no database has a write connection configured.
+	Write bool
 }
 
 type Handler[In, Out any] func(ctx context.Context, args In) (Out, error)
 
+// tools holds every tool registered via AddTool, in registration order.
+var tools []Tool
+
+type toolNameKey struct{}
+
+// ToolName returns the name of the tool being invoked, or "" outside a tool call.
+func ToolName(ctx context.Context) string {
+	name, _ := ctx.Value(toolNameKey{}).(string)
+	return name
+}
+
+// Caller identifies the MCP client behind a tool call.
+type Caller struct {
+	// Client is the name the client sent when it initialized the session
+	Client string `json:"client,omitempty"`
+	// Session is the MCP session ID. STDIO sessions have none.
+	Session string `json:"session,omitempty"`
+}
+
+type callerKey struct{}
+
+// CallerOf returns the client making the current tool call, or the zero Caller
+// outside a tool call.
+func CallerOf(ctx context.Context) Caller {
+	caller, _ := ctx.Value(callerKey{}).(Caller)
+	return caller
+}
+
+// requestCaller returns the client that sent request.
+func requestCaller(request *mcp.CallToolRequest) Caller {
+	var caller Caller
+	if request == nil || request.Session == nil {
+		return caller
+	}
+	caller.Session = request.Session.ID()
+	if params := request.Session.InitializeParams(); params != nil && params.ClientInfo != nil {
+		caller.Client = params.ClientInfo.Name
+	}
+	return caller
+}
+
+// Tools returns all registered tools.
+func Tools() []Tool {
+	return tools
+}
+
+// IsMutating returns true if the named tool is registered and changes the database.
+func IsMutating(name string) bool {
+	for _, t := range tools {
+		if t.Name == name {
+			return t.Mutates
+		}
+	}
+	return false
+}
+
+// HasTool returns true if a tool with the given name is registered.
+func HasTool(name string) bool {
+	for _, t := range tools {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SetReadOnly removes every tool that mutates the database, regardless of the
+// database configuration. Must be called before the server starts.
+func SetReadOnly() {
+	removed := removeTools(func(t Tool) bool { return t.Mutates })
+	log.Printf("Read-only mode active, disabled tools: %s", strings.Join(removed, ", "))
+}
+
+// RemoveAdminTools removes every tool that needs an admin connection, so clients
+// are not offered tools that cannot work. Must be called before the server starts.
+func RemoveAdminTools() {
+	removed := removeTools(func(t Tool) bool { return t.Admin })
+	log.Printf("No database has an admin connection, disabled tools: %s", strings.Join(removed, ", "))
+}
+
+// RemoveWriteTools removes every tool that needs a write connection, so read-only
+// deployments do not offer them. Must be called before the server starts.
+func RemoveWriteTools() {
+	removed := removeTools(func(t Tool) bool { return t.Write })
+	log.Printf("No database has a write connection, disabled tools: %s", strings.Join(removed, ", "))
+}
+
+// removeTools unregisters the tools matching remove and returns their names.
+func removeTools(remove func(Tool) bool) []string {
+	var removed []string
+	kept := tools[:0]
+	for _, t := range tools {
+		if remove(t) {
+			removed = append(removed, t.Name)
+		} else {
+			kept = append(kept, t)
+		}
+	}
+	tools = kept
+	server.RemoveTools(removed...)
+	return removed
+}
+
 func AddTool[In, Out any](handler Handler[In, Out], tool Tool) {
 	t := &mcp.Tool{
 		Name:        tool.Name,
 		Description: tool.Description,
 	}
+	tools = append(tools, tool)
 
 	mcp.AddTool(server, t, func(ctx context.Context, request *mcp.CallToolRequest, input In) (*mcp.CallToolResult, Out, error) {
+		ctx = context.WithValue(ctx, toolNameKey{}, tool.Name)
+		ctx = context.WithValue(ctx, callerKey{}, requestCaller(request))
 		res, err := handler(ctx, input)
-		return nil, res, err
+		if err != nil {
+			return nil, res, toolError(err)
+		}
+		return nil, res, nil
 	})
 }
 
-func StartHTTP(address string) {
+// StartHTTP serves MCP over streamable HTTP until ctx is done. With a non-empty
+// token, every request must send it as "Authorization: Bearer <token>".
+func StartHTTP(ctx context.Context, address, token string) {
 	log.Printf("Starting HTTP server on %s", address)
-	handler := mcp.NewStreamableHTTPHandler(func(req *http.Request) *mcp.Server { return server }, nil)
-	if err := http.ListenAndServe(address, handler); err != nil {
+	var handler http.Handler = mcp.NewStreamableHTTPHandler(func(req *http.Request) *mcp.Server { return server }, nil)
+	if token != "" {
+		handler = requireBearer(token, handler)
+	} else {
+		logging.Warn("no auth token set: anyone who can reach %s can call every tool", address)
+	}
+	// Probes cannot authenticate, so /healthz is served without the token.
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", serveHealth)
+	mux.Handle("/", handler)
+
+	srv := &http.Server{Addr: address, Handler: mux}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			srv.Close()
+		}
+	}()
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("Server failed: %v", err)
 	}
+	<-stopped
+	log.Printf("HTTP server stopped")
+}
+
+// serveHealth answers 200 if the health check passes and 503 with its error otherwise.
+func serveHealth(w http.ResponseWriter, r *http.Request) {
+	if healthCheck != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+		defer cancel()
+		if err := healthCheck(ctx); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.Write([]byte("ok\n"))
+}
+
+// requireBearer rejects requests that do not carry token as a bearer token with
+// 401 Unauthorized. The comparison takes constant time.
+func requireBearer(token string, next http.Handler) http.Handler {
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="databaise"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
 }
 
-func StartSTDIO() {
+func StartSTDIO(ctx context.Context) {
 	log.Printf("Starting STDIO server")
 	logging.SetOutput(os.Stderr)
 	t := &mcp.LoggingTransport{Transport: &mcp.StdioTransport{}, Writer: os.Stderr}
-	if err := server.Run(context.Background(), t); err != nil {
+	if err := server.Run(ctx, t); err != nil && ctx.Err() == nil {
 		log.Printf("ERROR: Server failed: %v", err)
 	}
 }
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireBearer(t *testing.T) {
+	handler := requireBearer("s3cret", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for header, status := range map[string]int{
+		"Bearer s3cret":  http.StatusNoContent,
+		"":               http.StatusUnauthorized,
+		"Bearer wrong":   http.StatusUnauthorized,
+		"Bearer s3cret2": http.StatusUnauthorized,
+		"Basic s3cret":   http.StatusUnauthorized,
+		"s3cret":         http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, status, rec.Code, header)
+		if status == http.StatusUnauthorized {
+			require.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestServeHealth(t *testing.T) {
+	t.Cleanup(func() { healthCheck = nil })
+
+	check := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		serveHealth(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		return rec
+	}
+
+	require.Equal(t, http.StatusOK, check().Code)
+
+	SetHealthCheck(func(context.Context) error { return nil })
+	require.Equal(t, http.StatusOK, check().Code)
+
+	SetHealthCheck(func(context.Context) error { return errors.New("unreachable databases: app") })
+	rec := check()
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Contains(t, rec.Body.String(), "app")
+}
@@ -0,0 +1,161 @@
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+)
+
+type boolNormalizationKey struct{}
+
+type boolColumnsKey struct{}
+
+// WithBoolNormalization returns a context that makes QueryRows return the values
+// of boolean-like columns as true or false instead of 0/1 or a single byte.
+func WithBoolNormalization(ctx context.Context, enabled bool) context.Context {
+	if !enabled {
+		return ctx
+	}
+	return context.WithValue(ctx, boolNormalizationKey{}, true)
+}
+
+// BoolNormalization reports whether ctx asks for boolean normalization.
+func BoolNormalization(ctx context.Context) bool {
+	enabled, _ := ctx.Value(boolNormalizationKey{}).(bool)
+	return enabled
+}
+
+// WithBoolColumns returns a context that tells QueryRows which TINYINT and BIT
+// result columns hold booleans, by name. It is for MySQL, whose driver reports
+// TINYINT(1) and BIT(1) the same as any other width, so their types cannot tell;
+// see QueryTables for narrowing the names down to the tables a query reads.
+func WithBoolColumns(ctx context.Context, names []string) context.Context {
+	columns := make(map[string]bool, len(names))
+	for _, name := range names {
+		columns[name] = true
+	}
+	return context.WithValue(ctx, boolColumnsKey{}, columns)
+}
+
+// TableName is a table named in a query, with the schema it is qualified with, if any.
+type TableName struct {
+	Schema string
+	Name   string
+}
+
+// QueryTables returns the tables named after FROM and JOIN and in FROM lists
+// anywhere in a query, subqueries included. Common table expressions and views
+// are returned like tables. It returns nil if the query cannot be tokenized.
+func QueryTables(query string) []TableName {
+	tokens, err := tokenizeDDL(query)
+	if err != nil {
+		return nil
+	}
+	p := &ddlParser{src: query, tokens: tokens}
+	// inFrom is set for the depths whose current clause is a FROM clause.
+	inFrom := map[int]bool{}
+	var tables []TableName
+	for i, t := range tokens {
+		switch {
+		case t.upper == "FROM", t.upper == "JOIN":
+			inFrom[t.depth] = true
+		case t.punct && t.text == "," && inFrom[t.depth]:
+		case t.upper == "SELECT", clauseStarts[t.upper]:
+			inFrom[t.depth] = false
+			continue
+		default:
+			continue
+		}
+		p.pos = i + 1
+		if name, ok := p.name(); ok {
+			tables = append(tables, TableName{Schema: name.schema(), Name: name.table()})
+		}
+	}
+	return tables
+}
+
+// boolTypes are the database type names of boolean-like columns: BIT in SQL
+// Server, and the declared types SQLite stores booleans under as integers.
+var boolTypes = map[string]bool{"BIT": true, "BOOL": true, "BOOLEAN": true, "TINYINT(1)": true}
+
+// boolNormalizer converts the values of boolean-like columns to bool.
+type boolNormalizer struct {
+	// columns lists the boolean-like result columns, or is nil if normalization is off.
+	columns []string
+}
+
+func newBoolNormalizer(ctx context.Context, columnTypes []*sql.ColumnType) boolNormalizer {
+	if !BoolNormalization(ctx) {
+		return boolNormalizer{}
+	}
+	names, byName := ctx.Value(boolColumnsKey{}).(map[string]bool)
+
+	var n boolNormalizer
+	for _, ct := range columnTypes {
+		typ := ct.DatabaseTypeName()
+		if byName {
+			if names[ct.Name()] && (typ == "TINYINT" || typ == "BIT") {
+				n.columns = append(n.columns, ct.Name())
+			}
+		} else if boolTypes[typ] {
+			n.columns = append(n.columns, ct.Name())
+		}
+	}
+	return n
+}
+
+// row converts the boolean-like values of row in place. Values other than 0 and 1
+// are left alone, so a misdetected column is never silently changed.
+func (n boolNormalizer) row(row map[string]any) {
+	for _, name := range n.columns {
+		if b, ok := toBool(row[name]); ok {
+			row[name] = b
+		}
+	}
+}
+
+func toBool(v any) (bool, bool) {
+	v = deref(v)
+	switch v := v.(type) {
+	case bool:
+		return v, true
+	case []byte:
+		if len(v) != 1 || v[0] > 1 {
+			return false, false
+		}
+		return v[0] == 1, true
+	}
+	if i, ok := toInt64(v); ok && (i == 0 || i == 1) {
+		return i == 1, true
+	}
+	return false, false
+}
+
+// toInt64 returns the value of an integer scanned by gorm into a map.
+func toInt64(v any) (int64, bool) {
+	switch v := deref(v).(type) {
+	case int64:
+		return v, true
+	case int32:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// deref returns what v points to, since gorm scans columns without a driver scan
+// type into pointers.
+func deref(v any) any {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		return rv.Elem().Interface()
+	}
+	return v
+}
@@ -0,0 +1,26 @@
+package sqlcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryTables(t *testing.T) {
+	for _, tt := range []struct {
+		query string
+		want  []TableName
+	}{
+		{"SELECT 1", nil},
+		{"SELECT * FROM users", []TableName{{Name: "users"}}},
+		{"SELECT * FROM app.users u JOIN `orders` o ON o.user_id = u.id", []TableName{{Schema: "app", Name: "users"}, {Name: "orders"}}},
+		{"SELECT a.x, b.y FROM a, b WHERE a.id = b.id", []TableName{{Name: "a"}, {Name: "b"}}},
+		{"SELECT id, (SELECT COUNT(*) FROM orders) AS n FROM users ORDER BY id, n", []TableName{{Name: "orders"}, {Name: "users"}}},
+		{"SELECT * FROM (SELECT active FROM flags) f", []TableName{{Name: "flags"}}},
+		{"SELECT 'unterminated", nil},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			require.Equal(t, tt.want, QueryTables(tt.query))
+		})
+	}
+}
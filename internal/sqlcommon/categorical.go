@@ -0,0 +1,99 @@
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CategoricalColumn is a low-cardinality column with the frequency of each of its values.
+type CategoricalColumn struct {
+	Name         string       `json:"name" jsonschema:"The column name"`
+	DatabaseType string       `json:"database_type" jsonschema:"The type name reported by the database"`
+	Values       []ValueCount `json:"values" jsonschema:"The column's distinct values, most frequent first"`
+}
+
+// ValueCount is a distinct value of a column and the number of rows holding it.
+type ValueCount struct {
+	Value any   `json:"value" jsonschema:"The value (null for NULL)"`
+	Count int64 `json:"count" jsonschema:"Number of rows with this value"`
+}
+
+// ungroupableTypes are column types that cannot be grouped by in some dialect, or
+// hold documents or binary data rather than categories.
+var ungroupableTypes = map[string]bool{
+	"JSON": true, "JSONB": true, "XML": true,
+	"BYTEA": true, "BLOB": true, "TINYBLOB": true, "MEDIUMBLOB": true, "LONGBLOB": true,
+	"BINARY": true, "VARBINARY": true, "IMAGE": true, "NTEXT": true,
+	"GEOMETRY": true, "GEOGRAPHY": true,
+}
+
+func groupable(dialect string, ct *sql.ColumnType) bool {
+	typ := ct.DatabaseTypeName()
+	// TEXT is an ordinary string type except in SQL Server, where it is a legacy LOB.
+	return !ungroupableTypes[typ] && !(dialect == "sqlserver" && typ == "TEXT")
+}
+
+// ProfileCategoricalColumns finds the columns of table with at most maxDistinct
+// distinct non-NULL values and counts the rows holding each value. table is a
+// Raw argument naming the table, such as clause.Table. skipped lists the columns
+// left out for having more distinct values or a type that cannot be grouped.
+// It reads the whole table once to count distinct values, and again for each
+// low-cardinality column.
+func ProfileCategoricalColumns(ctx context.Context, db *gorm.DB, table any, maxDistinct int) (columns []CategoricalColumn, skipped []string, err error) {
+	db = db.WithContext(ctx)
+	empty, err := QueryRows(ctx, db, "SELECT * FROM ? WHERE 1 = 0", table)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var candidates []*sql.ColumnType
+	for _, ct := range empty.ColumnTypes {
+		if groupable(db.Dialector.Name(), ct) {
+			candidates = append(candidates, ct)
+		} else {
+			skipped = append(skipped, ct.Name())
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, skipped, nil
+	}
+
+	// One pass counts the distinct values of every candidate, so the cardinality
+	// guard costs a single scan however many columns the table has.
+	exprs := make([]string, len(candidates))
+	args := make([]any, 0, len(candidates)+1)
+	counts := make([]int64, len(candidates))
+	dest := make([]any, len(candidates))
+	for i, ct := range candidates {
+		exprs[i] = "COUNT(DISTINCT ?)"
+		args = append(args, clause.Column{Name: ct.Name()})
+		dest[i] = &counts[i]
+	}
+	args = append(args, table)
+	if err := db.Raw("SELECT "+strings.Join(exprs, ", ")+" FROM ?", args...).Row().Scan(dest...); err != nil {
+		return nil, nil, err
+	}
+
+	for i, ct := range candidates {
+		if counts[i] > int64(maxDistinct) {
+			skipped = append(skipped, ct.Name())
+			continue
+		}
+		column := clause.Column{Name: ct.Name()}
+		rows, err := QueryRows(ctx, db, "SELECT ? AS value, COUNT(*) AS count FROM ? GROUP BY ? ORDER BY 2 DESC, 1", column, table, column)
+		if err != nil {
+			return nil, nil, err
+		}
+		c := CategoricalColumn{Name: ct.Name(), DatabaseType: ct.DatabaseTypeName(), Values: make([]ValueCount, 0, len(rows.Rows))}
+		for _, row := range rows.Rows {
+			n, _ := toInt64(row["count"])
+			c.Values = append(c.Values, ValueCount{Value: deref(row["value"]), Count: n})
+		}
+		columns = append(columns, c)
+	}
+	return columns, skipped, nil
+}
@@ -0,0 +1,32 @@
+package sqlcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func TestProfileCategoricalColumns(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`
+		CREATE TABLE tickets (id INTEGER PRIMARY KEY, status TEXT, priority INTEGER, attachment BLOB);
+		INSERT INTO tickets (status, priority) VALUES ('open', 1), ('open', 2), ('closed', 1), ('open', NULL), (NULL, 3);
+	`).Error)
+
+	columns, skipped, err := ProfileCategoricalColumns(t.Context(), db, clause.Table{Name: "tickets"}, 3)
+	require.NoError(t, err)
+	require.Equal(t, []CategoricalColumn{
+		{Name: "status", DatabaseType: "TEXT", Values: []ValueCount{{Value: "open", Count: 3}, {Value: nil, Count: 1}, {Value: "closed", Count: 1}}},
+		{Name: "priority", DatabaseType: "INTEGER", Values: []ValueCount{{Value: int64(1), Count: 2}, {Value: nil, Count: 1}, {Value: int64(2), Count: 1}, {Value: int64(3), Count: 1}}},
+	}, columns)
+	require.ElementsMatch(t, []string{"id", "attachment"}, skipped)
+
+	t.Run("TableNotFound", func(t *testing.T) {
+		_, _, err := ProfileCategoricalColumns(t.Context(), db, clause.Table{Name: "missing"}, 3)
+		require.Error(t, err)
+	})
+}
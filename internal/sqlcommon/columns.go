@@ -0,0 +1,213 @@
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// Column describes a table column as declared in the catalog.
+type Column struct {
+	Name string
+	// DatabaseType is the declared type including its length or precision, e.g. varchar(50).
+	DatabaseType string
+	Nullable     bool
+	// HasDefault is set if the database fills the column when an insert omits it,
+	// from a default, an identity or auto-increment.
+	HasDefault bool
+	// EnumValues lists the allowed values of an enum type, in declaration order.
+	EnumValues []string
+	Comment    string
+}
+
+// GetColumns returns the columns of a table in declaration order. An empty schema
+// means the connection's default schema. It returns ErrTableNotFound if the table
+// does not exist.
+func GetColumns(ctx context.Context, db *gorm.DB, schema, table string) ([]Column, error) {
+	db = db.WithContext(ctx)
+	var columns []Column
+	var err error
+	switch name := db.Dialector.Name(); name {
+	case "postgres":
+		columns, err = postgresColumns(db, schema, table)
+	case "mysql":
+		columns, err = mysqlColumns(db, schema, table)
+	case "sqlserver":
+		columns, err = sqlserverColumns(db, schema, table)
+	case "sqlite":
+		columns, err = sqliteColumns(db, schema, table)
+	default:
+		return nil, fmt.Errorf("column lookup is not supported for %s", name)
+	}
+	if err == nil && len(columns) == 0 {
+		return nil, ErrTableNotFound
+	}
+	return columns, err
+}
+
+// CheckColumn returns ErrTableNotFound if the table does not exist, or
+// ErrColumnNotFound if it has no column with the given name. An empty column
+// only checks the table.
+func CheckColumn(ctx context.Context, db *gorm.DB, schema, table, column string) error {
+	columns, err := GetColumns(ctx, db, schema, table)
+	if err != nil {
+		return err
+	}
+	if column != "" && !slices.ContainsFunc(columns, func(c Column) bool { return c.Name == column }) {
+		return fmt.Errorf("%w: %s", ErrColumnNotFound, column)
+	}
+	return nil
+}
+
+// catalogColumn is a row of the per-dialect column queries.
+type catalogColumn struct {
+	Name         string  `gorm:"column:name"`
+	DatabaseType string  `gorm:"column:database_type"`
+	Nullable     bool    `gorm:"column:nullable"`
+	HasDefault   bool    `gorm:"column:has_default"`
+	EnumValues   *string `gorm:"column:enum_values"`
+	Comment      *string `gorm:"column:comment"`
+}
+
+func (c catalogColumn) column() Column {
+	col := Column{Name: c.Name, DatabaseType: c.DatabaseType, Nullable: c.Nullable, HasDefault: c.HasDefault}
+	if c.Comment != nil {
+		col.Comment = *c.Comment
+	}
+	return col
+}
+
+func postgresColumns(db *gorm.DB, schema, table string) ([]Column, error) {
+	name := pgx.Identifier{table}.Sanitize()
+	if schema != "" {
+		name = pgx.Identifier{schema, table}.Sanitize()
+	}
+
+	var rows []catalogColumn
+	err := db.Raw(`SELECT a.attname AS name,
+	format_type(a.atttypid, a.atttypmod) AS database_type,
+	NOT a.attnotnull AS nullable,
+	a.atthasdef OR a.attidentity <> '' AS has_default,
+	(SELECT json_agg(e.enumlabel ORDER BY e.enumsortorder)::text FROM pg_enum e WHERE e.enumtypid = a.atttypid) AS enum_values,
+	col_description(a.attrelid, a.attnum) AS comment
+FROM pg_attribute a
+WHERE a.attrelid = to_regclass(?) AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY a.attnum`, name).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]Column, len(rows))
+	for i, r := range rows {
+		columns[i] = r.column()
+		if r.EnumValues != nil {
+			if err := json.Unmarshal([]byte(*r.EnumValues), &columns[i].EnumValues); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return columns, nil
+}
+
+func mysqlColumns(db *gorm.DB, schema, table string) ([]Column, error) {
+	var rows []catalogColumn
+	err := db.Raw(`SELECT COLUMN_NAME AS name,
+	COLUMN_TYPE AS database_type,
+	IS_NULLABLE = 'YES' AS nullable,
+	COLUMN_DEFAULT IS NOT NULL OR EXTRA LIKE '%auto_increment%' OR EXTRA LIKE '%GENERATED%' AS has_default,
+	NULLIF(COLUMN_COMMENT, '') AS comment
+FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ?
+ORDER BY ORDINAL_POSITION`, schema, table).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]Column, len(rows))
+	for i, r := range rows {
+		columns[i] = r.column()
+		columns[i].EnumValues = mysqlEnumValues(r.DatabaseType)
+	}
+	return columns, nil
+}
+
+// mysqlEnumValues parses the values of a column type such as enum('a','b'), in
+// which a quote inside a value is doubled.
+func mysqlEnumValues(columnType string) []string {
+	list, ok := strings.CutPrefix(columnType, "enum(")
+	if !ok {
+		return nil
+	}
+	list = strings.TrimSuffix(list, ")")
+
+	var values []string
+	var value strings.Builder
+	quoted := false
+	for i := 0; i < len(list); i++ {
+		switch c := list[i]; {
+		case c == '\'' && quoted && i+1 < len(list) && list[i+1] == '\'':
+			value.WriteByte('\'')
+			i++
+		case c == '\'':
+			if quoted {
+				values = append(values, value.String())
+				value.Reset()
+			}
+			quoted = !quoted
+		case quoted:
+			value.WriteByte(c)
+		}
+	}
+	return values
+}
+
+func sqlserverColumns(db *gorm.DB, schema, table string) ([]Column, error) {
+	var rows []catalogColumn
+	err := db.Raw(`SELECT c.name,
+	TYPE_NAME(c.user_type_id) + CASE
+		WHEN TYPE_NAME(c.system_type_id) IN ('varchar', 'char', 'varbinary', 'binary') THEN '(' + IIF(c.max_length = -1, 'max', CAST(c.max_length AS varchar(10))) + ')'
+		WHEN TYPE_NAME(c.system_type_id) IN ('nvarchar', 'nchar') THEN '(' + IIF(c.max_length = -1, 'max', CAST(c.max_length / 2 AS varchar(10))) + ')'
+		WHEN TYPE_NAME(c.system_type_id) IN ('decimal', 'numeric') THEN '(' + CAST(c.precision AS varchar(10)) + ',' + CAST(c.scale AS varchar(10)) + ')'
+		ELSE '' END AS database_type,
+	c.is_nullable AS nullable,
+	CAST(IIF(c.default_object_id <> 0 OR c.is_identity = 1 OR c.is_computed = 1, 1, 0) AS bit) AS has_default,
+	CAST(ep.value AS nvarchar(max)) AS comment
+FROM sys.columns c
+LEFT JOIN sys.extended_properties ep ON ep.class = 1 AND ep.major_id = c.object_id AND ep.minor_id = c.column_id AND ep.name = 'MS_Description'
+WHERE c.object_id = OBJECT_ID(QUOTENAME(COALESCE(NULLIF(?, ''), SCHEMA_NAME())) + '.' + QUOTENAME(?), 'U')
+ORDER BY c.column_id`, schema, table).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]Column, len(rows))
+	for i, r := range rows {
+		columns[i] = r.column()
+	}
+	return columns, nil
+}
+
+func sqliteColumns(db *gorm.DB, schema, table string) ([]Column, error) {
+	// An INTEGER PRIMARY KEY is an alias for the rowid, which SQLite assigns if omitted.
+	var rows []catalogColumn
+	err := db.Raw(`SELECT name,
+	type AS database_type,
+	NOT "notnull" AND NOT (pk > 0 AND upper(type) = 'INTEGER') AS nullable,
+	dflt_value IS NOT NULL OR (pk > 0 AND upper(type) = 'INTEGER') AS has_default
+FROM pragma_table_info(?, COALESCE(NULLIF(?, ''), 'main'))
+ORDER BY cid`, table, schema).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]Column, len(rows))
+	for i, r := range rows {
+		columns[i] = r.column()
+	}
+	return columns, nil
+}
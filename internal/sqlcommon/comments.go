@@ -0,0 +1,56 @@
+package sqlcommon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// GetComments returns the comment of a table and those of its columns by column
+// name, leaving out columns without one. An empty schema means the connection's
+// default schema. SQLite has no comments, so it always returns none.
+func GetComments(ctx context.Context, db *gorm.DB, schema, table string) (string, map[string]string, error) {
+	db = db.WithContext(ctx)
+	var comment string
+	var err error
+	switch name := db.Dialector.Name(); name {
+	case "postgres":
+		name := pgx.Identifier{table}.Sanitize()
+		if schema != "" {
+			name = pgx.Identifier{schema, table}.Sanitize()
+		}
+		err = db.Raw("SELECT COALESCE(obj_description(to_regclass(?), 'pg_class'), '')", name).Scan(&comment).Error
+	case "mysql":
+		err = db.Raw(`SELECT TABLE_COMMENT FROM information_schema.TABLES
+WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ? AND TABLE_TYPE = 'BASE TABLE'`, schema, table).Scan(&comment).Error
+	case "sqlserver":
+		err = db.Raw(`SELECT CAST(value AS nvarchar(max)) FROM sys.extended_properties
+WHERE class = 1 AND minor_id = 0 AND name = 'MS_Description'
+	AND major_id = OBJECT_ID(QUOTENAME(COALESCE(NULLIF(?, ''), SCHEMA_NAME())) + '.' + QUOTENAME(?), 'U')`, schema, table).Scan(&comment).Error
+	case "sqlite":
+		return "", nil, nil
+	default:
+		return "", nil, fmt.Errorf("comment lookup is not supported for %s", name)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	columns, err := GetColumns(ctx, db, schema, table)
+	if err != nil {
+		return "", nil, err
+	}
+	var comments map[string]string
+	for _, c := range columns {
+		if c.Comment == "" {
+			continue
+		}
+		if comments == nil {
+			comments = make(map[string]string)
+		}
+		comments[c.Name] = c.Comment
+	}
+	return comment, comments, nil
+}
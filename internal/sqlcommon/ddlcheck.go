@@ -0,0 +1,705 @@
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// DDLCheck is a check that existing data allows a schema change.
+type DDLCheck struct {
+	Check      string           `json:"check" jsonschema:"What was checked"`
+	Passed     bool             `json:"passed" jsonschema:"Whether the data allows the change"`
+	Violations int64            `json:"violations,omitempty" jsonschema:"Number of rows or values that would make the change fail"`
+	Samples    []map[string]any `json:"samples,omitempty" jsonschema:"Up to 5 of the violating values or rows"`
+	Message    string           `json:"message,omitempty" jsonschema:"Why the change would fail and how to fix the data first"`
+	Query      string           `json:"query,omitempty" jsonschema:"The query that counted the violations"`
+}
+
+// DDLCheckReport is the result of CheckDDL.
+type DDLCheckReport struct {
+	WouldSucceed bool       `json:"would_succeed" jsonschema:"Whether every check passed. The statement can still fail for reasons that do not depend on data, such as permissions, locks or syntax"`
+	Checks       []DDLCheck `json:"checks" jsonschema:"The data checks the statement depends on"`
+	Note         string     `json:"note,omitempty" jsonschema:"Parts of the statement that were not checked, and why"`
+}
+
+// ddlCheckSamples is the number of violations returned as samples per check.
+const ddlCheckSamples = 5
+
+// ddlCheckPlan is a check of a DDLCheckReport before it runs.
+type ddlCheckPlan struct {
+	check string
+	// count returns the number of violations. An empty count means the change
+	// fails whatever the data, and message says why.
+	count   string
+	samples string
+	// message explains a failure, with %d for the number of violations.
+	message string
+}
+
+// CheckDDL finds out whether a DDL statement would fail on the data already in
+// the database, without applying it: duplicates for a unique index, unique or
+// primary key constraint, NULLs for a NOT NULL or primary key column, orphan rows
+// for a foreign key, rows violating a CHECK constraint, and existing rows for a
+// NOT NULL column added without a default. The checks scan the affected tables,
+// and run in a transaction that is rolled back.
+func CheckDDL(ctx context.Context, db *gorm.DB, ddl string) (*DDLCheckReport, error) {
+	tokens, err := tokenizeDDL(ddl)
+	if err != nil {
+		return nil, err
+	}
+	dialect := db.Dialector.Name()
+	plans, notes, err := planDDLChecks(ctx, db, dialect, &ddlParser{src: ddl, tokens: tokens, foldCase: dialect == "postgres"})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DDLCheckReport{WouldSucceed: true, Checks: []DDLCheck{}, Note: strings.Join(notes, " ")}
+	if len(plans) == 0 {
+		if report.Note == "" {
+			report.Note = "No data checks apply to this statement."
+		}
+		return report, nil
+	}
+
+	// SQL Server and SQLite reject read-only transactions; the rollback covers them.
+	tx := db.WithContext(ctx).Begin(&sql.TxOptions{ReadOnly: dialect == "postgres" || dialect == "mysql"})
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	defer tx.Rollback()
+
+	for _, plan := range plans {
+		check := DDLCheck{Check: plan.check, Query: plan.count}
+		if plan.count == "" {
+			check.Message = plan.message
+		} else {
+			if err := tx.Raw(plan.count).Row().Scan(&check.Violations); err != nil {
+				return nil, err
+			}
+			check.Passed = check.Violations == 0
+			if !check.Passed {
+				check.Message = fmt.Sprintf(plan.message, check.Violations)
+				if plan.samples != "" {
+					rows, err := QueryRows(WithMaxRows(ctx, ddlCheckSamples), tx, plan.samples)
+					if err != nil {
+						return nil, err
+					}
+					check.Samples = rows.Rows
+				}
+			}
+		}
+		if !check.Passed {
+			report.WouldSucceed = false
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	return report, nil
+}
+
+func planDDLChecks(ctx context.Context, db *gorm.DB, dialect string, p *ddlParser) ([]ddlCheckPlan, []string, error) {
+	switch {
+	case p.accept("CREATE"):
+		return planCreateIndex(dialect, p)
+	case p.accept("ALTER", "TABLE"):
+		return planAlterTable(ctx, db, dialect, p)
+	}
+	return nil, []string{"Only CREATE INDEX and ALTER TABLE statements are checked."}, nil
+}
+
+func planCreateIndex(dialect string, p *ddlParser) ([]ddlCheckPlan, []string, error) {
+	unique := p.accept("UNIQUE")
+	_ = p.accept("CLUSTERED") || p.accept("NONCLUSTERED")
+	if !p.accept("INDEX") {
+		return nil, []string{"Only CREATE INDEX and ALTER TABLE statements are checked."}, nil
+	}
+	if !unique {
+		return nil, []string{"A non-unique index does not depend on existing data."}, nil
+	}
+	p.accept("CONCURRENTLY")
+	p.accept("IF", "NOT", "EXISTS")
+	// The index name is optional in PostgreSQL.
+	if !p.peek("ON") {
+		if _, ok := p.name(); !ok {
+			return nil, nil, errUnrecognizedDDL
+		}
+	}
+	if !p.accept("ON") {
+		return nil, nil, errUnrecognizedDDL
+	}
+	p.accept("ONLY")
+	table, ok := p.name()
+	if !ok {
+		return nil, nil, errUnrecognizedDDL
+	}
+	if p.accept("USING") {
+		p.pos++
+	}
+	columns, ok := p.columnList()
+	if !ok {
+		return nil, []string{"Uniqueness of index expressions and column prefixes is not checked."}, nil
+	}
+	nullsEqual := dialect == "sqlserver" || p.has("NULLS", "NOT", "DISTINCT")
+	return []ddlCheckPlan{duplicatesPlan(table, columns, p.where(), nullsEqual)}, nil, nil
+}
+
+func planAlterTable(ctx context.Context, db *gorm.DB, dialect string, p *ddlParser) ([]ddlCheckPlan, []string, error) {
+	p.accept("IF", "EXISTS")
+	p.accept("ONLY")
+	table, ok := p.name()
+	if !ok {
+		return nil, nil, errUnrecognizedDDL
+	}
+	// SQL Server: ALTER TABLE t WITH NOCHECK ADD ... skips validating existing rows.
+	nocheck := p.accept("WITH", "NOCHECK")
+	p.accept("WITH", "CHECK")
+
+	var plans []ddlCheckPlan
+	var notes []string
+	for _, a := range p.actions() {
+		switch {
+		case a.accept("ADD"):
+			constraint := a.accept("CONSTRAINT")
+			if constraint {
+				if _, ok := a.name(); !ok {
+					return nil, nil, errUnrecognizedDDL
+				}
+			}
+			if dialect == "sqlite" && (constraint || a.peek("PRIMARY") || a.peek("UNIQUE") || a.peek("FOREIGN") || a.peek("CHECK")) {
+				plans = append(plans, ddlCheckPlan{
+					check:   "constraint can be added to " + table.raw,
+					message: "SQLite's ALTER TABLE cannot add constraints: recreate the table with the constraint and copy the rows over, or use CREATE UNIQUE INDEX for uniqueness",
+				})
+				continue
+			}
+
+			switch {
+			case a.accept("PRIMARY", "KEY"):
+				_ = a.accept("CLUSTERED") || a.accept("NONCLUSTERED")
+				columns, ok := a.columnList()
+				if !ok {
+					return nil, nil, errUnrecognizedDDL
+				}
+				plans = append(plans, nullsPlan(table, columns), duplicatesPlan(table, columns, "", true))
+
+			case a.accept("UNIQUE"):
+				_ = a.accept("KEY") || a.accept("INDEX")
+				_ = a.accept("CLUSTERED") || a.accept("NONCLUSTERED")
+				nullsNotDistinct := a.accept("NULLS", "NOT", "DISTINCT")
+				if !a.peekPunct("(") {
+					// MySQL: ADD UNIQUE [INDEX | KEY] name (columns)
+					a.name()
+				}
+				nullsNotDistinct = nullsNotDistinct || a.accept("NULLS", "NOT", "DISTINCT")
+				columns, ok := a.columnList()
+				if !ok {
+					notes = append(notes, "Uniqueness of index expressions and column prefixes is not checked.")
+					continue
+				}
+				plans = append(plans, duplicatesPlan(table, columns, "", dialect == "sqlserver" || nullsNotDistinct))
+
+			case a.accept("FOREIGN", "KEY"):
+				if !a.peekPunct("(") {
+					a.name()
+				}
+				columns, ok := a.columnList()
+				if !ok || !a.accept("REFERENCES") {
+					return nil, nil, errUnrecognizedDDL
+				}
+				parent, ok := a.name()
+				if !ok {
+					return nil, nil, errUnrecognizedDDL
+				}
+				if nocheck || a.has("NOT", "VALID") {
+					notes = append(notes, "The foreign key is added without validating existing rows, so they are not checked.")
+					continue
+				}
+				var parentColumns []string
+				if a.peekPunct("(") {
+					if parentColumns, ok = a.columnList(); !ok {
+						return nil, nil, errUnrecognizedDDL
+					}
+				} else {
+					// The primary key of the parent table is referenced by default.
+					key, err := GetPrimaryKey(ctx, db, parent.schema(), parent.table())
+					if err != nil {
+						return nil, nil, err
+					}
+					for _, col := range key {
+						parentColumns = append(parentColumns, db.Statement.Quote(col))
+					}
+				}
+				if len(parentColumns) != len(columns) {
+					return nil, nil, fmt.Errorf("the foreign key has %d columns but references %d", len(columns), len(parentColumns))
+				}
+				plans = append(plans, orphansPlan(table, columns, parent, parentColumns))
+
+			case a.accept("CHECK"):
+				expr, ok := a.group()
+				if !ok {
+					return nil, nil, errUnrecognizedDDL
+				}
+				if nocheck || a.has("NOT", "VALID") {
+					notes = append(notes, "The check constraint is added without validating existing rows, so they are not checked.")
+					continue
+				}
+				plans = append(plans, checkConstraintPlan(table, expr))
+
+			case constraint, a.peek("EXCLUDE"):
+				notes = append(notes, "Only primary key, unique, foreign key and check constraints are checked.")
+
+			case a.peek("INDEX"), a.peek("KEY"), a.peek("FULLTEXT"), a.peek("SPATIAL"):
+				// MySQL: a non-unique index does not depend on existing data.
+
+			default:
+				a.accept("COLUMN")
+				a.accept("IF", "NOT", "EXISTS")
+				column, ok := a.name()
+				if !ok {
+					return nil, nil, errUnrecognizedDDL
+				}
+				if !a.has("NOT", "NULL") || a.hasAny("DEFAULT", "IDENTITY", "GENERATED", "AUTO_INCREMENT", "SERIAL", "SMALLSERIAL", "BIGSERIAL", "AS") {
+					continue
+				}
+				switch dialect {
+				case "mysql":
+					// MySQL fills existing rows with the type's implicit default.
+				case "sqlite":
+					plans = append(plans, ddlCheckPlan{
+						check:   "NOT NULL column " + column.raw + " can be added to " + table.raw,
+						message: "SQLite cannot add a NOT NULL column without a non-NULL DEFAULT, even to an empty table: add a DEFAULT",
+					})
+				default:
+					plans = append(plans, ddlCheckPlan{
+						check:   table.raw + " has no rows that would need a value for NOT NULL column " + column.raw,
+						count:   "SELECT COUNT(*) FROM " + table.raw,
+						message: "the table has %d rows, which would have no value for the new column: add a DEFAULT, or add the column as nullable, fill it and then set NOT NULL",
+					})
+				}
+			}
+
+		case a.accept("ALTER"):
+			// PostgreSQL: ALTER [COLUMN] c SET NOT NULL; SQL Server: ALTER COLUMN c type NOT NULL
+			a.accept("COLUMN")
+			column, ok := a.name()
+			if !ok {
+				return nil, nil, errUnrecognizedDDL
+			}
+			if a.has("SET", "NOT", "NULL") || dialect == "sqlserver" && a.has("NOT", "NULL") {
+				plans = append(plans, nullsPlan(table, []string{column.raw}))
+			}
+
+		case a.accept("MODIFY"):
+			// MySQL: MODIFY [COLUMN] c definition
+			a.accept("COLUMN")
+			column, ok := a.name()
+			if !ok {
+				return nil, nil, errUnrecognizedDDL
+			}
+			if a.has("NOT", "NULL") {
+				plans = append(plans, nullsPlan(table, []string{column.raw}))
+			}
+
+		case a.accept("CHANGE"):
+			// MySQL: CHANGE [COLUMN] old new definition
+			a.accept("COLUMN")
+			column, ok := a.name()
+			if !ok {
+				return nil, nil, errUnrecognizedDDL
+			}
+			if a.has("NOT", "NULL") {
+				plans = append(plans, nullsPlan(table, []string{column.raw}))
+			}
+		}
+	}
+	return plans, notes, nil
+}
+
+func nullsPlan(table ddlName, columns []string) ddlCheckPlan {
+	conds := make([]string, len(columns))
+	for i, col := range columns {
+		conds[i] = col + " IS NULL"
+	}
+	return ddlCheckPlan{
+		check:   fmt.Sprintf("no NULLs in %s of %s", strings.Join(columns, ", "), table.raw),
+		count:   fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", table.raw, strings.Join(conds, " OR ")),
+		message: "%d rows have NULL in the column: update or delete them first",
+	}
+}
+
+// duplicatesPlan checks that columns are unique in table, among the rows matching
+// predicate if it is not empty. Unless nullsEqual, rows with a NULL in any of the
+// columns are exempt, as they are from unique indexes in most databases.
+func duplicatesPlan(table ddlName, columns []string, predicate string, nullsEqual bool) ddlCheckPlan {
+	list := strings.Join(columns, ", ")
+	var conds []string
+	if !nullsEqual {
+		for _, col := range columns {
+			conds = append(conds, col+" IS NOT NULL")
+		}
+	}
+	if predicate != "" {
+		conds = append(conds, "("+predicate+")")
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+	groups := fmt.Sprintf("SELECT %s, COUNT(*) AS occurrences FROM %s%s GROUP BY %s HAVING COUNT(*) > 1", list, table.raw, where, list)
+	return ddlCheckPlan{
+		check:   fmt.Sprintf("no duplicate values of (%s) in %s", list, table.raw),
+		count:   "SELECT COUNT(*) FROM (" + groups + ") d",
+		samples: groups + " ORDER BY COUNT(*) DESC",
+		message: "%d values occur more than once (samples shows the most repeated): remove or merge the duplicates first",
+	}
+}
+
+// orphansPlan checks that every row of table whose columns are all non-NULL has a
+// matching row in parent.
+func orphansPlan(table ddlName, columns []string, parent ddlName, parentColumns []string) ddlCheckPlan {
+	var notNull, match, selected []string
+	for i, col := range columns {
+		notNull = append(notNull, "c."+col+" IS NOT NULL")
+		match = append(match, "p."+parentColumns[i]+" = c."+col)
+		selected = append(selected, "c."+col)
+	}
+	from := fmt.Sprintf("FROM %s c WHERE %s AND NOT EXISTS (SELECT 1 FROM %s p WHERE %s)",
+		table.raw, strings.Join(notNull, " AND "), parent.raw, strings.Join(match, " AND "))
+	return ddlCheckPlan{
+		check:   fmt.Sprintf("every (%s) in %s exists in (%s) of %s", strings.Join(columns, ", "), table.raw, strings.Join(parentColumns, ", "), parent.raw),
+		count:   "SELECT COUNT(*) " + from,
+		samples: "SELECT DISTINCT " + strings.Join(selected, ", ") + " " + from,
+		message: "%d rows reference a missing parent row (samples shows some of the missing keys): insert the parents or fix the rows first",
+	}
+}
+
+// checkConstraintPlan checks that no row makes expr false. Rows where it is NULL
+// satisfy a CHECK constraint.
+func checkConstraintPlan(table ddlName, expr string) ddlCheckPlan {
+	from := fmt.Sprintf("FROM %s WHERE NOT (%s)", table.raw, expr)
+	return ddlCheckPlan{
+		check:   fmt.Sprintf("every row of %s satisfies CHECK (%s)", table.raw, expr),
+		count:   "SELECT COUNT(*) " + from,
+		samples: "SELECT * " + from,
+		message: "%d rows violate the check (samples shows some of them): fix or delete them first",
+	}
+}
+
+var errUnrecognizedDDL = errors.New("the statement could not be parsed for checking: check its syntax, and pass one statement at a time")
+
+// ddlToken is a token of a DDL statement.
+type ddlToken struct {
+	// text is the token as written; upper is it upper-cased for unquoted words,
+	// and empty for everything else.
+	text, upper string
+	// quoted is set for quoted identifiers, punct for single punctuation characters.
+	quoted, punct bool
+	start, end    int
+	// depth is the number of parentheses the token is nested in.
+	depth int
+}
+
+// tokenizeDDL splits a single statement into tokens, skipping whitespace and
+// comments. String literals become tokens that are neither words nor identifiers.
+func tokenizeDDL(ddl string) ([]ddlToken, error) {
+	var tokens []ddlToken
+	depth := 0
+	ended := false
+	for i := 0; i < len(ddl); {
+		c := ddl[i]
+		start := i
+		if ended && !unicode.IsSpace(rune(c)) && c != ';' && !strings.HasPrefix(ddl[i:], "--") && !strings.HasPrefix(ddl[i:], "/*") {
+			return nil, errUnrecognizedDDL
+		}
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+			continue
+		case strings.HasPrefix(ddl[i:], "--"):
+			if j := strings.IndexByte(ddl[i:], '\n'); j >= 0 {
+				i += j + 1
+			} else {
+				i = len(ddl)
+			}
+			continue
+		case strings.HasPrefix(ddl[i:], "/*"):
+			j := strings.Index(ddl[i+2:], "*/")
+			if j < 0 {
+				return nil, errUnrecognizedDDL
+			}
+			i += j + 4
+			continue
+		case c == ';':
+			if depth != 0 {
+				return nil, errUnrecognizedDDL
+			}
+			ended = true
+			i++
+			continue
+		case c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			j := strings.IndexByte(ddl[i+1:], closing)
+			if j < 0 {
+				return nil, errUnrecognizedDDL
+			}
+			i += j + 2
+			tokens = append(tokens, ddlToken{text: ddl[start:i], quoted: true, start: start, end: i, depth: depth})
+			continue
+		case c == '\'':
+			// A doubled quote inside the literal reads as two adjacent literals, which is harmless here.
+			j := strings.IndexByte(ddl[i+1:], '\'')
+			if j < 0 {
+				return nil, errUnrecognizedDDL
+			}
+			i += j + 2
+		case c == '$' && dollarTag(ddl[i:]) != "":
+			tag := dollarTag(ddl[i:])
+			j := strings.Index(ddl[i+len(tag):], tag)
+			if j < 0 {
+				return nil, errUnrecognizedDDL
+			}
+			i += len(tag) + j + len(tag)
+		default:
+			r, size := utf8.DecodeRuneInString(ddl[i:])
+			if !isIdentRune(r) {
+				i += size
+				tok := ddlToken{text: ddl[start:i], punct: true, start: start, end: i, depth: depth}
+				switch c {
+				case '(':
+					depth++
+				case ')':
+					if depth--; depth < 0 {
+						return nil, errUnrecognizedDDL
+					}
+					tok.depth = depth
+				}
+				tokens = append(tokens, tok)
+				continue
+			}
+			for i < len(ddl) {
+				r, size := utf8.DecodeRuneInString(ddl[i:])
+				if !isIdentRune(r) {
+					break
+				}
+				i += size
+			}
+			word := ddl[start:i]
+			tokens = append(tokens, ddlToken{text: word, upper: strings.ToUpper(word), start: start, end: i, depth: depth})
+			continue
+		}
+		tokens = append(tokens, ddlToken{text: ddl[start:i], start: start, end: i, depth: depth})
+	}
+	if depth != 0 {
+		return nil, errUnrecognizedDDL
+	}
+	return tokens, nil
+}
+
+// ddlName is a possibly qualified name, as written and split into unquoted parts.
+type ddlName struct {
+	raw   string
+	parts []string
+}
+
+func (n ddlName) schema() string {
+	if len(n.parts) < 2 {
+		return ""
+	}
+	return n.parts[len(n.parts)-2]
+}
+
+func (n ddlName) table() string {
+	return n.parts[len(n.parts)-1]
+}
+
+// ddlParser reads a statement's tokens from left to right.
+type ddlParser struct {
+	src    string
+	tokens []ddlToken
+	pos    int
+	// foldCase lower-cases unquoted names, as PostgreSQL does.
+	foldCase bool
+}
+
+// peek reports whether the next tokens are the given upper-case keywords.
+func (p *ddlParser) peek(words ...string) bool {
+	if p.pos+len(words) > len(p.tokens) {
+		return false
+	}
+	for i, w := range words {
+		if p.tokens[p.pos+i].upper != w {
+			return false
+		}
+	}
+	return true
+}
+
+// accept consumes the given keywords if they come next.
+func (p *ddlParser) accept(words ...string) bool {
+	if !p.peek(words...) {
+		return false
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *ddlParser) peekPunct(s string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].punct && p.tokens[p.pos].text == s
+}
+
+// has reports whether the keywords occur in sequence anywhere in the rest of the
+// tokens at the current nesting depth.
+func (p *ddlParser) has(words ...string) bool {
+	if p.pos >= len(p.tokens) {
+		return false
+	}
+	depth := p.tokens[p.pos].depth
+	for i := p.pos; i+len(words) <= len(p.tokens); i++ {
+		match := true
+		for j, w := range words {
+			if t := p.tokens[i+j]; t.depth != depth || t.upper != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAny reports whether any of the keywords occurs in the rest of the tokens at
+// the current nesting depth.
+func (p *ddlParser) hasAny(words ...string) bool {
+	for _, w := range words {
+		if p.has(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// name consumes a possibly qualified identifier.
+func (p *ddlParser) name() (ddlName, bool) {
+	var n ddlName
+	start := p.pos
+	for {
+		if p.pos >= len(p.tokens) {
+			return ddlName{}, false
+		}
+		t := p.tokens[p.pos]
+		switch {
+		case t.quoted:
+			n.parts = append(n.parts, t.text[1:len(t.text)-1])
+		case t.upper != "" && p.foldCase:
+			n.parts = append(n.parts, strings.ToLower(t.text))
+		case t.upper != "":
+			n.parts = append(n.parts, t.text)
+		default:
+			return ddlName{}, false
+		}
+		p.pos++
+		if !p.peekPunct(".") {
+			break
+		}
+		p.pos++
+	}
+	n.raw = p.src[p.tokens[start].start:p.tokens[p.pos-1].end]
+	return n, true
+}
+
+// group consumes a parenthesized group and returns the text inside it.
+func (p *ddlParser) group() (string, bool) {
+	if !p.peekPunct("(") {
+		return "", false
+	}
+	open := p.tokens[p.pos]
+	for i := p.pos + 1; i < len(p.tokens); i++ {
+		if t := p.tokens[i]; t.punct && t.text == ")" && t.depth == open.depth {
+			p.pos = i + 1
+			return strings.TrimSpace(p.src[open.end:t.start]), true
+		}
+	}
+	return "", false
+}
+
+// columnList consumes a parenthesized list of columns, each optionally followed by
+// keywords such as ASC or DESC, and returns the columns as written. It returns false
+// if an entry is an expression or a column prefix rather than a plain column.
+func (p *ddlParser) columnList() ([]string, bool) {
+	if !p.peekPunct("(") {
+		return nil, false
+	}
+	open := p.tokens[p.pos]
+	var columns []string
+	entryStart := true
+	for i := p.pos + 1; i < len(p.tokens); i++ {
+		t := p.tokens[i]
+		switch {
+		case t.punct && t.text == ")" && t.depth == open.depth:
+			p.pos = i + 1
+			return columns, len(columns) > 0
+		case t.punct && t.text == "," && t.depth == open.depth+1:
+			entryStart = true
+		case t.depth != open.depth+1 || t.punct:
+			return nil, false
+		case entryStart:
+			if !t.quoted && t.upper == "" {
+				return nil, false
+			}
+			columns = append(columns, t.text)
+			entryStart = false
+		}
+	}
+	return nil, false
+}
+
+// where returns the text of a top-level WHERE clause in the rest of the tokens,
+// up to a following WITH, TABLESPACE or ON clause.
+func (p *ddlParser) where() string {
+	start := -1
+	for i := p.pos; i < len(p.tokens); i++ {
+		t := p.tokens[i]
+		if t.depth != 0 {
+			continue
+		}
+		if start < 0 {
+			if t.upper == "WHERE" && i+1 < len(p.tokens) {
+				start = p.tokens[i+1].start
+			}
+			continue
+		}
+		if t.upper == "WITH" || t.upper == "TABLESPACE" || t.upper == "ON" {
+			return strings.TrimSpace(p.src[start:t.start])
+		}
+	}
+	if start < 0 {
+		return ""
+	}
+	return strings.TrimSpace(p.src[start:p.tokens[len(p.tokens)-1].end])
+}
+
+// actions splits the rest of an ALTER TABLE statement at its top-level commas.
+func (p *ddlParser) actions() []*ddlParser {
+	var actions []*ddlParser
+	start := p.pos
+	for i := p.pos; i <= len(p.tokens); i++ {
+		if i == len(p.tokens) || p.tokens[i].punct && p.tokens[i].text == "," && p.tokens[i].depth == 0 {
+			if i > start {
+				actions = append(actions, &ddlParser{src: p.src, tokens: p.tokens[start:i], foldCase: p.foldCase})
+			}
+			start = i + 1
+		}
+	}
+	return actions
+}
@@ -0,0 +1,195 @@
+package sqlcommon
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCheckDDL(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`
+		CREATE TABLE accounts (id INTEGER PRIMARY KEY, email TEXT, active INTEGER);
+		INSERT INTO accounts (email, active) VALUES ('a@example.com', 1), ('b@example.com', 0), ('b@example.com', 0), (NULL, 1), (NULL, 1);
+	`).Error)
+
+	t.Run("UniqueIndex", func(t *testing.T) {
+		report, err := CheckDDL(t.Context(), db, "CREATE UNIQUE INDEX idx_email ON accounts (email)")
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+		require.Len(t, report.Checks, 1)
+		check := report.Checks[0]
+		require.False(t, check.Passed)
+		// NULLs do not collide in a unique index.
+		require.EqualValues(t, 1, check.Violations)
+		encoded, err := json.Marshal(check.Samples)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"email": "b@example.com", "occurrences": 2}]`, string(encoded))
+	})
+
+	t.Run("PartialUniqueIndex", func(t *testing.T) {
+		report, err := CheckDDL(t.Context(), db, "CREATE UNIQUE INDEX idx_email ON accounts (email) WHERE active = 1;")
+		require.NoError(t, err)
+		require.True(t, report.WouldSucceed)
+		require.True(t, report.Checks[0].Passed)
+	})
+
+	t.Run("NonUniqueIndex", func(t *testing.T) {
+		report, err := CheckDDL(t.Context(), db, "CREATE INDEX idx_email ON accounts (email)")
+		require.NoError(t, err)
+		require.True(t, report.WouldSucceed)
+		require.Empty(t, report.Checks)
+		require.NotEmpty(t, report.Note)
+	})
+
+	t.Run("AddNotNullColumn", func(t *testing.T) {
+		report, err := CheckDDL(t.Context(), db, "ALTER TABLE accounts ADD COLUMN plan TEXT NOT NULL")
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+		require.Contains(t, report.Checks[0].Message, "DEFAULT")
+
+		report, err = CheckDDL(t.Context(), db, "ALTER TABLE accounts ADD COLUMN plan TEXT NOT NULL DEFAULT 'free'")
+		require.NoError(t, err)
+		require.True(t, report.WouldSucceed)
+	})
+
+	t.Run("AddConstraint", func(t *testing.T) {
+		report, err := CheckDDL(t.Context(), db, "ALTER TABLE accounts ADD CONSTRAINT uq_email UNIQUE (email)")
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+		require.Contains(t, report.Checks[0].Message, "cannot add constraints")
+	})
+
+	t.Run("Unrecognized", func(t *testing.T) {
+		for _, ddl := range []string{
+			"CREATE UNIQUE INDEX idx ON",
+			"CREATE UNIQUE INDEX idx ON accounts (email); DROP TABLE accounts",
+			"ALTER TABLE accounts ADD CONSTRAINT c CHECK (active IN (0, 1)",
+		} {
+			_, err := CheckDDL(t.Context(), db, ddl)
+			require.ErrorIs(t, err, errUnrecognizedDDL, ddl)
+		}
+	})
+
+	var count int64
+	require.NoError(t, db.Raw("SELECT COUNT(*) FROM accounts").Scan(&count).Error)
+	require.EqualValues(t, 5, count)
+}
+
+func TestPlanDDLChecks(t *testing.T) {
+	tests := []struct {
+		name, dialect, ddl string
+		counts             []string
+		note               bool
+	}{
+		{
+			name:    "SetNotNull",
+			dialect: "postgres",
+			ddl:     "ALTER TABLE public.orders ALTER COLUMN customer_id SET NOT NULL",
+			counts:  []string{"SELECT COUNT(*) FROM public.orders WHERE customer_id IS NULL"},
+		},
+		{
+			name:    "ForeignKey",
+			dialect: "postgres",
+			ddl:     `ALTER TABLE orders ADD CONSTRAINT fk_customer FOREIGN KEY (customer_id) REFERENCES "Customers" (id) ON DELETE CASCADE`,
+			counts:  []string{`SELECT COUNT(*) FROM orders c WHERE c.customer_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM "Customers" p WHERE p.id = c.customer_id)`},
+		},
+		{
+			name:    "ForeignKeyNotValid",
+			dialect: "postgres",
+			ddl:     "ALTER TABLE orders ADD FOREIGN KEY (customer_id) REFERENCES customers (id) NOT VALID",
+			note:    true,
+		},
+		{
+			name:    "Check",
+			dialect: "postgres",
+			ddl:     "ALTER TABLE orders ADD CONSTRAINT positive CHECK (amount > 0 AND status IN ('new', 'paid'))",
+			counts:  []string{"SELECT COUNT(*) FROM orders WHERE NOT (amount > 0 AND status IN ('new', 'paid'))"},
+		},
+		{
+			name:    "NullsNotDistinct",
+			dialect: "postgres",
+			ddl:     "CREATE UNIQUE INDEX ON orders USING btree (code DESC) NULLS NOT DISTINCT",
+			counts:  []string{"SELECT COUNT(*) FROM (SELECT code, COUNT(*) AS occurrences FROM orders GROUP BY code HAVING COUNT(*) > 1) d"},
+		},
+		{
+			name:    "ExpressionIndex",
+			dialect: "postgres",
+			ddl:     "CREATE UNIQUE INDEX idx ON users (lower(email))",
+			note:    true,
+		},
+		{
+			name:    "PrimaryKey",
+			dialect: "postgres",
+			ddl:     "ALTER TABLE line_items ADD PRIMARY KEY (order_id, line)",
+			counts: []string{
+				"SELECT COUNT(*) FROM line_items WHERE order_id IS NULL OR line IS NULL",
+				"SELECT COUNT(*) FROM (SELECT order_id, line, COUNT(*) AS occurrences FROM line_items GROUP BY order_id, line HAVING COUNT(*) > 1) d",
+			},
+		},
+		{
+			name:    "SeveralActions",
+			dialect: "mysql",
+			ddl:     "ALTER TABLE `orders` MODIFY COLUMN note varchar(10) NOT NULL, ADD UNIQUE KEY uk_code (code), ADD COLUMN total int NOT NULL",
+			counts: []string{
+				"SELECT COUNT(*) FROM `orders` WHERE note IS NULL",
+				"SELECT COUNT(*) FROM (SELECT code, COUNT(*) AS occurrences FROM `orders` WHERE code IS NOT NULL GROUP BY code HAVING COUNT(*) > 1) d",
+			},
+		},
+		{
+			name:    "PrefixIndex",
+			dialect: "mysql",
+			ddl:     "ALTER TABLE orders ADD UNIQUE INDEX uk_note (note(20))",
+			note:    true,
+		},
+		{
+			name:    "FilteredIndex",
+			dialect: "sqlserver",
+			ddl:     "CREATE UNIQUE NONCLUSTERED INDEX ix_code ON [dbo].[orders] ([code]) INCLUDE (amount) WHERE status = 'open' WITH (ONLINE = ON)",
+			counts:  []string{"SELECT COUNT(*) FROM (SELECT [code], COUNT(*) AS occurrences FROM [dbo].[orders] WHERE (status = 'open') GROUP BY [code] HAVING COUNT(*) > 1) d"},
+		},
+		{
+			name:    "AlterColumnNotNull",
+			dialect: "sqlserver",
+			ddl:     "ALTER TABLE dbo.orders ALTER COLUMN note nvarchar(100) NOT NULL",
+			counts:  []string{"SELECT COUNT(*) FROM dbo.orders WHERE note IS NULL"},
+		},
+		{
+			name:    "AddNotNullColumn",
+			dialect: "sqlserver",
+			ddl:     "ALTER TABLE dbo.orders ADD total int NOT NULL",
+			counts:  []string{"SELECT COUNT(*) FROM dbo.orders"},
+		},
+		{
+			name:    "NoCheck",
+			dialect: "sqlserver",
+			ddl:     "ALTER TABLE dbo.orders WITH NOCHECK ADD CONSTRAINT ck_amount CHECK (amount > 0)",
+			note:    true,
+		},
+		{
+			name:    "OtherStatement",
+			dialect: "postgres",
+			ddl:     "DROP INDEX idx_email",
+			note:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := tokenizeDDL(tt.ddl)
+			require.NoError(t, err)
+			plans, notes, err := planDDLChecks(t.Context(), nil, tt.dialect, &ddlParser{src: tt.ddl, tokens: tokens, foldCase: tt.dialect == "postgres"})
+			require.NoError(t, err)
+
+			var counts []string
+			for _, p := range plans {
+				counts = append(counts, p.count)
+			}
+			require.Equal(t, tt.counts, counts)
+			require.Equal(t, tt.note, len(notes) > 0)
+		})
+	}
+}
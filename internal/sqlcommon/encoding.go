@@ -0,0 +1,64 @@
+package sqlcommon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+type resultEncodingKey struct{}
+
+// LookupCharset returns the encoding for a charset name such as "latin1",
+// "windows-1252" or "shift_jis".
+func LookupCharset(name string) (encoding.Encoding, error) {
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown charset %q", name)
+	}
+	return enc, nil
+}
+
+// WithResultEncoding returns a context that makes QueryRows decode string values
+// that are not valid UTF-8 from enc. A nil enc leaves the context unchanged.
+func WithResultEncoding(ctx context.Context, enc encoding.Encoding) context.Context {
+	if enc == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, resultEncodingKey{}, enc)
+}
+
+// utf8Normalizer makes string values valid UTF-8, so they can be sent in a JSON response.
+type utf8Normalizer struct {
+	dec *encoding.Decoder
+}
+
+func newUTF8Normalizer(ctx context.Context) utf8Normalizer {
+	if enc, ok := ctx.Value(resultEncodingKey{}).(encoding.Encoding); ok {
+		return utf8Normalizer{dec: enc.NewDecoder()}
+	}
+	return utf8Normalizer{}
+}
+
+// row normalizes every string value of row in place.
+func (n utf8Normalizer) row(row map[string]any) {
+	for k, v := range row {
+		if s, ok := v.(string); ok && !utf8.ValidString(s) {
+			row[k] = n.string(s)
+		}
+	}
+}
+
+// string transcodes s from the configured charset if there is one, and replaces
+// any sequences that are still invalid with U+FFFD.
+func (n utf8Normalizer) string(s string) string {
+	if n.dec != nil {
+		if decoded, err := n.dec.String(s); err == nil {
+			s = decoded
+		}
+	}
+	return strings.ToValidUTF8(s, "\uFFFD")
+}
@@ -0,0 +1,278 @@
+package sqlcommon
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+// TranslatedError is a driver error rewritten into an actionable message.
+// The original driver error stays available through errors.Unwrap, and errors.Is
+// matches Kind when the error falls into one of the sentinel categories
+// (ErrPermissionDenied, ErrTableNotFound, ErrColumnNotFound, ErrReadonlyViolation).
+type TranslatedError struct {
+	Message string
+	Kind    error
+	Err     error
+}
+
+func (e *TranslatedError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Message, e.Err)
+}
+
+func (e *TranslatedError) Unwrap() error {
+	return e.Err
+}
+
+func (e *TranslatedError) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+const (
+	msgPermissionDenied = "permission denied: the connected user lacks privileges for this object or operation. Query a different table, or ask the operator to grant access"
+	msgTableNotFound    = "table not found: check the name and schema with list_tables"
+	msgColumnNotFound   = "column not found: check the column names with describe_table"
+	msgSyntaxError      = "syntax error: check the query against the database's SQL dialect"
+	msgDeadlock         = "the statement was chosen as a deadlock victim: retry it, it usually succeeds on the next attempt"
+	msgLockTimeout      = "timed out waiting for a lock held by another session: retry later"
+	msgQueryCanceled    = "the query was canceled, usually by a statement timeout: add a selective WHERE clause or a LIMIT"
+	msgReadOnly         = "the connection is read-only: write statements are not allowed here"
+)
+
+// TranslateError maps common driver errors (PostgreSQL, MySQL, SQL Server and SQLite)
+// to messages that tell the caller what went wrong and how to fix it.
+// Errors it does not recognize are returned unchanged.
+func TranslateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var msg string
+	var (
+		pgErr    *pgconn.PgError
+		mysqlErr *mysql.MySQLError
+		mssqlErr mssql.Error
+	)
+	switch {
+	case errors.As(err, &pgErr):
+		msg = postgresMessage(pgErr)
+	case errors.As(err, &mysqlErr):
+		msg = mysqlMessage(mysqlErr)
+	case errors.As(err, &mssqlErr):
+		msg = sqlserverMessage(mssqlErr)
+	default:
+		msg = sqliteMessage(err)
+	}
+
+	if msg == "" {
+		return err
+	}
+	return &TranslatedError{Message: msg, Kind: kinds[msg], Err: err}
+}
+
+// kinds maps translated messages to the sentinel error they are reported as.
+var kinds = map[string]error{
+	msgPermissionDenied: ErrPermissionDenied,
+	msgTableNotFound:    ErrTableNotFound,
+	msgColumnNotFound:   ErrColumnNotFound,
+	msgReadOnly:         ErrReadonlyViolation,
+}
+
+func postgresMessage(err *pgconn.PgError) string {
+	switch err.Code {
+	case "42501":
+		return msgPermissionDenied
+	case "42P01":
+		return msgTableNotFound
+	case "42703":
+		return msgColumnNotFound
+	case "42601":
+		if err.Position > 0 {
+			return fmt.Sprintf("%s (at character %d)", msgSyntaxError, err.Position)
+		}
+		return msgSyntaxError
+	case "40P01":
+		return msgDeadlock
+	case "55P03":
+		return msgLockTimeout
+	case "57014":
+		return msgQueryCanceled
+	case "25006":
+		return msgReadOnly
+	}
+	return ""
+}
+
+func mysqlMessage(err *mysql.MySQLError) string {
+	switch err.Number {
+	case 1044, 1142, 1143, 1227, 1370:
+		return msgPermissionDenied
+	case 1146:
+		return msgTableNotFound
+	case 1054:
+		return msgColumnNotFound
+	case 1064:
+		return msgSyntaxError
+	case 1213:
+		return msgDeadlock
+	case 1205:
+		return msgLockTimeout
+	case 3024:
+		return msgQueryCanceled
+	case 1290, 1792:
+		return msgReadOnly
+	}
+	return ""
+}
+
+func sqlserverMessage(err mssql.Error) string {
+	switch err.Number {
+	case 229, 230, 262, 297, 300:
+		return msgPermissionDenied
+	case 208:
+		return msgTableNotFound
+	case 207:
+		return msgColumnNotFound
+	case 102, 156, 170:
+		return msgSyntaxError
+	case 1205:
+		return msgDeadlock
+	case 1222:
+		return msgLockTimeout
+	case 3906:
+		return msgReadOnly
+	}
+	return ""
+}
+
+// SyntaxError is a driver syntax error annotated with the offending line of the
+// query and a caret under the position the database reported.
+type SyntaxError struct {
+	Snippet string
+	Err     error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%v\n\n%s", e.Err, e.Snippet)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// MySQL: "... near 'FROM users' at line 2"
+	mysqlNearRe = regexp.MustCompile(`near '((?s).*)' at line (\d+)`)
+	// SQL Server: "Incorrect syntax near 'FROM'." or "... near the keyword 'FROM'."
+	mssqlNearRe = regexp.MustCompile(`near (?:the keyword )?'([^']*)'`)
+)
+
+// HighlightSyntaxError annotates a syntax error with a caret-marked snippet of query.
+// prefixLen is the length of any text the backend prepended to the query before
+// sending it (e.g. "EXPLAIN (FORMAT JSON) "), so reported offsets can be mapped back.
+// Errors without position info are returned unchanged.
+func HighlightSyntaxError(err error, query string, prefixLen int) error {
+	if err == nil {
+		return nil
+	}
+
+	line, col := 0, 0
+	var (
+		pgErr    *pgconn.PgError
+		mysqlErr *mysql.MySQLError
+		mssqlErr mssql.Error
+	)
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == "42601" && pgErr.Position > 0:
+		// Position is a 1-based character offset into the statement.
+		line, col = lineCol(query, int(pgErr.Position)-prefixLen)
+	case errors.As(err, &mysqlErr) && mysqlErr.Number == 1064:
+		// MySQL reports the line and the remainder of the statement from the bad token.
+		if m := mysqlNearRe.FindStringSubmatch(mysqlErr.Message); m != nil {
+			fmt.Sscan(m[2], &line)
+			col = column(query, line, m[1])
+		}
+	case errors.As(err, &mssqlErr) && (mssqlErr.Number == 102 || mssqlErr.Number == 156) && mssqlErr.LineNo > 0:
+		// SQL Server reports only the line; the token it names gives the column.
+		line = int(mssqlErr.LineNo)
+		if m := mssqlNearRe.FindStringSubmatch(mssqlErr.Message); m != nil {
+			col = column(query, line, m[1])
+		}
+	}
+
+	snippet := caretSnippet(query, line, col)
+	if snippet == "" {
+		return err
+	}
+	return &SyntaxError{Snippet: snippet, Err: err}
+}
+
+// lineCol converts a 1-based character offset into a 1-based line and column.
+func lineCol(query string, offset int) (line, col int) {
+	if offset < 1 {
+		return 0, 0
+	}
+	line, col = 1, 0
+	for i, r := range []rune(query) {
+		if i == offset-1 {
+			return line, col + 1
+		}
+		if r == '\n' {
+			line, col = line+1, 0
+		} else {
+			col++
+		}
+	}
+	return 0, 0
+}
+
+// column returns the 1-based column where token starts on the given line, or 0 if not found.
+func column(query string, line int, token string) int {
+	lines := strings.Split(query, "\n")
+	if line < 1 || line > len(lines) || token == "" {
+		return 0
+	}
+	token, _, _ = strings.Cut(token, "\n")
+	if i := strings.Index(lines[line-1], token); i >= 0 {
+		return len([]rune(lines[line-1][:i])) + 1
+	}
+	return 0
+}
+
+// caretSnippet renders a line of the query with a ^ under col, in the style of psql:
+//
+//	LINE 2: WHERE id = = 1
+//	                   ^
+//
+// A col of 0 renders the line without a caret.
+func caretSnippet(query string, line, col int) string {
+	lines := strings.Split(query, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	prefix := fmt.Sprintf("LINE %d: ", line)
+	text := strings.TrimRight(lines[line-1], "\r")
+	if col < 1 {
+		return prefix + text
+	}
+
+	// Keep tabs so the caret lines up with the query text in a terminal.
+	var pad strings.Builder
+	pad.WriteString(strings.Repeat(" ", len(prefix)))
+	for i, r := range []rune(text) {
+		if i >= col-1 {
+			break
+		}
+		if r == '\t' {
+			pad.WriteRune('\t')
+		} else {
+			pad.WriteByte(' ')
+		}
+	}
+	return prefix + text + "\n" + pad.String() + "^"
+}
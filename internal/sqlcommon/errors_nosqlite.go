@@ -0,0 +1,9 @@
+//go:build !cgo
+
+package sqlcommon
+
+// sqliteMessage returns "": without cgo the SQLite driver is a stub that only
+// reports that it needs cgo, and never returns a sqlite3.Error.
+func sqliteMessage(err error) string {
+	return ""
+}
@@ -0,0 +1,40 @@
+//go:build cgo
+
+package sqlcommon
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteMessage returns the message for a SQLite error, or "" if err is not one.
+func sqliteMessage(err error) string {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return ""
+	}
+	switch sqliteErr.Code {
+	case sqlite3.ErrPerm, sqlite3.ErrAuth:
+		return msgPermissionDenied
+	case sqlite3.ErrBusy, sqlite3.ErrLocked:
+		return msgLockTimeout
+	case sqlite3.ErrReadonly:
+		return msgReadOnly
+	case sqlite3.ErrInterrupt:
+		return msgQueryCanceled
+	case sqlite3.ErrError:
+		// SQLite reports most statement errors as SQLITE_ERROR and only the text tells them apart.
+		text := sqliteErr.Error()
+		switch {
+		case strings.Contains(text, "no such table"):
+			return msgTableNotFound
+		case strings.Contains(text, "no such column"):
+			return msgColumnNotFound
+		case strings.Contains(text, "syntax error"):
+			return msgSyntaxError
+		}
+	}
+	return ""
+}
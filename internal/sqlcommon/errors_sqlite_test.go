@@ -0,0 +1,31 @@
+//go:build cgo
+
+package sqlcommon
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateSQLiteError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"Busy", sqlite3.Error{Code: sqlite3.ErrBusy}, msgLockTimeout},
+		{"Readonly", sqlite3.Error{Code: sqlite3.ErrReadonly}, msgReadOnly},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := TranslateError(tt.err)
+			var translated *TranslatedError
+			require.ErrorAs(t, err, &translated)
+			require.Equal(t, tt.want, translated.Message)
+			require.Equal(t, tt.err, errors.Unwrap(err))
+		})
+	}
+}
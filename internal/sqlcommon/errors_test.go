@@ -0,0 +1,76 @@
+package sqlcommon
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"PostgresPermissionDenied", &pgconn.PgError{Code: "42501", Message: "permission denied for table x"}, msgPermissionDenied},
+		{"PostgresSyntaxPosition", &pgconn.PgError{Code: "42601", Position: 8}, msgSyntaxError + " (at character 8)"},
+		{"MySQLTableNotFound", &mysql.MySQLError{Number: 1146}, msgTableNotFound},
+		{"SQLServerDeadlock", mssql.Error{Number: 1205}, msgDeadlock},
+		{"Wrapped", fmt.Errorf("query failed: %w", &pgconn.PgError{Code: "42P01"}), msgTableNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := TranslateError(tt.err)
+			var translated *TranslatedError
+			require.ErrorAs(t, err, &translated)
+			require.Equal(t, tt.want, translated.Message)
+			require.Equal(t, tt.err, errors.Unwrap(err))
+		})
+	}
+
+	t.Run("Kinds", func(t *testing.T) {
+		require.ErrorIs(t, TranslateError(&pgconn.PgError{Code: "42501"}), ErrPermissionDenied)
+		require.ErrorIs(t, TranslateError(&pgconn.PgError{Code: "25006"}), ErrReadonlyViolation)
+		require.ErrorIs(t, TranslateError(mssql.Error{Number: 208}), ErrTableNotFound)
+		require.NotErrorIs(t, TranslateError(&mysql.MySQLError{Number: 1213}), ErrPermissionDenied)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		err := errors.New("boom")
+		require.Same(t, err, TranslateError(err))
+		require.NoError(t, TranslateError(nil))
+	})
+}
+
+func TestHighlightSyntaxError(t *testing.T) {
+	query := "SELECT id\nFROM users\nWHERE id = = 1"
+	tests := []struct {
+		name      string
+		err       error
+		prefixLen int
+		want      string
+	}{
+		{"Postgres", &pgconn.PgError{Code: "42601", Position: 33}, 0, "LINE 3: WHERE id = = 1\n                   ^"},
+		{"PostgresExplainPrefix", &pgconn.PgError{Code: "42601", Position: 55}, 22, "LINE 3: WHERE id = = 1\n                   ^"},
+		{"MySQL", &mysql.MySQLError{Number: 1064, Message: "You have an error in your SQL syntax; check the manual for the right syntax to use near '= 1' at line 3"}, 0, "LINE 3: WHERE id = = 1\n                   ^"},
+		{"SQLServer", mssql.Error{Number: 102, LineNo: 2, Message: "Incorrect syntax near 'users'."}, 0, "LINE 2: FROM users\n             ^"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := HighlightSyntaxError(tt.err, query, tt.prefixLen)
+			var syntaxErr *SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			require.Equal(t, tt.want, syntaxErr.Snippet)
+		})
+	}
+
+	t.Run("NoPosition", func(t *testing.T) {
+		err := &pgconn.PgError{Code: "42P01"}
+		require.Equal(t, error(err), HighlightSyntaxError(err, query, 0))
+	})
+}
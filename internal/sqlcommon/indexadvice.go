@@ -0,0 +1,891 @@
+package sqlcommon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// IndexRecommendation is an index proposed for one table of a query.
+type IndexRecommendation struct {
+	Table           string   `json:"table" jsonschema:"The table to index, as named in the query"`
+	Columns         []string `json:"columns" jsonschema:"The key columns in index order: equality filters and join keys first, then sort columns, then one range filter"`
+	Include         []string `json:"include,omitempty" jsonschema:"Columns stored in the index only, so the query can be answered without reading the table"`
+	CreateStatement string   `json:"create_statement" jsonschema:"The CREATE INDEX statement, to review and run with execute_ddl"`
+	Reasoning       []string `json:"reasoning" jsonschema:"Why each column is in the index and in that position"`
+	CostBefore      *float64 `json:"cost_before,omitempty" jsonschema:"The planner's estimated cost of the query as it is (only where hypothetical indexes are supported)"`
+	CostAfter       *float64 `json:"cost_after,omitempty" jsonschema:"The planner's estimated cost of the query with this index added as a hypothetical index"`
+}
+
+// IndexAdvice is the result of RecommendIndexes.
+type IndexAdvice struct {
+	Recommendations []IndexRecommendation `json:"recommendations" jsonschema:"One proposed index per table the query filters, joins or sorts on"`
+	Notes           []string              `json:"notes,omitempty" jsonschema:"Parts of the query that no index can serve, and indexes that already exist"`
+}
+
+// maxIncludeColumns is the most columns added to an index only to cover a query.
+const maxIncludeColumns = 5
+
+var errUnsupportedQuery = errors.New("the query could not be analyzed: pass a single SELECT statement, without WITH or set operations such as UNION")
+
+// RecommendIndexes proposes an index for each table of a SELECT statement from the
+// columns its WHERE clause, join conditions and ORDER BY use: columns compared for
+// equality first, then the sort columns, then one column filtered by a range, and
+// the other columns the query reads as covering columns if there are only a few.
+// Only the outer query is analyzed. Indexes that already start with the proposed
+// columns are reported in the notes instead.
+func RecommendIndexes(ctx context.Context, db *gorm.DB, query string) (*IndexAdvice, error) {
+	tokens, err := tokenizeDDL(query)
+	if err != nil || len(tokens) == 0 || tokens[0].upper != "SELECT" {
+		return nil, errUnsupportedQuery
+	}
+	dialect := db.Dialector.Name()
+	a := &indexAdvisor{
+		ctx:     ctx,
+		db:      db,
+		dialect: dialect,
+		p:       &ddlParser{src: query, tokens: tokens, foldCase: dialect == "postgres"},
+	}
+	if err := a.analyze(); err != nil {
+		return nil, err
+	}
+	return a.advice()
+}
+
+// queryTable is a table of the FROM clause and how the query uses its columns.
+type queryTable struct {
+	name    ddlName
+	alias   string
+	columns []Column
+
+	// equality holds the columns compared with = or IN, or used as join keys;
+	// ranges the columns filtered by <, >, BETWEEN or a prefix LIKE.
+	equality, ranges []string
+	// joinKeys holds the equality columns that are compared to another table.
+	joinKeys []string
+	// sort holds the ORDER BY columns, with DESC appended where given.
+	sort []string
+	// referenced holds every column the query reads.
+	referenced []string
+	// star is set if the query selects all columns with * or table.*.
+	star bool
+	// reasons holds why each indexable column was picked, from its first use.
+	reasons map[string]string
+}
+
+// column returns the catalog name of a column of the table, or "".
+func (t *queryTable) column(name string) string {
+	for _, c := range t.columns {
+		if strings.EqualFold(c.Name, name) {
+			return c.Name
+		}
+	}
+	return ""
+}
+
+func (t *queryTable) use(list *[]string, column, reason string) {
+	if slices.Contains(*list, column) {
+		return
+	}
+	*list = append(*list, column)
+	if t.reasons == nil {
+		t.reasons = make(map[string]string)
+	}
+	if _, ok := t.reasons[column]; !ok {
+		t.reasons[column] = reason
+	}
+}
+
+func (t *queryTable) reference(column string) {
+	if !slices.Contains(t.referenced, column) {
+		t.referenced = append(t.referenced, column)
+	}
+}
+
+// columnRef is a column of the query resolved to its table.
+type columnRef struct {
+	table  *queryTable
+	column string
+}
+
+type indexAdvisor struct {
+	ctx     context.Context
+	db      *gorm.DB
+	dialect string
+	p       *ddlParser
+	tables  []*queryTable
+	notes   []string
+	// nested is set if the query has subqueries, whose columns are not tracked.
+	nested bool
+}
+
+func (a *indexAdvisor) note(format string, args ...any) {
+	if n := fmt.Sprintf(format, args...); !slices.Contains(a.notes, n) {
+		a.notes = append(a.notes, n)
+	}
+}
+
+// clauseStarts are the keywords that start a clause of a SELECT statement.
+var clauseStarts = map[string]bool{
+	"FROM": true, "WHERE": true, "GROUP": true, "HAVING": true, "WINDOW": true, "ORDER": true,
+	"LIMIT": true, "OFFSET": true, "FETCH": true, "FOR": true, "OPTION": true,
+}
+
+func (a *indexAdvisor) analyze() error {
+	tokens := a.p.tokens
+	// Split the statement into its top-level clauses.
+	clauses := map[string][2]int{}
+	current, start := "SELECT", 1
+	for i, t := range tokens {
+		if t.depth != 0 {
+			if t.upper == "SELECT" {
+				a.nested = true
+			}
+			continue
+		}
+		switch t.upper {
+		case "UNION", "INTERSECT", "EXCEPT", "MINUS", "INTO":
+			return errUnsupportedQuery
+		}
+		if i > 0 && clauseStarts[t.upper] {
+			if _, seen := clauses[t.upper]; !seen {
+				clauses[current] = [2]int{start, i}
+				current, start = t.upper, i+1
+				if t.upper == "GROUP" || t.upper == "ORDER" {
+					start++ // BY
+				}
+			}
+		}
+	}
+	clauses[current] = [2]int{start, len(tokens)}
+	if a.nested {
+		a.note("Subqueries are not analyzed; pass each one separately to get indexes for it.")
+	}
+
+	from, ok := clauses["FROM"]
+	if !ok {
+		return errUnsupportedQuery
+	}
+	conditions, err := a.fromClause(from[0], from[1])
+	if err != nil {
+		return err
+	}
+	if where, ok := clauses["WHERE"]; ok {
+		conditions = append(conditions, [2]int{where[0], where[1]})
+	}
+	for _, c := range conditions {
+		for _, conjunct := range a.conjuncts(c[0], c[1]) {
+			a.condition(conjunct[0], conjunct[1])
+		}
+	}
+	if order, ok := clauses["ORDER"]; ok {
+		a.orderBy(order[0], order[1])
+	}
+
+	sel := clauses["SELECT"]
+	for i := sel[0]; i < sel[1]; i++ {
+		if t := tokens[i]; t.depth == 0 && t.punct && t.text == "*" {
+			if i > sel[0] && tokens[i-1].punct && tokens[i-1].text == "." {
+				if ref := a.tableByName(tokens[i-2].text); ref != nil {
+					ref.star = true
+				}
+			} else {
+				for _, t := range a.tables {
+					t.star = true
+				}
+			}
+		}
+	}
+	for _, c := range clauses {
+		a.references(c[0], c[1])
+	}
+	return nil
+}
+
+// joinWords are the keywords that separate the tables of a FROM clause.
+var joinWords = map[string]bool{
+	"JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "OUTER": true,
+	"CROSS": true, "NATURAL": true, "STRAIGHT_JOIN": true, "APPLY": true, "LATERAL": true,
+}
+
+// fromClause reads the tables of the FROM clause and returns the token ranges
+// of its ON conditions.
+func (a *indexAdvisor) fromClause(start, end int) ([][2]int, error) {
+	tokens := a.p.tokens
+	var conditions [][2]int
+	expectTable := true
+	for i := start; i < end; {
+		t := tokens[i]
+		switch {
+		case t.depth != 0:
+			i++
+		case expectTable && t.upper == "LATERAL", expectTable && t.upper == "ONLY":
+			i++
+		case expectTable && t.punct && t.text == "(":
+			a.note("Derived tables and subqueries in the FROM clause are not analyzed.")
+			a.p.pos = i
+			a.p.group()
+			i = a.skipAlias(a.p.pos, end)
+			expectTable = false
+		case expectTable:
+			a.p.pos = i
+			name, ok := a.p.name()
+			if !ok || a.p.pos > end {
+				return nil, errUnsupportedQuery
+			}
+			i = a.p.pos
+			if a.p.peekPunct("(") {
+				// A table-valued function.
+				a.p.group()
+				i = a.skipAlias(a.p.pos, end)
+				expectTable = false
+				continue
+			}
+			table := &queryTable{name: name}
+			if i < end && !tokens[i].punct && !joinWords[tokens[i].upper] && !aliasStops[tokens[i].upper] {
+				if tokens[i].upper == "AS" {
+					i++
+				}
+				if i < end {
+					table.alias = identText(tokens[i])
+					i++
+				}
+			}
+			columns, err := GetColumns(a.ctx, a.db, name.schema(), name.table())
+			switch {
+			case errors.Is(err, ErrTableNotFound):
+				a.note("%s is not a table (a view or a common table expression?), so it was not analyzed.", name.raw)
+			case err != nil:
+				return nil, err
+			default:
+				table.columns = columns
+				a.tables = append(a.tables, table)
+			}
+			expectTable = false
+		case t.punct && t.text == ",", t.upper == "JOIN", t.upper == "APPLY":
+			expectTable = true
+			i++
+		case t.upper == "ON":
+			j := i + 1
+			for j < end && (tokens[j].depth != 0 || !(tokens[j].punct && tokens[j].text == ",") && !joinWords[tokens[j].upper]) {
+				j++
+			}
+			conditions = append(conditions, [2]int{i + 1, j})
+			i = j
+		case t.upper == "USING":
+			a.p.pos = i + 1
+			columns, _ := a.p.columnList()
+			a.using(columns)
+			i = a.p.pos
+		case t.upper == "WITH" || t.upper == "USE" || t.upper == "FORCE" || t.upper == "IGNORE":
+			// Table hints: WITH (NOLOCK), USE INDEX (idx).
+			for i < end && !(tokens[i].punct && tokens[i].text == "(") {
+				i++
+			}
+			a.p.pos = i
+			a.p.group()
+			i = a.p.pos
+		default:
+			i++
+		}
+	}
+	return conditions, nil
+}
+
+// aliasStops are the keywords that can follow a table name in place of an alias.
+var aliasStops = map[string]bool{
+	"ON": true, "USING": true, "WITH": true, "USE": true, "FORCE": true, "IGNORE": true,
+	"TABLESAMPLE": true, "WHERE": true,
+}
+
+// skipAlias skips the alias of a derived table or table function.
+func (a *indexAdvisor) skipAlias(i, end int) int {
+	tokens := a.p.tokens
+	if i < end && tokens[i].upper == "AS" {
+		i++
+	}
+	if i < end && !tokens[i].punct && !joinWords[tokens[i].upper] && !aliasStops[tokens[i].upper] {
+		i++
+	}
+	// A column alias list: AS t (a, b).
+	if i < end && tokens[i].punct && tokens[i].text == "(" {
+		a.p.pos = i
+		a.p.group()
+		i = a.p.pos
+	}
+	return i
+}
+
+// using records the columns of a JOIN ... USING clause as join keys of every
+// table that has them.
+func (a *indexAdvisor) using(columns []string) {
+	for _, name := range columns {
+		name = strings.Trim(name, "\"`[]")
+		for _, t := range a.tables {
+			if c := t.column(name); c != "" {
+				t.use(&t.equality, c, fmt.Sprintf("%s is a join key (USING %s), so the join can look up matching rows", c, name))
+				t.use(&t.joinKeys, c, "")
+			}
+		}
+	}
+}
+
+// conjuncts splits a condition at its top-level ANDs, unwrapping parentheses
+// around the whole condition or a conjunct.
+func (a *indexAdvisor) conjuncts(start, end int) [][2]int {
+	tokens := a.p.tokens
+	start, end = a.unwrap(start, end)
+	if start >= end {
+		return nil
+	}
+	depth := tokens[start].depth
+	var parts [][2]int
+	between := false
+	from := start
+	for i := start; i < end; i++ {
+		t := tokens[i]
+		if t.depth != depth {
+			continue
+		}
+		switch {
+		case t.upper == "BETWEEN":
+			between = true
+		case t.upper == "AND" && between:
+			between = false
+		case t.upper == "AND":
+			parts = append(parts, [2]int{from, i})
+			from = i + 1
+		}
+	}
+	parts = append(parts, [2]int{from, end})
+
+	var out [][2]int
+	for _, part := range parts {
+		s, e := a.unwrap(part[0], part[1])
+		if s != part[0] {
+			// A parenthesized conjunct can itself be a conjunction.
+			out = append(out, a.conjuncts(s, e)...)
+		} else {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// unwrap strips parentheses enclosing the whole token range.
+func (a *indexAdvisor) unwrap(start, end int) (int, int) {
+	tokens := a.p.tokens
+	for end-start >= 2 && tokens[start].punct && tokens[start].text == "(" &&
+		tokens[end-1].punct && tokens[end-1].text == ")" && tokens[end-1].depth == tokens[start].depth {
+		// The closing parenthesis must match the opening one.
+		matched := true
+		for i := start + 1; i < end-1; i++ {
+			if t := tokens[i]; t.punct && t.text == ")" && t.depth == tokens[start].depth {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			break
+		}
+		start, end = start+1, end-1
+	}
+	return start, end
+}
+
+// condition records the columns a single predicate lets an index seek on.
+func (a *indexAdvisor) condition(start, end int) {
+	tokens := a.p.tokens
+	if start >= end {
+		return
+	}
+	text := a.text(start, end)
+	depth := tokens[start].depth
+	for i := start; i < end; i++ {
+		if tokens[i].depth == depth && tokens[i].upper == "OR" {
+			a.note("The condition %q combines alternatives with OR, which one index cannot serve; consider an index per alternative, or rewriting the query as a UNION.", text)
+			return
+		}
+	}
+
+	// Find the operator: the first comparison at the predicate's own depth.
+	op, opStart, opEnd := "", -1, -1
+	for i := start; i < end && opStart < 0; i++ {
+		t := tokens[i]
+		if t.depth != depth {
+			continue
+		}
+		switch {
+		case t.punct && strings.Contains("=<>!", t.text):
+			// The tokenizer splits <= and <> into single characters.
+			j := i + 1
+			for j < end && tokens[j].punct && strings.Contains("=<>!", tokens[j].text) && tokens[j].start == tokens[j-1].end {
+				j++
+			}
+			op, opStart, opEnd = a.text(i, j), i, j
+		case t.upper == "IS" || t.upper == "IN" || t.upper == "BETWEEN" || t.upper == "LIKE" || t.upper == "ILIKE":
+			op, opStart, opEnd = t.upper, i, i+1
+			if t.upper == "IS" && i+1 < end && tokens[i+1].upper == "NOT" {
+				op, opEnd = "IS NOT", i+2
+			}
+			if i > start && tokens[i-1].upper == "NOT" {
+				op, opStart = "NOT "+op, i-1
+			}
+		}
+	}
+	if opStart < 0 {
+		return
+	}
+
+	left := a.columnAt(start, opStart)
+	right := a.columnAt(opEnd, end)
+	if left == nil && right == nil {
+		if a.mentionsColumn(start, opStart) {
+			a.note("The condition %q applies a function or expression to the column, so an index on the column cannot be used; compare the bare column, or index the expression.", text)
+		}
+		return
+	}
+	if left == nil {
+		// 5 < t.amount
+		left, right = right, nil
+		op = flipOperator(op)
+	}
+
+	switch op {
+	case "=", "<=>", "IN", "IS":
+		if right != nil {
+			if right.table == left.table {
+				return
+			}
+			for _, ref := range []*columnRef{left, right} {
+				ref.table.use(&ref.table.equality, ref.column, fmt.Sprintf("%s is a join key (%s), so the join can look up matching rows", ref.column, text))
+				ref.table.use(&ref.table.joinKeys, ref.column, "")
+			}
+			return
+		}
+		left.table.use(&left.table.equality, left.column, fmt.Sprintf("%s is compared for equality (%s), so it comes before any sort or range column", left.column, text))
+	case "<", ">", "<=", ">=", "BETWEEN":
+		if right != nil {
+			return
+		}
+		left.table.use(&left.table.ranges, left.column, fmt.Sprintf("%s is filtered by a range (%s); an index can seek on only one range column, after the equality columns", left.column, text))
+	case "LIKE", "ILIKE":
+		if opEnd < end && (strings.HasPrefix(tokens[opEnd].text, "'%") || strings.HasPrefix(tokens[opEnd].text, "'_")) {
+			a.note("The condition %q starts its pattern with a wildcard, so no index can seek on it.", text)
+			return
+		}
+		if op == "ILIKE" {
+			a.note("The condition %q is case-insensitive, which a plain index cannot serve; an index on lower(column) with a matching condition can.", text)
+			return
+		}
+		left.table.use(&left.table.ranges, left.column, fmt.Sprintf("%s is matched by a prefix (%s), which an index scans as a range", left.column, text))
+	case "<>", "!=", "NOT IN", "NOT LIKE", "IS NOT":
+		a.note("The condition %q excludes values rather than selecting them, which an index rarely helps with.", text)
+	}
+}
+
+func flipOperator(op string) string {
+	switch op {
+	case "<":
+		return ">"
+	case ">":
+		return "<"
+	case "<=":
+		return ">="
+	case ">=":
+		return "<="
+	}
+	return op
+}
+
+// columnAt resolves a token range that is exactly a column reference.
+func (a *indexAdvisor) columnAt(start, end int) *columnRef {
+	start, end = a.unwrap(start, end)
+	if start >= end {
+		return nil
+	}
+	a.p.pos = start
+	name, ok := a.p.name()
+	if !ok || a.p.pos != end {
+		return nil
+	}
+	return a.resolve(name)
+}
+
+// mentionsColumn reports whether a token range references a column.
+func (a *indexAdvisor) mentionsColumn(start, end int) bool {
+	for i := start; i < end; i++ {
+		a.p.pos = i
+		if name, ok := a.p.name(); ok && !a.p.peekPunct("(") && a.resolve(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve finds the table of a column reference: by its qualifier, or else the
+// only table of the query that has the column.
+func (a *indexAdvisor) resolve(name ddlName) *columnRef {
+	parts := name.parts
+	column := parts[len(parts)-1]
+	if len(parts) > 1 {
+		t := a.tableByName(parts[len(parts)-2])
+		if t == nil {
+			return nil
+		}
+		if c := t.column(column); c != "" {
+			return &columnRef{table: t, column: c}
+		}
+		return nil
+	}
+	var found *columnRef
+	for _, t := range a.tables {
+		if c := t.column(column); c != "" {
+			if found != nil {
+				return nil
+			}
+			found = &columnRef{table: t, column: c}
+		}
+	}
+	return found
+}
+
+// tableByName finds a table by its alias, or by its name if it has none.
+func (a *indexAdvisor) tableByName(name string) *queryTable {
+	name = strings.Trim(name, "\"`[]")
+	for _, t := range a.tables {
+		if t.alias != "" {
+			if strings.EqualFold(t.alias, name) {
+				return t
+			}
+		} else if strings.EqualFold(t.name.table(), name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// orderBy records the ORDER BY columns if they all belong to one table, which an
+// index can then return in order.
+func (a *indexAdvisor) orderBy(start, end int) {
+	tokens := a.p.tokens
+	var table *queryTable
+	var columns []string
+	for i := start; i < end; {
+		a.p.pos = i
+		name, ok := a.p.name()
+		var ref *columnRef
+		if ok {
+			ref = a.resolve(name)
+		}
+		if ref == nil || table != nil && ref.table != table {
+			a.note("ORDER BY %s does not sort on plain columns of a single table, so no index can return the rows in order.", a.text(start, end))
+			return
+		}
+		table = ref.table
+		column := ref.column
+		for i = a.p.pos; i < end && !(tokens[i].punct && tokens[i].text == ","); i++ {
+			switch tokens[i].upper {
+			case "DESC":
+				column += " DESC"
+			case "ASC", "NULLS", "FIRST", "LAST":
+			default:
+				a.note("ORDER BY %s does not sort on plain columns of a single table, so no index can return the rows in order.", a.text(start, end))
+				return
+			}
+		}
+		columns = append(columns, column)
+		i++
+	}
+	if table == nil {
+		return
+	}
+	for _, c := range columns {
+		table.use(&table.sort, c, fmt.Sprintf("%s is in ORDER BY, so the index returns rows already sorted when %s is read first", c, table.name.table()))
+	}
+}
+
+// references records the columns a token range reads, for covering indexes.
+func (a *indexAdvisor) references(start, end int) {
+	tokens := a.p.tokens
+	for i := start; i < end; {
+		t := tokens[i]
+		if t.punct || t.upper == "" && !t.quoted || i > 0 && tokens[i-1].upper == "AS" {
+			i++
+			continue
+		}
+		a.p.pos = i
+		name, ok := a.p.name()
+		if !ok {
+			i++
+			continue
+		}
+		i = a.p.pos
+		if a.p.peekPunct("(") {
+			continue
+		}
+		if ref := a.resolve(name); ref != nil {
+			ref.table.reference(ref.column)
+		}
+	}
+}
+
+// text returns the query text of a token range.
+func (a *indexAdvisor) text(start, end int) string {
+	return a.p.src[a.p.tokens[start].start:a.p.tokens[end-1].end]
+}
+
+func (a *indexAdvisor) advice() (*IndexAdvice, error) {
+	advice := &IndexAdvice{Recommendations: []IndexRecommendation{}}
+	for _, t := range a.tables {
+		pk, err := GetPrimaryKey(a.ctx, a.db, t.name.schema(), t.name.table())
+		if err != nil {
+			return nil, err
+		}
+		if len(pk) > 0 && containsAll(t.equality, pk) {
+			if !containsAll(t.joinKeys, pk) {
+				a.note("%s is looked up by its primary key (%s), which is already indexed.", t.name.raw, strings.Join(pk, ", "))
+				continue
+			}
+			// The join can use the primary key when the other table is read first.
+			t.equality = slices.DeleteFunc(t.equality, func(c string) bool { return slices.Contains(pk, c) })
+		}
+
+		columns := slices.Clone(t.equality)
+		for _, c := range t.sort {
+			if !slices.Contains(columns, strings.TrimSuffix(c, " DESC")) {
+				columns = append(columns, c)
+			}
+		}
+		if len(t.ranges) > 0 && !slices.Contains(columns, t.ranges[0]) {
+			columns = append(columns, t.ranges[0])
+		}
+		if len(columns) == 0 {
+			continue
+		}
+		keys := make([]string, len(columns))
+		for i, c := range columns {
+			keys[i] = strings.TrimSuffix(c, " DESC")
+		}
+
+		rec := IndexRecommendation{Table: t.name.raw, Columns: columns}
+		for _, c := range keys {
+			rec.Reasoning = append(rec.Reasoning, t.reasons[c])
+		}
+		for _, r := range t.ranges {
+			if !slices.Contains(keys, r) {
+				rec.Reasoning = append(rec.Reasoning, fmt.Sprintf("%s is also filtered by a range, but the index can seek on only one range column", r))
+			}
+		}
+
+		// Apart from PostgreSQL, secondary indexes already hold the primary key.
+		var include []string
+		for _, c := range t.referenced {
+			if !slices.Contains(keys, c) && (a.dialect == "postgres" || !slices.Contains(pk, c)) {
+				include = append(include, c)
+			}
+		}
+		switch {
+		case t.star:
+			rec.Reasoning = append(rec.Reasoning, "The query selects every column, so the index cannot cover it; select only the needed columns to allow a covering index")
+		case a.nested:
+			// Subqueries may read more columns than tracked.
+		case len(include) > maxIncludeColumns:
+			rec.Reasoning = append(rec.Reasoning, fmt.Sprintf("The query reads %d more columns of %s, too many to cover with the index", len(include), t.name.table()))
+		case len(include) > 0 && (a.dialect == "postgres" || a.dialect == "sqlserver"):
+			rec.Include = include
+			rec.Reasoning = append(rec.Reasoning, fmt.Sprintf("Including %s lets the query be answered from the index without reading the table", strings.Join(include, ", ")))
+		case len(include) > 0:
+			rec.Columns = append(rec.Columns, include...)
+			rec.Reasoning = append(rec.Reasoning, fmt.Sprintf("Appending %s lets the query be answered from the index without reading the table", strings.Join(include, ", ")))
+		}
+
+		existing, err := tableIndexes(a.db.WithContext(a.ctx), a.dialect, t.name.schema(), t.name.table())
+		if err != nil {
+			return nil, err
+		}
+		if idx, ok := coveringIndex(existing, keys); ok {
+			a.note("The existing index %s on %s already starts with (%s); if the plan does not use it, refresh the table statistics with analyze_table.", idx.name, t.name.raw, strings.Join(keys, ", "))
+			continue
+		}
+		for _, idx := range existing {
+			if len(idx.columns) < len(keys) && prefixOf(idx.columns, keys) {
+				rec.Reasoning = append(rec.Reasoning, fmt.Sprintf("It extends the existing index %s (%s), which it could replace", idx.name, strings.Join(idx.columns, ", ")))
+			}
+		}
+
+		rec.CreateStatement = a.createStatement(t, rec)
+		advice.Recommendations = append(advice.Recommendations, rec)
+	}
+	if len(advice.Recommendations) == 0 && len(a.notes) == 0 {
+		a.note("The query has no WHERE, JOIN or ORDER BY on plain columns of its tables that an index could serve.")
+	}
+	advice.Notes = a.notes
+	return advice, nil
+}
+
+// createStatement writes the CREATE INDEX statement of a recommendation.
+func (a *indexAdvisor) createStatement(t *queryTable, rec IndexRecommendation) string {
+	name := "idx_" + t.name.table()
+	columns := make([]string, len(rec.Columns))
+	for i, c := range rec.Columns {
+		column, desc := strings.CutSuffix(c, " DESC")
+		name += "_" + column
+		columns[i] = a.quote(column)
+		if desc {
+			columns[i] += " DESC"
+		}
+	}
+	// PostgreSQL truncates names to 63 bytes, MySQL rejects names over 64.
+	name = nonIdentChars.ReplaceAllString(strings.ToLower(name), "_")
+	if len(name) > 63 {
+		name = name[:63]
+	}
+
+	stmt := fmt.Sprintf("CREATE INDEX %s ON %s (%s)", a.quote(name), t.name.raw, strings.Join(columns, ", "))
+	if len(rec.Include) > 0 {
+		include := make([]string, len(rec.Include))
+		for i, c := range rec.Include {
+			include[i] = a.quote(c)
+		}
+		stmt += " INCLUDE (" + strings.Join(include, ", ") + ")"
+	}
+	return stmt
+}
+
+var (
+	nonIdentChars = regexp.MustCompile(`[^a-z0-9_]+`)
+	plainIdent    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// quote quotes an identifier if it is not a plain name in the dialect.
+func (a *indexAdvisor) quote(name string) string {
+	if plainIdent.MatchString(name) && (a.dialect != "postgres" || name == strings.ToLower(name)) {
+		return name
+	}
+	switch a.dialect {
+	case "mysql":
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case "sqlserver":
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	default:
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+}
+
+// identText returns an identifier token without its quotes.
+func identText(t ddlToken) string {
+	if t.quoted {
+		return t.text[1 : len(t.text)-1]
+	}
+	return t.text
+}
+
+// tableIndex is an existing index and its key columns in order.
+type tableIndex struct {
+	name    string
+	columns []string
+}
+
+func containsAll(list, values []string) bool {
+	for _, v := range values {
+		if !slices.Contains(list, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// coveringIndex returns an index whose key starts with the given columns.
+func coveringIndex(indexes []tableIndex, columns []string) (tableIndex, bool) {
+	for _, idx := range indexes {
+		if prefixOf(columns, idx.columns) {
+			return idx, true
+		}
+	}
+	return tableIndex{}, false
+}
+
+// prefixOf reports whether prefix is a prefix of columns, ignoring case.
+func prefixOf(prefix, columns []string) bool {
+	if len(prefix) > len(columns) {
+		return false
+	}
+	for i, c := range prefix {
+		if !strings.EqualFold(c, columns[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// tableIndexes returns the indexes of a table that can serve any query: those on
+// plain columns, without a WHERE clause.
+func tableIndexes(db *gorm.DB, dialect, schema, table string) ([]tableIndex, error) {
+	var rows []struct {
+		Name   string  `gorm:"column:name"`
+		Column *string `gorm:"column:column_name"`
+	}
+	var err error
+	switch dialect {
+	case "postgres":
+		name := pgx.Identifier{table}.Sanitize()
+		if schema != "" {
+			name = pgx.Identifier{schema, table}.Sanitize()
+		}
+		err = db.Raw(`SELECT c.relname AS name, a.attname AS column_name
+FROM pg_index i
+JOIN pg_class c ON c.oid = i.indexrelid
+CROSS JOIN LATERAL unnest(i.indkey::int2[]) WITH ORDINALITY AS k(attnum, ord)
+LEFT JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = k.attnum
+WHERE i.indrelid = to_regclass(?) AND i.indpred IS NULL AND k.ord <= i.indnkeyatts
+ORDER BY c.relname, k.ord`, name).Scan(&rows).Error
+	case "mysql":
+		err = db.Raw(`SELECT INDEX_NAME AS name, COLUMN_NAME AS column_name
+FROM information_schema.STATISTICS
+WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ?
+ORDER BY INDEX_NAME, SEQ_IN_INDEX`, schema, table).Scan(&rows).Error
+	case "sqlserver":
+		err = db.Raw(`SELECT i.name, c.name AS column_name
+FROM sys.indexes i
+JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id AND ic.key_ordinal > 0
+JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+WHERE i.object_id = OBJECT_ID(QUOTENAME(COALESCE(NULLIF(?, ''), SCHEMA_NAME())) + '.' + QUOTENAME(?), 'U') AND i.has_filter = 0
+ORDER BY i.name, ic.key_ordinal`, schema, table).Scan(&rows).Error
+	case "sqlite":
+		err = db.Raw(`SELECT il.name, ii.name AS column_name
+FROM pragma_index_list(?, COALESCE(NULLIF(?, ''), 'main')) il
+JOIN pragma_index_info(il.name, COALESCE(NULLIF(?, ''), 'main')) ii
+WHERE il.partial = 0
+ORDER BY il.name, ii.seqno`, table, schema, schema).Scan(&rows).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows come ordered by index. A NULL column is an expression, which skips the index.
+	var indexes []tableIndex
+	skip := ""
+	for _, r := range rows {
+		last := len(indexes) - 1
+		switch {
+		case r.Name == skip:
+		case r.Column == nil:
+			skip = r.Name
+			if last >= 0 && indexes[last].name == r.Name {
+				indexes = indexes[:last]
+			}
+		case last >= 0 && indexes[last].name == r.Name:
+			indexes[last].columns = append(indexes[last].columns, *r.Column)
+		default:
+			indexes = append(indexes, tableIndex{name: r.Name, columns: []string{*r.Column}})
+		}
+	}
+	return indexes, nil
+}
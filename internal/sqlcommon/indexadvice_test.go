@@ -0,0 +1,92 @@
+package sqlcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRecommendIndexes(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT, country TEXT, created_at TEXT);
+		CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, status TEXT, total REAL, created_at TEXT);
+		CREATE INDEX idx_users_email ON users (email);
+		CREATE INDEX idx_orders_user ON orders (user_id);
+	`).Error)
+
+	tests := []struct {
+		name, query string
+		// want maps each recommended table to its CREATE INDEX statement.
+		want  map[string]string
+		notes []string
+	}{
+		{
+			name:  "EqualitySortRange",
+			query: "SELECT id, total FROM orders WHERE status = 'paid' AND total > 100 ORDER BY created_at DESC",
+			want:  map[string]string{"orders": "CREATE INDEX idx_orders_status_created_at_total ON orders (status, created_at DESC, total)"},
+		},
+		{
+			name:  "Join",
+			query: "SELECT u.email, o.total FROM users u JOIN orders o ON o.user_id = u.id WHERE u.country = ? AND o.created_at >= ?",
+			want: map[string]string{
+				"users":  "CREATE INDEX idx_users_country_email ON users (country, email)",
+				"orders": "CREATE INDEX idx_orders_user_id_created_at_total ON orders (user_id, created_at, total)",
+			},
+		},
+		{
+			name:  "ReversedComparison",
+			query: "SELECT * FROM orders WHERE 100 < total AND (status IN ('new', 'paid'))",
+			want:  map[string]string{"orders": "CREATE INDEX idx_orders_status_total ON orders (status, total)"},
+		},
+		{
+			name:  "PrimaryKey",
+			query: "SELECT email FROM users WHERE id = 42 AND country = 'DE'",
+			want:  map[string]string{},
+			notes: []string{"users is looked up by its primary key (id), which is already indexed."},
+		},
+		{
+			name:  "ExistingIndex",
+			query: "SELECT * FROM users WHERE email = ?",
+			want:  map[string]string{},
+			notes: []string{"The existing index idx_users_email on users already starts with (email); if the plan does not use it, refresh the table statistics with analyze_table."},
+		},
+		{
+			name:  "Unindexable",
+			query: "SELECT * FROM users WHERE lower(email) = 'a@example.com' AND (country = 'DE' OR country = 'FR') AND email LIKE '%@example.com'",
+			want:  map[string]string{},
+			notes: []string{
+				`The condition "lower(email) = 'a@example.com'" applies a function or expression to the column, so an index on the column cannot be used; compare the bare column, or index the expression.`,
+				`The condition "country = 'DE' OR country = 'FR'" combines alternatives with OR, which one index cannot serve; consider an index per alternative, or rewriting the query as a UNION.`,
+				`The condition "email LIKE '%@example.com'" starts its pattern with a wildcard, so no index can seek on it.`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			advice, err := RecommendIndexes(t.Context(), db, tt.query)
+			require.NoError(t, err)
+			got := map[string]string{}
+			for _, rec := range advice.Recommendations {
+				got[rec.Table] = rec.CreateStatement
+				require.NotEmpty(t, rec.Reasoning)
+			}
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.notes, advice.Notes)
+		})
+	}
+
+	t.Run("Unsupported", func(t *testing.T) {
+		for _, query := range []string{
+			"UPDATE users SET email = NULL",
+			"SELECT id FROM users UNION SELECT user_id FROM orders",
+			"SELECT 1",
+		} {
+			_, err := RecommendIndexes(t.Context(), db, query)
+			require.ErrorIs(t, err, errUnsupportedQuery, query)
+		}
+	})
+}
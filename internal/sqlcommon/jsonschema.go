@@ -0,0 +1,107 @@
+package sqlcommon
+
+import (
+	"strconv"
+	"strings"
+)
+
+// JSONSchemaDraft is the JSON Schema version of the documents built by TableJSONSchema.
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// TableJSONSchema builds a JSON Schema document describing a row of a table with
+// the given columns, as returned by GetColumns. Columns that are NOT NULL and have
+// no default are required; nullable columns also accept null.
+func TableJSONSchema(dialect, title string, columns []Column) map[string]any {
+	properties := make(map[string]any, len(columns))
+	required := []string{}
+	for _, col := range columns {
+		properties[col.Name] = columnJSONSchema(dialect, col)
+		if !col.Nullable && !col.HasDefault {
+			required = append(required, col.Name)
+		}
+	}
+	return map[string]any{
+		"$schema":              JSONSchemaDraft,
+		"title":                title,
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+func columnJSONSchema(dialect string, col Column) map[string]any {
+	schema := typeJSONSchema(dialect, col.DatabaseType)
+	schema["x-database-type"] = col.DatabaseType
+	if col.Comment != "" {
+		schema["description"] = col.Comment
+	}
+	if len(col.EnumValues) > 0 {
+		values := make([]any, 0, len(col.EnumValues)+1)
+		for _, v := range col.EnumValues {
+			values = append(values, v)
+		}
+		if col.Nullable {
+			values = append(values, nil)
+		}
+		schema["enum"] = values
+	}
+	if col.Nullable {
+		if typ, ok := schema["type"].(string); ok {
+			schema["type"] = []string{typ, "null"}
+		}
+	}
+	return schema
+}
+
+// typeJSONSchema maps a declared column type, such as varchar(50) or integer[],
+// to a JSON Schema. Types it does not recognize map to strings, the form most
+// drivers return them in.
+func typeJSONSchema(dialect, databaseType string) map[string]any {
+	typ := strings.ToLower(strings.TrimSpace(databaseType))
+	if elem, ok := strings.CutSuffix(typ, "[]"); ok {
+		return map[string]any{"type": "array", "items": typeJSONSchema(dialect, elem)}
+	}
+	base, params, _ := strings.Cut(typ, "(")
+	base = strings.TrimSpace(base)
+	params, _, _ = strings.Cut(params, ")")
+
+	switch {
+	case base == "bool" || base == "boolean" || base == "bit" && (dialect == "sqlserver" || params == "1"),
+		base == "tinyint" && params == "1" && dialect == "mysql":
+		return map[string]any{"type": "boolean"}
+	case base == "json" || base == "jsonb":
+		// Any JSON value.
+		return map[string]any{}
+	case base == "uuid" || base == "uniqueidentifier":
+		return map[string]any{"type": "string", "format": "uuid"}
+	case base == "date":
+		return map[string]any{"type": "string", "format": "date"}
+	case strings.HasPrefix(base, "time") && !strings.HasPrefix(base, "timestamp"):
+		return map[string]any{"type": "string", "format": "time"}
+	case strings.HasPrefix(base, "timestamp") || strings.HasPrefix(base, "datetime") || base == "smalldatetime":
+		return map[string]any{"type": "string", "format": "date-time"}
+	case base == "bytea" || base == "image" || strings.HasSuffix(base, "blob") || strings.HasSuffix(base, "binary"):
+		return map[string]any{"type": "string", "contentEncoding": "base64"}
+	case strings.Contains(base, "int") && !strings.Contains(base, "interval") || base == "serial" || base == "bigserial" ||
+		base == "smallserial" || base == "year" || base == "bit" && dialect == "mysql":
+		return map[string]any{"type": "integer"}
+	case base == "numeric" || base == "decimal":
+		// Scale 0 holds whole numbers only.
+		if _, scale, ok := strings.Cut(params, ","); ok && strings.TrimSpace(scale) != "0" || params == "" {
+			return map[string]any{"type": "number"}
+		}
+		return map[string]any{"type": "integer"}
+	case base == "real" || base == "money" || base == "smallmoney" ||
+		strings.HasPrefix(base, "float") || strings.HasPrefix(base, "double"):
+		return map[string]any{"type": "number"}
+	}
+
+	schema := map[string]any{"type": "string"}
+	if strings.Contains(base, "char") {
+		if n, err := strconv.Atoi(strings.TrimSpace(params)); err == nil && n > 0 {
+			schema["maxLength"] = n
+		}
+	}
+	return schema
+}
@@ -0,0 +1,99 @@
+package sqlcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGetColumns(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`CREATE TABLE accounts (
+		id INTEGER PRIMARY KEY,
+		email VARCHAR(120) NOT NULL,
+		plan TEXT NOT NULL DEFAULT 'free',
+		verified BOOLEAN
+	)`).Error)
+
+	columns, err := GetColumns(t.Context(), db, "", "accounts")
+	require.NoError(t, err)
+	require.Equal(t, []Column{
+		{Name: "id", DatabaseType: "INTEGER", HasDefault: true},
+		{Name: "email", DatabaseType: "VARCHAR(120)"},
+		{Name: "plan", DatabaseType: "TEXT", HasDefault: true},
+		{Name: "verified", DatabaseType: "BOOLEAN", Nullable: true},
+	}, columns)
+
+	t.Run("TableNotFound", func(t *testing.T) {
+		_, err := GetColumns(t.Context(), db, "", "missing")
+		require.ErrorIs(t, err, ErrTableNotFound)
+	})
+
+	t.Run("CheckColumn", func(t *testing.T) {
+		require.NoError(t, CheckColumn(t.Context(), db, "", "accounts", "email"))
+		require.NoError(t, CheckColumn(t.Context(), db, "", "accounts", ""))
+		require.ErrorIs(t, CheckColumn(t.Context(), db, "", "accounts", "missing"), ErrColumnNotFound)
+		require.ErrorIs(t, CheckColumn(t.Context(), db, "", "missing", ""), ErrTableNotFound)
+	})
+}
+
+func TestTableJSONSchema(t *testing.T) {
+	schema := TableJSONSchema("postgres", "public.accounts", []Column{
+		{Name: "id", DatabaseType: "bigint", HasDefault: true},
+		{Name: "email", DatabaseType: "character varying(120)", Comment: "Login address"},
+		{Name: "status", DatabaseType: "account_status", Nullable: true, EnumValues: []string{"active", "closed"}},
+		{Name: "tags", DatabaseType: "text[]", Nullable: true},
+	})
+	require.Equal(t, map[string]any{
+		"$schema": JSONSchemaDraft,
+		"title":   "public.accounts",
+		"type":    "object",
+		"properties": map[string]any{
+			"id":     map[string]any{"type": "integer", "x-database-type": "bigint"},
+			"email":  map[string]any{"type": "string", "maxLength": 120, "description": "Login address", "x-database-type": "character varying(120)"},
+			"status": map[string]any{"type": []string{"string", "null"}, "enum": []any{"active", "closed", nil}, "x-database-type": "account_status"},
+			"tags":   map[string]any{"type": []string{"array", "null"}, "items": map[string]any{"type": "string"}, "x-database-type": "text[]"},
+		},
+		"required":             []string{"email"},
+		"additionalProperties": false,
+	}, schema)
+}
+
+func TestTypeJSONSchema(t *testing.T) {
+	tests := []struct {
+		dialect, databaseType string
+		want                  map[string]any
+	}{
+		{"mysql", "tinyint(1)", map[string]any{"type": "boolean"}},
+		{"mysql", "tinyint(4)", map[string]any{"type": "integer"}},
+		{"mysql", "int unsigned", map[string]any{"type": "integer"}},
+		{"mysql", "bit(1)", map[string]any{"type": "boolean"}},
+		{"sqlserver", "bit", map[string]any{"type": "boolean"}},
+		{"sqlserver", "nvarchar(max)", map[string]any{"type": "string"}},
+		{"sqlserver", "datetimeoffset", map[string]any{"type": "string", "format": "date-time"}},
+		{"sqlserver", "uniqueidentifier", map[string]any{"type": "string", "format": "uuid"}},
+		{"sqlserver", "decimal(10,0)", map[string]any{"type": "integer"}},
+		{"postgres", "numeric(10,2)", map[string]any{"type": "number"}},
+		{"postgres", "numeric", map[string]any{"type": "number"}},
+		{"postgres", "timestamp with time zone", map[string]any{"type": "string", "format": "date-time"}},
+		{"postgres", "time without time zone", map[string]any{"type": "string", "format": "time"}},
+		{"postgres", "interval", map[string]any{"type": "string"}},
+		{"postgres", "jsonb", map[string]any{}},
+		{"postgres", "bytea", map[string]any{"type": "string", "contentEncoding": "base64"}},
+		{"sqlite", "DOUBLE PRECISION", map[string]any{"type": "number"}},
+		{"sqlite", "", map[string]any{"type": "string"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect+"/"+tt.databaseType, func(t *testing.T) {
+			require.Equal(t, tt.want, typeJSONSchema(tt.dialect, tt.databaseType))
+		})
+	}
+}
+
+func TestMySQLEnumValues(t *testing.T) {
+	require.Equal(t, []string{"small", "it's", "a,b"}, mysqlEnumValues("enum('small','it''s','a,b')"))
+	require.Nil(t, mysqlEnumValues("varchar(10)"))
+}
@@ -0,0 +1,207 @@
+package sqlcommon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// limitBlockers are top-level keywords after which appending a row limit would
+// either change the meaning of the query or produce invalid SQL.
+var limitBlockers = map[string]bool{
+	"LIMIT": true, "TOP": true, "FETCH": true, "OFFSET": true,
+	"UNION": true, "INTERSECT": true, "EXCEPT": true, "MINUS": true,
+	"INTO": true, "FOR": true, "PROCEDURE": true, "LOCK": true,
+}
+
+// InjectLimit rewrites a single SELECT statement without its own row limit so the
+// database returns at most n rows: "TOP n" after SELECT when top is set (SQL Server),
+// or a trailing "LIMIT n" otherwise. It returns false and the query unchanged when the
+// statement is not a plain SELECT, already limits its rows, or uses set operations,
+// locking clauses, SELECT INTO or several statements.
+func InjectLimit(query string, n int, top bool) (string, bool) {
+	words, end, ok := topLevelWords(query)
+	if !ok || len(words) == 0 || n <= 0 {
+		return query, false
+	}
+	switch words[0].text {
+	case "SELECT":
+	case "WITH":
+		// TOP belongs to the outer SELECT, which is hard to find after the CTEs.
+		if top {
+			return query, false
+		}
+	default:
+		return query, false
+	}
+	for _, w := range words {
+		if limitBlockers[w.text] {
+			return query, false
+		}
+	}
+
+	body := strings.TrimRightFunc(query[:end], unicode.IsSpace)
+	if !top {
+		// On its own line, so a trailing -- comment cannot swallow it.
+		return fmt.Sprintf("%s\nLIMIT %d", body, n), true
+	}
+
+	// SELECT [ALL | DISTINCT] TOP n ...
+	at := words[0].end
+	if len(words) > 1 && (words[1].text == "DISTINCT" || words[1].text == "ALL") {
+		at = words[1].end
+	}
+	return fmt.Sprintf("%s TOP %d%s", body[:at], n, body[at:]), true
+}
+
+// ErrQueryHasLimit is returned by Paginate for a query that limits its own rows.
+var ErrQueryHasLimit = errors.New("the query has its own LIMIT, TOP, OFFSET or FETCH: remove it to page with offset and limit, or drop offset and limit")
+
+// rowLimits are the top-level keywords with which a query limits its own rows.
+var rowLimits = map[string]bool{"LIMIT": true, "TOP": true, "FETCH": true, "OFFSET": true}
+
+// setOperations combine whole SELECT statements, which Paginate pages as one result.
+var setOperations = map[string]bool{"UNION": true, "INTERSECT": true, "EXCEPT": true, "MINUS": true}
+
+// Paginate rewrites a single SELECT statement to return at most limit rows after
+// skipping offset. The statement is wrapped as "SELECT * FROM (query) AS page LIMIT n
+// OFFSET m", or, when top is set (SQL Server), given "OFFSET m ROWS FETCH NEXT n ROWS
+// ONLY" after its ORDER BY, or after ORDER BY (SELECT NULL) if it has none. It returns
+// ErrQueryHasLimit if the statement already limits its rows.
+func Paginate(query string, limit, offset int, top bool) (string, error) {
+	words, end, ok := topLevelWords(query)
+	if !ok || len(words) == 0 || words[0].text != "SELECT" && words[0].text != "WITH" {
+		return "", errors.New("offset and limit only apply to a single SELECT statement")
+	}
+	ordered := false
+	for i, w := range words {
+		switch {
+		case rowLimits[w.text]:
+			return "", ErrQueryHasLimit
+		case limitBlockers[w.text] && !setOperations[w.text]:
+			return "", fmt.Errorf("offset and limit cannot be applied to a query with %s", w.text)
+		case w.text == "ORDER" && i+1 < len(words) && words[i+1].text == "BY":
+			ordered = true
+		}
+	}
+
+	// The closing parenthesis and clauses go on their own line, so a trailing -- comment cannot swallow them.
+	body := strings.TrimRightFunc(query[:end], unicode.IsSpace)
+	if !top {
+		return fmt.Sprintf("SELECT * FROM (\n%s\n) AS page\nLIMIT %d OFFSET %d", body, limit, offset), nil
+	}
+	fetch := fmt.Sprintf("OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", offset, limit)
+	switch {
+	case ordered:
+		return body + "\n" + fetch, nil
+	case words[0].text == "WITH":
+		// SQL Server does not allow a WITH inside a derived table.
+		return body + "\nORDER BY (SELECT NULL) " + fetch, nil
+	default:
+		return fmt.Sprintf("SELECT * FROM (\n%s\n) AS page\nORDER BY (SELECT NULL) %s", body, fetch), nil
+	}
+}
+
+// sqlWord is an unquoted word outside parentheses, upper-cased, with the byte offset of its end.
+type sqlWord struct {
+	text string
+	end  int
+}
+
+// topLevelWords returns the unquoted words of query that are not nested in
+// parentheses, skipping string literals, quoted identifiers and comments. end is
+// the offset of the statement's end, before any trailing semicolon. ok is false if
+// the query holds more than one statement or has unbalanced quotes or parentheses.
+func topLevelWords(query string) (words []sqlWord, end int, ok bool) {
+	depth := 0
+	end = len(query)
+	for i := 0; i < len(query); {
+		c := query[i]
+		if end != len(query) && c != ';' && !unicode.IsSpace(rune(c)) && !strings.HasPrefix(query[i:], "--") && !strings.HasPrefix(query[i:], "/*") {
+			// Only comments and whitespace may follow the statement.
+			return nil, 0, false
+		}
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			j := strings.IndexByte(query[i+1:], c)
+			if j < 0 {
+				return nil, 0, false
+			}
+			i += j + 2
+		case c == '[':
+			j := strings.IndexByte(query[i+1:], ']')
+			if j < 0 {
+				return nil, 0, false
+			}
+			i += j + 2
+		case strings.HasPrefix(query[i:], "--"):
+			j := strings.IndexByte(query[i:], '\n')
+			if j < 0 {
+				i = len(query)
+			} else {
+				i += j + 1
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			j := strings.Index(query[i+2:], "*/")
+			if j < 0 {
+				return nil, 0, false
+			}
+			i += j + 4
+		case c == '$' && dollarTag(query[i:]) != "":
+			// PostgreSQL dollar-quoted string: $tag$ ... $tag$
+			tag := dollarTag(query[i:])
+			j := strings.Index(query[i+len(tag):], tag)
+			if j < 0 {
+				return nil, 0, false
+			}
+			i += len(tag) + j + len(tag)
+		case c == '(':
+			depth++
+			i++
+		case c == ')':
+			if depth--; depth < 0 {
+				return nil, 0, false
+			}
+			i++
+		case c == ';':
+			if depth == 0 && end == len(query) {
+				end = i
+			}
+			i++
+		default:
+			r, size := utf8.DecodeRuneInString(query[i:])
+			if !isIdentRune(r) {
+				i += size
+				continue
+			}
+			start := i
+			for i < len(query) {
+				r, size := utf8.DecodeRuneInString(query[i:])
+				if !isIdentRune(r) {
+					break
+				}
+				i += size
+			}
+			if depth == 0 {
+				words = append(words, sqlWord{text: strings.ToUpper(query[start:i]), end: i})
+			}
+		}
+	}
+	return words, end, depth == 0
+}
+
+// dollarTag returns the opening $tag$ at the start of s, or "" if there is none.
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '$':
+			return s[:i+1]
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 1 && c >= '0' && c <= '9':
+		default:
+			return ""
+		}
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package sqlcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjectLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		top   bool
+		want  string
+	}{
+		{"Select", "SELECT * FROM users", false, "SELECT * FROM users\nLIMIT 100"},
+		{"TrailingSemicolon", "SELECT * FROM users;  ", false, "SELECT * FROM users\nLIMIT 100"},
+		{"TrailingComment", "SELECT * FROM users -- all of them", false, "SELECT * FROM users -- all of them\nLIMIT 100"},
+		{"CTE", "WITH u AS (SELECT * FROM users) SELECT * FROM u", false, "WITH u AS (SELECT * FROM users) SELECT * FROM u\nLIMIT 100"},
+		{"NestedLimit", "SELECT * FROM (SELECT * FROM users LIMIT 5) u", false, "SELECT * FROM (SELECT * FROM users LIMIT 5) u\nLIMIT 100"},
+		{"KeywordInString", "SELECT 'union' FROM users", false, "SELECT 'union' FROM users\nLIMIT 100"},
+		{"Top", "SELECT * FROM users", true, "SELECT TOP 100 * FROM users"},
+		{"TopDistinct", "select distinct role from users;", true, "select distinct TOP 100 role from users"},
+
+		{"HasLimit", "SELECT * FROM users LIMIT 5", false, ""},
+		{"HasTop", "SELECT TOP 5 * FROM users", true, ""},
+		{"HasFetch", "SELECT * FROM users ORDER BY id OFFSET 0 ROWS FETCH NEXT 5 ROWS ONLY", true, ""},
+		{"Union", "SELECT id FROM users UNION SELECT id FROM orders", false, ""},
+		{"ForUpdate", "SELECT * FROM users FOR UPDATE", false, ""},
+		{"SelectInto", "SELECT * INTO backup FROM users", true, ""},
+		{"CTEWithTop", "WITH u AS (SELECT * FROM users) SELECT * FROM u", true, ""},
+		{"NotSelect", "SHOW TABLES", false, ""},
+		{"MultipleStatements", "SELECT 1; SELECT 2", false, ""},
+		{"Unbalanced", "SELECT (1 FROM users", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := InjectLimit(tt.query, 100, tt.top)
+			if tt.want == "" {
+				require.False(t, ok)
+				require.Equal(t, tt.query, got)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		top   bool
+		want  string
+	}{
+		{"Select", "SELECT * FROM users;", false, "SELECT * FROM (\nSELECT * FROM users\n) AS page\nLIMIT 10 OFFSET 20"},
+		{"TrailingComment", "SELECT * FROM users -- all of them", false, "SELECT * FROM (\nSELECT * FROM users -- all of them\n) AS page\nLIMIT 10 OFFSET 20"},
+		{"Union", "SELECT id FROM users UNION SELECT id FROM orders", false, "SELECT * FROM (\nSELECT id FROM users UNION SELECT id FROM orders\n) AS page\nLIMIT 10 OFFSET 20"},
+		{"NestedLimit", "SELECT * FROM (SELECT * FROM users LIMIT 5) u", false, "SELECT * FROM (\nSELECT * FROM (SELECT * FROM users LIMIT 5) u\n) AS page\nLIMIT 10 OFFSET 20"},
+		{"TopOrdered", "SELECT * FROM users ORDER BY id", true, "SELECT * FROM users ORDER BY id\nOFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY"},
+		{"TopUnordered", "SELECT * FROM users", true, "SELECT * FROM (\nSELECT * FROM users\n) AS page\nORDER BY (SELECT NULL) OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY"},
+		{"TopCTE", "WITH u AS (SELECT * FROM users) SELECT * FROM u", true, "WITH u AS (SELECT * FROM users) SELECT * FROM u\nORDER BY (SELECT NULL) OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Paginate(tt.query, 10, 20, tt.top)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	refused := []struct {
+		name  string
+		query string
+		top   bool
+	}{
+		{"HasLimit", "SELECT * FROM users LIMIT 5", false},
+		{"HasTop", "SELECT TOP 5 * FROM users", true},
+		{"HasFetch", "SELECT * FROM users ORDER BY id OFFSET 0 ROWS FETCH NEXT 5 ROWS ONLY", true},
+		{"ForUpdate", "SELECT * FROM users FOR UPDATE", false},
+		{"NotSelect", "SHOW TABLES", false},
+		{"MultipleStatements", "SELECT 1; SELECT 2", false},
+	}
+	for _, tt := range refused {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Paginate(tt.query, 10, 20, tt.top)
+			require.Error(t, err)
+		})
+	}
+	_, err := Paginate("SELECT * FROM users LIMIT 5", 10, 0, false)
+	require.ErrorIs(t, err, ErrQueryHasLimit)
+}
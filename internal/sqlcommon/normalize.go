@@ -0,0 +1,88 @@
+package sqlcommon
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NormalizeQuery replaces string and numeric literals in a SQL query with `?`
+// and collapses whitespace, so queries that differ only in their literal values
+// normalize to the same text (similar to pg_stat_statements and MySQL digests).
+func NormalizeQuery(query string) string {
+	var out strings.Builder
+	out.Grow(len(query))
+
+	r := []rune(query)
+	space := false
+	for i := 0; i < len(r); i++ {
+		c := r[i]
+
+		if unicode.IsSpace(c) {
+			space = true
+			continue
+		}
+		if space && out.Len() > 0 {
+			out.WriteByte(' ')
+		}
+		space = false
+
+		switch {
+		// String literals, including N'...' (T-SQL) and E'...' (PostgreSQL) prefixes.
+		case c == '\'' || ((c == 'N' || c == 'n' || c == 'E' || c == 'e') && i+1 < len(r) && r[i+1] == '\'' && !isIdentRune(prev(r, i))):
+			if c != '\'' {
+				i++
+			}
+			for i++; i < len(r); i++ {
+				if r[i] == '\'' {
+					if i+1 < len(r) && r[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			out.WriteByte('?')
+
+		// Numeric literals that are not part of an identifier (e.g. t1, col_2).
+		case unicode.IsDigit(c) && !isIdentRune(prev(r, i)):
+			for i+1 < len(r) && (unicode.IsDigit(r[i+1]) || r[i+1] == '.' || r[i+1] == 'x' || r[i+1] == 'X' || isHexRune(r[i+1])) {
+				i++
+			}
+			out.WriteByte('?')
+
+		// Quoted identifiers are copied verbatim.
+		case c == '"' || c == '`' || c == '[':
+			end := c
+			if c == '[' {
+				end = ']'
+			}
+			out.WriteRune(c)
+			for i++; i < len(r); i++ {
+				out.WriteRune(r[i])
+				if r[i] == end {
+					break
+				}
+			}
+
+		default:
+			out.WriteRune(c)
+		}
+	}
+
+	return out.String()
+}
+
+func prev(r []rune, i int) rune {
+	if i == 0 {
+		return ' '
+	}
+	return r[i-1]
+}
+
+func isIdentRune(c rune) bool {
+	return c == '_' || c == '@' || c == '$' || c == '#' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+func isHexRune(c rune) bool {
+	return (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,26 @@
+package sqlcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"Numbers", "SELECT * FROM orders WHERE id = 42 AND amount > 9.99", "SELECT * FROM orders WHERE id = ? AND amount > ?"},
+		{"Strings", "SELECT * FROM users WHERE name = 'O''Brien'", "SELECT * FROM users WHERE name = ?"},
+		{"UnicodeString", "SELECT * FROM users WHERE name = N'bob'", "SELECT * FROM users WHERE name = ?"},
+		{"Identifiers", "SELECT t1.col_2 FROM [table 1] t1", "SELECT t1.col_2 FROM [table 1] t1"},
+		{"Whitespace", "SELECT  *\n\tFROM   users ", "SELECT * FROM users"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, NormalizeQuery(tt.query))
+		})
+	}
+}
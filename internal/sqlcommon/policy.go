@@ -0,0 +1,156 @@
+package sqlcommon
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tinternet/databaise/internal/config"
+)
+
+// ErrQueryBlocked is returned for queries that a query policy refuses.
+var ErrQueryBlocked = errors.New("query blocked by policy")
+
+// QueryPolicy decides which queries may run, by matching them against regular
+// expressions after their comments are removed.
+type QueryPolicy struct {
+	allow []*regexp.Regexp
+	deny  []*regexp.Regexp
+}
+
+// NewQueryPolicy compiles the patterns of cfg. Patterns match case-insensitively.
+func NewQueryPolicy(cfg config.QueryPolicy) (*QueryPolicy, error) {
+	compile := func(patterns []string) ([]*regexp.Regexp, error) {
+		res := make([]*regexp.Regexp, 0, len(patterns))
+		for _, p := range patterns {
+			re, err := regexp.Compile("(?i)" + p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+			}
+			res = append(res, re)
+		}
+		return res, nil
+	}
+	allow, err := compile(cfg.Allow)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := compile(cfg.Deny)
+	if err != nil {
+		return nil, err
+	}
+	return &QueryPolicy{allow: allow, deny: deny}, nil
+}
+
+// Check returns an ErrQueryBlocked error if query matches a deny pattern, or
+// matches none of the allow patterns when there are any. Patterns are matched
+// with comments removed and whitespace collapsed, so neither can split a pattern.
+// Deny patterns are also matched against the query with its comments kept, in
+// case the database reads a comment differently than StripComments does.
+// A nil policy allows every query.
+func (p *QueryPolicy) Check(query string) error {
+	if p == nil {
+		return nil
+	}
+	text := StripComments(query)
+	raw := strings.Join(strings.Fields(query), " ")
+	for _, re := range p.deny {
+		if re.MatchString(text) || re.MatchString(raw) {
+			return fmt.Errorf("%w: it matches deny pattern %q", ErrQueryBlocked, strings.TrimPrefix(re.String(), "(?i)"))
+		}
+	}
+	if len(p.allow) == 0 {
+		return nil
+	}
+	for _, re := range p.allow {
+		if re.MatchString(text) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: it matches none of the allowed patterns", ErrQueryBlocked)
+}
+
+// StripComments removes -- and /* */ comments from query, replacing each with a
+// space, and collapses runs of whitespace into one space. String literals and
+// quoted identifiers are kept as they are. The text of MySQL executable comments
+// (/*! ... */) is kept, since MySQL runs it. Block comments are not nested: after
+// "/* /* */" the rest of the query is kept, which MySQL runs.
+func StripComments(query string) string {
+	var out strings.Builder
+	out.Grow(len(query))
+	executable := false
+	space := func() {
+		if s := out.String(); s != "" && s[len(s)-1] != ' ' {
+			out.WriteByte(' ')
+		}
+	}
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			space()
+
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end
+			}
+			space()
+
+		case c == '/' && strings.HasPrefix(query[i:], "/*!"):
+			// Skip the optional version number after the marker; the body stays.
+			i += 3
+			for i < len(query) && query[i] >= '0' && query[i] <= '9' {
+				i++
+			}
+			i--
+			executable = true
+			space()
+
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += 2 + end + 1
+			}
+			space()
+
+		case executable && c == '*' && strings.HasPrefix(query[i:], "*/"):
+			i++
+			executable = false
+			space()
+
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			end := c
+			if c == '[' {
+				end = ']'
+			}
+			j := i + 1
+			for j < len(query) {
+				if query[j] == end {
+					// A doubled quote is an escaped quote.
+					if end != ']' && j+1 < len(query) && query[j+1] == end {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			if j >= len(query) {
+				j = len(query) - 1
+			}
+			out.WriteString(query[i : j+1])
+			i = j
+
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return strings.TrimSpace(out.String())
+}
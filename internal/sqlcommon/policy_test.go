@@ -0,0 +1,87 @@
+package sqlcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinternet/databaise/internal/config"
+)
+
+func TestStripComments(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"LineComment", "SELECT * -- all columns\nFROM users", "SELECT * FROM users"},
+		{"BlockComment", "SELECT * FROM/* x */users", "SELECT * FROM users"},
+		{"MultilineBlock", "SELECT /*\n a\n b\n*/ 1", "SELECT 1"},
+		{"Unterminated", "SELECT 1 /* rest", "SELECT 1"},
+		{"MySQLExecutable", "SELECT * FROM /*!50000 secrets*/", "SELECT * FROM secrets"},
+		{"StringKept", "SELECT '-- not /* a comment */' AS s", "SELECT '-- not /* a comment */' AS s"},
+		{"EscapedQuote", "SELECT 'it''s -- here' x", "SELECT 'it''s -- here' x"},
+		{"QuotedIdentifiers", `SELECT "a--b", [c/*d*/], ` + "`e`", `SELECT "a--b", [c/*d*/], ` + "`e`"},
+		{"Whitespace", "  SELECT\t*\n\nFROM   users  ", "SELECT * FROM users"},
+		{"Division", "SELECT 4*/* x */2", "SELECT 4* 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, StripComments(tt.query))
+		})
+	}
+}
+
+func TestQueryPolicy(t *testing.T) {
+	policy, err := NewQueryPolicy(config.QueryPolicy{
+		Allow: []string{`^(SELECT|WITH)\b`},
+		Deny:  []string{`\bsecrets\b`, `FROM pg_shadow`},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		query   string
+		blocked bool
+	}{
+		{"Allowed", "SELECT * FROM users", false},
+		{"Denied", "SELECT * FROM secrets", true},
+		{"CaseInsensitive", "select * from SECRETS", true},
+		{"QuotedIdentifier", `SELECT * FROM "Secrets"`, true},
+		{"BlockCommentSplit", "SELECT * FROM/**/pg_shadow", true},
+		{"LineCommentSplit", "SELECT * FROM -- x\npg_shadow", true},
+		{"MySQLExecutableComment", "SELECT * FROM /*!secrets*/ s", true},
+		{"LeadingComment", "/* report */ SELECT 1", false},
+		{"NotAllowed", "SHOW TABLES", true},
+		{"AllowHiddenInComment", "/* SELECT */ SHOW TABLES", true},
+		// PostgreSQL reads '\'' as a backslash and a quote, so the rest of the
+		// line is not a comment. Deny patterns still see it in the raw query.
+		{"BackslashQuote", "SELECT '\\'' -- ' , x FROM secrets", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.query)
+			if tt.blocked {
+				require.ErrorIs(t, err, ErrQueryBlocked)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("DenyOnly", func(t *testing.T) {
+		policy, err := NewQueryPolicy(config.QueryPolicy{Deny: []string{`secrets`}})
+		require.NoError(t, err)
+		require.NoError(t, policy.Check("SHOW TABLES"))
+		require.ErrorContains(t, policy.Check("SELECT * FROM secrets"), `deny pattern "secrets"`)
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		var policy *QueryPolicy
+		require.NoError(t, policy.Check("SELECT * FROM secrets"))
+	})
+
+	t.Run("InvalidPattern", func(t *testing.T) {
+		_, err := NewQueryPolicy(config.QueryPolicy{Deny: []string{`(`}})
+		require.ErrorContains(t, err, "invalid pattern")
+	})
+}
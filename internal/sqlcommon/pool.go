@@ -0,0 +1,16 @@
+package sqlcommon
+
+import (
+	"github.com/tinternet/databaise/internal/config"
+	"gorm.io/gorm"
+)
+
+// ApplyPool sets the connection pool limits of p on db.
+func ApplyPool(db *gorm.DB, p config.Pool) error {
+	pool, err := db.DB()
+	if err != nil {
+		return err
+	}
+	p.Apply(pool)
+	return nil
+}
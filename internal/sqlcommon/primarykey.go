@@ -0,0 +1,172 @@
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// The primary key rows of each dialect, as the FROM and WHERE clauses of a query,
+// with the table filled in by fmt.Sprintf. GetPrimaryKey selects the key columns
+// from them and TablesWithoutPrimaryKey checks that there are none, so the two
+// agree on what counts as a primary key.
+const (
+	// postgresPrimaryKeyRows takes the oid of the table.
+	postgresPrimaryKeyRows = `pg_index i
+JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+WHERE i.indrelid = %s AND i.indisprimary`
+
+	// mysqlPrimaryKeyRows takes the database and the name of the table. MySQL
+	// always names the primary key PRIMARY.
+	mysqlPrimaryKeyRows = `information_schema.KEY_COLUMN_USAGE k
+WHERE k.TABLE_SCHEMA = %s AND k.TABLE_NAME = %s AND k.CONSTRAINT_NAME = 'PRIMARY'`
+
+	// sqlserverPrimaryKeyRows takes the object id of the table.
+	sqlserverPrimaryKeyRows = `sys.key_constraints kc
+JOIN sys.index_columns ic ON ic.object_id = kc.parent_object_id AND ic.index_id = kc.unique_index_id
+JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+WHERE kc.type = 'PK' AND kc.parent_object_id = %s`
+
+	// sqlitePrimaryKeyRows takes the name of the table and its schema.
+	sqlitePrimaryKeyRows = `pragma_table_info(%s, %s) p
+WHERE p.pk > 0`
+)
+
+// GetPrimaryKey returns the primary key columns of a table in key order, or an
+// empty slice if the table has no primary key. An empty schema means the
+// connection's default schema. It returns ErrTableNotFound if the table does not exist.
+func GetPrimaryKey(ctx context.Context, db *gorm.DB, schema, table string) ([]string, error) {
+	db = db.WithContext(ctx)
+	switch name := db.Dialector.Name(); name {
+	case "postgres":
+		return postgresPrimaryKey(db, schema, table)
+	case "mysql":
+		return mysqlPrimaryKey(db, schema, table)
+	case "sqlserver":
+		return sqlserverPrimaryKey(db, schema, table)
+	case "sqlite":
+		return sqlitePrimaryKey(db, schema, table)
+	default:
+		return nil, fmt.Errorf("primary key lookup is not supported for %s", name)
+	}
+}
+
+func postgresPrimaryKey(db *gorm.DB, schema, table string) ([]string, error) {
+	name := pgx.Identifier{table}.Sanitize()
+	if schema != "" {
+		name = pgx.Identifier{schema, table}.Sanitize()
+	}
+
+	var oid *int64
+	if err := db.Raw("SELECT to_regclass(?)::oid::bigint", name).Scan(&oid).Error; err != nil {
+		return nil, err
+	}
+	if oid == nil {
+		return nil, ErrTableNotFound
+	}
+
+	columns := []string{}
+	err := db.Raw("SELECT a.attname FROM "+fmt.Sprintf(postgresPrimaryKeyRows, "?")+`
+ORDER BY array_position(i.indkey::int2[], a.attnum)`, *oid).Scan(&columns).Error
+	return columns, err
+}
+
+func mysqlPrimaryKey(db *gorm.DB, schema, table string) ([]string, error) {
+	var exists bool
+	err := db.Raw(`SELECT COUNT(*) > 0 FROM information_schema.TABLES
+WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ?`, schema, table).Scan(&exists).Error
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTableNotFound
+	}
+
+	columns := []string{}
+	err = db.Raw("SELECT k.COLUMN_NAME FROM "+fmt.Sprintf(mysqlPrimaryKeyRows, "COALESCE(NULLIF(?, ''), DATABASE())", "?")+`
+ORDER BY k.ORDINAL_POSITION`, schema, table).Scan(&columns).Error
+	return columns, err
+}
+
+func sqlserverPrimaryKey(db *gorm.DB, schema, table string) ([]string, error) {
+	var id *int64
+	err := db.Raw("SELECT OBJECT_ID(QUOTENAME(COALESCE(NULLIF(?, ''), SCHEMA_NAME())) + '.' + QUOTENAME(?), 'U')", schema, table).Scan(&id).Error
+	if err != nil {
+		return nil, err
+	}
+	if id == nil {
+		return nil, ErrTableNotFound
+	}
+
+	columns := []string{}
+	err = db.Raw("SELECT c.name FROM "+fmt.Sprintf(sqlserverPrimaryKeyRows, "?")+`
+ORDER BY ic.key_ordinal`, *id).Scan(&columns).Error
+	return columns, err
+}
+
+func sqlitePrimaryKey(db *gorm.DB, schema, table string) ([]string, error) {
+	// pragma_table_info returns no rows for a table that does not exist.
+	var exists bool
+	err := db.Raw("SELECT COUNT(*) > 0 FROM pragma_table_info(?, COALESCE(NULLIF(?, ''), 'main'))", table, schema).Scan(&exists).Error
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTableNotFound
+	}
+
+	columns := []string{}
+	err = db.Raw("SELECT p.name FROM "+fmt.Sprintf(sqlitePrimaryKeyRows, "?", "COALESCE(NULLIF(?, ''), 'main')")+`
+ORDER BY p.pk`, table, schema).Scan(&columns).Error
+	return columns, err
+}
+
+// TablesWithoutPrimaryKey returns the base tables that have no primary key,
+// ordered by schema and name. An empty schema means every user schema in
+// PostgreSQL and SQL Server and the current database in MySQL; SQLite always
+// looks in main. Tables are returned with their schema in PostgreSQL and SQL Server, with the
+// given schema in MySQL and without one in SQLite. PostgreSQL partitions are
+// left out, since they are covered by their parent's primary key.
+func TablesWithoutPrimaryKey(ctx context.Context, db *gorm.DB, schema string) ([]TableName, error) {
+	db = db.WithContext(ctx)
+	tables := []TableName{}
+	var err error
+	switch name := db.Dialector.Name(); name {
+	case "postgres":
+		err = db.Raw(`SELECT n.nspname AS schema, c.relname AS name
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p') AND NOT c.relispartition
+  AND NOT EXISTS (SELECT 1 FROM `+fmt.Sprintf(postgresPrimaryKeyRows, "c.oid")+`)
+  AND CASE WHEN $1 = ''
+      THEN n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname NOT LIKE 'pg\_toast%' AND n.nspname NOT LIKE 'pg\_temp\_%'
+      ELSE n.nspname = $1
+  END
+ORDER BY n.nspname, c.relname`, schema).Scan(&tables).Error
+	case "mysql":
+		err = db.Raw(`SELECT ? AS `+"`schema`"+`, t.TABLE_NAME AS name
+FROM information_schema.TABLES t
+WHERE t.TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND t.TABLE_TYPE = 'BASE TABLE'
+  AND NOT EXISTS (SELECT 1 FROM `+fmt.Sprintf(mysqlPrimaryKeyRows, "t.TABLE_SCHEMA", "t.TABLE_NAME")+`)
+ORDER BY t.TABLE_NAME`, schema, schema).Scan(&tables).Error
+	case "sqlserver":
+		err = db.Raw(`SELECT SCHEMA_NAME(t.schema_id) AS [schema], t.name
+FROM sys.tables t
+WHERE t.is_ms_shipped = 0
+  AND NOT EXISTS (SELECT 1 FROM `+fmt.Sprintf(sqlserverPrimaryKeyRows, "t.object_id")+`)
+  AND SCHEMA_NAME(t.schema_id) = CASE @schema WHEN '' THEN SCHEMA_NAME(t.schema_id) ELSE @schema END
+ORDER BY [schema], t.name`, sql.Named("schema", schema)).Scan(&tables).Error
+	case "sqlite":
+		err = db.Raw(`SELECT '' AS schema, m.name
+FROM sqlite_master m
+WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%'
+  AND NOT EXISTS (SELECT 1 FROM ` + fmt.Sprintf(sqlitePrimaryKeyRows, "m.name", "'main'") + `)
+ORDER BY m.name`).Scan(&tables).Error
+	default:
+		return nil, fmt.Errorf("primary key lookup is not supported for %s", name)
+	}
+	return tables, err
+}
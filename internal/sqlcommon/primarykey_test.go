@@ -0,0 +1,49 @@
+package sqlcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGetPrimaryKey(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`
+		CREATE TABLE line_items (order_id INTEGER, line INTEGER, sku TEXT, PRIMARY KEY (line, order_id));
+		CREATE TABLE events (payload TEXT);
+	`).Error)
+
+	t.Run("Composite", func(t *testing.T) {
+		columns, err := GetPrimaryKey(t.Context(), db, "", "line_items")
+		require.NoError(t, err)
+		require.Equal(t, []string{"line", "order_id"}, columns)
+	})
+
+	t.Run("NoPrimaryKey", func(t *testing.T) {
+		columns, err := GetPrimaryKey(t.Context(), db, "", "events")
+		require.NoError(t, err)
+		require.Empty(t, columns)
+	})
+
+	t.Run("TableNotFound", func(t *testing.T) {
+		_, err := GetPrimaryKey(t.Context(), db, "", "missing")
+		require.ErrorIs(t, err, ErrTableNotFound)
+	})
+}
+
+func TestTablesWithoutPrimaryKey(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`
+		CREATE TABLE line_items (order_id INTEGER, line INTEGER, PRIMARY KEY (line, order_id));
+		CREATE TABLE tags (name TEXT UNIQUE);
+		CREATE TABLE events (payload TEXT);
+	`).Error)
+
+	tables, err := TablesWithoutPrimaryKey(t.Context(), db, "")
+	require.NoError(t, err)
+	require.Equal(t, []TableName{{Name: "events"}, {Name: "tags"}}, tables)
+}
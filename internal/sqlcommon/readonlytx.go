@@ -0,0 +1,36 @@
+package sqlcommon
+
+import "fmt"
+
+// readStatements are the statement keywords allowed in a read-only transaction.
+var readStatements = map[string]bool{
+	"SELECT": true, "WITH": true, "SHOW": true, "DESCRIBE": true, "DESC": true,
+	"EXPLAIN": true, "TABLE": true, "VALUES": true,
+}
+
+// txEscapes are keywords that end or escape the surrounding transaction, or run
+// code the statement text does not show.
+var txEscapes = map[string]bool{
+	"COMMIT": true, "ROLLBACK": true, "BEGIN": true, "START": true, "SAVEPOINT": true,
+	"SAVE": true, "RELEASE": true, "EXEC": true, "EXECUTE": true, "CALL": true,
+}
+
+// CheckReadonlyTxStatement returns an ErrReadonlyViolation error unless query is a
+// single read statement that cannot leave the transaction it runs in. Backends whose
+// read-only transactions do not stop every write (MySQL commits implicitly before DDL;
+// SQL Server has no read-only transactions and relies on rolling back) call it first.
+func CheckReadonlyTxStatement(query string) error {
+	words, _, ok := topLevelWords(query)
+	if !ok {
+		return fmt.Errorf("%w: only a single statement with balanced quotes and parentheses can run in a read-only transaction", ErrReadonlyViolation)
+	}
+	if len(words) == 0 || !readStatements[words[0].text] {
+		return fmt.Errorf("%w: only SELECT, WITH, SHOW, DESCRIBE, EXPLAIN, TABLE and VALUES statements can run in a read-only transaction", ErrReadonlyViolation)
+	}
+	for _, w := range words {
+		if txEscapes[w.text] {
+			return fmt.Errorf("%w: %s is not allowed in a read-only transaction", ErrReadonlyViolation, w.text)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package sqlcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckReadonlyTxStatement(t *testing.T) {
+	for _, query := range []string{
+		"SELECT * FROM users",
+		"with u as (select 1) select * from u;",
+		"SHOW TABLES",
+		"SELECT 'COMMIT' AS word",
+	} {
+		require.NoError(t, CheckReadonlyTxStatement(query), query)
+	}
+
+	for _, query := range []string{
+		"CREATE TABLE t (id int)",
+		"DELETE FROM users",
+		"SELECT 1 COMMIT DELETE FROM users",
+		"SELECT 1; DROP TABLE users",
+		"SELECT 1 EXEC('DROP TABLE users')",
+		"SELECT 'unterminated",
+	} {
+		require.ErrorIs(t, CheckReadonlyTxStatement(query), ErrReadonlyViolation, query)
+	}
+}
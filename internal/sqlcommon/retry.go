@@ -0,0 +1,64 @@
+package sqlcommon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/tinternet/databaise/internal/logging"
+)
+
+var log = logging.New("sqlcommon")
+
+const (
+	// MaxDeadlockRetries is how many times a statement chosen as a deadlock victim is retried.
+	MaxDeadlockRetries = 3
+	deadlockBackoff    = 50 * time.Millisecond
+)
+
+// IsDeadlock reports whether err is a deadlock error from PostgreSQL (40P01),
+// MySQL (1213) or SQL Server (1205).
+func IsDeadlock(err error) bool {
+	var (
+		pgErr    *pgconn.PgError
+		mysqlErr *mysql.MySQLError
+		mssqlErr mssql.Error
+	)
+	switch {
+	case errors.As(err, &pgErr):
+		return pgErr.Code == "40P01"
+	case errors.As(err, &mysqlErr):
+		return mysqlErr.Number == 1213
+	case errors.As(err, &mssqlErr):
+		return mssqlErr.Number == 1205
+	}
+	return false
+}
+
+// RetryOnDeadlock runs fn and retries it with jittered exponential backoff while it
+// fails with a deadlock. The database rolls back the victim's statement, so the retry is safe.
+func RetryOnDeadlock(ctx context.Context, fn func() error) error {
+	err := fn()
+	for attempt := 0; attempt < MaxDeadlockRetries && IsDeadlock(err); attempt++ {
+		backoff := deadlockBackoff << attempt
+		backoff += rand.N(backoff)
+
+		log.Printf("Deadlock detected, retrying in %s (attempt %d/%d)", backoff, attempt+1, MaxDeadlockRetries)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		err = fn()
+	}
+
+	if IsDeadlock(err) {
+		return fmt.Errorf("deadlock persisted after %d retries: %w", MaxDeadlockRetries, err)
+	}
+	return err
+}
@@ -0,0 +1,48 @@
+package sqlcommon
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryOnDeadlock(t *testing.T) {
+	deadlock := &pgconn.PgError{Code: "40P01"}
+
+	t.Run("RecoversAfterRetry", func(t *testing.T) {
+		calls := 0
+		err := RetryOnDeadlock(t.Context(), func() error {
+			calls++
+			if calls < 2 {
+				return deadlock
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("RetriesExhausted", func(t *testing.T) {
+		calls := 0
+		err := RetryOnDeadlock(t.Context(), func() error {
+			calls++
+			return deadlock
+		})
+		require.ErrorIs(t, err, deadlock)
+		require.ErrorContains(t, err, "deadlock persisted after 3 retries")
+		require.Equal(t, MaxDeadlockRetries+1, calls)
+	})
+
+	t.Run("OtherErrorsNotRetried", func(t *testing.T) {
+		calls := 0
+		boom := errors.New("boom")
+		err := RetryOnDeadlock(t.Context(), func() error {
+			calls++
+			return boom
+		})
+		require.ErrorIs(t, err, boom)
+		require.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,146 @@
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+type maxResultBytesKey struct{}
+
+// WithMaxResultBytes returns a context that caps the serialized size of the rows
+// QueryRows collects. A limit of zero or less leaves results uncapped.
+func WithMaxResultBytes(ctx context.Context, limit int64) context.Context {
+	if limit <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, maxResultBytesKey{}, limit)
+}
+
+type maxRowsKey struct{}
+
+// WithMaxRows returns a context that caps the number of rows QueryRows collects.
+// A limit of zero or less leaves results uncapped.
+func WithMaxRows(ctx context.Context, limit int) context.Context {
+	if limit <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, maxRowsKey{}, limit)
+}
+
+type rowSinkKey struct{}
+
+// RowSink receives the rows of a query as QueryRows scans them.
+type RowSink interface {
+	// WriteHeader is called once with the result columns, before any row.
+	WriteHeader(columns []string) error
+	WriteRow(row map[string]any) error
+}
+
+// WithRowSink returns a context that makes QueryRows pass each row to sink instead
+// of collecting it, for results too large to hold in memory. The row and byte
+// caps do not apply to streamed rows.
+func WithRowSink(ctx context.Context, sink RowSink) context.Context {
+	return context.WithValue(ctx, rowSinkKey{}, sink)
+}
+
+// Rows is the result of QueryRows.
+type Rows struct {
+	// Columns lists the result columns in the order the query returned them.
+	Columns []string
+	// ColumnTypes describes Columns as reported by the driver, in the same order.
+	ColumnTypes []*sql.ColumnType
+	Rows        []map[string]any
+	Truncated   bool
+}
+
+// QueryRows runs a raw query and scans the result rows into maps.
+// It checks ctx between rows, so a client that disconnects or gives up stops the
+// scan and releases the connection instead of buffering rows nobody will read.
+// If ctx carries a byte cap (see WithMaxResultBytes), it stops adding rows before
+// their JSON size exceeds it and reports truncated. A row cap (see WithMaxRows)
+// stops it the same way once another row arrives past the cap.
+// String values that are not valid UTF-8 are transcoded from the charset set with
+// WithResultEncoding, or have their invalid sequences replaced, and boolean-like
+// columns are returned as bool if WithBoolNormalization is set.
+// If ctx carries a RowSink (see WithRowSink), rows go to it and Rows is left empty.
+func QueryRows(ctx context.Context, db *gorm.DB, query string, args ...any) (*Rows, error) {
+	limit, _ := ctx.Value(maxResultBytesKey{}).(int64)
+	maxRows, _ := ctx.Value(maxRowsKey{}).(int)
+	sink, _ := ctx.Value(rowSinkKey{}).(RowSink)
+	normalize := newUTF8Normalizer(ctx)
+
+	tx := db.WithContext(ctx)
+	rows, err := tx.Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	result := &Rows{Columns: columns, ColumnTypes: columnTypes, Rows: []map[string]any{}}
+	bools := newBoolNormalizer(ctx, columnTypes)
+	if sink != nil {
+		if err := sink.WriteHeader(columns); err != nil {
+			return nil, err
+		}
+	}
+
+	var size int64
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if sink == nil && maxRows > 0 && len(result.Rows) == maxRows {
+			result.Truncated = true
+			return result, nil
+		}
+		row := make(map[string]any)
+		if err := tx.ScanRows(rows, &row); err != nil {
+			return nil, err
+		}
+		normalize.row(row)
+		bools.row(row)
+
+		if sink != nil {
+			if err := sink.WriteRow(row); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if limit > 0 {
+			encoded, err := json.Marshal(row)
+			if err != nil {
+				return nil, err
+			}
+			// +1 for the separating comma in the rows array.
+			if size += int64(len(encoded)) + 1; size > limit {
+				result.Truncated = true
+				return result, nil
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, ctx.Err()
+}
+
+// BoundSQL renders query with args inlined in the dialect's literal syntax, for
+// showing the caller what ran. The result is for display only; never execute it.
+func BoundSQL(db *gorm.DB, query string, args ...any) string {
+	if len(args) == 0 {
+		return query
+	}
+	return db.Dialector.Explain(query, args...)
+}
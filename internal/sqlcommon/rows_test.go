@@ -0,0 +1,118 @@
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// cancelQuery is called by the cancel_at SQL function registered below.
+var cancelQuery context.CancelFunc
+
+func init() {
+	sql.Register("sqlite3_cancel", &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			// cancel_at(i, n) cancels the running query's context when row i reaches n.
+			return conn.RegisterFunc("cancel_at", func(i, n int64) int64 {
+				if i == n {
+					cancelQuery()
+				}
+				return i
+			}, false)
+		},
+	})
+}
+
+func TestQueryRows(t *testing.T) {
+	db, err := gorm.Open(sqlite.Dialector{DriverName: "sqlite3_cancel", DSN: ":memory:"}, &gorm.Config{})
+	require.NoError(t, err)
+
+	const series = "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 1000) "
+
+	t.Run("AllRows", func(t *testing.T) {
+		rows, err := QueryRows(t.Context(), db, series+"SELECT i FROM n")
+		require.NoError(t, err)
+		require.False(t, rows.Truncated)
+		require.Equal(t, []string{"i"}, rows.Columns)
+		require.Len(t, rows.Rows, 1000)
+
+		// Same values as gorm's own Scan into maps.
+		var want []map[string]any
+		require.NoError(t, db.Raw(series+"SELECT i FROM n").Scan(&want).Error)
+		require.Equal(t, want, rows.Rows)
+	})
+
+	t.Run("MaxResultBytes", func(t *testing.T) {
+		// Rows 1-9 encode as {"i":N}, 8 bytes each with the separator, so 6 fit in 50.
+		ctx := WithMaxResultBytes(t.Context(), 50)
+		rows, err := QueryRows(ctx, db, series+"SELECT i FROM n")
+		require.NoError(t, err)
+		require.True(t, rows.Truncated)
+		require.Len(t, rows.Rows, 6)
+	})
+
+	t.Run("MaxRows", func(t *testing.T) {
+		rows, err := QueryRows(WithMaxRows(t.Context(), 10), db, series+"SELECT i FROM n")
+		require.NoError(t, err)
+		require.True(t, rows.Truncated)
+		require.Len(t, rows.Rows, 10)
+
+		rows, err = QueryRows(WithMaxRows(t.Context(), 10), db, series+"SELECT i FROM n LIMIT 10")
+		require.NoError(t, err)
+		require.False(t, rows.Truncated)
+		require.Len(t, rows.Rows, 10)
+	})
+
+	t.Run("Booleans", func(t *testing.T) {
+		require.NoError(t, db.Exec("CREATE TABLE flags (a BOOL, b TINYINT(1), c BIT, d INTEGER, e TINYINT); INSERT INTO flags VALUES (1, 0, 2, 1, 1)").Error)
+		const query = "SELECT a, b, c, d, e FROM flags"
+
+		firstRow := func(ctx context.Context) string {
+			rows, err := QueryRows(ctx, db, query)
+			require.NoError(t, err)
+			encoded, err := json.Marshal(rows.Rows[0])
+			require.NoError(t, err)
+			return string(encoded)
+		}
+
+		require.JSONEq(t, `{"a": 1, "b": 0, "c": 2, "d": 1, "e": 1}`, firstRow(t.Context()))
+
+		// c holds 2, which is not a boolean, so it is left alone.
+		ctx := WithBoolNormalization(t.Context(), true)
+		require.JSONEq(t, `{"a": true, "b": false, "c": 2, "d": 1, "e": 1}`, firstRow(ctx))
+
+		// Named columns replace type detection, and only apply to TINYINT and BIT.
+		require.JSONEq(t, `{"a": 1, "b": 0, "c": 2, "d": 1, "e": true}`, firstRow(WithBoolColumns(ctx, []string{"c", "d", "e"})))
+	})
+
+	t.Run("Latin1Column", func(t *testing.T) {
+		// "café" stored as Latin1 bytes, which are not valid UTF-8.
+		require.NoError(t, db.Exec("CREATE TABLE legacy (name TEXT); INSERT INTO legacy VALUES (CAST(X'636166E9' AS TEXT))").Error)
+
+		rows, err := QueryRows(t.Context(), db, "SELECT name FROM legacy")
+		require.NoError(t, err)
+		require.Equal(t, "caf\uFFFD", rows.Rows[0]["name"])
+
+		latin1, err := LookupCharset("latin1")
+		require.NoError(t, err)
+		rows, err = QueryRows(WithResultEncoding(t.Context(), latin1), db, "SELECT name FROM legacy")
+		require.NoError(t, err)
+		require.Equal(t, "café", rows.Rows[0]["name"])
+	})
+
+	t.Run("CanceledMidIteration", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		cancelQuery = cancel
+
+		rows, err := QueryRows(ctx, db, series+"SELECT cancel_at(i, 10) AS i FROM n")
+		require.ErrorIs(t, err, context.Canceled)
+		require.Nil(t, rows)
+	})
+}
@@ -7,7 +7,13 @@ import (
 )
 
 var (
-	ErrTableNotFound = errors.New("the table does not exist")
+	ErrTableNotFound      = errors.New("the table does not exist")
+	ErrColumnNotFound     = errors.New("the column does not exist")
+	ErrDatabaseNotFound   = errors.New("database not found")
+	ErrAdminNotConfigured = errors.New("admin not configured")
+	ErrWriteNotConfigured = errors.New("write not configured")
+	ErrReadonlyViolation  = errors.New("write attempted on a read-only connection")
+	ErrPermissionDenied   = errors.New("permission denied")
 )
 
 // VerifyReadonly executes the given SQL query and checks if the result indicates readonly.
@@ -0,0 +1,48 @@
+package sqlcommon
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// writeStatements are the statement keywords write_query runs.
+var writeStatements = map[string]bool{"INSERT": true, "UPDATE": true, "DELETE": true}
+
+// CheckWriteStatement returns an error unless query is a single INSERT, UPDATE or
+// DELETE statement that cannot leave the transaction it runs in.
+func CheckWriteStatement(query string) error {
+	words, _, ok := topLevelWords(query)
+	if !ok {
+		return errors.New("only a single statement with balanced quotes and parentheses can be executed")
+	}
+	if len(words) == 0 || !writeStatements[words[0].text] {
+		return errors.New("only INSERT, UPDATE and DELETE statements can be executed: use execute_query to read and execute_ddl to change the schema")
+	}
+	for _, w := range words {
+		if txEscapes[w.text] {
+			return errors.New(w.text + " is not allowed in a write statement")
+		}
+	}
+	return nil
+}
+
+// ExecuteWrite runs a single INSERT, UPDATE or DELETE statement with params bound to
+// its ? placeholders and returns the number of rows it affected. A statement chosen
+// as a deadlock victim is retried.
+func ExecuteWrite(ctx context.Context, db *gorm.DB, query string, params []any) (int64, error) {
+	if err := CheckWriteStatement(query); err != nil {
+		return 0, err
+	}
+	var affected int64
+	err := RetryOnDeadlock(ctx, func() error {
+		res := db.WithContext(ctx).Exec(query, params...)
+		affected = res.RowsAffected
+		return res.Error
+	})
+	if err != nil {
+		return 0, HighlightSyntaxError(err, query, 0)
+	}
+	return affected, nil
+}
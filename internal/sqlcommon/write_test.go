@@ -0,0 +1,49 @@
+package sqlcommon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCheckWriteStatement(t *testing.T) {
+	for _, query := range []string{
+		"INSERT INTO users (name) VALUES ('COMMIT')",
+		"update users set active = false where id in (select user_id from orders);",
+		"DELETE FROM users WHERE id = ?",
+	} {
+		require.NoError(t, CheckWriteStatement(query), query)
+	}
+
+	for _, query := range []string{
+		"SELECT * FROM users",
+		"DROP TABLE users",
+		"DELETE FROM users; DROP TABLE orders",
+		"UPDATE users SET name = 'x' COMMIT",
+		"INSERT INTO users (name) VALUES ('unterminated)",
+	} {
+		require.Error(t, CheckWriteStatement(query), query)
+	}
+}
+
+func TestExecuteWrite(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)").Error)
+
+	affected, err := ExecuteWrite(t.Context(), db, "INSERT INTO users (name) VALUES (?), (?)", []any{"a", "b"})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, affected)
+
+	affected, err = ExecuteWrite(t.Context(), db, "UPDATE users SET name = 'c' WHERE name = ?", []any{"a"})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, affected)
+
+	_, err = ExecuteWrite(t.Context(), db, "DROP TABLE users", nil)
+	require.Error(t, err)
+	var count int64
+	require.NoError(t, db.Raw("SELECT count(*) FROM users").Scan(&count).Error)
+	require.EqualValues(t, 2, count)
+}
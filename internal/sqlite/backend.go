@@ -1,27 +1,36 @@
 package sqlite
 
 import (
+	"cmp"
 	"context"
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/tinternet/databaise/internal/backend"
+	"github.com/tinternet/databaise/internal/config"
 	"github.com/tinternet/databaise/internal/logging"
 	"github.com/tinternet/databaise/internal/sqlcommon"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var log = logging.New("sqlite")
 
 // ReadConfig for read connections.
 type ReadConfig struct {
+	config.Pool
 	Path string `json:"path"`
 }
 
 // AdminConfig for admin connections.
 type AdminConfig struct {
+	config.Pool
 	Path string `json:"path"`
 }
 
@@ -30,6 +39,10 @@ type Factory struct{}
 
 func (Factory) Dialect() string { return "SQLite" }
 
+func (Factory) UnsupportedTools() []string {
+	return []string{"list_waiting_queries", "list_active_connections", "list_slowest_queries", "list_deadlocks", "kill_idle_transactions", "table_profile", "set_comment"}
+}
+
 func (Factory) New(db *gorm.DB) backend.SQLBackend {
 	return &Backend{db: db}
 }
@@ -40,13 +53,30 @@ type Connector struct{}
 func (Connector) ConnectRead(c ReadConfig) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("%s?mode=ro", c.Path)
 	log.Printf("Opening readonly connection [path=%s]", c.Path)
-	return gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logging.NewGormLogger()})
+	return open(dsn, c.Pool)
+}
+
+// CheckReadonly reports the read connection as read-only: it is opened with mode=ro,
+// which SQLite enforces for every statement.
+func (Connector) CheckReadonly(c ReadConfig, db *gorm.DB) backend.ReadonlyStatus {
+	return backend.ReadonlyStatus{Mode: backend.ReadonlyFile, Verified: true, CheckedAt: time.Now()}
 }
 
 func (Connector) ConnectAdmin(c AdminConfig) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("%s?mode=rw", c.Path)
 	log.Printf("Opening admin connection [path=%s]", c.Path)
-	return gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logging.NewGormLogger()})
+	return open(dsn, c.Pool)
+}
+
+func open(dsn string, pool config.Pool) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logging.NewGormLogger()})
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlcommon.ApplyPool(db, pool); err != nil {
+		return nil, err
+	}
+	return db, nil
 }
 
 func init() {
@@ -70,16 +100,77 @@ func (b *Backend) ListTables(ctx context.Context, in backend.ListTablesIn) ([]ba
 	result := make([]backend.Table, len(tables))
 	for i, t := range tables {
 		result[i] = backend.Table{Name: t}
+		// SQLite keeps no row statistics, so the rows are counted.
+		if in.WithStats {
+			err := b.db.WithContext(ctx).Raw("SELECT COUNT(*) FROM ?", clause.Table{Name: t}).Scan(&result[i].EstimatedRows).Error
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 	return result, nil
 }
 
+func (b *Backend) ListTablesWithoutPK(ctx context.Context, in backend.ListTablesWithoutPKIn) ([]backend.Table, error) {
+	names, err := sqlcommon.TablesWithoutPrimaryKey(ctx, b.db, in.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]backend.Table, len(names))
+	for i, name := range names {
+		tables[i] = backend.Table{Schema: name.Schema, Name: name.Name}
+	}
+	return tables, nil
+}
+
+// ListSequences returns the counter of each AUTOINCREMENT table, kept in
+// sqlite_sequence once a row has been inserted.
+func (b *Backend) ListSequences(ctx context.Context, in backend.ListSequencesIn) ([]backend.Sequence, error) {
+	sequences := []backend.Sequence{}
+	var exists bool
+	if err := b.db.WithContext(ctx).Raw("SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = 'sqlite_sequence'").Scan(&exists).Error; err != nil || !exists {
+		return sequences, err
+	}
+	err := b.db.WithContext(ctx).Raw(`SELECT s.name, s.name AS "table", (SELECT p.name FROM pragma_table_info(s.name) p WHERE p.pk = 1) AS "column",
+	s.seq AS last_value, s.seq + 1 AS next_value, 1 AS increment, 9223372036854775807 AS max_value
+FROM sqlite_sequence s
+ORDER BY s.name`).Scan(&sequences).Error
+	return sequences, err
+}
+
+// ListSchemas returns main and the attached databases, and temp if it is in use
+// and system schemas are asked for.
+func (b *Backend) ListSchemas(ctx context.Context, in backend.ListSchemasIn) ([]string, error) {
+	schemas := []string{}
+	err := b.db.WithContext(ctx).Raw("SELECT name FROM pragma_database_list WHERE ? OR name <> 'temp' ORDER BY seq", in.IncludeSystem).Scan(&schemas).Error
+	return schemas, err
+}
+
+func (b *Backend) ListViews(ctx context.Context, in backend.ListViewsIn) ([]backend.View, error) {
+	views := []backend.View{}
+	err := b.db.WithContext(ctx).Raw("SELECT name, sql AS definition FROM sqlite_master WHERE type = 'view' ORDER BY name").Scan(&views).Error
+	return views, err
+}
+
 //go:embed ddl_table.sql
 var ddlCreateTableQuery string
 
 //go:embed ddl_indexes.sql
 var ddlCreateIndexesQuery string
 
+//go:embed inbound_foreign_keys.sql
+var inboundForeignKeysQuery string
+
+//go:embed list_foreign_keys.sql
+var listForeignKeysQuery string
+
+func (b *Backend) ListForeignKeys(ctx context.Context, in backend.ListForeignKeysIn) ([]backend.ForeignKey, error) {
+	foreignKeys := []backend.ForeignKey{}
+	err := b.db.WithContext(ctx).Raw(listForeignKeysQuery, in.Table, in.Table).Scan(&foreignKeys).Error
+	return foreignKeys, err
+}
+
 func (b *Backend) DescribeTable(ctx context.Context, in backend.DescribeTableIn) (*backend.TableDescription, error) {
 	var out backend.TableDescription
 
@@ -94,15 +185,60 @@ func (b *Backend) DescribeTable(ctx context.Context, in backend.DescribeTableIn)
 		return nil, err
 	}
 
+	if err := b.db.WithContext(ctx).Raw(listForeignKeysQuery, in.Table, in.Table).Scan(&out.ForeignKeys).Error; err != nil {
+		return nil, err
+	}
+
+	if in.IncludeReferencedBy {
+		if err := b.db.WithContext(ctx).Raw(inboundForeignKeysQuery, in.Table).Scan(&out.ReferencedBy).Error; err != nil {
+			return nil, err
+		}
+	}
+
 	return &out, nil
 }
 
+func (b *Backend) ProfileCategoricalColumns(ctx context.Context, in backend.ProfileCategoricalColumnsIn) (*backend.ProfileCategoricalColumnsOut, error) {
+	name := in.Table
+	if in.Schema != "" {
+		name = in.Schema + "." + in.Table
+	}
+	columns, skipped, err := sqlcommon.ProfileCategoricalColumns(ctx, b.db, clause.Table{Name: name}, in.MaxDistinct)
+	if err != nil {
+		return nil, err
+	}
+	return &backend.ProfileCategoricalColumnsOut{Columns: columns, Skipped: skipped}, nil
+}
+
+func (b *Backend) TableJSONSchema(ctx context.Context, in backend.TableJSONSchemaIn) (*backend.TableJSONSchemaOut, error) {
+	columns, err := sqlcommon.GetColumns(ctx, b.db, in.Schema, in.Table)
+	if err != nil {
+		return nil, err
+	}
+	title := in.Table
+	if in.Schema != "" {
+		title = in.Schema + "." + in.Table
+	}
+	return &backend.TableJSONSchemaOut{JSONSchema: sqlcommon.TableJSONSchema("sqlite", title, columns)}, nil
+}
+
+func (b *Backend) SampleTable(ctx context.Context, in backend.SampleTableIn) (*backend.QueryResult, error) {
+	name := in.Table
+	if in.Schema != "" {
+		name = in.Schema + "." + in.Table
+	}
+	return b.ExecuteQuery(ctx, backend.ReadQueryIn{Query: fmt.Sprintf("SELECT * FROM %s LIMIT %d", b.db.Statement.Quote(clause.Table{Name: name}), in.Limit)})
+}
+
 func (b *Backend) ExecuteQuery(ctx context.Context, in backend.ReadQueryIn) (*backend.QueryResult, error) {
-	var rows []map[string]any
-	if err := b.db.WithContext(ctx).Raw(in.Query).Scan(&rows).Error; err != nil {
+	if in.Schema != "" {
+		return nil, fmt.Errorf("the schema parameter of execute_query is only supported for MySQL: qualify table names with their schema instead")
+	}
+	rows, err := sqlcommon.QueryRows(ctx, b.db, in.Query)
+	if err != nil {
 		return nil, err
 	}
-	return &backend.QueryResult{Rows: rows}, nil
+	return backend.NewQueryResult(in.Query, rows), nil
 }
 
 func (b *Backend) ExplainQuery(ctx context.Context, in backend.ExplainQueryIn) (*backend.ExplainResult, error) {
@@ -111,8 +247,9 @@ func (b *Backend) ExplainQuery(ctx context.Context, in backend.ExplainQueryIn) (
 		suffix = " QUERY PLAN"
 	}
 
+	explainQuery := "EXPLAIN" + suffix + " " + in.Query
 	var plan []map[string]any
-	if err := b.db.WithContext(ctx).Raw("EXPLAIN"+suffix+" "+in.Query).Scan(&plan).Error; err != nil {
+	if err := b.db.WithContext(ctx).Raw(explainQuery, in.Params...).Scan(&plan).Error; err != nil {
 		return nil, err
 	}
 
@@ -121,23 +258,167 @@ func (b *Backend) ExplainQuery(ctx context.Context, in backend.ExplainQueryIn) (
 		return nil, err
 	}
 
+	var steps []planStep
+	if err := b.db.WithContext(ctx).Raw("EXPLAIN QUERY PLAN "+in.Query, in.Params...).Scan(&steps).Error; err != nil {
+		return nil, err
+	}
+	scans := b.fullScans(ctx, steps)
+
 	return &backend.ExplainResult{
-		Format:     "json",
-		Result:     string(planJson),
-		ResultInfo: "The query plan of sqlite query",
+		Format:           "json",
+		Result:           string(planJson),
+		ResultInfo:       "The query plan of sqlite query",
+		FullScanDetected: len(scans) > 0,
+		FullScans:        scans,
+		Plan:             planTree(steps),
+		ExecutedSQL:      sqlcommon.BoundSQL(b.db, explainQuery, in.Params...),
 	}, nil
 }
 
+// fullScans returns the tables a query scans in full according to EXPLAIN QUERY PLAN.
+// SQLite plans carry no row estimates, so the row count comes from sqlite_stat1 and
+// is only known for tables that have been analyzed.
+func (b *Backend) fullScans(ctx context.Context, steps []planStep) []backend.TableScan {
+	var scans []backend.TableScan
+	for _, table := range scannedTables(steps) {
+		scan := backend.TableScan{Table: table}
+		var stat string
+		if err := b.db.WithContext(ctx).Raw("SELECT stat FROM sqlite_stat1 WHERE tbl = ? LIMIT 1", scan.Table).Scan(&stat).Error; err == nil {
+			if n, _, _ := strings.Cut(stat, " "); n != "" {
+				scan.EstimatedRows, _ = strconv.ParseFloat(n, 64)
+			}
+		}
+		scans = append(scans, scan)
+	}
+	return scans
+}
+
 func (b *Backend) ExecuteDDL(ctx context.Context, in backend.ExecuteDDLIn) (*backend.DDLResult, error) {
 	if err := b.db.WithContext(ctx).Exec(in.DDL).Error; err != nil {
 		return nil, err
 	}
-	return &backend.DDLResult{Success: true, Message: "DDL executed successfully"}, nil
+	return &backend.DDLResult{Success: true, Message: "DDL executed successfully", ExecutedSQL: in.DDL}, nil
+}
+
+func (b *Backend) ExecuteWrite(ctx context.Context, in backend.ExecuteWriteIn) (*backend.WriteResult, error) {
+	affected, err := sqlcommon.ExecuteWrite(ctx, b.db, in.Query, in.Params)
+	if err != nil {
+		return nil, err
+	}
+	return &backend.WriteResult{RowsAffected: affected, ExecutedSQL: sqlcommon.BoundSQL(b.db, in.Query, in.Params...)}, nil
+}
+
+func (b *Backend) CheckDDL(ctx context.Context, in backend.CheckDDLIn) (*sqlcommon.DDLCheckReport, error) {
+	return sqlcommon.CheckDDL(ctx, b.db, in.DDL)
+}
+
+func (b *Backend) RecommendIndexes(ctx context.Context, in backend.RecommendIndexesIn) (*sqlcommon.IndexAdvice, error) {
+	return sqlcommon.RecommendIndexes(ctx, b.db, in.Query)
 }
 
-// SQLite doesn't have built-in missing index recommendations
+//go:embed index_columns.sql
+var indexColumnsQuery string
+
+//go:embed table_rows.sql
+var tableRowsQuery string
+
+// ListMissingIndexes reports foreign keys whose columns no index starts with.
+// SQLite keeps no query statistics, so the schema is all there is to go on, but
+// an unindexed foreign key makes every join through it, and every delete or key
+// update of a referenced row, scan the referencing table. Tables are ranked by
+// their sqlite_stat1 row count, which is only known after ANALYZE.
 func (b *Backend) ListMissingIndexes(ctx context.Context) ([]backend.MissingIndex, error) {
-	return nil, fmt.Errorf("missing index recommendations are not available for SQLite")
+	db := b.db.WithContext(ctx)
+
+	var foreignKeys []backend.ForeignKey
+	if err := db.Raw(listForeignKeysQuery, "", "").Scan(&foreignKeys).Error; err != nil {
+		return nil, err
+	}
+
+	var indexColumns []struct {
+		TableName  string
+		IndexName  string
+		ColumnName string
+	}
+	if err := db.Raw(indexColumnsQuery).Scan(&indexColumns).Error; err != nil {
+		return nil, err
+	}
+	// indexes maps each table to the columns of each of its indexes, in index order.
+	indexes := make(map[string]map[string][]string)
+	for _, c := range indexColumns {
+		if indexes[c.TableName] == nil {
+			indexes[c.TableName] = make(map[string][]string)
+		}
+		indexes[c.TableName][c.IndexName] = append(indexes[c.TableName][c.IndexName], c.ColumnName)
+	}
+
+	rowCounts := make(map[string]int64)
+	var hasStats bool
+	if err := db.Raw("SELECT count(*) > 0 FROM sqlite_master WHERE name = 'sqlite_stat1'").Scan(&hasStats).Error; err != nil {
+		return nil, err
+	}
+	if hasStats {
+		var counts []struct {
+			TableName string
+			RowCount  int64
+		}
+		if err := db.Raw(tableRowsQuery).Scan(&counts).Error; err != nil {
+			return nil, err
+		}
+		for _, c := range counts {
+			rowCounts[c.TableName] = c.RowCount
+		}
+	}
+
+	result := []backend.MissingIndex{}
+	for _, fk := range foreignKeys {
+		columns := strings.Split(fk.Columns, ", ")
+		if hasLeadingIndex(indexes[fk.Table], columns) {
+			continue
+		}
+		reason := fmt.Sprintf("foreign key (%s) to %s has no index, so joins from %s and deletes or key updates there scan %s",
+			fk.Columns, fk.ReferencedTable, fk.ReferencedTable, fk.Table)
+		rows, ok := rowCounts[fk.Table]
+		if ok {
+			reason += fmt.Sprintf(" (%d rows)", rows)
+		}
+		quoted := make([]string, len(columns))
+		for i, c := range columns {
+			quoted[i] = quoteIdent(c)
+		}
+		result = append(result, backend.MissingIndex{
+			TableName:       fk.Table,
+			Reason:          reason,
+			EstimatedImpact: float64(rows),
+			Suggestion: fmt.Sprintf("CREATE INDEX %s ON %s (%s)",
+				quoteIdent("idx_"+fk.Table+"_"+strings.Join(columns, "_")), quoteIdent(fk.Table), strings.Join(quoted, ", ")),
+		})
+	}
+	slices.SortStableFunc(result, func(a, b backend.MissingIndex) int {
+		return cmp.Compare(b.EstimatedImpact, a.EstimatedImpact)
+	})
+	return result, nil
+}
+
+// hasLeadingIndex returns true if one of indexes starts with columns, in any order.
+func hasLeadingIndex(indexes map[string][]string, columns []string) bool {
+	for _, index := range indexes {
+		if len(index) < len(columns) {
+			continue
+		}
+		leading := index[:len(columns)]
+		if !slices.ContainsFunc(columns, func(c string) bool {
+			return !slices.ContainsFunc(leading, func(l string) bool { return strings.EqualFold(l, c) })
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteIdent quotes an SQLite identifier.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
 }
 
 // SQLite doesn't have query monitoring
@@ -145,6 +426,11 @@ func (b *Backend) ListWaitingQueries(ctx context.Context) ([]backend.WaitingQuer
 	return nil, fmt.Errorf("waiting query monitoring is not available for SQLite")
 }
 
+// SQLite is an embedded library, with no server to connect to
+func (b *Backend) ListConnections(ctx context.Context) ([]backend.Connection, error) {
+	return nil, fmt.Errorf("connection listing is not available for SQLite")
+}
+
 // SQLite doesn't have query statistics
 func (b *Backend) ListSlowestQueries(ctx context.Context) (*backend.SlowQueryResult, error) {
 	return nil, fmt.Errorf("slow query statistics are not available for SQLite")
@@ -154,3 +440,43 @@ func (b *Backend) ListSlowestQueries(ctx context.Context) (*backend.SlowQueryRes
 func (b *Backend) ListDeadlocks(ctx context.Context) ([]backend.Deadlock, error) {
 	return nil, fmt.Errorf("deadlock detection is not available for SQLite")
 }
+
+func (b *Backend) SetComment(ctx context.Context, in backend.SetCommentIn) (*backend.SetCommentOut, error) {
+	return nil, fmt.Errorf("comments are not available for SQLite")
+}
+
+// SQLite has no server sessions
+func (b *Backend) KillIdleTransactions(ctx context.Context, in backend.KillIdleTransactionsIn) (*backend.KillIdleTransactionsOut, error) {
+	return nil, fmt.Errorf("killing idle transactions is not available for SQLite")
+}
+
+// SQLite keeps statistics in sqlite_stat1 but does not record when they were gathered.
+func (b *Backend) AnalyzeTable(ctx context.Context, in backend.AnalyzeTableIn) (*backend.AnalyzeTableOut, error) {
+	if in.Table == "" {
+		// ANALYZE with a schema name analyzes every table of that attached database.
+		query, args, scope := "ANALYZE", []any{}, "the database"
+		if in.Schema != "" {
+			query, args, scope = "ANALYZE ?", []any{clause.Table{Name: in.Schema}}, in.Schema
+		}
+		if err := b.db.WithContext(ctx).Exec(query, args...).Error; err != nil {
+			return nil, err
+		}
+		return &backend.AnalyzeTableOut{Success: true, Message: fmt.Sprintf("Statistics refreshed for every table in %s", scope)}, nil
+	}
+	var exists bool
+	if err := b.db.WithContext(ctx).Raw("SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?", in.Table).Scan(&exists).Error; err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sqlcommon.ErrTableNotFound
+	}
+	if err := b.db.WithContext(ctx).Exec("ANALYZE ?", clause.Table{Name: in.Table}).Error; err != nil {
+		return nil, err
+	}
+	return &backend.AnalyzeTableOut{Success: true, Message: fmt.Sprintf("Statistics refreshed for %s", in.Table)}, nil
+}
+
+// SQLite doesn't track table usage statistics
+func (b *Backend) TableProfile(ctx context.Context, in backend.TableProfileIn) (*backend.TableProfile, error) {
+	return nil, fmt.Errorf("table profiles are not available for SQLite")
+}
@@ -0,0 +1,12 @@
+SELECT
+  m.name AS table_name,
+  group_concat(p."from", ', ') AS columns,
+  group_concat(p."to", ', ') AS referenced_columns,
+  p.on_delete AS on_delete,
+  p.on_update AS on_update
+FROM sqlite_master m
+JOIN pragma_foreign_key_list(m.name) p
+WHERE m.type = 'table'
+  AND p."table" = ?
+GROUP BY m.name, p.id
+ORDER BY m.name, p.id;
@@ -0,0 +1,19 @@
+SELECT
+  m.name AS table_name,
+  il.name AS index_name,
+  ii.seqno AS seqno,
+  COALESCE(ii.name, '') AS column_name
+FROM sqlite_master m
+JOIN pragma_index_list(m.name) il
+JOIN pragma_index_info(il.name) ii
+WHERE m.type = 'table'
+UNION ALL
+-- An INTEGER PRIMARY KEY is an alias for the rowid, which needs no index.
+SELECT m.name, '', 0, c.name
+FROM sqlite_master m
+JOIN pragma_table_info(m.name) c
+WHERE m.type = 'table'
+  AND c.pk = 1
+  AND upper(c.type) = 'INTEGER'
+  AND (SELECT count(*) FROM pragma_table_info(m.name) WHERE pk > 0) = 1
+ORDER BY 1, 2, 3;
@@ -4,6 +4,7 @@ package sqlite
 
 import (
 	"os"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -40,6 +41,7 @@ func TestConnect(t *testing.T) {
 		db, err := Connector{}.ConnectRead(ReadConfig{Path: file})
 		require.NotNil(t, db)
 		require.NoError(t, err)
+		require.Equal(t, backend.ReadonlyFile, Connector{}.CheckReadonly(ReadConfig{Path: file}, db).Mode)
 	})
 
 	t.Run("Admin", func(t *testing.T) {
@@ -61,6 +63,92 @@ func TestListTables(t *testing.T) {
 	})
 }
 
+func TestListTablesWithStats(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	tables, err := b.ListTables(t.Context(), backend.ListTablesIn{WithStats: true})
+	require.NoError(t, err)
+	i := slices.IndexFunc(tables, func(tbl backend.Table) bool { return tbl.Name == "users" })
+	require.GreaterOrEqual(t, i, 0)
+	require.EqualValues(t, 3, tables[i].EstimatedRows)
+	require.Zero(t, tables[i].SizeBytes)
+}
+
+func TestListTablesWithoutPK(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	require.NoError(t, b.db.Exec("CREATE TABLE events (payload text)").Error)
+	require.NoError(t, b.db.Exec("CREATE TABLE tags (name text UNIQUE)").Error)
+
+	tables, err := b.ListTablesWithoutPK(t.Context(), backend.ListTablesWithoutPKIn{})
+	require.NoError(t, err)
+	require.Equal(t, []backend.Table{{Name: "events"}, {Name: "tags"}}, tables)
+}
+
+func TestListSequences(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	sequences, err := b.ListSequences(t.Context(), backend.ListSequencesIn{})
+	require.NoError(t, err)
+	require.Len(t, sequences, 2)
+	require.Equal(t, "users", sequences[1].Name)
+	require.Equal(t, "id", sequences[1].Column)
+	require.EqualValues(t, 3, *sequences[1].LastValue)
+	require.EqualValues(t, 4, *sequences[1].NextValue)
+}
+
+func TestListSchemas(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	schemas, err := b.ListSchemas(t.Context(), backend.ListSchemasIn{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"main"}, schemas)
+
+	require.NoError(t, b.db.Exec("CREATE TEMP TABLE scratch (id INTEGER)").Error)
+	schemas, err = b.ListSchemas(t.Context(), backend.ListSchemasIn{IncludeSystem: true})
+	require.NoError(t, err)
+	require.Contains(t, schemas, "temp")
+}
+
+func TestListViews(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	require.NoError(t, b.db.Exec("CREATE VIEW admins AS SELECT username, email FROM users WHERE role = 'admin'").Error)
+
+	views, err := b.ListViews(t.Context(), backend.ListViewsIn{})
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	require.Equal(t, "admins", views[0].Name)
+	require.Contains(t, views[0].Definition, "users")
+}
+
+func TestListForeignKeys(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	foreignKeys, err := b.ListForeignKeys(t.Context(), backend.ListForeignKeysIn{})
+	require.NoError(t, err)
+	require.Len(t, foreignKeys, 1)
+	fk := foreignKeys[0]
+	require.Equal(t, "orders", fk.Table)
+	require.Equal(t, "user_id", fk.Columns)
+	require.Equal(t, "users", fk.ReferencedTable)
+	require.Equal(t, "id", fk.ReferencedColumns)
+	require.NotEmpty(t, fk.OnUpdate)
+	require.NotEmpty(t, fk.OnDelete)
+
+	foreignKeys, err = b.ListForeignKeys(t.Context(), backend.ListForeignKeysIn{Table: "users"})
+	require.NoError(t, err)
+	require.Empty(t, foreignKeys)
+
+	res, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Table: "orders"})
+	require.NoError(t, err)
+	require.Len(t, res.ForeignKeys, 1)
+	require.Equal(t, "users", res.ForeignKeys[0].ReferencedTable)
+}
+
 func TestDescribeTable(t *testing.T) {
 	t.Parallel()
 	b := openTestConnection(t)
@@ -81,6 +169,16 @@ func TestDescribeTable(t *testing.T) {
 			assert.Contains(t, i, "INDEX")
 		}
 	})
+	t.Run("ReferencedBy", func(t *testing.T) {
+		t.Parallel()
+		res, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Table: "users", IncludeReferencedBy: true})
+		require.NoError(t, err)
+		require.Len(t, res.ReferencedBy, 1)
+		require.Equal(t, "orders", res.ReferencedBy[0].Table)
+		require.Equal(t, "user_id", res.ReferencedBy[0].Columns)
+		require.Equal(t, "id", res.ReferencedBy[0].ReferencedColumns)
+		require.NotEmpty(t, res.ReferencedBy[0].OnDelete)
+	})
 	t.Run("NonExistentTable", func(t *testing.T) {
 		t.Parallel()
 		res, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Table: "nonexistent"})
@@ -108,6 +206,15 @@ func TestExecuteQuery(t *testing.T) {
 		require.NoError(t, err)
 		require.Len(t, res.Rows, 0)
 	})
+
+	t.Run("Column Types", func(t *testing.T) {
+		res, err := b.ExecuteQuery(t.Context(), backend.ReadQueryIn{Query: "SELECT id, amount * 2 AS doubled FROM orders"})
+		require.NoError(t, err)
+		require.Len(t, res.ColumnTypes, 2)
+		require.Equal(t, "id", res.ColumnTypes[0].Name)
+		require.Equal(t, "INTEGER", res.ColumnTypes[0].DatabaseType)
+		require.Equal(t, "doubled", res.ColumnTypes[1].Name)
+	})
 }
 
 func TestExecuteDDL(t *testing.T) {
@@ -137,6 +244,70 @@ func TestExecuteDDL(t *testing.T) {
 	})
 }
 
+func TestExecuteWrite(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	res, err := b.ExecuteWrite(t.Context(), backend.ExecuteWriteIn{
+		Query:  "UPDATE users SET bio = ? WHERE username = ?",
+		Params: []any{"updated", "admin_user"},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, res.RowsAffected)
+
+	t.Run("RefusesDDL", func(t *testing.T) {
+		_, err := b.ExecuteWrite(t.Context(), backend.ExecuteWriteIn{Query: "DROP TABLE orders"})
+		require.ErrorContains(t, err, "only INSERT, UPDATE and DELETE")
+	})
+}
+
+func TestCheckDDL(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	t.Run("UniqueIndexWithDuplicates", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "CREATE UNIQUE INDEX ix_users_active ON users (active)"})
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+		require.EqualValues(t, 1, report.Checks[0].Violations)
+	})
+
+	t.Run("PartialUniqueIndex", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "CREATE UNIQUE INDEX ix_users_active ON users (active) WHERE role = 'admin'"})
+		require.NoError(t, err)
+		require.True(t, report.WouldSucceed)
+	})
+
+	t.Run("AddNotNullColumn", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "ALTER TABLE users ADD COLUMN plan TEXT NOT NULL"})
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+	})
+}
+
+func TestRecommendIndexes(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	t.Run("EqualitySortRange", func(t *testing.T) {
+		t.Parallel()
+		advice, err := b.RecommendIndexes(t.Context(), backend.RecommendIndexesIn{Query: "SELECT username FROM users WHERE role = ? AND age > ? ORDER BY salary", Params: []any{"admin", 18}})
+		require.NoError(t, err)
+		require.Len(t, advice.Recommendations, 1)
+		rec := advice.Recommendations[0]
+		require.Equal(t, []string{"role", "salary", "age"}, rec.Columns[:3])
+		require.Contains(t, rec.CreateStatement, "CREATE INDEX")
+	})
+	t.Run("ExistingIndex", func(t *testing.T) {
+		t.Parallel()
+		advice, err := b.RecommendIndexes(t.Context(), backend.RecommendIndexesIn{Query: "SELECT id FROM orders WHERE user_id = ?", Params: []any{1}})
+		require.NoError(t, err)
+		require.Empty(t, advice.Recommendations)
+		require.Len(t, advice.Notes, 1)
+		require.Contains(t, advice.Notes[0], "idx_orders_user_id")
+	})
+}
+
 func TestExplainQuery(t *testing.T) {
 	t.Parallel()
 	b := openTestConnection(t)
@@ -154,6 +325,19 @@ func TestExplainQuery(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "json", res.Format)
 		require.GreaterOrEqual(t, len(res.Result), 1)
+		require.Len(t, res.Plan, 1)
+		require.Equal(t, "orders", res.Plan[0].Table)
+		require.True(t, res.FullScanDetected)
+	})
+	t.Run("ExplainWithParams", func(t *testing.T) {
+		t.Parallel()
+		res, err := b.ExplainQuery(t.Context(), backend.ExplainQueryIn{Query: "SELECT * FROM orders WHERE id = ?", Params: []any{1}})
+		require.NoError(t, err)
+		require.Greater(t, len(res.Result), 1)
+		require.Len(t, res.Plan, 1)
+		require.Equal(t, "orders", res.Plan[0].Table)
+		require.Contains(t, res.Plan[0].Operation, "SEARCH")
+		require.False(t, res.FullScanDetected)
 	})
 	t.Run("MalformedQuery", func(t *testing.T) {
 		t.Parallel()
@@ -165,8 +349,36 @@ func TestExplainQuery(t *testing.T) {
 func TestListMissingIndexes(t *testing.T) {
 	t.Parallel()
 	b := openTestConnection(t)
-	_, err := b.ListMissingIndexes(t.Context())
-	require.ErrorContains(t, err, "not available for SQLite")
+
+	// orders.user_id is indexed by the seed, so nothing is missing yet.
+	missing, err := b.ListMissingIndexes(t.Context())
+	require.NoError(t, err)
+	require.Empty(t, missing)
+
+	require.NoError(t, b.db.Exec(`CREATE TABLE payments (
+		id INTEGER PRIMARY KEY,
+		order_id INTEGER REFERENCES orders(id),
+		user_id INTEGER REFERENCES users(id)
+	)`).Error)
+	require.NoError(t, b.db.Exec(`CREATE INDEX idx_payments_user ON payments (user_id, id)`).Error)
+	// A one-to-one extension keyed by its foreign key is covered by the rowid.
+	require.NoError(t, b.db.Exec(`CREATE TABLE user_settings (user_id INTEGER PRIMARY KEY REFERENCES users(id))`).Error)
+
+	missing, err = b.ListMissingIndexes(t.Context())
+	require.NoError(t, err)
+	require.Len(t, missing, 1)
+	require.Equal(t, "payments", missing[0].TableName)
+	require.Contains(t, missing[0].Reason, "(order_id) to orders")
+	require.Equal(t, `CREATE INDEX "idx_payments_order_id" ON "payments" ("order_id")`, missing[0].Suggestion)
+	require.Zero(t, missing[0].EstimatedImpact)
+
+	require.NoError(t, b.db.Exec(`INSERT INTO payments (order_id) VALUES (1), (1), (2)`).Error)
+	require.NoError(t, b.db.Exec(`ANALYZE`).Error)
+	missing, err = b.ListMissingIndexes(t.Context())
+	require.NoError(t, err)
+	require.Len(t, missing, 1)
+	require.Equal(t, 3.0, missing[0].EstimatedImpact)
+	require.Contains(t, missing[0].Reason, "(3 rows)")
 }
 
 func TestListWaitingQueries(t *testing.T) {
@@ -176,6 +388,13 @@ func TestListWaitingQueries(t *testing.T) {
 	require.ErrorContains(t, err, "not available for SQLite")
 }
 
+func TestListConnections(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	_, err := b.ListConnections(t.Context())
+	require.ErrorContains(t, err, "not available for SQLite")
+}
+
 func TestListSlowestQueries(t *testing.T) {
 	t.Parallel()
 	b := openTestConnection(t)
@@ -189,3 +408,98 @@ func TestListDeadlocks(t *testing.T) {
 	_, err := b.ListDeadlocks(t.Context())
 	require.ErrorContains(t, err, "not available for SQLite")
 }
+
+func TestSampleTable(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	res, err := b.SampleTable(t.Context(), backend.SampleTableIn{Table: "users", Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, 2, res.RowCount)
+	require.Contains(t, res.Columns, "username")
+	require.NotEmpty(t, res.Rows[0]["username"])
+
+	_, err = b.SampleTable(t.Context(), backend.SampleTableIn{Table: "nonexistent", Limit: 2})
+	require.Error(t, err)
+}
+
+func TestAnalyzeTable(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	t.Run("Success", func(t *testing.T) {
+		res, err := b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{Table: "users"})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+		require.Nil(t, res.StatsUpdatedAt)
+	})
+
+	t.Run("WholeDatabase", func(t *testing.T) {
+		res, err := b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+
+		res, err = b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{Schema: "main"})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+
+		var analyzed int
+		require.NoError(t, b.db.Raw("SELECT COUNT(DISTINCT tbl) FROM sqlite_stat1").Scan(&analyzed).Error)
+		require.Equal(t, 2, analyzed)
+	})
+
+	t.Run("NonExistentTable", func(t *testing.T) {
+		res, err := b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{Table: "nonexistent"})
+		require.Nil(t, res)
+		require.ErrorIs(t, err, sqlcommon.ErrTableNotFound)
+	})
+}
+
+func TestProfileCategoricalColumns(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	out, err := b.ProfileCategoricalColumns(t.Context(), backend.ProfileCategoricalColumnsIn{Schema: "", Table: "users", MaxDistinct: 2})
+	require.NoError(t, err)
+	require.Contains(t, out.Skipped, "email")
+
+	var active *sqlcommon.CategoricalColumn
+	for i, c := range out.Columns {
+		if c.Name == "active" {
+			active = &out.Columns[i]
+		}
+	}
+	require.NotNil(t, active)
+	require.Len(t, active.Values, 1)
+	require.EqualValues(t, 3, active.Values[0].Count)
+}
+
+func TestTableJSONSchema(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	out, err := b.TableJSONSchema(t.Context(), backend.TableJSONSchemaIn{Schema: "", Table: "users"})
+	require.NoError(t, err)
+	require.Equal(t, "object", out.JSONSchema["type"])
+	require.Subset(t, out.JSONSchema["required"], []string{"username", "email"})
+	require.NotContains(t, out.JSONSchema["required"], "role")
+
+	properties := out.JSONSchema["properties"].(map[string]any)
+	require.Equal(t, "integer", properties["id"].(map[string]any)["type"])
+	// gorm declares sized strings as text in SQLite, so there is no length to report.
+	require.Equal(t, "string", properties["username"].(map[string]any)["type"])
+	require.Equal(t, "date-time", properties["created_at"].(map[string]any)["format"])
+
+	t.Run("TableNotFound", func(t *testing.T) {
+		_, err := b.TableJSONSchema(t.Context(), backend.TableJSONSchemaIn{Schema: "", Table: "missing"})
+		require.ErrorIs(t, err, sqlcommon.ErrTableNotFound)
+	})
+}
+
+func TestSetComment(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	_, err := b.SetComment(t.Context(), backend.SetCommentIn{Table: "users", Comment: "Registered accounts"})
+	require.Error(t, err)
+}
@@ -0,0 +1,13 @@
+SELECT
+  m.name AS table_name,
+  group_concat(p."from", ', ') AS columns,
+  p."table" AS referenced_table,
+  group_concat(p."to", ', ') AS referenced_columns,
+  p.on_delete AS on_delete,
+  p.on_update AS on_update
+FROM sqlite_master m
+JOIN pragma_foreign_key_list(m.name) p
+WHERE m.type = 'table'
+  AND (? = '' OR m.name = ?)
+GROUP BY m.name, p.id
+ORDER BY m.name, p.id;
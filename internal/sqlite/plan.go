@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"strings"
+
+	"github.com/tinternet/databaise/internal/backend"
+)
+
+// planStep is a row of EXPLAIN QUERY PLAN.
+type planStep struct {
+	ID     int
+	Parent int
+	Detail string
+}
+
+// planTree converts the rows of EXPLAIN QUERY PLAN to backend.PlanNode trees,
+// nesting each step under the step its parent column points to.
+func planTree(steps []planStep) []backend.PlanNode {
+	children := make(map[int][]planStep)
+	for _, step := range steps {
+		children[step.Parent] = append(children[step.Parent], step)
+	}
+
+	var build func(step planStep) backend.PlanNode
+	build = func(step planStep) backend.PlanNode {
+		node := backend.PlanNode{Operation: step.Detail}
+		node.Table, node.Index = planObjects(step.Detail)
+		for _, child := range children[step.ID] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+
+	var roots []backend.PlanNode
+	for _, step := range children[0] {
+		roots = append(roots, build(step))
+	}
+	return roots
+}
+
+// planObjects returns the table and index named by a step such as
+// "SEARCH orders USING INDEX idx_orders_user (user_id=?)" or "SCAN TABLE users".
+func planObjects(detail string) (table, index string) {
+	rest, ok := strings.CutPrefix(detail, "SCAN ")
+	if !ok {
+		if rest, ok = strings.CutPrefix(detail, "SEARCH "); !ok {
+			return "", ""
+		}
+	}
+	fields := strings.Fields(strings.TrimPrefix(rest, "TABLE "))
+	if len(fields) == 0 || fields[0] == "CONSTANT" || strings.HasPrefix(fields[0], "(") {
+		return "", ""
+	}
+	table = fields[0]
+	for i, f := range fields[:len(fields)-1] {
+		// Automatic indexes have no name: "USING AUTOMATIC COVERING INDEX (x=?)".
+		if f == "INDEX" && !strings.HasPrefix(fields[i+1], "(") {
+			index = fields[i+1]
+			break
+		}
+	}
+	return table, index
+}
+
+// scannedTables returns the tables that the steps read in full, in plan order.
+// Scans of a subquery or of a constant row are left out.
+func scannedTables(steps []planStep) []string {
+	var tables []string
+	for _, step := range steps {
+		// "SCAN orders" on SQLite 3.36+, "SCAN TABLE orders" before that.
+		detail, ok := strings.CutPrefix(step.Detail, "SCAN ")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(detail, "TABLE "))
+		if len(fields) == 0 || fields[0] == "CONSTANT" || strings.HasPrefix(fields[0], "(") {
+			continue
+		}
+		tables = append(tables, fields[0])
+	}
+	return tables
+}
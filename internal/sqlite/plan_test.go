@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinternet/databaise/internal/backend"
+)
+
+// joinPlan is the EXPLAIN QUERY PLAN of a join of orders to users on its primary key
+// with a subquery, as SQLite 3.45 reports it.
+var joinPlan = []planStep{
+	{ID: 2, Parent: 0, Detail: "SCAN o"},
+	{ID: 5, Parent: 0, Detail: "SEARCH u USING INTEGER PRIMARY KEY (rowid=?)"},
+	{ID: 9, Parent: 0, Detail: "SCALAR SUBQUERY 1"},
+	{ID: 13, Parent: 9, Detail: "SEARCH items USING INDEX idx_items_order (order_id=?)"},
+}
+
+func TestPlanTree(t *testing.T) {
+	nodes := planTree(joinPlan)
+	require.Len(t, nodes, 3)
+	require.Equal(t, backend.PlanNode{Operation: "SCAN o", Table: "o"}, nodes[0])
+	require.Equal(t, "u", nodes[1].Table)
+	require.Len(t, nodes[2].Children, 1)
+	require.Equal(t, "idx_items_order", nodes[2].Children[0].(backend.PlanNode).Index)
+}
+
+func TestScannedTables(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []planStep
+		want  []string
+	}{
+		{"Join", joinPlan, []string{"o"}},
+		{"LegacyFormat", []planStep{{ID: 2, Detail: "SCAN TABLE users"}}, []string{"users"}},
+		{"Subquery", []planStep{{ID: 2, Detail: "SCAN (subquery-1)"}}, nil},
+		{"Constant", []planStep{{ID: 2, Detail: "SCAN CONSTANT ROW"}}, nil},
+		{"Search", []planStep{{ID: 2, Detail: "SEARCH users USING INDEX idx_email (email=?)"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, scannedTables(tt.steps))
+		})
+	}
+}
@@ -0,0 +1,4 @@
+-- The first number of each sqlite_stat1 entry is the table's row count at the last ANALYZE.
+SELECT tbl AS table_name, max(CAST(stat AS INTEGER)) AS row_count
+FROM sqlite_stat1
+GROUP BY tbl;
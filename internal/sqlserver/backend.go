@@ -4,63 +4,127 @@ import (
 	"context"
 	"database/sql"
 	_ "embed"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
+	mssql "github.com/microsoft/go-mssqldb"
 	"github.com/tinternet/databaise/internal/backend"
+	"github.com/tinternet/databaise/internal/config"
 	"github.com/tinternet/databaise/internal/logging"
 	"github.com/tinternet/databaise/internal/sqlcommon"
 	"golang.org/x/sync/errgroup"
 	"gorm.io/driver/sqlserver"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var log = logging.New("sqlserver")
 
 // ReadConfig for read connections.
 type ReadConfig struct {
+	config.Pool
 	DSN                 string `json:"dsn"`
 	BypassReadonlyCheck bool   `json:"bypass_readonly_check,omitempty"`
+	LockTimeoutMs       int    `json:"lock_timeout_ms,omitempty"`
+	UseReadonlyTx       bool   `json:"use_readonly_tx,omitempty"`
 }
 
 // AdminConfig for admin connections.
 type AdminConfig struct {
+	config.Pool
 	DSN string `json:"dsn"`
 }
 
+// DB wraps gorm.DB with SQL Server-specific settings.
+type DB struct {
+	*gorm.DB
+	UseReadonlyTx bool
+}
+
+// Pool returns the connection pool, for pool_stats.
+func (db DB) Pool() (*sql.DB, error) {
+	return db.DB.DB()
+}
+
 // Factory implements backend.BackendFactory for SQL Server.
 type Factory struct{}
 
 func (Factory) Dialect() string { return "T-SQL" }
 
-func (Factory) New(db *gorm.DB) backend.SQLBackend {
+func (Factory) UnsupportedTools() []string {
+	return []string{"kill_idle_transactions"}
+}
+
+func (Factory) New(db DB) backend.SQLBackend {
 	return &Backend{db: db}
 }
 
 // Connector implements backend.Connector for SQL Server.
 type Connector struct{}
 
-func (Connector) ConnectRead(c ReadConfig) (*gorm.DB, error) {
+func (Connector) ConnectRead(c ReadConfig) (DB, error) {
 	log.Printf("Opening read connection")
-	db, err := gorm.Open(sqlserver.Open(c.DSN), &gorm.Config{Logger: logging.NewGormLogger()})
+	dialector := sqlserver.Open(c.DSN)
+	if c.LockTimeoutMs > 0 {
+		// SQL Server has no server-side statement timeout; LOCK_TIMEOUT at least stops
+		// a read from waiting forever behind a writer. It is re-applied whenever the
+		// driver resets a pooled session.
+		connector, err := mssql.NewConnector(c.DSN)
+		if err != nil {
+			return DB{}, err
+		}
+		connector.SessionInitSQL = fmt.Sprintf("SET LOCK_TIMEOUT %d", c.LockTimeoutMs)
+		dialector = sqlserver.New(sqlserver.Config{Conn: sql.OpenDB(connector)})
+		log.Printf("Using LOCK_TIMEOUT of %dms (lock_timeout_ms)", c.LockTimeoutMs)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logging.NewGormLogger()})
 	if err != nil {
-		return nil, err
+		return DB{}, err
+	}
+	if err := sqlcommon.ApplyPool(db, c.Pool); err != nil {
+		return DB{}, err
 	}
 
-	if !c.BypassReadonlyCheck {
-		if !sqlcommon.VerifyReadonly(db, sqlcommon.SQLServerVerifyReadonlySQL) {
-			return nil, fmt.Errorf("read DSN user has write permissions (set bypass_readonly_check:true to bypass)")
-		}
-		log.Printf("Verified read connection is readonly")
-	} else {
+	return DB{DB: db, UseReadonlyTx: c.UseReadonlyTx}, nil
+}
+
+func (Connector) CheckReadonly(c ReadConfig, db DB) backend.ReadonlyStatus {
+	status := backend.ReadonlyStatus{CheckedAt: time.Now()}
+	switch {
+	case c.UseReadonlyTx:
+		log.Println("Using rolled-back transactions for reads (use_readonly_tx: true)")
+		status.Mode, status.Verified = backend.ReadonlyTx, true
+	case c.BypassReadonlyCheck:
 		log.Printf("Skipping readonly verification (bypass_readonly_check:true)")
+		status.Mode = backend.ReadonlyNone
+	case !sqlcommon.VerifyReadonly(db.DB, sqlcommon.SQLServerVerifyReadonlySQL):
+		status.Mode, status.Error = backend.ReadonlyGrantCheck, "read DSN user has write permissions (set bypass_readonly_check:true to bypass)"
+	default:
+		log.Printf("Verified read connection is readonly")
+		status.Mode, status.Verified = backend.ReadonlyGrantCheck, true
 	}
+	return status
+}
 
-	return db, nil
+func (Connector) DowngradeReadonly(db DB) DB {
+	db.UseReadonlyTx = true
+	return db
 }
 
-func (Connector) ConnectAdmin(c AdminConfig) (*gorm.DB, error) {
+func (Connector) ConnectAdmin(c AdminConfig) (DB, error) {
 	log.Printf("Opening admin connection")
-	return gorm.Open(sqlserver.Open(c.DSN), &gorm.Config{Logger: logging.NewGormLogger()})
+	db, err := gorm.Open(sqlserver.Open(c.DSN), &gorm.Config{Logger: logging.NewGormLogger()})
+	if err != nil {
+		return DB{}, err
+	}
+	if err := sqlcommon.ApplyPool(db, c.Pool); err != nil {
+		return DB{}, err
+	}
+	return DB{DB: db}, nil
 }
 
 func init() {
@@ -69,7 +133,7 @@ func init() {
 
 // Backend implements backend.SQLBackend for SQL Server.
 type Backend struct {
-	db *gorm.DB
+	db DB
 }
 
 //go:embed list_tables.sql
@@ -80,7 +144,12 @@ func (b *Backend) ListTables(ctx context.Context, in backend.ListTablesIn) ([]ba
 		Schema string `gorm:"column:schema"`
 		Name   string `gorm:"column:name"`
 	}
-	if err := b.db.WithContext(ctx).Raw(listTablesQuery, sql.Named("schema", in.Schema)).Scan(&tables).Error; err != nil {
+	schema := in.Schema
+	if in.AllSchemas {
+		// An empty filter matches every schema; system objects are not in INFORMATION_SCHEMA.TABLES.
+		schema = ""
+	}
+	if err := b.db.WithContext(ctx).Raw(listTablesQuery, sql.Named("schema", schema)).Scan(&tables).Error; err != nil {
 		return nil, err
 	}
 
@@ -88,9 +157,78 @@ func (b *Backend) ListTables(ctx context.Context, in backend.ListTablesIn) ([]ba
 	for i, t := range tables {
 		result[i] = backend.Table{Schema: t.Schema, Name: t.Name}
 	}
+	if in.WithStats {
+		sizes, err := b.TableSizes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		backend.AddTableSizes(result, sizes)
+	}
 	return result, nil
 }
 
+func (b *Backend) ListTablesWithoutPK(ctx context.Context, in backend.ListTablesWithoutPKIn) ([]backend.Table, error) {
+	names, err := sqlcommon.TablesWithoutPrimaryKey(ctx, b.db.DB, in.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]backend.Table, len(names))
+	for i, name := range names {
+		tables[i] = backend.Table{Schema: name.Schema, Name: name.Name}
+	}
+	return tables, nil
+}
+
+//go:embed list_sequences.sql
+var listSequencesQuery string
+
+// ListSequences returns sequences and identity columns, whose counters play the same role.
+func (b *Backend) ListSequences(ctx context.Context, in backend.ListSequencesIn) ([]backend.Sequence, error) {
+	sequences := []backend.Sequence{}
+	err := b.db.WithContext(ctx).Raw(listSequencesQuery, sql.Named("schema", in.Schema)).Scan(&sequences).Error
+	return sequences, err
+}
+
+// ListSchemas leaves out sys, INFORMATION_SCHEMA, guest and the schemas of the
+// fixed database roles (db_owner, db_datareader, ...) unless system schemas are asked for.
+func (b *Backend) ListSchemas(ctx context.Context, in backend.ListSchemasIn) ([]string, error) {
+	schemas := []string{}
+	err := b.db.WithContext(ctx).Raw(`SELECT name FROM sys.schemas
+WHERE @include_system = 1 OR (schema_id < 16384 AND name NOT IN ('sys', 'INFORMATION_SCHEMA', 'guest'))
+ORDER BY name`, sql.Named("include_system", in.IncludeSystem)).Scan(&schemas).Error
+	return schemas, err
+}
+
+//go:embed list_views.sql
+var listViewsQuery string
+
+// ListViews reads definitions from sys.sql_modules, which needs VIEW DEFINITION on the view.
+func (b *Backend) ListViews(ctx context.Context, in backend.ListViewsIn) ([]backend.View, error) {
+	views := []backend.View{}
+	err := b.db.WithContext(ctx).Raw(listViewsQuery, sql.Named("schema", in.Schema)).Scan(&views).Error
+	return views, err
+}
+
+//go:embed list_foreign_keys.sql
+var listForeignKeysQuery string
+
+func (b *Backend) ListForeignKeys(ctx context.Context, in backend.ListForeignKeysIn) ([]backend.ForeignKey, error) {
+	foreignKeys := []backend.ForeignKey{}
+	err := b.db.WithContext(ctx).Raw(listForeignKeysQuery, sql.Named("schema", in.Schema), sql.Named("table", in.Table)).Scan(&foreignKeys).Error
+	return foreignKeys, err
+}
+
+//go:embed table_sizes.sql
+var tableSizesQuery string
+
+// TableSizes implements backend.TableSizer. It needs VIEW DATABASE STATE.
+func (b *Backend) TableSizes(ctx context.Context) ([]backend.TableSize, error) {
+	var sizes []backend.TableSize
+	err := b.db.WithContext(ctx).Raw(tableSizesQuery).Scan(&sizes).Error
+	return sizes, err
+}
+
 //go:embed ddl_table.sql
 var ddlTableQuery string
 
@@ -100,6 +238,9 @@ var ddlIndexesQuery string
 //go:embed ddl_constraints.sql
 var ddlConstraintsQuery string
 
+//go:embed inbound_foreign_keys.sql
+var inboundForeignKeysQuery string
+
 func (b *Backend) DescribeTable(ctx context.Context, in backend.DescribeTableIn) (*backend.TableDescription, error) {
 	var out backend.TableDescription
 	g, ctx := errgroup.WithContext(ctx)
@@ -114,6 +255,19 @@ func (b *Backend) DescribeTable(ctx context.Context, in backend.DescribeTableIn)
 		st := fmt.Sprintf("%s.%s", in.Schema, in.Table)
 		return b.db.WithContext(ctx).Raw(ddlConstraintsQuery, st, in.Table, in.Schema).Scan(&out.CreateConstraints).Error
 	})
+	g.Go(func() error {
+		return b.db.WithContext(ctx).Raw(listForeignKeysQuery, sql.Named("schema", in.Schema), sql.Named("table", in.Table)).Scan(&out.ForeignKeys).Error
+	})
+	g.Go(func() error {
+		var err error
+		out.TableComment, out.ColumnComments, err = sqlcommon.GetComments(ctx, b.db.DB, in.Schema, in.Table)
+		return err
+	})
+	if in.IncludeReferencedBy {
+		g.Go(func() error {
+			return b.db.WithContext(ctx).Raw(inboundForeignKeysQuery, in.Table, in.Schema).Scan(&out.ReferencedBy).Error
+		})
+	}
 
 	if err := g.Wait(); err != nil {
 		return nil, err
@@ -124,12 +278,66 @@ func (b *Backend) DescribeTable(ctx context.Context, in backend.DescribeTableIn)
 	return &out, nil
 }
 
-func (b *Backend) ExecuteQuery(ctx context.Context, in backend.ReadQueryIn) (*backend.QueryResult, error) {
-	var rows []map[string]any
-	if err := b.db.WithContext(ctx).Raw(in.Query).Scan(&rows).Error; err != nil {
+func (b *Backend) ProfileCategoricalColumns(ctx context.Context, in backend.ProfileCategoricalColumnsIn) (*backend.ProfileCategoricalColumnsOut, error) {
+	name := in.Table
+	if in.Schema != "" {
+		name = in.Schema + "." + in.Table
+	}
+	columns, skipped, err := sqlcommon.ProfileCategoricalColumns(ctx, b.db.DB, clause.Table{Name: name}, in.MaxDistinct)
+	if err != nil {
 		return nil, err
 	}
-	return &backend.QueryResult{Rows: rows}, nil
+	return &backend.ProfileCategoricalColumnsOut{Columns: columns, Skipped: skipped}, nil
+}
+
+func (b *Backend) TableJSONSchema(ctx context.Context, in backend.TableJSONSchemaIn) (*backend.TableJSONSchemaOut, error) {
+	columns, err := sqlcommon.GetColumns(ctx, b.db.DB, in.Schema, in.Table)
+	if err != nil {
+		return nil, err
+	}
+	title := in.Table
+	if in.Schema != "" {
+		title = in.Schema + "." + in.Table
+	}
+	return &backend.TableJSONSchemaOut{JSONSchema: sqlcommon.TableJSONSchema("sqlserver", title, columns)}, nil
+}
+
+func (b *Backend) SampleTable(ctx context.Context, in backend.SampleTableIn) (*backend.QueryResult, error) {
+	name := in.Table
+	if in.Schema != "" {
+		name = in.Schema + "." + in.Table
+	}
+	return b.ExecuteQuery(ctx, backend.ReadQueryIn{Query: fmt.Sprintf("SELECT TOP (%d) * FROM %s", in.Limit, b.db.Statement.Quote(clause.Table{Name: name}))})
+}
+
+func (b *Backend) ExecuteQuery(ctx context.Context, in backend.ReadQueryIn) (*backend.QueryResult, error) {
+	if in.Schema != "" {
+		return nil, fmt.Errorf("the schema parameter of execute_query is only supported for MySQL: qualify table names with their schema instead")
+	}
+	if b.db.UseReadonlyTx {
+		// SQL Server has no read-only transactions: the query runs in one that is always
+		// rolled back, so statements that could commit it are refused up front.
+		if err := sqlcommon.CheckReadonlyTxStatement(in.Query); err != nil {
+			return nil, err
+		}
+		tx := b.db.WithContext(ctx).Begin()
+		if tx.Error != nil {
+			return nil, tx.Error
+		}
+		defer tx.Rollback()
+
+		rows, err := sqlcommon.QueryRows(ctx, tx, in.Query)
+		if err != nil {
+			return nil, sqlcommon.HighlightSyntaxError(err, in.Query, 0)
+		}
+		return backend.NewQueryResult(in.Query, rows), nil
+	}
+
+	rows, err := sqlcommon.QueryRows(ctx, b.db.DB, in.Query)
+	if err != nil {
+		return nil, sqlcommon.HighlightSyntaxError(err, in.Query, 0)
+	}
+	return backend.NewQueryResult(in.Query, rows), nil
 }
 
 func (b *Backend) ExplainQuery(ctx context.Context, in backend.ExplainQueryIn) (*backend.ExplainResult, error) {
@@ -155,9 +363,9 @@ func (b *Backend) ExplainQuery(ctx context.Context, in backend.ExplainQueryIn) (
 	var plan string
 
 	if in.Analyze {
-		rows, err := tx.Raw(in.Query).Rows()
+		rows, err := tx.Raw(in.Query, in.Params...).Rows()
 		if err != nil {
-			return nil, err
+			return nil, sqlcommon.HighlightSyntaxError(err, in.Query, 0)
 		}
 		defer rows.Close()
 
@@ -174,8 +382,8 @@ func (b *Backend) ExplainQuery(ctx context.Context, in backend.ExplainQueryIn) (
 			}
 		}
 	} else {
-		if err := tx.Raw(in.Query).Scan(&plan).Error; err != nil {
-			return nil, err
+		if err := tx.Raw(in.Query, in.Params...).Scan(&plan).Error; err != nil {
+			return nil, sqlcommon.HighlightSyntaxError(err, in.Query, 0)
 		}
 	}
 
@@ -183,18 +391,76 @@ func (b *Backend) ExplainQuery(ctx context.Context, in backend.ExplainQueryIn) (
 		return nil, err
 	}
 
+	scans := fullScans(plan)
 	return &backend.ExplainResult{
-		Format:     "xml",
-		Result:     plan,
-		ResultInfo: "The mssql plan",
+		Format:           "xml",
+		Result:           plan,
+		ResultInfo:       "The mssql plan",
+		FullScanDetected: len(scans) > 0,
+		FullScans:        scans,
+		Plan:             planTree(plan),
+		ExecutedSQL:      strings.Join([]string{enable, sqlcommon.BoundSQL(b.db.DB, in.Query, in.Params...), disable}, "\n"),
 	}, nil
 }
 
+// fullScans returns the Table Scan and Clustered Index Scan operators in a showplan XML.
+func fullScans(planXML string) []backend.TableScan {
+	var scans []backend.TableScan
+	var stack []xml.StartElement
+
+	dec := xml.NewDecoder(strings.NewReader(planXML))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			// <RelOp PhysicalOp="Table Scan"><TableScan><Object Table="[t]"/></TableScan></RelOp>
+			if t.Name.Local == "Object" && len(stack) >= 2 {
+				parent, relOp := stack[len(stack)-1], stack[len(stack)-2]
+				op := xmlAttr(relOp, "PhysicalOp")
+				if (parent.Name.Local == "TableScan" || parent.Name.Local == "IndexScan") &&
+					relOp.Name.Local == "RelOp" && (op == "Table Scan" || op == "Clustered Index Scan") {
+					rows, _ := strconv.ParseFloat(xmlAttr(relOp, "TableCardinality"), 64)
+					table := strings.Trim(xmlAttr(t, "Schema"), "[]") + "." + strings.Trim(xmlAttr(t, "Table"), "[]")
+					scans = append(scans, backend.TableScan{Table: strings.TrimPrefix(table, "."), EstimatedRows: rows})
+				}
+			}
+			stack = append(stack, t)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	return scans
+}
+
 func (b *Backend) ExecuteDDL(ctx context.Context, in backend.ExecuteDDLIn) (*backend.DDLResult, error) {
-	if err := b.db.WithContext(ctx).Exec(in.DDL).Error; err != nil {
+	err := sqlcommon.RetryOnDeadlock(ctx, func() error {
+		return b.db.WithContext(ctx).Exec(in.DDL).Error
+	})
+	if err != nil {
+		return nil, sqlcommon.HighlightSyntaxError(err, in.DDL, 0)
+	}
+	return &backend.DDLResult{Success: true, Message: "DDL executed successfully", ExecutedSQL: in.DDL}, nil
+}
+
+func (b *Backend) ExecuteWrite(ctx context.Context, in backend.ExecuteWriteIn) (*backend.WriteResult, error) {
+	affected, err := sqlcommon.ExecuteWrite(ctx, b.db.DB, in.Query, in.Params)
+	if err != nil {
 		return nil, err
 	}
-	return &backend.DDLResult{Success: true, Message: "DDL executed successfully"}, nil
+	return &backend.WriteResult{RowsAffected: affected, ExecutedSQL: sqlcommon.BoundSQL(b.db.DB, in.Query, in.Params...)}, nil
+}
+
+func (b *Backend) CheckDDL(ctx context.Context, in backend.CheckDDLIn) (*sqlcommon.DDLCheckReport, error) {
+	return sqlcommon.CheckDDL(ctx, b.db.DB, in.DDL)
+}
+
+func (b *Backend) RecommendIndexes(ctx context.Context, in backend.RecommendIndexesIn) (*sqlcommon.IndexAdvice, error) {
+	return sqlcommon.RecommendIndexes(ctx, b.db.DB, in.Query)
 }
 
 //go:embed missing_indexes.sql
@@ -254,6 +520,42 @@ func (b *Backend) ListWaitingQueries(ctx context.Context) ([]backend.WaitingQuer
 	return result, nil
 }
 
+//go:embed list_connections.sql
+var connectionsQuery string
+
+func (b *Backend) ListConnections(ctx context.Context) ([]backend.Connection, error) {
+	var sessions []struct {
+		SessionID        int     `gorm:"column:session_id"`
+		Username         string  `gorm:"column:username"`
+		DatabaseName     string  `gorm:"column:database_name"`
+		ClientAddress    string  `gorm:"column:client_address"`
+		ApplicationName  string  `gorm:"column:application_name"`
+		State            string  `gorm:"column:state"`
+		ConnectedSec     float64 `gorm:"column:connected_sec"`
+		QueryDurationSec float64 `gorm:"column:query_duration_sec"`
+		QueryText        string  `gorm:"column:query_text"`
+	}
+	if err := b.db.WithContext(ctx).Raw(connectionsQuery).Scan(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]backend.Connection, len(sessions))
+	for i, s := range sessions {
+		result[i] = backend.Connection{
+			ID:               fmt.Sprintf("%d", s.SessionID),
+			Username:         s.Username,
+			Database:         s.DatabaseName,
+			ClientAddress:    s.ClientAddress,
+			ApplicationName:  s.ApplicationName,
+			State:            s.State,
+			ConnectedSec:     s.ConnectedSec,
+			Query:            s.QueryText,
+			QueryDurationSec: s.QueryDurationSec,
+		}
+	}
+	return result, nil
+}
+
 //go:embed list_slowest_queries.sql
 var slowestQueriesQuery string
 
@@ -263,9 +565,18 @@ func (b *Backend) ListSlowestQueries(ctx context.Context) (*backend.SlowQueryRes
 		return nil, err
 	}
 
+	// The plan cache stores the statement text with its literals, normalize it so
+	// the output matches the other backends and can be correlated across executions.
+	for _, q := range queries {
+		if text, ok := q["query"].(string); ok {
+			q["query"] = sqlcommon.NormalizeQuery(text)
+		}
+	}
+
 	return &backend.SlowQueryResult{
 		Columns: map[string]string{
-			"query":                 "The SQL query text",
+			"query_hash":            "Unique identifier for the normalized query (query_hash)",
+			"query":                 "The normalized SQL query text",
 			"calls":                 "Number of times this query was executed",
 			"total_time_sec":        "Total elapsed time in seconds",
 			"avg_time_sec":          "Average elapsed time in seconds",
@@ -307,3 +618,157 @@ func (b *Backend) ListDeadlocks(ctx context.Context) ([]backend.Deadlock, error)
 	}
 	return result, nil
 }
+
+//go:embed set_comment.sql
+var setCommentQuery string
+
+func (b *Backend) SetComment(ctx context.Context, in backend.SetCommentIn) (*backend.SetCommentOut, error) {
+	if err := sqlcommon.CheckColumn(ctx, b.db.DB, in.Schema, in.Table, in.Column); err != nil {
+		return nil, err
+	}
+	err := b.db.WithContext(ctx).Exec(setCommentQuery,
+		sql.Named("schema", in.Schema), sql.Named("table", in.Table), sql.Named("column", in.Column), sql.Named("comment", in.Comment),
+	).Error
+	if err != nil {
+		return nil, err
+	}
+
+	kind, name := "table", in.Table
+	if in.Schema != "" {
+		name = in.Schema + "." + in.Table
+	}
+	if in.Column != "" {
+		kind, name = "column", name+"."+in.Column
+	}
+	out := &backend.SetCommentOut{Success: true, Message: fmt.Sprintf("Comment set on %s %s", kind, name)}
+	if in.Comment == "" {
+		out.Message = fmt.Sprintf("Comment removed from %s %s", kind, name)
+	}
+	return out, nil
+}
+
+func (b *Backend) KillIdleTransactions(ctx context.Context, in backend.KillIdleTransactionsIn) (*backend.KillIdleTransactionsOut, error) {
+	return nil, fmt.Errorf("killing idle transactions is only available for PostgreSQL")
+}
+
+func (b *Backend) AnalyzeTable(ctx context.Context, in backend.AnalyzeTableIn) (*backend.AnalyzeTableOut, error) {
+	if in.Table == "" {
+		return b.analyzeAll(ctx, in.Schema)
+	}
+	name := in.Table
+	if in.Schema != "" {
+		name = in.Schema + "." + in.Table
+	}
+	if err := b.db.WithContext(ctx).Exec("UPDATE STATISTICS ?", clause.Table{Name: name}).Error; err != nil {
+		return nil, err
+	}
+
+	var updated sql.NullTime
+	err := b.db.WithContext(ctx).Raw(
+		"SELECT MAX(STATS_DATE(object_id, stats_id)) FROM sys.stats WHERE object_id = OBJECT_ID(QUOTENAME(COALESCE(NULLIF(?, ''), SCHEMA_NAME())) + '.' + QUOTENAME(?))",
+		in.Schema, in.Table,
+	).Row().Scan(&updated)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	out := &backend.AnalyzeTableOut{Success: true, Message: fmt.Sprintf("Statistics refreshed for %s", name)}
+	if updated.Valid {
+		out.StatsUpdatedAt = &updated.Time
+	}
+	return out, nil
+}
+
+// analyzeAll refreshes the statistics of every table in schema, or in the whole
+// database with sp_updatestats if schema is empty.
+func (b *Backend) analyzeAll(ctx context.Context, schema string) (*backend.AnalyzeTableOut, error) {
+	if schema == "" {
+		if err := b.db.WithContext(ctx).Exec("EXEC sp_updatestats").Error; err != nil {
+			return nil, err
+		}
+		return &backend.AnalyzeTableOut{Success: true, Message: "Statistics refreshed for every table in the database"}, nil
+	}
+
+	var tables []string
+	if err := b.db.WithContext(ctx).Raw("SELECT name FROM sys.tables WHERE schema_id = SCHEMA_ID(?) ORDER BY name", schema).Scan(&tables).Error; err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("schema %q has no tables", schema)
+	}
+	for _, t := range tables {
+		if err := b.db.WithContext(ctx).Exec("UPDATE STATISTICS ?", clause.Table{Name: schema + "." + t}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &backend.AnalyzeTableOut{Success: true, Message: fmt.Sprintf("Statistics refreshed for %d tables in %s", len(tables), schema)}, nil
+}
+
+//go:embed table_profile.sql
+var tableProfileQuery string
+
+// TableProfile reads sizes and usage from the partition and index usage DMVs, which
+// need VIEW DATABASE STATE. Usage counts are reset when the server restarts.
+func (b *Backend) TableProfile(ctx context.Context, in backend.TableProfileIn) (*backend.TableProfile, error) {
+	db := b.db.WithContext(ctx)
+
+	var table []struct {
+		ID     int64  `gorm:"column:id"`
+		Schema string `gorm:"column:schema_name"`
+		Table  string `gorm:"column:table_name"`
+	}
+	err := db.Raw("SELECT object_id AS id, SCHEMA_NAME(schema_id) AS schema_name, name AS table_name FROM sys.tables WHERE object_id = OBJECT_ID(QUOTENAME(COALESCE(NULLIF(?, ''), SCHEMA_NAME())) + '.' + QUOTENAME(?))",
+		in.Schema, in.Table,
+	).Scan(&table).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(table) == 0 {
+		return nil, sqlcommon.ErrTableNotFound
+	}
+
+	var indexes []struct {
+		IndexID     int     `gorm:"column:index_id"`
+		Name        *string `gorm:"column:name"`
+		Bytes       int64   `gorm:"column:bytes"`
+		RowCount    int64   `gorm:"column:row_count"`
+		UserSeeks   int64   `gorm:"column:user_seeks"`
+		UserScans   int64   `gorm:"column:user_scans"`
+		UserLookups int64   `gorm:"column:user_lookups"`
+	}
+	if err := db.Raw(tableProfileQuery, sql.Named("id", table[0].ID)).Scan(&indexes).Error; err != nil {
+		return nil, err
+	}
+
+	out := &backend.TableProfile{Schema: table[0].Schema, Table: table[0].Table}
+	var seqScans, indexScans int64
+	for _, ix := range indexes {
+		out.TotalBytes += ix.Bytes
+		// Index 0 is the heap and index 1 the clustered index: either holds the table data,
+		// and scanning it is a full scan.
+		if ix.IndexID <= 1 {
+			out.RowEstimate = ix.RowCount
+			out.TableBytes = ix.Bytes
+			seqScans += ix.UserScans
+			indexScans += ix.UserSeeks
+		} else {
+			out.IndexBytes += ix.Bytes
+			indexScans += ix.UserSeeks + ix.UserScans
+		}
+		if ix.Name != nil {
+			scans := ix.UserSeeks + ix.UserScans + ix.UserLookups
+			out.Indexes = append(out.Indexes, backend.IndexProfile{Name: *ix.Name, Bytes: ix.Bytes, Scans: &scans})
+		}
+	}
+	out.SeqScans, out.IndexScans = &seqScans, &indexScans
+
+	var updated sql.NullTime
+	err = db.Raw("SELECT MAX(STATS_DATE(object_id, stats_id)) FROM sys.stats WHERE object_id = ?", table[0].ID).Row().Scan(&updated)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if updated.Valid {
+		out.LastAnalyze = &updated.Time
+	}
+	return out, nil
+}
@@ -0,0 +1,20 @@
+SELECT
+  fk.name AS constraint_name,
+  s.name AS schema_name,
+  t.name AS table_name,
+  STRING_AGG(pc.name, ', ') WITHIN GROUP (ORDER BY fkc.constraint_column_id) AS columns,
+  STRING_AGG(rc.name, ', ') WITHIN GROUP (ORDER BY fkc.constraint_column_id) AS referenced_columns,
+  REPLACE(fk.delete_referential_action_desc, '_', ' ') AS on_delete,
+  REPLACE(fk.update_referential_action_desc, '_', ' ') AS on_update
+FROM sys.foreign_keys fk
+JOIN sys.tables t ON t.object_id = fk.parent_object_id
+JOIN sys.schemas s ON s.schema_id = t.schema_id
+JOIN sys.tables rt ON rt.object_id = fk.referenced_object_id
+JOIN sys.schemas rs ON rs.schema_id = rt.schema_id
+JOIN sys.foreign_key_columns fkc ON fkc.constraint_object_id = fk.object_id
+JOIN sys.columns pc ON pc.object_id = fkc.parent_object_id AND pc.column_id = fkc.parent_column_id
+JOIN sys.columns rc ON rc.object_id = fkc.referenced_object_id AND rc.column_id = fkc.referenced_column_id
+WHERE rt.name = ?
+  AND rs.name = ISNULL(NULLIF(?, ''), rs.name)
+GROUP BY fk.name, s.name, t.name, fk.delete_referential_action_desc, fk.update_referential_action_desc
+ORDER BY s.name, t.name, fk.name;
@@ -4,6 +4,7 @@ package sqlserver
 
 import (
 	"slices"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -19,7 +20,7 @@ func openTestConnection(t *testing.T) *Backend {
 	dsn := sqltest.SetupSqlServerContainer(t)
 	db, err := Connector{}.ConnectAdmin(AdminConfig{DSN: dsn})
 	require.NoError(t, err)
-	sqltest.Seed(t, db)
+	sqltest.Seed(t, db.DB)
 	return &Backend{db: db}
 }
 
@@ -29,8 +30,12 @@ func TestConnect(t *testing.T) {
 
 	t.Run("ReadOnly With BypassReadonlyCheck=false", func(t *testing.T) {
 		t.Parallel()
-		_, err := Connector{}.ConnectRead(ReadConfig{DSN: dsn})
-		require.ErrorContains(t, err, "read DSN user has write permissions")
+		db, err := Connector{}.ConnectRead(ReadConfig{DSN: dsn})
+		require.NoError(t, err)
+		status := Connector{}.CheckReadonly(ReadConfig{DSN: dsn}, db)
+		require.Equal(t, backend.ReadonlyGrantCheck, status.Mode)
+		require.False(t, status.Verified)
+		require.Contains(t, status.Error, "read DSN user has write permissions")
 	})
 
 	t.Run("ReadOnly With BypassReadonlyCheck=true", func(t *testing.T) {
@@ -38,6 +43,7 @@ func TestConnect(t *testing.T) {
 		db, err := Connector{}.ConnectRead(ReadConfig{DSN: dsn, BypassReadonlyCheck: true})
 		require.NotNil(t, db)
 		require.NoError(t, err)
+		require.Equal(t, backend.ReadonlyNone, Connector{}.CheckReadonly(ReadConfig{DSN: dsn, BypassReadonlyCheck: true}, db).Mode)
 	})
 
 	t.Run("Admin", func(t *testing.T) {
@@ -57,6 +63,111 @@ func TestListTables(t *testing.T) {
 	assert.Contains(t, tables, backend.Table{Schema: "dbo", Name: "users"})
 }
 
+func TestListTablesWithStats(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	tables, err := b.ListTables(t.Context(), backend.ListTablesIn{WithStats: true})
+	require.NoError(t, err)
+	i := slices.IndexFunc(tables, func(tbl backend.Table) bool { return tbl.Name == "users" })
+	require.GreaterOrEqual(t, i, 0)
+	require.EqualValues(t, 3, tables[i].EstimatedRows)
+	require.Positive(t, tables[i].SizeBytes)
+}
+
+func TestListTablesWithoutPK(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	require.NoError(t, b.db.Exec("CREATE TABLE dbo.events (payload nvarchar(max))").Error)
+	require.NoError(t, b.db.Exec("CREATE TABLE dbo.tags (name nvarchar(50) UNIQUE)").Error)
+
+	tables, err := b.ListTablesWithoutPK(t.Context(), backend.ListTablesWithoutPKIn{})
+	require.NoError(t, err)
+	require.Equal(t, []backend.Table{{Schema: "dbo", Name: "events"}, {Schema: "dbo", Name: "tags"}}, tables)
+}
+
+func TestListSequences(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	require.NoError(t, b.db.Exec("CREATE SEQUENCE dbo.invoice_numbers AS int START WITH 100 INCREMENT BY 10").Error)
+	require.NoError(t, b.db.Exec("CREATE TABLE dbo.invoices (number int NOT NULL DEFAULT (NEXT VALUE FOR dbo.invoice_numbers))").Error)
+	require.NoError(t, b.db.Exec("INSERT INTO dbo.invoices DEFAULT VALUES").Error)
+
+	sequences, err := b.ListSequences(t.Context(), backend.ListSequencesIn{Schema: "dbo"})
+	require.NoError(t, err)
+	require.Len(t, sequences, 3)
+	invoices := sequences[0]
+	require.Equal(t, "invoice_numbers", invoices.Name)
+	require.Equal(t, "invoices", invoices.Table)
+	require.Equal(t, "number", invoices.Column)
+	require.EqualValues(t, 100, *invoices.StartValue)
+	require.EqualValues(t, 100, *invoices.LastValue)
+	require.EqualValues(t, 10, invoices.Increment)
+	require.EqualValues(t, 2147483647, *invoices.MaxValue)
+
+	users := sequences[2]
+	require.Equal(t, backend.Sequence{Schema: "dbo", Name: "users", Table: "users", Column: "id", StartValue: users.StartValue, LastValue: users.LastValue, Increment: 1, MaxValue: users.MaxValue}, users)
+	require.EqualValues(t, 1, *users.StartValue)
+	require.EqualValues(t, 3, *users.LastValue)
+}
+
+func TestListSchemas(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	schemas, err := b.ListSchemas(t.Context(), backend.ListSchemasIn{})
+	require.NoError(t, err)
+	require.Contains(t, schemas, "dbo")
+	require.NotContains(t, schemas, "sys")
+	require.NotContains(t, schemas, "db_owner")
+
+	schemas, err = b.ListSchemas(t.Context(), backend.ListSchemasIn{IncludeSystem: true})
+	require.NoError(t, err)
+	require.Contains(t, schemas, "sys")
+}
+
+func TestListViews(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+	require.NoError(t, b.db.Exec("CREATE VIEW dbo.admins AS SELECT username, email FROM dbo.users WHERE role = 'admin'").Error)
+
+	views, err := b.ListViews(t.Context(), backend.ListViewsIn{})
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	require.Equal(t, "admins", views[0].Name)
+	require.Equal(t, "dbo", views[0].Schema)
+	require.Contains(t, views[0].Definition, "users")
+
+	views, err = b.ListViews(t.Context(), backend.ListViewsIn{Schema: "other"})
+	require.NoError(t, err)
+	require.Empty(t, views)
+}
+
+func TestListForeignKeys(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	foreignKeys, err := b.ListForeignKeys(t.Context(), backend.ListForeignKeysIn{Schema: "dbo"})
+	require.NoError(t, err)
+	require.Len(t, foreignKeys, 1)
+	fk := foreignKeys[0]
+	require.Equal(t, "orders", fk.Table)
+	require.Equal(t, "user_id", fk.Columns)
+	require.Equal(t, "users", fk.ReferencedTable)
+	require.Equal(t, "id", fk.ReferencedColumns)
+	require.NotEmpty(t, fk.OnUpdate)
+	require.NotEmpty(t, fk.OnDelete)
+	require.Equal(t, "dbo", fk.ReferencedSchema)
+
+	foreignKeys, err = b.ListForeignKeys(t.Context(), backend.ListForeignKeysIn{Schema: "dbo", Table: "users"})
+	require.NoError(t, err)
+	require.Empty(t, foreignKeys)
+
+	res, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Schema: "dbo", Table: "orders"})
+	require.NoError(t, err)
+	require.Len(t, res.ForeignKeys, 1)
+	require.Equal(t, "users", res.ForeignKeys[0].ReferencedTable)
+}
+
 func TestDescribeTable(t *testing.T) {
 	t.Parallel()
 	b := openTestConnection(t)
@@ -82,6 +193,16 @@ func TestDescribeTable(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, res)
 	})
+	t.Run("ReferencedBy", func(t *testing.T) {
+		t.Parallel()
+		res, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Schema: "dbo", Table: "users", IncludeReferencedBy: true})
+		require.NoError(t, err)
+		require.Len(t, res.ReferencedBy, 1)
+		require.Equal(t, "orders", res.ReferencedBy[0].Table)
+		require.Equal(t, "user_id", res.ReferencedBy[0].Columns)
+		require.Equal(t, "id", res.ReferencedBy[0].ReferencedColumns)
+		require.NotEmpty(t, res.ReferencedBy[0].OnDelete)
+	})
 	t.Run("NonExistentTable", func(t *testing.T) {
 		t.Parallel()
 		res, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Schema: "dbo", Table: "nonexistent"})
@@ -109,6 +230,30 @@ func TestExecuteQuery(t *testing.T) {
 		require.NoError(t, err)
 		require.Len(t, res.Rows, 0)
 	})
+
+	t.Run("Booleans", func(t *testing.T) {
+		t.Parallel()
+		ctx := sqlcommon.WithBoolNormalization(t.Context(), true)
+		res, err := b.ExecuteQuery(ctx, backend.ReadQueryIn{Query: "SELECT CAST(1 AS bit) AS flag, 1 AS n"})
+		require.NoError(t, err)
+		require.Equal(t, true, res.Rows[0]["flag"])
+		require.EqualValues(t, 1, res.Rows[0]["n"])
+	})
+
+	t.Run("UseReadonlyTx", func(t *testing.T) {
+		t.Parallel()
+		b := &Backend{db: DB{DB: b.db.DB, UseReadonlyTx: true}}
+
+		res, err := b.ExecuteQuery(t.Context(), backend.ReadQueryIn{Query: "SELECT * FROM dbo.orders"})
+		require.NoError(t, err)
+		require.Len(t, res.Rows, 2)
+
+		_, err = b.ExecuteQuery(t.Context(), backend.ReadQueryIn{Query: "DELETE FROM dbo.orders"})
+		require.ErrorIs(t, err, sqlcommon.ErrReadonlyViolation)
+
+		_, err = b.ExecuteQuery(t.Context(), backend.ReadQueryIn{Query: "SELECT 1; COMMIT"})
+		require.ErrorIs(t, err, sqlcommon.ErrReadonlyViolation)
+	})
 }
 
 func TestExecuteDDL(t *testing.T) {
@@ -138,6 +283,93 @@ func TestExecuteDDL(t *testing.T) {
 	})
 }
 
+func TestExecuteWrite(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	res, err := b.ExecuteWrite(t.Context(), backend.ExecuteWriteIn{
+		Query:  "UPDATE users SET bio = ? WHERE username = ?",
+		Params: []any{"updated", "admin_user"},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, res.RowsAffected)
+
+	t.Run("RefusesDDL", func(t *testing.T) {
+		_, err := b.ExecuteWrite(t.Context(), backend.ExecuteWriteIn{Query: "DROP TABLE orders"})
+		require.ErrorContains(t, err, "only INSERT, UPDATE and DELETE")
+	})
+}
+
+func TestCheckDDL(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	t.Run("UniqueIndexWithDuplicates", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "CREATE UNIQUE INDEX ix_users_active ON dbo.users (active)"})
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+		require.Len(t, report.Checks, 1)
+		require.EqualValues(t, 1, report.Checks[0].Violations)
+		require.Len(t, report.Checks[0].Samples, 1)
+	})
+
+	t.Run("UniqueIndex", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "CREATE UNIQUE INDEX ix_users_email ON dbo.users (email)"})
+		require.NoError(t, err)
+		require.True(t, report.WouldSucceed)
+	})
+
+	t.Run("AlterColumnNotNull", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "ALTER TABLE dbo.orders ALTER COLUMN shipped_at datetimeoffset NOT NULL"})
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+		require.EqualValues(t, 2, report.Checks[0].Violations)
+	})
+
+	t.Run("Check", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "ALTER TABLE dbo.users ADD CONSTRAINT ck_users_salary CHECK (salary > 0)"})
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+		require.EqualValues(t, 1, report.Checks[0].Violations)
+	})
+
+	t.Run("ForeignKeyToPrimaryKey", func(t *testing.T) {
+		report, err := b.CheckDDL(t.Context(), backend.CheckDDLIn{DDL: "ALTER TABLE dbo.users ADD CONSTRAINT fk_users_age FOREIGN KEY (age) REFERENCES dbo.orders"})
+		require.NoError(t, err)
+		require.False(t, report.WouldSucceed)
+		require.EqualValues(t, 3, report.Checks[0].Violations)
+	})
+
+	t.Run("NotApplied", func(t *testing.T) {
+		res, err := b.ExecuteDDL(t.Context(), backend.ExecuteDDLIn{DDL: "CREATE UNIQUE INDEX ix_users_email ON dbo.users (email)"})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+	})
+}
+
+func TestRecommendIndexes(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	t.Run("EqualitySortRange", func(t *testing.T) {
+		t.Parallel()
+		advice, err := b.RecommendIndexes(t.Context(), backend.RecommendIndexesIn{Query: "SELECT username FROM dbo.users WHERE role = ? AND age > ? ORDER BY salary", Params: []any{"admin", 18}})
+		require.NoError(t, err)
+		require.Len(t, advice.Recommendations, 1)
+		rec := advice.Recommendations[0]
+		require.Equal(t, []string{"role", "salary", "age"}, rec.Columns[:3])
+		require.Contains(t, rec.CreateStatement, "CREATE INDEX")
+	})
+	t.Run("ExistingIndex", func(t *testing.T) {
+		t.Parallel()
+		advice, err := b.RecommendIndexes(t.Context(), backend.RecommendIndexesIn{Query: "SELECT id FROM dbo.orders WHERE user_id = ?", Params: []any{1}})
+		require.NoError(t, err)
+		require.Empty(t, advice.Recommendations)
+		require.Len(t, advice.Notes, 1)
+		require.Contains(t, advice.Notes[0], "idx_orders_user_id")
+	})
+}
+
 func TestExplainQuery(t *testing.T) {
 	t.Parallel()
 	b := openTestConnection(t)
@@ -148,6 +380,9 @@ func TestExplainQuery(t *testing.T) {
 		require.NotNil(t, res)
 		require.Equal(t, "xml", res.Format)
 		require.Contains(t, res.Result, "<ShowPlanXML")
+		require.Len(t, res.Plan, 1)
+		require.Equal(t, "dbo.orders", res.Plan[0].Table)
+		require.NotNil(t, res.Plan[0].EstimatedRows)
 	})
 	t.Run("Actual", func(t *testing.T) {
 		res, err := b.ExplainQuery(t.Context(), backend.ExplainQueryIn{Query: "SELECT id FROM dbo.orders", Analyze: true})
@@ -155,6 +390,16 @@ func TestExplainQuery(t *testing.T) {
 		require.NotNil(t, res)
 		require.Equal(t, "xml", res.Format)
 		require.Contains(t, res.Result, "<ShowPlanXML")
+		require.Len(t, res.Plan, 1)
+		require.NotNil(t, res.Plan[0].ActualRows)
+		require.EqualValues(t, 2, *res.Plan[0].ActualRows)
+	})
+	t.Run("ExplainWithParams", func(t *testing.T) {
+		// The ? placeholder is sent to SQL Server as @p1.
+		res, err := b.ExplainQuery(t.Context(), backend.ExplainQueryIn{Query: "SELECT * FROM dbo.orders WHERE id = ?", Params: []any{1}})
+		require.NoError(t, err)
+		require.Equal(t, "xml", res.Format)
+		require.Contains(t, res.Result, "<ShowPlanXML")
 	})
 }
 
@@ -172,6 +417,24 @@ func TestListWaitingQueries(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestListConnections(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	// Hold a second session open in a transaction.
+	tx := b.db.Begin()
+	defer tx.Rollback()
+	var id int
+	require.NoError(t, tx.Raw("SELECT @@SPID").Scan(&id).Error)
+
+	connections, err := b.ListConnections(t.Context())
+	require.NoError(t, err)
+	idx := slices.IndexFunc(connections, func(c backend.Connection) bool { return c.ID == strconv.Itoa(id) })
+	require.NotEqual(t, -1, idx, "the held session is listed")
+	require.NotEmpty(t, connections[idx].Username)
+	require.Equal(t, "sleeping", connections[idx].State)
+}
+
 func TestListSlowestQueries(t *testing.T) {
 	t.Parallel()
 	b := openTestConnection(t)
@@ -181,6 +444,7 @@ func TestListSlowestQueries(t *testing.T) {
 	require.NotNil(t, res.Columns)
 	require.NotNil(t, res.Queries)
 	// Verify expected columns are documented
+	require.Contains(t, res.Columns, "query_hash")
 	require.Contains(t, res.Columns, "query")
 	require.Contains(t, res.Columns, "calls")
 	require.Contains(t, res.Columns, "total_time_sec")
@@ -193,3 +457,163 @@ func TestListDeadlocks(t *testing.T) {
 	_, err := b.ListDeadlocks(t.Context())
 	require.NoError(t, err)
 }
+
+func TestSampleTable(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	res, err := b.SampleTable(t.Context(), backend.SampleTableIn{Schema: "dbo", Table: "users", Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, 2, res.RowCount)
+	require.Contains(t, res.Columns, "username")
+	require.NotEmpty(t, res.Rows[0]["username"])
+
+	_, err = b.SampleTable(t.Context(), backend.SampleTableIn{Schema: "dbo", Table: "nonexistent", Limit: 2})
+	require.Error(t, err)
+}
+
+func TestAnalyzeTable(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	t.Run("Success", func(t *testing.T) {
+		res, err := b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{Schema: "dbo", Table: "users"})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+		require.NotNil(t, res.StatsUpdatedAt)
+	})
+
+	t.Run("WholeDatabase", func(t *testing.T) {
+		res, err := b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{})
+		require.NoError(t, err)
+		require.True(t, res.Success)
+
+		res, err = b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{Schema: "dbo"})
+		require.NoError(t, err)
+		require.Contains(t, res.Message, "tables in dbo")
+
+		_, err = b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{Schema: "nope"})
+		require.ErrorContains(t, err, "has no tables")
+	})
+
+	t.Run("NonExistentTable", func(t *testing.T) {
+		res, err := b.AnalyzeTable(t.Context(), backend.AnalyzeTableIn{Schema: "dbo", Table: "nonexistent"})
+		require.Nil(t, res)
+		require.Error(t, err)
+	})
+}
+
+func TestGetPrimaryKey(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	columns, err := sqlcommon.GetPrimaryKey(t.Context(), b.db.DB, "dbo", "users")
+	require.NoError(t, err)
+	require.Equal(t, []string{"id"}, columns)
+
+	_, err = sqlcommon.GetPrimaryKey(t.Context(), b.db.DB, "dbo", "nonexistent")
+	require.ErrorIs(t, sqlcommon.TranslateError(err), sqlcommon.ErrTableNotFound)
+}
+
+func TestTableProfile(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	profile, err := b.TableProfile(t.Context(), backend.TableProfileIn{Schema: "dbo", Table: "orders"})
+	require.NoError(t, err)
+	require.Equal(t, "dbo", profile.Schema)
+	require.Equal(t, "orders", profile.Table)
+	require.Positive(t, profile.TotalBytes)
+	require.NotEmpty(t, profile.Indexes)
+	require.NotNil(t, profile.SeqScans)
+
+	_, err = b.TableProfile(t.Context(), backend.TableProfileIn{Schema: "dbo", Table: "nonexistent"})
+	require.ErrorIs(t, sqlcommon.TranslateError(err), sqlcommon.ErrTableNotFound)
+}
+
+func TestProfileCategoricalColumns(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	out, err := b.ProfileCategoricalColumns(t.Context(), backend.ProfileCategoricalColumnsIn{Schema: "dbo", Table: "users", MaxDistinct: 2})
+	require.NoError(t, err)
+	require.Contains(t, out.Skipped, "email")
+
+	var active *sqlcommon.CategoricalColumn
+	for i, c := range out.Columns {
+		if c.Name == "active" {
+			active = &out.Columns[i]
+		}
+	}
+	require.NotNil(t, active)
+	require.Len(t, active.Values, 1)
+	require.EqualValues(t, 3, active.Values[0].Count)
+}
+
+func TestTableJSONSchema(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	out, err := b.TableJSONSchema(t.Context(), backend.TableJSONSchemaIn{Schema: "dbo", Table: "users"})
+	require.NoError(t, err)
+	require.Equal(t, "object", out.JSONSchema["type"])
+	require.Subset(t, out.JSONSchema["required"], []string{"username", "email"})
+	require.NotContains(t, out.JSONSchema["required"], "role")
+
+	properties := out.JSONSchema["properties"].(map[string]any)
+	require.Equal(t, "integer", properties["id"].(map[string]any)["type"])
+	require.Equal(t, 50, properties["username"].(map[string]any)["maxLength"])
+	require.Equal(t, "date-time", properties["created_at"].(map[string]any)["format"])
+
+	t.Run("TableNotFound", func(t *testing.T) {
+		_, err := b.TableJSONSchema(t.Context(), backend.TableJSONSchemaIn{Schema: "dbo", Table: "missing"})
+		require.ErrorIs(t, err, sqlcommon.ErrTableNotFound)
+	})
+}
+
+func TestSetComment(t *testing.T) {
+	t.Parallel()
+	b := openTestConnection(t)
+
+	description := func() any {
+		out, err := b.TableJSONSchema(t.Context(), backend.TableJSONSchemaIn{Schema: "dbo", Table: "users"})
+		require.NoError(t, err)
+		return out.JSONSchema["properties"].(map[string]any)["email"].(map[string]any)["description"]
+	}
+
+	t.Run("Column", func(t *testing.T) {
+		comment := "Login address, e.g. o'brien@example.com"
+		out, err := b.SetComment(t.Context(), backend.SetCommentIn{Schema: "dbo", Table: "users", Column: "email", Comment: comment})
+		require.NoError(t, err)
+		require.True(t, out.Success)
+		require.Equal(t, comment, description())
+
+		_, err = b.SetComment(t.Context(), backend.SetCommentIn{Schema: "dbo", Table: "users", Column: "email", Comment: "Updated"})
+		require.NoError(t, err)
+		require.Equal(t, "Updated", description())
+
+		desc, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Schema: "dbo", Table: "users"})
+		require.NoError(t, err)
+		require.Equal(t, "Updated", desc.ColumnComments["email"])
+
+		out, err = b.SetComment(t.Context(), backend.SetCommentIn{Schema: "dbo", Table: "users", Column: "email"})
+		require.NoError(t, err)
+		require.Contains(t, out.Message, "removed")
+		require.Nil(t, description())
+	})
+
+	t.Run("Table", func(t *testing.T) {
+		out, err := b.SetComment(t.Context(), backend.SetCommentIn{Schema: "dbo", Table: "users", Comment: "Registered accounts"})
+		require.NoError(t, err)
+		require.True(t, out.Success)
+
+		desc, err := b.DescribeTable(t.Context(), backend.DescribeTableIn{Schema: "dbo", Table: "users"})
+		require.NoError(t, err)
+		require.Equal(t, "Registered accounts", desc.TableComment)
+	})
+
+	t.Run("ColumnNotFound", func(t *testing.T) {
+		_, err := b.SetComment(t.Context(), backend.SetCommentIn{Schema: "dbo", Table: "users", Column: "missing", Comment: "x"})
+		require.ErrorIs(t, err, sqlcommon.ErrColumnNotFound)
+	})
+}
@@ -0,0 +1,18 @@
+SELECT
+    s.session_id,
+    s.login_name AS username,
+    COALESCE(DB_NAME(s.database_id), '') AS database_name,
+    COALESCE(c.client_net_address, '') AS client_address,
+    COALESCE(s.program_name, '') AS application_name,
+    COALESCE(r.status, s.status) AS state,
+    DATEDIFF(second, s.login_time, SYSDATETIME()) AS connected_sec,
+    COALESCE(DATEDIFF(second, r.start_time, SYSDATETIME()), 0) AS query_duration_sec,
+    COALESCE(t.text, '') AS query_text
+FROM sys.dm_exec_sessions AS s
+-- MARS opens child connections under the physical one
+LEFT JOIN sys.dm_exec_connections AS c ON c.session_id = s.session_id AND c.parent_connection_id IS NULL
+LEFT JOIN sys.dm_exec_requests AS r ON r.session_id = s.session_id
+OUTER APPLY sys.dm_exec_sql_text(r.sql_handle) AS t
+WHERE s.is_user_process = 1
+  AND s.session_id != @@SPID       -- don't show this query
+ORDER BY s.login_time ASC
@@ -0,0 +1,31 @@
+SELECT SCHEMA_NAME(s.schema_id) AS [schema], s.name, u.table_name AS [table], u.column_name AS [column],
+       TRY_CAST(s.start_value AS bigint) AS start_value,
+       TRY_CAST(s.last_used_value AS bigint) AS last_value,
+       TRY_CAST(s.increment AS bigint) AS increment,
+       TRY_CAST(s.maximum_value AS bigint) AS max_value
+FROM sys.sequences s
+-- A sequence fills a column through a DEFAULT (NEXT VALUE FOR ...) constraint
+OUTER APPLY (
+    SELECT TOP 1 OBJECT_NAME(dc.parent_object_id) AS table_name, c.name AS column_name
+    FROM sys.sql_expression_dependencies dep
+    JOIN sys.default_constraints dc ON dc.object_id = dep.referencing_id
+    JOIN sys.columns c ON c.object_id = dc.parent_object_id AND c.column_id = dc.parent_column_id
+    WHERE dep.referenced_id = s.object_id
+) u
+WHERE SCHEMA_NAME(s.schema_id) = CASE @schema WHEN '' THEN SCHEMA_NAME(s.schema_id) ELSE @schema END
+UNION ALL
+SELECT SCHEMA_NAME(t.schema_id), t.name, t.name, ic.name,
+       TRY_CAST(ic.seed_value AS bigint),
+       TRY_CAST(ic.last_value AS bigint),
+       TRY_CAST(ic.increment_value AS bigint),
+       CASE TYPE_NAME(ic.system_type_id)
+           WHEN 'tinyint' THEN 255
+           WHEN 'smallint' THEN 32767
+           WHEN 'int' THEN 2147483647
+           WHEN 'bigint' THEN 9223372036854775807
+       END
+FROM sys.identity_columns ic
+JOIN sys.tables t ON t.object_id = ic.object_id
+WHERE t.is_ms_shipped = 0
+  AND SCHEMA_NAME(t.schema_id) = CASE @schema WHEN '' THEN SCHEMA_NAME(t.schema_id) ELSE @schema END
+ORDER BY [schema], name
@@ -1,4 +1,5 @@
 SELECT TOP 25
+    CONVERT(VARCHAR(18), qs.query_hash, 1) AS query_hash,
     SUBSTRING(
         st.text,
         (qs.statement_start_offset / 2) + 1,
@@ -0,0 +1,8 @@
+-- sys.sql_modules rather than INFORMATION_SCHEMA.VIEWS, which cuts definitions off
+-- at 4000 characters.
+SELECT SCHEMA_NAME(v.schema_id) AS [schema], v.name, m.definition
+FROM sys.views v
+LEFT JOIN sys.sql_modules m ON m.object_id = v.object_id
+WHERE v.is_ms_shipped = 0
+  AND SCHEMA_NAME(v.schema_id) = CASE @schema WHEN '' THEN SCHEMA_NAME(v.schema_id) ELSE @schema END
+ORDER BY [schema], v.name
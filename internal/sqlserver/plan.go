@@ -0,0 +1,108 @@
+package sqlserver
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"github.com/tinternet/databaise/internal/backend"
+)
+
+// relOp is a RelOp element of a showplan being read.
+type relOp struct {
+	node  backend.PlanNode
+	depth int
+	// Sums of the RunTimeCountersPerThread of an actual plan.
+	rows, executions float64
+	ran              bool
+}
+
+// planTree converts a showplan XML to backend.PlanNode trees, one per statement.
+//
+//	<RelOp PhysicalOp="Hash Match" LogicalOp="Inner Join" EstimateRows="10" EstimatedTotalSubtreeCost="0.1">
+//	  <RunTimeInformation><RunTimeCountersPerThread ActualRows="8" ActualExecutions="1"/></RunTimeInformation>
+//	  <Hash><RelOp ...>...</RelOp></Hash>
+//	</RelOp>
+func planTree(planXML string) []backend.PlanNode {
+	var roots []backend.PlanNode
+	var ops []*relOp
+	depth := 0
+
+	dec := xml.NewDecoder(strings.NewReader(planXML))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			var op *relOp
+			if len(ops) > 0 {
+				op = ops[len(ops)-1]
+			}
+			switch {
+			case t.Name.Local == "RelOp":
+				node := backend.PlanNode{
+					Operation:     xmlAttr(t, "PhysicalOp"),
+					EstimatedRows: xmlNumber(t, "EstimateRows"),
+					Cost:          xmlNumber(t, "EstimatedTotalSubtreeCost"),
+				}
+				if logical := xmlAttr(t, "LogicalOp"); logical != "" && logical != node.Operation {
+					node.Operation += " (" + logical + ")"
+				}
+				ops = append(ops, &relOp{node: node, depth: depth})
+			// The Object of the operator itself, not of a RelOp nested in it.
+			case t.Name.Local == "Object" && op != nil && depth == op.depth+2:
+				table := strings.Trim(xmlAttr(t, "Schema"), "[]") + "." + strings.Trim(xmlAttr(t, "Table"), "[]")
+				op.node.Table = strings.Trim(table, ".")
+				op.node.Index = strings.Trim(xmlAttr(t, "Index"), "[]")
+			case t.Name.Local == "RunTimeCountersPerThread" && op != nil && depth == op.depth+2:
+				if rows := xmlNumber(t, "ActualRows"); rows != nil {
+					op.rows += *rows
+					op.ran = true
+				}
+				if executions := xmlNumber(t, "ActualExecutions"); executions != nil {
+					op.executions += *executions
+				}
+			}
+		case xml.EndElement:
+			if len(ops) > 0 && ops[len(ops)-1].depth == depth && t.Name.Local == "RelOp" {
+				op := ops[len(ops)-1]
+				ops = ops[:len(ops)-1]
+				if op.ran {
+					rows := op.rows
+					if op.executions > 0 {
+						rows /= op.executions
+					}
+					op.node.ActualRows = &rows
+				}
+				if len(ops) > 0 {
+					parent := ops[len(ops)-1]
+					parent.node.Children = append(parent.node.Children, op.node)
+				} else {
+					roots = append(roots, op.node)
+				}
+			}
+			depth--
+		}
+	}
+	return roots
+}
+
+func xmlAttr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func xmlNumber(e xml.StartElement, name string) *float64 {
+	f, err := strconv.ParseFloat(xmlAttr(e, name), 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
@@ -0,0 +1,39 @@
+package sqlserver
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tinternet/databaise/internal/backend"
+)
+
+func readPlan(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestPlanTree(t *testing.T) {
+	nodes := planTree(readPlan(t, "table_scan.xml"))
+	require.Len(t, nodes, 1)
+	require.Equal(t, "Nested Loops (Inner Join)", nodes[0].Operation)
+	require.Equal(t, 3.0, *nodes[0].EstimatedRows)
+	require.Len(t, nodes[0].Children, 2)
+
+	scan := nodes[0].Children[0].(backend.PlanNode)
+	require.Equal(t, "Table Scan", scan.Operation)
+	require.Equal(t, "dbo.orders", scan.Table)
+	require.Empty(t, scan.Index)
+
+	seek := nodes[0].Children[1].(backend.PlanNode)
+	require.Equal(t, "Clustered Index Seek", seek.Operation)
+	require.Equal(t, "dbo.users", seek.Table)
+	require.Equal(t, "PK_users", seek.Index)
+}
+
+func TestFullScans(t *testing.T) {
+	require.Equal(t, []backend.TableScan{{Table: "dbo.orders", EstimatedRows: 5}}, fullScans(readPlan(t, "table_scan.xml")))
+	require.Empty(t, fullScans(`<ShowPlanXML><RelOp PhysicalOp="Clustered Index Seek"><IndexScan><Object Schema="[dbo]" Table="[users]"/></IndexScan></RelOp></ShowPlanXML>`))
+}
@@ -0,0 +1,20 @@
+DECLARE @level0 sysname = COALESCE(NULLIF(@schema, ''), SCHEMA_NAME());
+DECLARE @level2type varchar(128) = IIF(@column = '', NULL, 'COLUMN');
+DECLARE @level2 sysname = NULLIF(@column, '');
+DECLARE @value sql_variant = CAST(@comment AS nvarchar(max));
+
+IF EXISTS (
+    SELECT 1 FROM sys.extended_properties
+    WHERE class = 1
+      AND major_id = OBJECT_ID(QUOTENAME(@level0) + '.' + QUOTENAME(@table))
+      AND minor_id = COALESCE(COLUMNPROPERTY(OBJECT_ID(QUOTENAME(@level0) + '.' + QUOTENAME(@table)), @level2, 'ColumnId'), 0)
+      AND name = N'MS_Description'
+)
+BEGIN
+    IF @comment = ''
+        EXEC sp_dropextendedproperty N'MS_Description', N'SCHEMA', @level0, N'TABLE', @table, @level2type, @level2;
+    ELSE
+        EXEC sp_updateextendedproperty N'MS_Description', @value, N'SCHEMA', @level0, N'TABLE', @table, @level2type, @level2;
+END
+ELSE IF @comment <> ''
+    EXEC sp_addextendedproperty N'MS_Description', @value, N'SCHEMA', @level0, N'TABLE', @table, @level2type, @level2;
@@ -0,0 +1,12 @@
+SELECT i.index_id, i.name,
+       CAST(SUM(ps.used_page_count) AS bigint) * 8192 AS bytes,
+       CAST(SUM(ps.row_count) AS bigint) AS row_count,
+       COALESCE(MAX(u.user_seeks), 0) AS user_seeks,
+       COALESCE(MAX(u.user_scans), 0) AS user_scans,
+       COALESCE(MAX(u.user_lookups), 0) AS user_lookups
+FROM sys.indexes i
+JOIN sys.dm_db_partition_stats ps ON ps.object_id = i.object_id AND ps.index_id = i.index_id
+LEFT JOIN sys.dm_db_index_usage_stats u ON u.database_id = DB_ID() AND u.object_id = i.object_id AND u.index_id = i.index_id
+WHERE i.object_id = @id
+GROUP BY i.index_id, i.name
+ORDER BY bytes DESC, i.name
@@ -0,0 +1,8 @@
+SELECT s.name AS [schema], t.name AS name,
+       CAST(SUM(CASE WHEN ps.index_id IN (0, 1) THEN ps.row_count ELSE 0 END) AS bigint) AS row_estimate,
+       CAST(SUM(ps.used_page_count) AS bigint) * 8192 AS bytes
+FROM sys.tables t
+JOIN sys.schemas s ON s.schema_id = t.schema_id
+JOIN sys.dm_db_partition_stats ps ON ps.object_id = t.object_id
+WHERE t.is_ms_shipped = 0
+GROUP BY s.name, t.name
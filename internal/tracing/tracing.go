@@ -0,0 +1,84 @@
+// Package tracing records OpenTelemetry spans for database tool calls and the
+// SQL statements they run. Spans go to the global tracer provider, so they are
+// dropped unless Setup (or otel.SetTracerProvider) installs one.
+package tracing
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/tinternet/databaise")
+
+type databaseKey struct{}
+
+// database holds the attributes a tool call shares with its statement spans.
+type database struct {
+	system string
+	name   string
+}
+
+func (d database) attributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("db.system", d.system),
+		attribute.String("db.name", d.name),
+	}
+}
+
+// StartTool starts the span of a tool call against the named database. system is
+// the OpenTelemetry db.system value, such as "postgresql". Statements run with the
+// returned context are recorded as child spans.
+func StartTool(ctx context.Context, tool, system, name string) (context.Context, trace.Span) {
+	db := database{system: system, name: name}
+	ctx = context.WithValue(ctx, databaseKey{}, db)
+	return tracer.Start(ctx, tool, trace.WithAttributes(db.attributes()...))
+}
+
+// End ends span, marking it failed if err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Statement records a span for a SQL statement that ran from begin until now.
+// fc returns the statement and the number of rows it affected or returned, as
+// passed to a GORM logger. Nothing is recorded outside a recording tool span.
+func Statement(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return
+	}
+	db, _ := ctx.Value(databaseKey{}).(database)
+	sql, rows := fc()
+	attrs := append(db.attributes(), attribute.String("db.statement", sql))
+	if rows >= 0 {
+		attrs = append(attrs, attribute.Int64("db.rows", rows))
+	}
+	_, span := tracer.Start(ctx, operation(sql),
+		trace.WithTimestamp(begin),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// operation returns the first keyword of sql, used as the statement span name.
+func operation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "query"
+	}
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,134 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+)
+
+// recordedSpan is a span that remembers what was recorded on it. The embedded
+// no-op span covers the methods the tests do not look at.
+type recordedSpan struct {
+	trace.Span
+	name  string
+	attrs map[attribute.Key]attribute.Value
+	err   error
+	ended bool
+}
+
+func (s *recordedSpan) IsRecording() bool { return true }
+
+func (s *recordedSpan) End(...trace.SpanEndOption) { s.ended = true }
+
+func (s *recordedSpan) RecordError(err error, _ ...trace.EventOption) { s.err = err }
+
+func (s *recordedSpan) SetStatus(codes.Code, string) {}
+
+// recorder is a tracer that keeps every span started with it.
+type recorder struct {
+	embedded.Tracer
+	spans []*recordedSpan
+}
+
+// provider hands out its recorder as every tracer.
+type provider struct {
+	embedded.TracerProvider
+	rec *recorder
+}
+
+func (p provider) Tracer(string, ...trace.TracerOption) trace.Tracer { return p.rec }
+
+func (r *recorder) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	cfg := trace.NewSpanStartConfig(opts...)
+	span := &recordedSpan{Span: trace.SpanFromContext(context.Background()), name: name, attrs: make(map[attribute.Key]attribute.Value)}
+	for _, kv := range cfg.Attributes() {
+		span.attrs[kv.Key] = kv.Value
+	}
+	r.spans = append(r.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func TestSpans(t *testing.T) {
+	rec := &recorder{}
+	otel.SetTracerProvider(provider{rec: rec})
+
+	statement := func() (string, int64) { return "select * from users", 3 }
+
+	// Statements outside a tool call are not traced.
+	Statement(t.Context(), time.Now(), statement, nil)
+	require.Empty(t, rec.spans)
+
+	ctx, span := StartTool(t.Context(), "execute_query", "postgresql", "app")
+	Statement(ctx, time.Now(), statement, errors.New("canceled"))
+	End(span, nil)
+
+	require.Len(t, rec.spans, 2)
+	tool, query := rec.spans[0], rec.spans[1]
+	require.Equal(t, "execute_query", tool.name)
+	require.Equal(t, "app", tool.attrs["db.name"].AsString())
+	require.True(t, tool.ended)
+	require.NoError(t, tool.err)
+
+	require.Equal(t, "SELECT", query.name)
+	require.Equal(t, "postgresql", query.attrs["db.system"].AsString())
+	require.Equal(t, "app", query.attrs["db.name"].AsString())
+	require.Equal(t, "select * from users", query.attrs["db.statement"].AsString())
+	require.Equal(t, int64(3), query.attrs["db.rows"].AsInt64())
+	require.EqualError(t, query.err, "canceled")
+	require.True(t, query.ended)
+}
+
+func TestSetup(t *testing.T) {
+	for _, key := range []string{"OTEL_TRACES_EXPORTER", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_PROTOCOL", "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"} {
+		t.Setenv(key, "")
+	}
+	global := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(global) })
+
+	t.Run("NotConfigured", func(t *testing.T) {
+		shutdown, err := Setup(t.Context())
+		require.NoError(t, err)
+		require.NoError(t, shutdown(t.Context()))
+		require.Equal(t, global, otel.GetTracerProvider())
+	})
+
+	t.Run("None", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://127.0.0.1:4318")
+		t.Setenv("OTEL_TRACES_EXPORTER", "none")
+		_, err := Setup(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, global, otel.GetTracerProvider())
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		t.Setenv("OTEL_TRACES_EXPORTER", "zipkin")
+		_, err := Setup(t.Context())
+		require.ErrorContains(t, err, `unsupported OTEL_TRACES_EXPORTER "zipkin"`)
+
+		t.Setenv("OTEL_TRACES_EXPORTER", "otlp")
+		t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/json")
+		_, err = Setup(t.Context())
+		require.ErrorContains(t, err, `unsupported OTLP protocol "http/json"`)
+	})
+
+	for _, protocol := range []string{"http/protobuf", "grpc"} {
+		t.Run(protocol, func(t *testing.T) {
+			t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://127.0.0.1:4318")
+			t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", protocol)
+			shutdown, err := Setup(t.Context())
+			require.NoError(t, err)
+			require.IsType(t, &sdktrace.TracerProvider{}, otel.GetTracerProvider())
+			// Nothing was recorded, so there is nothing to send to the endpoint.
+			require.NoError(t, shutdown(t.Context()))
+		})
+	}
+}